@@ -0,0 +1,370 @@
+// go-uploader-ctl 命令行管理工具，通过HTTP接口管理上传任务
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// 输出格式
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// 默认服务器地址和接口路径前缀
+const (
+	defaultServer = "http://localhost:9876"
+	apiPrefix     = "/go-uploader"
+)
+
+// 命令行全局参数
+var (
+	serverAddr string
+	secretKey  string
+	outputMode string
+)
+
+// httpClient 接口请求客户端
+var httpClient = &http.Client{Timeout: 60 * time.Second}
+
+func main() {
+	if err := newRootCommand().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// newRootCommand 创建根命令并注册所有子命令
+func newRootCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:          "go-uploader-ctl",
+		Short:        "go-uploader 任务管理工具",
+		SilenceUsage: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// 未通过参数指定时从环境变量读取
+			if !cmd.Flags().Changed("server") {
+				if value := os.Getenv("GO_UPLOADER_SERVER"); value != "" {
+					serverAddr = value
+				}
+			}
+			if !cmd.Flags().Changed("secret-key") {
+				secretKey = os.Getenv("GO_UPLOADER_SECRET_KEY")
+			}
+			if outputMode != outputTable && outputMode != outputJSON {
+				return fmt.Errorf("不支持的输出格式: %s（可选 json 或 table）", outputMode)
+			}
+			return nil
+		},
+	}
+
+	root.PersistentFlags().StringVar(&serverAddr, "server", defaultServer, "服务器地址（环境变量 GO_UPLOADER_SERVER）")
+	root.PersistentFlags().StringVar(&secretKey, "secret-key", "", "访问密钥（环境变量 GO_UPLOADER_SECRET_KEY）")
+	root.PersistentFlags().StringVarP(&outputMode, "output", "o", outputTable, "输出格式: json|table")
+
+	root.AddCommand(
+		newListCommand(),
+		newStatusCommand(),
+		newTaskActionCommand("delete", "删除任务", http.MethodDelete, ""),
+		newTaskActionCommand("pause", "暂停任务", http.MethodPost, "/pause"),
+		newTaskActionCommand("resume", "恢复任务", http.MethodPost, "/resume"),
+		newCleanupCommand(),
+		newResumeAllFailedCommand(),
+	)
+	return root
+}
+
+// newListCommand 列出任务：GET /tasks
+func newListCommand() *cobra.Command {
+	var status, filename string
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "列出任务",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			query := url.Values{}
+			if status != "" {
+				query.Set("status", status)
+			}
+			if filename != "" {
+				query.Set("filename", filename)
+			}
+			if limit > 0 {
+				query.Set("limit", strconv.Itoa(limit))
+			}
+
+			var result struct {
+				Tasks []map[string]interface{} `json:"tasks"`
+				Total int                      `json:"total"`
+			}
+			raw, err := doRequest(http.MethodGet, "/tasks", query, &result)
+			if err != nil {
+				return err
+			}
+			if outputMode == outputJSON {
+				return printJSON(raw)
+			}
+
+			writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(writer, "TASK_ID\tTYPE\tFILENAME\tSTATUS\tPROGRESS\tSIZE\tUPDATED_AT")
+			for _, task := range result.Tasks {
+				fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%.1f%%\t%s\t%s\n",
+					stringField(task, "task_id"),
+					stringField(task, "task_type"),
+					stringField(task, "filename"),
+					stringField(task, "status"),
+					numberField(task, "completion_rate"),
+					formatBytes(int64(numberField(task, "file_size"))),
+					formatTime(stringField(task, "updated_at")),
+				)
+			}
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+			fmt.Printf("共 %d 个任务\n", result.Total)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&status, "status", "", "按任务状态筛选")
+	cmd.Flags().StringVar(&filename, "filename", "", "按文件名包含匹配")
+	cmd.Flags().IntVar(&limit, "limit", 0, "最多返回的任务数（0 表示不限制）")
+	return cmd
+}
+
+// newStatusCommand 查看任务详情：GET /tasks/:file_id
+func newStatusCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status <file_id>",
+		Short: "查看任务详情",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var task map[string]interface{}
+			raw, err := doRequest(http.MethodGet, "/tasks/"+url.PathEscape(args[0]), nil, &task)
+			if err != nil {
+				return err
+			}
+			if outputMode == outputJSON {
+				return printJSON(raw)
+			}
+			return printFields(task)
+		},
+	}
+}
+
+// newTaskActionCommand 针对单个任务的操作命令（删除、暂停、恢复）
+func newTaskActionCommand(use, short, method, suffix string) *cobra.Command {
+	return &cobra.Command{
+		Use:   use + " <file_id>",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAction(method, "/tasks/"+url.PathEscape(args[0])+suffix, nil)
+		},
+	}
+}
+
+// newCleanupCommand 清理任务：POST /tasks/cleanup
+func newCleanupCommand() *cobra.Command {
+	var status, taskType string
+	var olderThan int
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "cleanup",
+		Short: "清理任务（不指定条件时按服务端配置的清理策略清理）",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			query := url.Values{}
+			if status != "" {
+				query.Set("status", status)
+			}
+			if olderThan > 0 {
+				query.Set("older_than", strconv.Itoa(olderThan))
+			}
+			if taskType != "" {
+				query.Set("task_type", taskType)
+			}
+			if dryRun {
+				query.Set("dry_run", "true")
+			}
+			return runAction(http.MethodPost, "/tasks/cleanup", query)
+		},
+	}
+
+	cmd.Flags().StringVar(&status, "status", "", "只清理指定状态的任务")
+	cmd.Flags().IntVar(&olderThan, "older-than", 0, "只清理N天前的任务")
+	cmd.Flags().StringVar(&taskType, "task-type", "", "只清理 file 或 folder 任务")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "只列出会被清理的任务，不删除")
+	return cmd
+}
+
+// newResumeAllFailedCommand 批量恢复失败任务：POST /tasks/resume_all_failed
+func newResumeAllFailedCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "resume-all-failed",
+		Short: "批量恢复所有失败的任务",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAction(http.MethodPost, "/tasks/resume_all_failed", nil)
+		},
+	}
+}
+
+// runAction 执行操作类请求并输出结果
+func runAction(method, path string, query url.Values) error {
+	var result map[string]interface{}
+	raw, err := doRequest(method, path, query, &result)
+	if err != nil {
+		return err
+	}
+	if outputMode == outputJSON {
+		return printJSON(raw)
+	}
+	return printFields(result)
+}
+
+// doRequest 发送接口请求，非2xx响应返回服务端的错误信息
+func doRequest(method, path string, query url.Values, out interface{}) ([]byte, error) {
+	endpoint := strings.TrimRight(serverAddr, "/") + apiPrefix + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequest(method, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %v", err)
+	}
+	if secretKey != "" {
+		req.Header.Set("X-Secret-Key", secretKey)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求服务器失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %v", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error   string `json:"error"`
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(raw, &apiErr) == nil && apiErr.Error != "" {
+			if apiErr.Message != "" {
+				return nil, fmt.Errorf("%s (HTTP %d): %s", apiErr.Error, resp.StatusCode, apiErr.Message)
+			}
+			return nil, fmt.Errorf("%s (HTTP %d)", apiErr.Error, resp.StatusCode)
+		}
+		return nil, fmt.Errorf("请求失败 (HTTP %d): %s", resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(raw, out); err != nil {
+			return nil, fmt.Errorf("解析响应失败: %v", err)
+		}
+	}
+	return raw, nil
+}
+
+// printJSON 格式化输出原始JSON响应
+func printJSON(raw []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return fmt.Errorf("解析响应失败: %v", err)
+	}
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// printFields 以键值表格输出响应字段，嵌套对象以JSON显示
+func printFields(fields map[string]interface{}) error {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, key := range keys {
+		fmt.Fprintf(writer, "%s\t%s\n", key, formatValue(fields[key]))
+	}
+	return writer.Flush()
+}
+
+// formatValue 格式化单个字段值
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "-"
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
+
+// stringField 读取字符串字段，缺失时返回 "-"
+func stringField(fields map[string]interface{}, key string) string {
+	if value, ok := fields[key].(string); ok && value != "" {
+		return value
+	}
+	return "-"
+}
+
+// numberField 读取数值字段，缺失时返回0
+func numberField(fields map[string]interface{}, key string) float64 {
+	value, _ := fields[key].(float64)
+	return value
+}
+
+// formatTime 将RFC3339时间格式化为本地时间
+func formatTime(value string) string {
+	parsed, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return value
+	}
+	return parsed.Local().Format("2006-01-02 15:04:05")
+}
+
+// formatBytes 以人类可读的单位显示字节数
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,4460 @@
+// Package docs Code generated by swaggo/swag. DO NOT EDIT
+package docs
+
+import "github.com/swaggo/swag"
+
+const docTemplate = `{
+    "schemes": {{ marshal .Schemes }},
+    "swagger": "2.0",
+    "info": {
+        "description": "{{escape .Description}}",
+        "title": "{{.Title}}",
+        "contact": {},
+        "version": "{{.Version}}"
+    },
+    "host": "{{.Host}}",
+    "basePath": "{{.BasePath}}",
+    "paths": {
+        "/admin/archive": {
+            "get": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "已完成任务超过 archive_after_days 天未更新后归档，可按任务最后更新时间的年月过滤，最近归档的排在前面",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理"
+                ],
+                "summary": "列出归档任务",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "年份，例如 2024",
+                        "name": "year",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "月份，例如 01，需同时指定年份",
+                        "name": "month",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "entries": {
+                                    "type": "array",
+                                    "items": {
+                                        "$ref": "#/definitions/utils.ArchiveEntry"
+                                    }
+                                },
+                                "total": {
+                                    "type": "integer"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/archive/{file_id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理"
+                ],
+                "summary": "从归档恢复任务",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "文件ID",
+                        "name": "file_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/checkpoint": {
+            "post": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "启用 enable_wal 时将所有任务写入JSON快照并清空预写日志；预写日志超过 wal_max_size_mb 时会自动写入检查点",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理"
+                ],
+                "summary": "写入任务检查点",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/circuit_breakers": {
+            "get": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "分片写入（chunk_write）、分片合并（chunk_merge）和任务持久化（storage_persist）连续失败达到上限后熔断，在重置时间内直接拒绝执行",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理"
+                ],
+                "summary": "列出熔断器状态",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "circuit_breakers": {
+                                    "type": "array",
+                                    "items": {
+                                        "$ref": "#/definitions/utils.CircuitBreakerStatus"
+                                    }
+                                },
+                                "total": {
+                                    "type": "integer"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/circuit_breakers/{name}/reset": {
+            "post": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "将熔断器强制切换为 closed 并清零失败次数，用于确认故障已排除后立即恢复服务",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理"
+                ],
+                "summary": "手动重置熔断器",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "熔断器名称",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/collisions": {
+            "get": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "不同任务ID经 SanitizeFileID 生成相同的安全文件名时，后出现的任务改用更长的哈希保存，冲突记录在此列出",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理"
+                ],
+                "summary": "列出任务文件名冲突",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/compact": {
+            "post": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "删除内存中不存在对应任务的孤立任务文件，把文件夹的单文件子任务合并到父任务文件的 embedded_sub_tasks 中，只重写内容有变化的文件；仅文件存储后端支持",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理"
+                ],
+                "summary": "压缩任务元数据",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "只统计预计的结果，不修改文件",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/utils.CompactionResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/dashboard": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "description": "按状态统计的任务数、今日/本周/全部已完成字节数（UTC）、最大的10个文件、平均合并耗时和当前协程数，统计结果缓存30秒",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理"
+                ],
+                "summary": "管理仪表盘",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/utils.DashboardStats"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/export": {
+            "get": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "每行一个完整的任务记录（包含分片信息），可通过 /admin/import 导入到其他服务器",
+                "produces": [
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "管理"
+                ],
+                "summary": "导出任务",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/gc": {
+            "post": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "删除上传目录下超过指定时间未修改的 *.tmp.* 临时文件（原子写入提交前进程崩溃时遗留），启动时和定期清理任务也会执行",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理"
+                ],
+                "summary": "清理遗留的临时文件",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "只删除超过该秒数未修改的文件，默认3600",
+                        "name": "older_than_seconds",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/import": {
+            "post": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "文件格式与 /admin/export 相同；已存在的任务默认跳过，单条记录出错不影响其他记录",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理"
+                ],
+                "summary": "导入任务",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "导出的任务文件",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "覆盖已存在的任务",
+                        "name": "overwrite",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/check": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "认证"
+                ],
+                "summary": "检查认证状态",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.LoginResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.LoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/keys": {
+            "get": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API密钥"
+                ],
+                "summary": "列出API密钥",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "原始密钥只在创建时返回一次",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API密钥"
+                ],
+                "summary": "生成API密钥",
+                "parameters": [
+                    {
+                        "description": "API密钥参数",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreateAPIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/keys/{key_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API密钥"
+                ],
+                "summary": "吊销API密钥",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "密钥ID",
+                        "name": "key_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/login": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "认证"
+                ],
+                "summary": "使用访问密钥登录",
+                "parameters": [
+                    {
+                        "description": "登录请求",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.LoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.LoginResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.LoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/logout": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "认证"
+                ],
+                "summary": "退出登录",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.LoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/oidc/callback": {
+            "get": {
+                "tags": [
+                    "认证"
+                ],
+                "summary": "OIDC登录回调",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "授权码",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "登录状态",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "登录成功，重定向到首页"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/oidc/login": {
+            "get": {
+                "description": "生成 state 并重定向到身份提供方的授权地址（需配置 auth_driver 为 oidc）",
+                "tags": [
+                    "认证"
+                ],
+                "summary": "OIDC登录",
+                "responses": {
+                    "302": {
+                        "description": "重定向到身份提供方"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/refresh": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "认证"
+                ],
+                "summary": "刷新JWT令牌",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.LoginResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.LoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/dlq": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "description": "分片重试次数耗尽的任务会移出活动任务并进入死信队列，最近失败的排在前面",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "任务"
+                ],
+                "summary": "列出死信队列",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/dlq/{file_id}/retry": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "description": "重置所有分片的重试次数，失败的分片需重新上传",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "任务"
+                ],
+                "summary": "重试死信队列中的任务",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "任务ID",
+                        "name": "file_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/estimate": {
+            "post": {
+                "description": "检查文件大小、分片大小、MIME类型和磁盘可用空间是否满足要求；suggested_chunk_size 为 file_size / (concurrent_uploads * 2)，限制在 [min_chunk_size, max_chunk_size] 内；estimated_merge_time_ms 按最近合并的吞吐量估算",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "上传"
+                ],
+                "summary": "上传前预估",
+                "parameters": [
+                    {
+                        "description": "文件信息",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.EstimateUploadRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/events": {
+            "get": {
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "上传"
+                ],
+                "summary": "通过SSE订阅任务进度",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "只订阅指定任务",
+                        "name": "file_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "SSE事件流",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/files": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "文件"
+                ],
+                "summary": "分页列出已合并的文件",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "子目录前缀",
+                        "name": "prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "files": {
+                                    "type": "array",
+                                    "items": {
+                                        "$ref": "#/definitions/handler.MergedFileInfo"
+                                    }
+                                },
+                                "page": {
+                                    "type": "integer"
+                                },
+                                "page_size": {
+                                    "type": "integer"
+                                },
+                                "total": {
+                                    "type": "integer"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/{filepath}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "description": "支持 Range 断点续传和 If-None-Match 缓存校验",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "文件"
+                ],
+                "summary": "下载已合并的文件",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "文件相对路径",
+                        "name": "filepath",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "下载范围，如 bytes=0-1023",
+                        "name": "Range",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "206": {
+                        "description": "Partial Content",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "304": {
+                        "description": "未修改"
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "文件"
+                ],
+                "summary": "删除已合并的文件",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "文件相对路径",
+                        "name": "filepath",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/{filepath}/versions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "description": "启用 enable_versioning 后合并到已存在的路径时，原文件保存为 \u003c路径\u003e.v\u003cN\u003e",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "文件"
+                ],
+                "summary": "列出文件的历史版本",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "文件相对路径",
+                        "name": "filepath",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "current_version": {
+                                    "type": "integer"
+                                },
+                                "path": {
+                                    "type": "string"
+                                },
+                                "versions": {
+                                    "type": "array",
+                                    "items": {
+                                        "$ref": "#/definitions/utils.FileVersion"
+                                    }
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/{filepath}/versions/{n}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "description": "需要开启 allow_file_deletion，当前版本的文件不受影响",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "文件"
+                ],
+                "summary": "删除文件的历史版本",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "文件相对路径",
+                        "name": "filepath",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "版本号",
+                        "name": "n",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/folder_tasks": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "文件夹任务"
+                ],
+                "summary": "创建文件夹任务",
+                "parameters": [
+                    {
+                        "description": "文件夹任务参数",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreateFolderTaskRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "文件夹存储配额（字节）",
+                        "name": "X-Max-Size",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/folder_tasks/{folder_task_id}/files": {
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "description": "为每个新文件创建子任务并累加文件夹大小，适用于边扫描边上传的场景；\n依赖项可以是同一请求中其他文件的相对路径或已有任务的ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "文件夹任务"
+                ],
+                "summary": "向文件夹任务追加文件",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "文件夹任务ID",
+                        "name": "folder_task_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "追加的文件",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.AddFolderFilesRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/folder_tasks/{folder_task_id}/sub_tasks": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "文件夹任务"
+                ],
+                "summary": "获取文件夹子任务列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "文件夹任务ID",
+                        "name": "folder_task_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/folder_tasks/{folder_task_id}/sub_tasks/batch_retry": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "description": "子任务必须属于该文件夹且状态为 failed；重置后状态为 uploading，失败的分片恢复为 pending，重试次数加1。至少重置一个子任务时，失败或部分失败的文件夹任务恢复为 uploading",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "文件夹任务"
+                ],
+                "summary": "批量重试文件夹的部分子任务",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "文件夹任务ID",
+                        "name": "folder_task_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "要重试的子任务ID",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.BatchRetrySubTasksRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "failed": {
+                                    "type": "array",
+                                    "items": {
+                                        "$ref": "#/definitions/handler.SubTaskRetryFailure"
+                                    }
+                                },
+                                "folder_status": {
+                                    "type": "string"
+                                },
+                                "folder_task_id": {
+                                    "type": "string"
+                                },
+                                "reset": {
+                                    "type": "array",
+                                    "items": {
+                                        "type": "string"
+                                    }
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/folder_tasks/{folder_task_id}/summary": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "文件夹任务"
+                ],
+                "summary": "获取文件夹任务摘要",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "文件夹任务ID",
+                        "name": "folder_task_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/utils.FolderTaskSummary"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "description": "开启 health_check_write_test 时在上传、合并和元数据目录中写入并删除32字节的测试文件，写入失败的目录标记为 write_error，结果缓存 health_check_write_cache_ttl 秒",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "监控"
+                ],
+                "summary": "健康检查",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/merge_chunks": {
+            "post": {
+                "description": "已在自动合并队列中的任务返回202及队列状态；已合并完成的任务再次提交时直接返回上次的合并结果（cached=true），不会重复合并",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "上传"
+                ],
+                "summary": "合并文件分片",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "文件ID",
+                        "name": "file_id",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "文件名",
+                        "name": "filename",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "分片总数",
+                        "name": "total_chunks",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "文件相对路径",
+                        "name": "relative_path",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "期望的文件MD5",
+                        "name": "expected_md5",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "合并超时时间（秒），默认 merge_timeout_seconds，不能超过 max_merge_timeout_seconds",
+                        "name": "X-Merge-Timeout",
+                        "in": "header"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "只校验分片是否齐全及MD5和总大小，不生成合并文件，也不改变任务状态",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "507": {
+                        "description": "Insufficient Storage",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/metrics": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "监控"
+                ],
+                "summary": "性能指标",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/metrics/tasks": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "description": "列出上传中的任务及其当前、峰值和平均速度，按当前速度从高到低排序；速度只保存在内存中，重启后重新统计",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "监控"
+                ],
+                "summary": "任务速度指标",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/presign": {
+            "post": {
+                "security": [
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "description": "返回的URL在有效期内无需访问密钥即可上传指定分片，签名使用 hmac_secret 计算",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "上传"
+                ],
+                "summary": "签发预签名上传URL",
+                "parameters": [
+                    {
+                        "description": "预签名参数",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.PresignRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/system": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "监控"
+                ],
+                "summary": "系统信息",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/task_groups": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "任务组"
+                ],
+                "summary": "列出任务组",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "description": "任务组把多个文件夹任务作为一个批次跟踪整体进度",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "任务组"
+                ],
+                "summary": "创建任务组",
+                "parameters": [
+                    {
+                        "description": "任务组参数",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreateTaskGroupRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/utils.TaskGroup"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/task_groups/{group_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "description": "只删除任务组，其中的文件夹任务保持不变",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "任务组"
+                ],
+                "summary": "解散任务组",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "任务组ID",
+                        "name": "group_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/task_groups/{group_id}/folder_tasks": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "任务组"
+                ],
+                "summary": "向任务组添加文件夹任务",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "任务组ID",
+                        "name": "group_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "文件夹任务",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.AddGroupFolderTaskRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/utils.TaskGroup"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/task_groups/{group_id}/summary": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "description": "汇总所有文件夹任务（含嵌套文件夹）的文件数、大小和完成率；已删除的文件夹任务列在 missing_folder_tasks 中",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "任务组"
+                ],
+                "summary": "获取任务组进度摘要",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "任务组ID",
+                        "name": "group_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/utils.TaskGroupSummary"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "任务"
+                ],
+                "summary": "获取所有任务",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "按标签筛选，格式 key:value",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "按文件名或相对路径包含匹配（不区分大小写）",
+                        "name": "filename",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "按文件名或相对路径前缀匹配（不区分大小写）",
+                        "name": "filename_prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "按任务状态筛选",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "最多返回的任务数（按更新时间倒序）",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/cleanup": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "description": "未指定条件时按配置的 cleanup_policies 清理；dry_run=true 时只返回会被清理的任务",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "任务"
+                ],
+                "summary": "清理任务",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "只清理指定状态的任务",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "只清理N天前的任务",
+                        "name": "older_than",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "只清理 file 或 folder 任务",
+                        "name": "task_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "只列出会被清理的任务，不删除",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/failed": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "任务"
+                ],
+                "summary": "获取失败的任务列表",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "failed_tasks": {
+                                    "type": "array",
+                                    "items": {
+                                        "$ref": "#/definitions/utils.UploadTask"
+                                    }
+                                },
+                                "message": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                },
+                                "total_failed": {
+                                    "type": "integer"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/resume_all_failed": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "任务"
+                ],
+                "summary": "批量恢复失败的任务",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{file_id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "description": "活动存储中不存在的任务会在归档中查找，归档的任务返回完整的任务元数据并带 archived=true",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "任务"
+                ],
+                "summary": "获取任务详情",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "任务ID",
+                        "name": "file_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "任务"
+                ],
+                "summary": "删除任务",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "任务ID",
+                        "name": "file_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{file_id}/chunks/{index}": {
+            "delete": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "重试次数耗尽的分片恢复为失败状态，之后可通过恢复接口继续上传",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "任务"
+                ],
+                "summary": "重置分片重试次数",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "任务ID",
+                        "name": "file_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "分片索引",
+                        "name": "index",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{file_id}/clone": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "description": "以新的任务ID复制任务元数据，清空分片、重试次数和合并结果；文件夹任务连同所有子任务一起复制",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "任务"
+                ],
+                "summary": "克隆任务",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "任务ID",
+                        "name": "file_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{file_id}/heartbeat": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "任务"
+                ],
+                "summary": "上传会话心跳",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "任务ID",
+                        "name": "file_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "心跳参数",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handler.HeartbeatRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{file_id}/merge_status": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "任务"
+                ],
+                "summary": "查询自动合并队列状态",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "任务ID",
+                        "name": "file_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/utils.MergeJobStatus"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{file_id}/metadata": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "description": "元数据为任意合法JSON，大小不超过 max_metadata_size_bytes 字节",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "任务"
+                ],
+                "summary": "替换任务自定义元数据",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "任务ID",
+                        "name": "file_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "元数据",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.UpdateTaskMetadataRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{file_id}/pause": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "任务"
+                ],
+                "summary": "暂停任务",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "任务ID",
+                        "name": "file_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{file_id}/quota": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "任务"
+                ],
+                "summary": "查询存储配额用量",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "任务ID",
+                        "name": "file_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/utils.QuotaUsage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{file_id}/resume": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "任务"
+                ],
+                "summary": "恢复任务",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "任务ID",
+                        "name": "file_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{file_id}/tags": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "任务"
+                ],
+                "summary": "设置或合并任务标签",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "任务ID",
+                        "name": "file_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "标签参数",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.UpdateTagsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/utils.UploadTask"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/trash": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "description": "启用 enable_trash 后删除和过期清理的任务会移入回收站，最近删除的排在前面",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "回收站"
+                ],
+                "summary": "列出回收站",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/trash/empty": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "description": "永久删除早于 trash_retention_days 天前移入回收站的条目，保留期为0时删除全部条目",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "回收站"
+                ],
+                "summary": "清空回收站",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/trash/{entry}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "description": "文件夹任务会同时恢复回收站中属于它的子任务和嵌套文件夹",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "回收站"
+                ],
+                "summary": "从回收站恢复任务",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "回收站条目名",
+                        "name": "entry",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/upload_chunk": {
+            "post": {
+                "description": "开启 accept_compressed_chunks 后，客户端可压缩 chunk 字段的数据并通过 Content-Encoding 声明格式，\nmd5 和分片大小限制均针对解压后的数据。服务端在响应头 Accept-Encoding 中返回支持的格式；\n未开启或格式不支持时返回 415，客户端应按 Accept-Encoding 退回未压缩上传。\n未声明 Content-Length 的请求（分块传输编码）流式写入分片数据，大小按实际写入的字节数计算，超过 max_chunk_size 时返回 413。",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "上传"
+                ],
+                "summary": "上传文件分片",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "文件ID（也可通过查询参数传递），指定 folder_task_id 时可省略",
+                        "name": "file_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "文件夹任务ID（也可通过查询参数传递），按 relative_path 定位子任务",
+                        "name": "folder_task_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "分片索引（也可通过查询参数传递）",
+                        "name": "chunk_index",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "分片总数",
+                        "name": "total_chunks",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "文件大小",
+                        "name": "file_size",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "文件相对路径，指定 folder_task_id 时必填",
+                        "name": "relative_path",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "分片MD5",
+                        "name": "md5",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "example": "{\"project\":\"demo\"}",
+                        "description": "JSON格式的任务标签",
+                        "name": "tags",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "example": "{\"record_id\":42}",
+                        "description": "JSON格式的自定义元数据，新建任务时记录，已有任务时整体替换",
+                        "name": "metadata",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "file",
+                        "description": "分片数据",
+                        "name": "chunk",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "文件ID，与查询参数 file_id 一样可在读取请求体之前确认已上传的分片",
+                        "name": "X-File-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "分片索引，与查询参数 chunk_index 一样可在读取请求体之前确认已上传的分片",
+                        "name": "X-Chunk-Index",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "带宽限制（字节/秒）",
+                        "name": "X-Bandwidth-Limit",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "会话存储配额（字节）",
+                        "name": "X-Max-Size",
+                        "in": "header"
+                    },
+                    {
+                        "enum": [
+                            "zstd",
+                            "gzip",
+                            "identity"
+                        ],
+                        "type": "string",
+                        "description": "分片数据的压缩格式：zstd 或 gzip，服务端解压后校验MD5并存储",
+                        "name": "Content-Encoding",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        },
+                        "headers": {
+                            "Accept-Encoding": {
+                                "type": "string",
+                                "description": "服务端接受的分片压缩格式"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/upload_chunk_signed": {
+            "post": {
+                "description": "无需访问密钥，file_id、chunk_index 等参数取自已签名的查询参数；签名无效或已过期时返回403。\n分片数据放在请求体中，因此使用POST而不是GET（部分代理和HTTP客户端会丢弃GET请求体）",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "上传"
+                ],
+                "summary": "通过预签名URL上传分片",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "文件ID",
+                        "name": "file_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "分片索引",
+                        "name": "chunk_index",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "分片总数",
+                        "name": "total_chunks",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "文件大小",
+                        "name": "file_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "过期时间（Unix秒）",
+                        "name": "expires",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "签名",
+                        "name": "sig",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "分片MD5",
+                        "name": "md5",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "file",
+                        "description": "分片数据",
+                        "name": "chunk",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/upload_session": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "description": "清单可由独立的签发方生成，作为上传期间校验分片的依据：设置清单后，上传的分片按清单中的大小和校验值（Config.HashAlgorithm）校验，不一致时返回400；\n分片总数和文件大小由清单决定。任务已存在时替换清单，已上传的分片与清单不一致时返回409",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "上传"
+                ],
+                "summary": "创建上传会话",
+                "parameters": [
+                    {
+                        "description": "会话参数",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreateUploadSessionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/upload_status": {
+            "get": {
+                "description": "format=ranges 时用 uploaded_ranges（连续已上传分片的闭区间，如 [[0,9],[15,30]]）代替 uploaded_chunks",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "上传"
+                ],
+                "summary": "查询上传状态",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "文件ID",
+                        "name": "file_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ranges：返回已上传分片的区间",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/upload_status/gaps": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "上传"
+                ],
+                "summary": "查询缺失的分片索引",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "文件ID",
+                        "name": "file_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/ws": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "SecretKey": []
+                    }
+                ],
+                "description": "客户端发送 {\"action\": \"subscribe|unsubscribe|pause|resume\", \"file_id\": \"...\"}，服务端推送 {\"event\": \"progress\", \"data\": ...} 以及指令结果 ack/error；浏览器可通过 Sec-WebSocket-Protocol: go-uploader, \u003c凭证\u003e 传递凭证",
+                "tags": [
+                    "上传"
+                ],
+                "summary": "WebSocket任务控制",
+                "responses": {
+                    "101": {
+                        "description": "切换到WebSocket协议",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "handler.AddFolderFilesRequest": {
+            "type": "object",
+            "required": [
+                "files"
+            ],
+            "properties": {
+                "files": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/utils.FileInfo"
+                    }
+                }
+            }
+        },
+        "handler.AddGroupFolderTaskRequest": {
+            "type": "object",
+            "required": [
+                "folder_task_id"
+            ],
+            "properties": {
+                "folder_task_id": {
+                    "type": "string",
+                    "example": "folder_photos_1700000000000000000"
+                }
+            }
+        },
+        "handler.BatchRetrySubTasksRequest": {
+            "type": "object",
+            "required": [
+                "sub_task_ids"
+            ],
+            "properties": {
+                "sub_task_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "handler.CreateAPIKeyRequest": {
+            "type": "object",
+            "properties": {
+                "expires_in": {
+                    "description": "有效期（秒），0表示永不过期",
+                    "type": "integer",
+                    "example": 86400
+                },
+                "label": {
+                    "type": "string",
+                    "example": "ci-uploader"
+                }
+            }
+        },
+        "handler.CreateFolderTaskRequest": {
+            "type": "object",
+            "required": [
+                "files",
+                "folder_name"
+            ],
+            "properties": {
+                "files": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/utils.FileInfo"
+                    }
+                },
+                "folder_name": {
+                    "type": "string",
+                    "example": "photos"
+                },
+                "metadata": {
+                    "description": "可选：文件夹任务的自定义元数据",
+                    "type": "object"
+                },
+                "parent_folder_task_id": {
+                    "description": "可选：挂载到已有文件夹任务下",
+                    "type": "string",
+                    "example": "folder_photos_1700000000000000000"
+                },
+                "progress_webhook_every_n": {
+                    "description": "可选：子任务每完成多少个分片触发一次 task.progress Webhook，覆盖全局配置",
+                    "type": "integer",
+                    "example": 10
+                },
+                "tags": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "handler.CreateTaskGroupRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "folder_task_ids": {
+                    "description": "可选：创建时加入的文件夹任务",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "folder_photos_1700000000000000000"
+                    ]
+                },
+                "name": {
+                    "type": "string",
+                    "example": "2024-archive"
+                }
+            }
+        },
+        "handler.CreateUploadSessionRequest": {
+            "type": "object",
+            "required": [
+                "file_id",
+                "manifest"
+            ],
+            "properties": {
+                "file_id": {
+                    "type": "string",
+                    "example": "video_1700000000000"
+                },
+                "filename": {
+                    "type": "string",
+                    "example": "video.mp4"
+                },
+                "manifest": {
+                    "description": "所有分片的索引、校验值和大小",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/utils.ManifestEntry"
+                    }
+                },
+                "relative_path": {
+                    "type": "string"
+                }
+            }
+        },
+        "handler.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "任务不存在"
+                }
+            }
+        },
+        "handler.EstimateUploadRequest": {
+            "type": "object",
+            "required": [
+                "file_size"
+            ],
+            "properties": {
+                "file_size": {
+                    "type": "integer",
+                    "example": 1073741824
+                },
+                "mime_type": {
+                    "description": "可选：按 allowed_mime_types / blocked_mime_types 检查",
+                    "type": "string",
+                    "example": "video/mp4"
+                },
+                "total_chunks": {
+                    "description": "可选：计划的分片数，用于检查分片大小",
+                    "type": "integer",
+                    "example": 128
+                }
+            }
+        },
+        "handler.HeartbeatRequest": {
+            "type": "object",
+            "properties": {
+                "estimated_completion_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "handler.LoginRequest": {
+            "type": "object",
+            "required": [
+                "secret_key"
+            ],
+            "properties": {
+                "secret_key": {
+                    "type": "string",
+                    "example": "your-secret-key-here"
+                }
+            }
+        },
+        "handler.LoginResponse": {
+            "type": "object",
+            "properties": {
+                "auth_token": {
+                    "type": "string"
+                },
+                "code": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "handler.MergedFileInfo": {
+            "type": "object",
+            "properties": {
+                "md5": {
+                    "type": "string"
+                },
+                "modified_at": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handler.PresignRequest": {
+            "type": "object",
+            "required": [
+                "chunk_index",
+                "file_id"
+            ],
+            "properties": {
+                "chunk_index": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "file_id": {
+                    "type": "string",
+                    "example": "file_123"
+                },
+                "file_size": {
+                    "description": "可选：新建任务时的文件大小",
+                    "type": "integer",
+                    "example": 1048576
+                },
+                "total_chunks": {
+                    "description": "可选：新建任务时的分片总数",
+                    "type": "integer",
+                    "example": 10
+                },
+                "ttl_seconds": {
+                    "description": "有效期（秒），0表示15分钟，最长24小时",
+                    "type": "integer",
+                    "example": 900
+                }
+            }
+        },
+        "handler.SubTaskRetryFailure": {
+            "type": "object",
+            "properties": {
+                "file_id": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "handler.UpdateTagsRequest": {
+            "type": "object",
+            "required": [
+                "tags"
+            ],
+            "properties": {
+                "replace": {
+                    "description": "为true时替换全部标签，否则与已有标签合并",
+                    "type": "boolean"
+                },
+                "tags": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    },
+                    "example": {
+                        "project": "demo"
+                    }
+                }
+            }
+        },
+        "handler.UpdateTaskMetadataRequest": {
+            "type": "object",
+            "properties": {
+                "metadata": {
+                    "description": "替换全部元数据，为 null 时清空",
+                    "type": "object"
+                }
+            }
+        },
+        "utils.ArchiveEntry": {
+            "type": "object",
+            "properties": {
+                "archived_at": {
+                    "type": "string"
+                },
+                "path": {
+                    "description": "相对于归档目录的路径：\u003c年\u003e/\u003c月\u003e/\u003c安全的文件ID\u003e.json.gz",
+                    "type": "string"
+                },
+                "task": {
+                    "description": "归档时的任务快照",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/utils.UploadTask"
+                        }
+                    ]
+                }
+            }
+        },
+        "utils.ChunkInfo": {
+            "type": "object",
+            "properties": {
+                "cas_key": {
+                    "description": "内容寻址存储的对象键，未启用时为空",
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "md5": {
+                    "type": "string"
+                },
+                "retry_count": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "pending, uploading, completed, failed, permanently_failed",
+                    "type": "string"
+                },
+                "uploaded_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "utils.CircuitBreakerStatus": {
+            "type": "object",
+            "properties": {
+                "failures": {
+                    "type": "integer"
+                },
+                "last_failure": {
+                    "type": "string"
+                },
+                "max_failures": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "reset_timeout_seconds": {
+                    "type": "number"
+                },
+                "state": {
+                    "description": "closed, open, half-open",
+                    "type": "string"
+                }
+            }
+        },
+        "utils.CompactionResult": {
+            "type": "object",
+            "properties": {
+                "bytes_saved": {
+                    "description": "压缩前后任务文件总大小之差",
+                    "type": "integer"
+                },
+                "compacted": {
+                    "description": "合并到父文件夹任务文件中的子任务文件数",
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "removed_orphans": {
+                    "description": "删除的孤立任务文件数（内存中不存在该任务或文件名已过期）",
+                    "type": "integer"
+                },
+                "rewritten": {
+                    "description": "内容有变化而重写的任务文件数",
+                    "type": "integer"
+                },
+                "skipped": {
+                    "description": "生成计划后任务又有更新而跳过的文件数",
+                    "type": "integer"
+                }
+            }
+        },
+        "utils.DashboardStats": {
+            "type": "object",
+            "properties": {
+                "average_merge_ms": {
+                    "type": "number"
+                },
+                "bytes_all_time": {
+                    "type": "integer"
+                },
+                "bytes_this_week": {
+                    "description": "本周从周一开始",
+                    "type": "integer"
+                },
+                "bytes_today": {
+                    "type": "integer"
+                },
+                "generated_at": {
+                    "type": "string"
+                },
+                "goroutine_count": {
+                    "type": "integer"
+                },
+                "largest_files": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/utils.LargestFile"
+                    }
+                },
+                "merged_files": {
+                    "description": "记录了合并耗时的文件数",
+                    "type": "integer"
+                },
+                "task_counts": {
+                    "description": "按状态统计的任务数",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "total_tasks": {
+                    "type": "integer"
+                }
+            }
+        },
+        "utils.FileInfo": {
+            "type": "object",
+            "properties": {
+                "depends_on": {
+                    "description": "依赖的文件：同一请求中其他文件的相对路径或已有任务的ID",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "2024/b.jpg"
+                    ]
+                },
+                "depth": {
+                    "description": "子目录层级，0表示位于文件夹根目录",
+                    "type": "integer",
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "example": "a.jpg"
+                },
+                "relative_path": {
+                    "type": "string",
+                    "example": "2024/a.jpg"
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 10485760
+                },
+                "sub_directory": {
+                    "description": "文件所在的子目录",
+                    "type": "string",
+                    "example": "2024"
+                },
+                "total_chunks": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "utils.FileVersion": {
+            "type": "object",
+            "properties": {
+                "archived_at": {
+                    "description": "被新版本覆盖的时间",
+                    "type": "string"
+                },
+                "modified_at": {
+                    "description": "该版本文件的最后修改时间",
+                    "type": "string"
+                },
+                "path": {
+                    "description": "版本文件相对于合并目录的路径：\u003c原路径\u003e.v\u003cN\u003e",
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "utils.FolderTaskSummary": {
+            "type": "object",
+            "properties": {
+                "completed_files": {
+                    "type": "integer"
+                },
+                "completion_rate": {
+                    "type": "number"
+                },
+                "eta_seconds": {
+                    "description": "预计剩余秒数，样本不足时为空",
+                    "type": "integer"
+                },
+                "failed_files": {
+                    "type": "integer"
+                },
+                "nested_folders": {
+                    "description": "嵌套子文件夹数量（递归统计）",
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "uploading, completed, failed, paused",
+                    "type": "string"
+                },
+                "total_files": {
+                    "type": "integer"
+                },
+                "total_size": {
+                    "type": "integer"
+                },
+                "upload_speed_bps": {
+                    "description": "上传速度（仅保存在内存中）",
+                    "type": "number"
+                },
+                "uploaded_size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "utils.LargestFile": {
+            "type": "object",
+            "properties": {
+                "completed_at": {
+                    "type": "string"
+                },
+                "file_id": {
+                    "type": "string"
+                },
+                "file_size": {
+                    "type": "integer"
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "relative_path": {
+                    "type": "string"
+                }
+            }
+        },
+        "utils.ManifestEntry": {
+            "type": "object",
+            "properties": {
+                "index": {
+                    "type": "integer"
+                },
+                "md5": {
+                    "type": "string",
+                    "example": "d41d8cd98f00b204e9800998ecf8427e"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "utils.MergeJobStatus": {
+            "type": "object",
+            "properties": {
+                "enqueued_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "file_id": {
+                    "type": "string"
+                },
+                "file_path": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "position": {
+                    "description": "排队位置，从1开始",
+                    "type": "integer"
+                },
+                "state": {
+                    "type": "string"
+                },
+                "worker": {
+                    "description": "正在处理的工作协程编号",
+                    "type": "integer"
+                }
+            }
+        },
+        "utils.MergeResult": {
+            "type": "object",
+            "properties": {
+                "file_path": {
+                    "description": "合并文件的本地路径",
+                    "type": "string"
+                },
+                "md5": {
+                    "description": "合并文件的校验值",
+                    "type": "string"
+                },
+                "merge_time": {
+                    "description": "合并耗时（纳秒）",
+                    "type": "integer"
+                },
+                "merged_at": {
+                    "description": "合并完成时间",
+                    "type": "string"
+                },
+                "size": {
+                    "description": "合并文件大小（字节）",
+                    "type": "integer"
+                },
+                "storage_url": {
+                    "description": "上传到对象存储后的地址",
+                    "type": "string"
+                }
+            }
+        },
+        "utils.QuotaUsage": {
+            "type": "object",
+            "properties": {
+                "file_id": {
+                    "type": "string"
+                },
+                "limit_bytes": {
+                    "description": "0表示不限制",
+                    "type": "integer"
+                },
+                "remaining_bytes": {
+                    "type": "integer"
+                },
+                "scope": {
+                    "description": "task 或 folder（子任务按所属文件夹统计）",
+                    "type": "string"
+                },
+                "scope_task_id": {
+                    "type": "string"
+                },
+                "unlimited": {
+                    "type": "boolean"
+                },
+                "used_bytes": {
+                    "type": "integer"
+                }
+            }
+        },
+        "utils.TaskGroup": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "folder_task_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "group_id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "tenant_id": {
+                    "description": "所属租户，只能加入同一租户的文件夹任务",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "utils.TaskGroupSummary": {
+            "type": "object",
+            "properties": {
+                "completed_files": {
+                    "type": "integer"
+                },
+                "completion_rate": {
+                    "type": "number"
+                },
+                "failed_files": {
+                    "type": "integer"
+                },
+                "folder_tasks": {
+                    "type": "integer"
+                },
+                "group_id": {
+                    "type": "string"
+                },
+                "missing_folder_tasks": {
+                    "description": "已被删除的文件夹任务",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "pending, uploading, completed, partial_failed",
+                    "type": "string"
+                },
+                "total_files": {
+                    "type": "integer"
+                },
+                "total_size": {
+                    "type": "integer"
+                },
+                "uploaded_size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "utils.UploadTask": {
+            "type": "object",
+            "properties": {
+                "chunks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/utils.ChunkInfo"
+                    }
+                },
+                "cloned_from": {
+                    "description": "任务克隆",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "current_bytes": {
+                    "description": "存储配额",
+                    "type": "integer"
+                },
+                "depends_on": {
+                    "description": "任务依赖",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "estimated_completion_at": {
+                    "description": "会话心跳",
+                    "type": "string"
+                },
+                "failure_reason": {
+                    "description": "失败原因，如 inactivity_timeout",
+                    "type": "string"
+                },
+                "file_id": {
+                    "type": "string"
+                },
+                "file_md5": {
+                    "type": "string"
+                },
+                "file_size": {
+                    "type": "integer"
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "folder_name": {
+                    "description": "文件夹名称",
+                    "type": "string"
+                },
+                "hash_algorithm": {
+                    "description": "文件校验算法",
+                    "type": "string"
+                },
+                "is_sub_task": {
+                    "description": "是否为子任务",
+                    "type": "boolean"
+                },
+                "manifest": {
+                    "description": "上传清单",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/utils.ManifestEntry"
+                    }
+                },
+                "max_bytes": {
+                    "description": "会话配额（字节），0表示使用全局配额",
+                    "type": "integer"
+                },
+                "merge_result": {
+                    "description": "最近一次成功合并的结果，重复的合并请求直接返回该结果",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/utils.MergeResult"
+                        }
+                    ]
+                },
+                "merge_time": {
+                    "description": "合并耗时（纳秒）",
+                    "type": "integer"
+                },
+                "merged_path": {
+                    "description": "合并结果",
+                    "type": "string"
+                },
+                "metadata": {
+                    "description": "自定义元数据",
+                    "type": "object"
+                },
+                "mime_type": {
+                    "description": "首个分片探测到的MIME类型",
+                    "type": "string"
+                },
+                "nested_sub_folders": {
+                    "description": "嵌套文件夹",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "parent_task_id": {
+                    "description": "父任务ID（用于子文件）",
+                    "type": "string"
+                },
+                "pending_cleanup": {
+                    "description": "分片清理",
+                    "type": "boolean"
+                },
+                "previous_versions": {
+                    "description": "合并时该路径已有的历史版本文件",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "progress_webhook_every_n": {
+                    "description": "进度通知",
+                    "type": "integer"
+                },
+                "relative_path": {
+                    "type": "string"
+                },
+                "retry_count": {
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "uploading, completed, failed, paused",
+                    "type": "string"
+                },
+                "storage_url": {
+                    "description": "合并文件上传到对象存储后的地址，如 s3://bucket/key",
+                    "type": "string"
+                },
+                "sub_tasks": {
+                    "description": "子任务ID列表（文件夹任务使用）",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tags": {
+                    "description": "任务标签",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "task_type": {
+                    "description": "新增字段 - 支持文件夹任务",
+                    "type": "string"
+                },
+                "tenant_id": {
+                    "description": "多租户",
+                    "type": "string"
+                },
+                "total_chunks": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uploaded_chunks_bitmap": {
+                    "description": "已上传分片位图，每个分片占一位",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "version_number": {
+                    "description": "文件版本",
+                    "type": "integer"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "AdminKey": {
+            "description": "管理员密钥，仅用于API密钥管理接口",
+            "type": "apiKey",
+            "name": "X-Admin-Key",
+            "in": "header"
+        },
+        "BearerAuth": {
+            "description": "登录获取的JWT令牌或API密钥，格式为 \"Bearer \u003ctoken\u003e\"",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        },
+        "SecretKey": {
+            "description": "访问密钥",
+            "type": "apiKey",
+            "name": "X-Secret-Key",
+            "in": "header"
+        }
+    }
+}`
+
+// SwaggerInfo holds exported Swagger Info so clients can modify it
+var SwaggerInfo = &swag.Spec{
+	Version:          "1.0",
+	Host:             "",
+	BasePath:         "/go-uploader",
+	Schemes:          []string{},
+	Title:            "go-uploader API",
+	Description:      "支持分片上传、断点续传和文件夹上传的文件上传服务",
+	InfoInstanceName: "swagger",
+	SwaggerTemplate:  docTemplate,
+	LeftDelim:        "{{",
+	RightDelim:       "}}",
+}
+
+func init() {
+	swag.Register(SwaggerInfo.InstanceName(), SwaggerInfo)
+}
@@ -0,0 +1,9 @@
+// Package docs 由 handler 包中的 swag 注释生成API文档，修改注释后需重新生成
+package docs
+
+//go:generate swag init --dir ../ --generalInfo main.go --output . --outputTypes go,json --parseDepth 1
+
+// SpecJSON 返回生成的API文档JSON
+func SpecJSON() string {
+	return SwaggerInfo.ReadDoc()
+}
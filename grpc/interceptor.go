@@ -0,0 +1,100 @@
+package grpc
+
+import (
+	"context"
+	"go-uploader/utils"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strings"
+)
+
+// 元数据键，gRPC元数据键均为小写
+const (
+	authorizationKey = "authorization"
+	requestIDKey     = "x-request-id"
+	tenantIDKey      = "x-tenant-id"
+)
+
+// tenantContextKey 请求的租户ID在上下文中的键
+type tenantContextKey struct{}
+
+// tenantFromContext 获取请求的租户ID，为空表示不限定租户
+func tenantFromContext(ctx context.Context) string {
+	tenantID, _ := ctx.Value(tenantContextKey{}).(string)
+	return tenantID
+}
+
+// unaryInterceptor 为一元调用附加请求ID并校验凭证
+func unaryInterceptor(ctx context.Context, req interface{}, info *gogrpc.UnaryServerInfo, handler gogrpc.UnaryHandler) (interface{}, error) {
+	ctx, err := authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// streamInterceptor 为流式调用附加请求ID并校验凭证
+func streamInterceptor(srv interface{}, ss gogrpc.ServerStream, info *gogrpc.StreamServerInfo, handler gogrpc.StreamHandler) error {
+	ctx, err := authenticate(ss.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+// authenticate 与HTTP认证和租户中间件一致：x-tenant-id 元数据中的租户写入上下文，
+// 启用认证时校验 authorization 元数据中的凭证，开启 enforce_tenant_claim 时JWT令牌中的租户必须与请求的租户一致
+func authenticate(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	ctx, id := utils.ContextWithRequestID(ctx, firstValue(md, requestIDKey))
+	gogrpc.SetHeader(ctx, metadata.Pairs(requestIDKey, id))
+
+	tenantID := firstValue(md, tenantIDKey)
+	if tenantID != "" {
+		if err := utils.ValidateTenantID(tenantID); err != nil {
+			return nil, status.Error(codes.InvalidArgument, "无效的租户ID: "+err.Error())
+		}
+		ctx = context.WithValue(ctx, tenantContextKey{}, tenantID)
+	}
+
+	if !utils.CurrentConfig().EnableAuth {
+		return ctx, nil
+	}
+
+	credential := strings.TrimSpace(strings.TrimPrefix(firstValue(md, authorizationKey), "Bearer "))
+	if _, ok := utils.AuthenticateCredential(ctx, credential); !ok {
+		utils.LoggerFromContext(ctx).Warn("gRPC请求认证失败")
+		return nil, status.Error(codes.Unauthenticated, "未授权访问: 请提供有效的访问密钥")
+	}
+
+	// 访问密钥和API密钥不携带租户，视为运维凭证不做限制
+	if utils.CurrentConfig().EnforceTenantClaim {
+		if claimed, ok := utils.CredentialTenant(ctx, credential); ok && claimed != tenantID {
+			utils.LoggerFromContext(ctx).Warn("gRPC请求的租户与令牌不一致", "tenant_id", tenantID)
+			return nil, status.Error(codes.PermissionDenied, "租户不匹配: 请求的租户与令牌中的租户不一致")
+		}
+	}
+	return ctx, nil
+}
+
+// firstValue 读取元数据键的第一个值
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// contextStream 替换流的上下文，使处理函数能读取拦截器写入的请求ID
+type contextStream struct {
+	gogrpc.ServerStream
+	ctx context.Context
+}
+
+// Context 返回附加了请求ID的上下文
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,55 @@
+package grpc
+
+import (
+	"context"
+	"go-uploader/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestAuthenticateEnforcesTenantClaim(t *testing.T) {
+	saved := utils.Config
+	defer func() { utils.Config = saved }()
+	utils.Config.EnableAuth = true
+	utils.Config.EnforceTenantClaim = true
+	utils.Config.AuthDriver = ""
+	utils.Config.SecretKey = "secret"
+	utils.Config.JWTSecret = "jwt-secret"
+
+	token, err := utils.GenerateToken("user", "tenant-a", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		credential string
+		tenantID   string
+		code       codes.Code
+	}{
+		{"令牌租户一致", token, "tenant-a", codes.OK},
+		{"令牌租户不一致", token, "tenant-b", codes.PermissionDenied},
+		{"令牌有租户但请求未指定", token, "", codes.PermissionDenied},
+		{"访问密钥不限制租户", "secret", "tenant-b", codes.OK},
+		{"无效的租户ID", token, "../a", codes.InvalidArgument},
+		{"无效的凭证", "wrong", "tenant-a", codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md := metadata.Pairs(authorizationKey, "Bearer "+tt.credential)
+			if tt.tenantID != "" {
+				md.Set(tenantIDKey, tt.tenantID)
+			}
+			ctx, err := authenticate(metadata.NewIncomingContext(context.Background(), md))
+			if code := status.Code(err); code != tt.code {
+				t.Fatalf("code = %v, want %v (%v)", code, tt.code, err)
+			}
+			if err == nil && tenantFromContext(ctx) != tt.tenantID {
+				t.Fatalf("tenant = %q, want %q", tenantFromContext(ctx), tt.tenantID)
+			}
+		})
+	}
+}
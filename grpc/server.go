@@ -0,0 +1,350 @@
+// Package grpc 提供与REST接口并行的gRPC上传服务，共用 utils.Storage 任务存储
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"go-uploader/handler"
+	uploaderpb "go-uploader/proto"
+	"go-uploader/utils"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// uploadChunkTimeout 超时时间与对应的HTTP接口一致，合并超时使用 Config.MergeTimeoutSeconds
+const uploadChunkTimeout = 30 * time.Second
+
+// maxMessageOverhead 单条消息中分片数据以外的字段预留大小
+const maxMessageOverhead = 1024 * 1024
+
+// Server gRPC服务器
+type Server struct {
+	server   *gogrpc.Server
+	listener net.Listener
+}
+
+// NewServer 创建监听 Config.GRPCPort 的gRPC服务器，启用TLS时与HTTP服务使用相同的证书
+func NewServer() (*Server, error) {
+	options := []gogrpc.ServerOption{
+		gogrpc.UnaryInterceptor(unaryInterceptor),
+		gogrpc.StreamInterceptor(streamInterceptor),
+		gogrpc.MaxRecvMsgSize(int(utils.CurrentConfig().MaxChunkSize) + maxMessageOverhead),
+	}
+
+	if utils.CurrentConfig().TLSEnabled {
+		tlsConfig, err := serverTLSConfig()
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, gogrpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%s", utils.CurrentConfig().GRPCPort))
+	if err != nil {
+		return nil, fmt.Errorf("监听gRPC端口失败: %v", err)
+	}
+
+	server := gogrpc.NewServer(options...)
+	uploaderpb.RegisterUploaderServiceServer(server, &uploaderService{})
+	return &Server{server: server, listener: listener}, nil
+}
+
+// serverTLSConfig 根据TLS配置加载证书或使用自动证书管理
+func serverTLSConfig() (*tls.Config, error) {
+	if utils.CurrentConfig().TLSAutoTLS {
+		manager, err := utils.NewAutocertManager()
+		if err != nil {
+			return nil, err
+		}
+		return manager.TLSConfig(), nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(utils.CurrentConfig().TLSCertFile, utils.CurrentConfig().TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("加载TLS证书失败: %v", err)
+	}
+	return &tls.Config{Certificates: []tls.Certificate{cert}, NextProtos: []string{"h2"}}, nil
+}
+
+// Serve 开始处理gRPC请求，直到服务器停止
+func (s *Server) Serve() error {
+	return s.server.Serve(s.listener)
+}
+
+// Shutdown 等待进行中的调用完成，超时后强制关闭
+func (s *Server) Shutdown(ctx context.Context) error {
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		return ctx.Err()
+	}
+}
+
+// uploaderService 实现 UploaderService，复用 handler 中与HTTP接口共用的上传和合并逻辑
+type uploaderService struct {
+	uploaderpb.UnimplementedUploaderServiceServer
+}
+
+// UploadChunk 接收流式分片数据，暂存到临时文件后按HTTP接口相同的流程保存
+func (s *uploaderService) UploadChunk(stream uploaderpb.UploaderService_UploadChunkServer) error {
+	// 登记进行中的操作，优雅关闭时等待其完成
+	done := utils.Inflight.Begin()
+	defer done()
+
+	ctx, cancel := context.WithTimeout(stream.Context(), uploadChunkTimeout)
+	defer cancel()
+
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return status.Error(codes.InvalidArgument, "缺少分片数据")
+	}
+	if err != nil {
+		return err
+	}
+	if first.GetFileId() == "" {
+		return status.Error(codes.InvalidArgument, "缺少必要参数: file_id 或 chunk_index")
+	}
+
+	upload := &handler.ChunkUpload{
+		FileID:       first.GetFileId(),
+		Index:        int(first.GetChunkIndex()),
+		TotalChunks:  int(first.GetTotalChunks()),
+		FileSize:     first.GetFileSize(),
+		FileName:     first.GetFilename(),
+		RelativePath: first.GetRelativePath(),
+		MD5:          first.GetMd5(),
+		Tags:         first.GetTags(),
+		TenantID:     tenantFromContext(ctx),
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	upload.MaxBytes = utils.RequestedQuotaLimit(firstValue(md, strings.ToLower(utils.MaxSizeHeader)))
+	upload.BandwidthLimit = utils.EffectiveBandwidthLimit(firstValue(md, strings.ToLower(utils.BandwidthLimitHeader)))
+
+	tmpPath, size, err := receiveChunkData(stream, first)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath)
+
+	upload.Size = size
+	upload.Open = func() (io.ReadCloser, error) {
+		return os.Open(tmpPath)
+	}
+
+	result, err := handler.StoreChunk(ctx, upload)
+	if err != nil {
+		return toStatus(err)
+	}
+
+	return stream.SendAndClose(&uploaderpb.ChunkResponse{
+		FileId:          upload.FileID,
+		ChunkIndex:      int32(result.Index),
+		Size:            result.Size,
+		AlreadyUploaded: result.AlreadyUploaded,
+		Md5Checked:      !result.AlreadyUploaded && upload.MD5 != "",
+	})
+}
+
+// receiveChunkData 将流中的分片数据写入上传目录下的临时文件，超过分片大小限制时中止
+func receiveChunkData(stream uploaderpb.UploaderService_UploadChunkServer, first *uploaderpb.ChunkRequest) (string, int64, error) {
+	if err := utils.EnsureDirectory(utils.CurrentConfig().UploadDir); err != nil {
+		return "", 0, status.Errorf(codes.Internal, "创建上传目录失败: %v", err)
+	}
+	file, err := os.CreateTemp(utils.CurrentConfig().UploadDir, ".grpc-chunk-*.tmp")
+	if err != nil {
+		return "", 0, status.Errorf(codes.Internal, "创建临时文件失败: %v", err)
+	}
+
+	fail := func(err error) (string, int64, error) {
+		file.Close()
+		os.Remove(file.Name())
+		return "", 0, err
+	}
+
+	var size int64
+	message := first
+	for {
+		size += int64(len(message.GetData()))
+		if size > utils.CurrentConfig().MaxChunkSize {
+			return fail(status.Errorf(codes.InvalidArgument, "分片大小超出限制: > %d", utils.CurrentConfig().MaxChunkSize))
+		}
+		if _, err := file.Write(message.GetData()); err != nil {
+			return fail(status.Errorf(codes.Internal, "写入临时文件失败: %v", err))
+		}
+
+		message, err = stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fail(err)
+		}
+	}
+
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return "", 0, status.Errorf(codes.Internal, "写入临时文件失败: %v", err)
+	}
+	return file.Name(), size, nil
+}
+
+// MergeChunks 合并已上传的分片
+func (s *uploaderService) MergeChunks(ctx context.Context, req *uploaderpb.MergeRequest) (*uploaderpb.MergeResponse, error) {
+	// 登记进行中的操作，优雅关闭时等待其完成
+	done := utils.Inflight.Begin()
+	defer done()
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(utils.CurrentConfig().MergeTimeoutSeconds)*time.Second)
+	defer cancel()
+
+	if req.GetFileId() == "" || req.GetFilename() == "" || req.GetTotalChunks() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "缺少必要参数")
+	}
+
+	outcome, err := handler.MergeUpload(ctx, handler.MergeRequest{
+		FileID:       req.GetFileId(),
+		Filename:     req.GetFilename(),
+		RelativePath: req.GetRelativePath(),
+		TotalChunks:  int(req.GetTotalChunks()),
+		ExpectedMD5:  req.GetExpectedMd5(),
+		TenantID:     tenantFromContext(ctx),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	if outcome.Pending {
+		return &uploaderpb.MergeResponse{FileId: req.GetFileId(), Status: outcome.Job.State}, nil
+	}
+	return &uploaderpb.MergeResponse{
+		FileId:      req.GetFileId(),
+		Status:      "ok",
+		FilePath:    outcome.FilePath,
+		Md5:         outcome.MD5,
+		Size:        outcome.Size,
+		MergeTimeMs: outcome.MergeTime.Milliseconds(),
+	}, nil
+}
+
+// GetTask 获取任务详情
+func (s *uploaderService) GetTask(ctx context.Context, req *uploaderpb.TaskRequest) (*uploaderpb.TaskResponse, error) {
+	if req.GetFileId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "缺少file_id参数")
+	}
+
+	task, exists := utils.Storage.GetTenantTask(req.GetFileId(), tenantFromContext(ctx))
+	if !exists {
+		return nil, status.Error(codes.NotFound, "任务不存在")
+	}
+	return taskResponse(task), nil
+}
+
+// ListTasks 按与 GET /tasks 相同的筛选条件逐个返回任务
+func (s *uploaderService) ListTasks(req *uploaderpb.ListRequest, stream uploaderpb.UploaderService_ListTasksServer) error {
+	if req.GetLimit() < 0 {
+		return status.Error(codes.InvalidArgument, "无效的limit参数")
+	}
+
+	query := utils.TaskQuery{
+		Filename:   req.GetFilename(),
+		SearchMode: utils.SearchModeContains,
+		Status:     req.GetStatus(),
+		Limit:      int(req.GetLimit()),
+		TenantID:   tenantFromContext(stream.Context()),
+	}
+	if prefix := req.GetFilenamePrefix(); prefix != "" {
+		query.Filename, query.SearchMode = prefix, utils.SearchModePrefix
+	}
+	if tag := req.GetTag(); tag != "" {
+		key, value, err := utils.ParseTagFilter(tag)
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		query.TagKey, query.TagValue = key, value
+	}
+
+	for _, task := range utils.Storage.QueryTasks(query) {
+		if err := stream.Send(taskResponse(task)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// taskResponse 转换任务记录，文件夹任务的状态和完成率使用递归统计结果
+func taskResponse(task *utils.UploadTask) *uploaderpb.TaskResponse {
+	response := &uploaderpb.TaskResponse{
+		FileId:       task.FileID,
+		TaskType:     task.TaskType,
+		Filename:     task.FileName,
+		RelativePath: task.RelativePath,
+		Status:       task.Status,
+		TotalChunks:  int32(task.TotalChunks),
+		FileSize:     task.FileSize,
+		FileMd5:      task.FileMD5,
+		MergedPath:   task.MergedPath,
+		RetryCount:   int32(task.RetryCount),
+		Tags:         task.Tags,
+		SubTasks:     task.SubTasks,
+		CreatedAt:    timestamppb.New(task.CreatedAt),
+		UpdatedAt:    timestamppb.New(task.UpdatedAt),
+	}
+
+	if task.TaskType == "folder" {
+		if summary, err := utils.Storage.GetFolderTaskSummary(task.FileID); err == nil {
+			response.Status = summary.Status
+			response.CompletionRate = summary.CompletionRate
+		}
+		return response
+	}
+
+	uploaded := len(utils.Storage.GetUploadedChunks(task.FileID))
+	response.UploadedChunks = int32(uploaded)
+	if task.TotalChunks > 0 {
+		response.CompletionRate = float64(uploaded) / float64(task.TotalChunks) * 100
+	}
+	return response
+}
+
+// toStatus 将接口错误的HTTP状态码映射为gRPC状态码
+func toStatus(err error) error {
+	var apiErr *handler.APIError
+	if !errors.As(err, &apiErr) {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	code := codes.Internal
+	switch apiErr.Status {
+	case 400, 415:
+		code = codes.InvalidArgument
+	case 401:
+		code = codes.Unauthenticated
+	case 404:
+		code = codes.NotFound
+	case 409:
+		code = codes.FailedPrecondition
+	case 413, 507:
+		code = codes.ResourceExhausted
+	}
+	return status.Error(code, apiErr.Message)
+}
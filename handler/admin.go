@@ -0,0 +1,341 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-uploader/utils"
+	"io"
+	"strconv"
+	"time"
+)
+
+// exportFlushEvery 导出时每写入多少条记录刷新一次响应
+const exportFlushEvery = 100
+
+// importRecordError 导入失败的记录
+type importRecordError struct {
+	Line   int    `json:"line"`
+	FileID string `json:"file_id,omitempty"`
+	Error  string `json:"error"`
+}
+
+// ExportTasks 以JSON Lines格式流式导出所有任务
+// @Summary 导出任务
+// @Description 每行一个完整的任务记录（包含分片信息），可通过 /admin/import 导入到其他服务器
+// @Tags 管理
+// @Produce application/x-ndjson
+// @Success 200 {file} file
+// @Failure 403 {object} ErrorResponse
+// @Security AdminKey
+// @Router /admin/export [get]
+func ExportTasks(c *gin.Context) {
+	if utils.Storage == nil {
+		c.JSON(500, gin.H{"error": "存储管理器未初始化"})
+		return
+	}
+
+	filename := fmt.Sprintf("go-uploader-tasks-%s.jsonl", time.Now().Format("20060102-150405"))
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(200)
+
+	encoder := json.NewEncoder(c.Writer)
+	exported := 0
+	for _, fileID := range utils.Storage.SortedTaskIDs() {
+		// 导出期间被删除的任务直接跳过
+		task, exists := utils.Storage.GetTask(fileID)
+		if !exists {
+			continue
+		}
+		if err := encoder.Encode(task); err != nil {
+			utils.RequestLogger(c).Warn("导出任务中断", "exported", exported, "error", err)
+			return
+		}
+		exported++
+		if exported%exportFlushEvery == 0 {
+			c.Writer.Flush()
+		}
+	}
+	c.Writer.Flush()
+
+	utils.RequestLogger(c).Info("任务导出完成", "exported", exported)
+}
+
+// ImportTasks 从JSON Lines文件导入任务
+// @Summary 导入任务
+// @Description 文件格式与 /admin/export 相同；已存在的任务默认跳过，单条记录出错不影响其他记录
+// @Tags 管理
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "导出的任务文件"
+// @Param overwrite query bool false "覆盖已存在的任务"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Security AdminKey
+// @Router /admin/import [post]
+func ImportTasks(c *gin.Context) {
+	if utils.Storage == nil {
+		c.JSON(500, gin.H{"error": "存储管理器未初始化"})
+		return
+	}
+
+	overwrite := c.Query("overwrite") == "true"
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(400, gin.H{"error": "缺少导入文件"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("打开导入文件失败: %v", err)})
+		return
+	}
+	defer file.Close()
+
+	imported := 0
+	skipped := make([]string, 0)
+	failures := make([]importRecordError, 0)
+
+	// 任务可能包含大量分片信息，按行读取不限制单行长度
+	reader := bufio.NewReader(file)
+	for line := 1; ; line++ {
+		data, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			failures = append(failures, importRecordError{Line: line, Error: fmt.Sprintf("读取导入文件失败: %v", readErr)})
+			break
+		}
+
+		if data = bytes.TrimSpace(data); len(data) > 0 {
+			fileID, err := importTaskRecord(data, overwrite)
+			switch {
+			case err == nil:
+				imported++
+			case errors.Is(err, utils.ErrTaskAlreadyActive):
+				skipped = append(skipped, fileID)
+			default:
+				failures = append(failures, importRecordError{Line: line, FileID: fileID, Error: err.Error()})
+			}
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+	}
+
+	utils.RequestLogger(c).Info("任务导入完成", "imported", imported, "skipped", len(skipped), "failed", len(failures))
+	c.JSON(200, gin.H{
+		"imported": imported,
+		"skipped":  skipped,
+		"failed":   len(failures),
+		"errors":   failures,
+	})
+}
+
+// importTaskRecord 解析并保存一条任务记录，返回记录中的任务ID
+func importTaskRecord(data []byte, overwrite bool) (string, error) {
+	var task utils.UploadTask
+	if err := json.Unmarshal(data, &task); err != nil {
+		return "", fmt.Errorf("无效的JSON: %v", err)
+	}
+	if err := utils.ValidateImportedTask(&task); err != nil {
+		return task.FileID, err
+	}
+	if err := utils.Storage.ImportTask(&task, overwrite); err != nil {
+		return task.FileID, err
+	}
+	return task.FileID, nil
+}
+
+// GetDashboard 获取系统负载概览
+// @Summary 管理仪表盘
+// @Description 按状态统计的任务数、今日/本周/全部已完成字节数（UTC）、最大的10个文件、平均合并耗时和当前协程数，统计结果缓存30秒
+// @Tags 管理
+// @Produce json
+// @Success 200 {object} utils.DashboardStats
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /admin/dashboard [get]
+func GetDashboard(c *gin.Context) {
+	if utils.Storage == nil {
+		c.JSON(500, gin.H{"error": "存储管理器未初始化"})
+		return
+	}
+
+	c.JSON(200, utils.Stats.Dashboard())
+}
+
+// Checkpoint 立即写入任务检查点
+// @Summary 写入任务检查点
+// @Description 启用 enable_wal 时将所有任务写入JSON快照并清空预写日志；预写日志超过 wal_max_size_mb 时会自动写入检查点
+// @Tags 管理
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security AdminKey
+// @Router /admin/checkpoint [post]
+func Checkpoint(c *gin.Context) {
+	if utils.Storage == nil {
+		c.JSON(500, gin.H{"error": "存储管理器未初始化"})
+		return
+	}
+
+	start := time.Now()
+	if err := utils.Storage.Checkpoint(); err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("写入检查点失败: %v", err)})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"status":      "ok",
+		"duration_ms": time.Since(start).Milliseconds(),
+	})
+}
+
+// CompactMetadata 压缩任务元数据目录
+// @Summary 压缩任务元数据
+// @Description 删除内存中不存在对应任务的孤立任务文件，把文件夹的单文件子任务合并到父任务文件的 embedded_sub_tasks 中，只重写内容有变化的文件；仅文件存储后端支持
+// @Tags 管理
+// @Produce json
+// @Param dry_run query bool false "只统计预计的结果，不修改文件"
+// @Success 200 {object} utils.CompactionResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security AdminKey
+// @Router /admin/compact [post]
+func CompactMetadata(c *gin.Context) {
+	if utils.Storage == nil {
+		c.JSON(500, gin.H{"error": "存储管理器未初始化"})
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+	result, err := utils.Storage.CompactMetadata(dryRun)
+	if err != nil {
+		if errors.Is(err, utils.ErrCompactionUnsupported) {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(500, gin.H{"error": fmt.Sprintf("压缩任务元数据失败: %v", err)})
+		return
+	}
+
+	c.JSON(200, result)
+}
+
+// ListFileIDCollisions 列出检测到的任务文件名冲突
+// @Summary 列出任务文件名冲突
+// @Description 不同任务ID经 SanitizeFileID 生成相同的安全文件名时，后出现的任务改用更长的哈希保存，冲突记录在此列出
+// @Tags 管理
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security AdminKey
+// @Router /admin/collisions [get]
+func ListFileIDCollisions(c *gin.Context) {
+	if utils.Storage == nil {
+		c.JSON(500, gin.H{"error": "存储管理器未初始化"})
+		return
+	}
+
+	collisions := utils.Storage.FileIDCollisions()
+	c.JSON(200, gin.H{
+		"collisions": collisions,
+		"total":      len(collisions),
+	})
+}
+
+// ListCircuitBreakers 列出所有熔断器的状态
+// @Summary 列出熔断器状态
+// @Description 分片写入（chunk_write）、分片合并（chunk_merge）和任务持久化（storage_persist）连续失败达到上限后熔断，在重置时间内直接拒绝执行
+// @Tags 管理
+// @Produce json
+// @Success 200 {object} object{circuit_breakers=[]utils.CircuitBreakerStatus,total=int}
+// @Failure 403 {object} ErrorResponse
+// @Security AdminKey
+// @Router /admin/circuit_breakers [get]
+func ListCircuitBreakers(c *gin.Context) {
+	breakers := utils.CircuitBreakers()
+	c.JSON(200, gin.H{
+		"circuit_breakers": breakers,
+		"total":            len(breakers),
+	})
+}
+
+// ResetCircuitBreaker 手动关闭熔断器
+// @Summary 手动重置熔断器
+// @Description 将熔断器强制切换为 closed 并清零失败次数，用于确认故障已排除后立即恢复服务
+// @Tags 管理
+// @Produce json
+// @Param name path string true "熔断器名称"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security AdminKey
+// @Router /admin/circuit_breakers/{name}/reset [post]
+func ResetCircuitBreaker(c *gin.Context) {
+	name := c.Param("name")
+	previous, err := utils.ResetCircuitBreaker(name)
+	if err != nil {
+		if errors.Is(err, utils.ErrCircuitBreakerNotFound) {
+			c.JSON(404, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	utils.RequestLogger(c).Info("手动重置熔断器", "client_ip", c.ClientIP(), "name", name, "previous_state", previous.State)
+	c.JSON(200, gin.H{
+		"status":            "ok",
+		"name":              name,
+		"previous_state":    previous.State,
+		"previous_failures": previous.Failures,
+	})
+}
+
+// CollectTempFiles 立即清理上传目录中遗留的临时文件
+// @Summary 清理遗留的临时文件
+// @Description 删除上传目录下超过指定时间未修改的 *.tmp.* 临时文件（原子写入提交前进程崩溃时遗留），启动时和定期清理任务也会执行
+// @Tags 管理
+// @Produce json
+// @Param older_than_seconds query int false "只删除超过该秒数未修改的文件，默认3600"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security AdminKey
+// @Router /admin/gc [post]
+func CollectTempFiles(c *gin.Context) {
+	olderThan := utils.DefaultTempFileMaxAge
+	if value := c.Query("older_than_seconds"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds < 0 {
+			c.JSON(400, gin.H{"error": "older_than_seconds参数无效"})
+			return
+		}
+		olderThan = time.Duration(seconds) * time.Second
+	}
+
+	removed, err := utils.TempFileGC(utils.CurrentConfig().UploadDir, olderThan)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error(), "removed": removed})
+		return
+	}
+
+	utils.RequestLogger(c).Info("已清理遗留的临时文件", "client_ip", c.ClientIP(), "removed", removed)
+	c.JSON(200, gin.H{
+		"status":             "ok",
+		"removed":            removed,
+		"older_than_seconds": int64(olderThan.Seconds()),
+	})
+}
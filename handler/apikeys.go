@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-uploader/utils"
+	"net/http"
+	"time"
+)
+
+// CreateAPIKeyRequest 创建API密钥请求结构
+type CreateAPIKeyRequest struct {
+	Label     string `json:"label" example:"ci-uploader"`
+	ExpiresIn int64  `json:"expires_in" example:"86400"` // 有效期（秒），0表示永不过期
+}
+
+// apiKeyView API密钥对外展示结构，不包含哈希
+type apiKeyView struct {
+	ID        string     `json:"id"`
+	Label     string     `json:"label"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Expired   bool       `json:"expired"`
+}
+
+// newAPIKeyView 转换为展示结构
+func newAPIKeyView(key utils.APIKey) apiKeyView {
+	return apiKeyView{
+		ID:        key.ID,
+		Label:     key.Label,
+		CreatedAt: key.CreatedAt,
+		ExpiresAt: key.ExpiresAt,
+		Expired:   key.IsExpired(time.Now()),
+	}
+}
+
+// CreateAPIKey 生成新的API密钥
+// @Summary 生成API密钥
+// @Description 原始密钥只在创建时返回一次
+// @Tags API密钥
+// @Accept json
+// @Produce json
+// @Param request body CreateAPIKeyRequest true "API密钥参数"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Security AdminKey
+// @Router /auth/keys [post]
+func CreateAPIKey(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+	if req.ExpiresIn < 0 {
+		c.JSON(400, gin.H{"error": "expires_in 不能为负数"})
+		return
+	}
+
+	rawKey, key, err := utils.APIKeys.Create(req.Label, time.Duration(req.ExpiresIn)*time.Second)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "创建API密钥失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "API密钥创建成功，请妥善保存，密钥不会再次显示",
+		"api_key": rawKey,
+		"key":     newAPIKeyView(key),
+	})
+}
+
+// ListAPIKeys 列出所有API密钥
+// @Summary 列出API密钥
+// @Tags API密钥
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} ErrorResponse
+// @Security AdminKey
+// @Router /auth/keys [get]
+func ListAPIKeys(c *gin.Context) {
+	keys := utils.APIKeys.List()
+	views := make([]apiKeyView, 0, len(keys))
+	for _, key := range keys {
+		views = append(views, newAPIKeyView(key))
+	}
+
+	c.JSON(200, gin.H{
+		"keys":  views,
+		"total": len(views),
+	})
+}
+
+// RevokeAPIKey 吊销API密钥
+// @Summary 吊销API密钥
+// @Tags API密钥
+// @Produce json
+// @Param key_id path string true "密钥ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Security AdminKey
+// @Router /auth/keys/{key_id} [delete]
+func RevokeAPIKey(c *gin.Context) {
+	keyID := c.Param("key_id")
+
+	if err := utils.APIKeys.Revoke(keyID); err != nil {
+		if err == utils.ErrAPIKeyNotFound {
+			c.JSON(404, gin.H{"error": "API密钥不存在"})
+			return
+		}
+		c.JSON(500, gin.H{"error": "吊销API密钥失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "API密钥已吊销", "key_id": keyID})
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-uploader/utils"
+	"strconv"
+)
+
+// ListArchive 列出归档的任务
+// @Summary 列出归档任务
+// @Description 已完成任务超过 archive_after_days 天未更新后归档，可按任务最后更新时间的年月过滤，最近归档的排在前面
+// @Tags 管理
+// @Produce json
+// @Param year query int false "年份，例如 2024"
+// @Param month query int false "月份，例如 01，需同时指定年份"
+// @Success 200 {object} object{entries=[]utils.ArchiveEntry,total=int}
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security AdminKey
+// @Router /admin/archive [get]
+func ListArchive(c *gin.Context) {
+	if utils.Archive == nil {
+		c.JSON(500, gin.H{"error": "归档存储未初始化"})
+		return
+	}
+
+	year, month := 0, 0
+	if value := c.Query("year"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 9999 {
+			c.JSON(400, gin.H{"error": "year参数无效"})
+			return
+		}
+		year = parsed
+	}
+	if value := c.Query("month"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 12 {
+			c.JSON(400, gin.H{"error": "month参数无效"})
+			return
+		}
+		if year == 0 {
+			c.JSON(400, gin.H{"error": "指定month时必须同时指定year"})
+			return
+		}
+		month = parsed
+	}
+
+	entries, err := utils.Archive.List(year, month)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("读取归档失败: %v", err)})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"entries": entries,
+		"total":   len(entries),
+	})
+}
+
+// RestoreArchivedTask 将归档的任务移回活动存储
+// @Summary 从归档恢复任务
+// @Tags 管理
+// @Produce json
+// @Param file_id path string true "文件ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security AdminKey
+// @Router /admin/archive/{file_id}/restore [post]
+func RestoreArchivedTask(c *gin.Context) {
+	fileID := c.Param("file_id")
+
+	if utils.Storage == nil {
+		c.JSON(500, gin.H{"error": "存储管理器未初始化"})
+		return
+	}
+
+	task, err := utils.Storage.RestoreArchivedTask(fileID)
+	if err != nil {
+		switch {
+		case errors.Is(err, utils.ErrArchivedTaskNotFound):
+			c.JSON(404, gin.H{"error": err.Error()})
+		case errors.Is(err, utils.ErrTaskAlreadyActive):
+			c.JSON(409, gin.H{"error": err.Error()})
+		default:
+			c.JSON(500, gin.H{"error": fmt.Sprintf("恢复任务失败: %v", err)})
+		}
+		return
+	}
+
+	utils.RequestLogger(c).Info("已从归档恢复任务", "file_id", task.FileID)
+	c.JSON(200, gin.H{
+		"status":      "ok",
+		"file_id":     task.FileID,
+		"task_status": task.Status,
+	})
+}
+
+// respondArchivedTask 活动存储中不存在的任务在归档中查找，找到时返回归档的任务元数据和 archived 标记；
+// 未找到或属于其他租户时返回false
+func respondArchivedTask(c *gin.Context, fileID string) bool {
+	if utils.Archive == nil {
+		return false
+	}
+
+	entry, err := utils.Archive.Find(fileID)
+	if err != nil || !entry.Task.VisibleToTenant(utils.TenantFromContext(c)) {
+		return false
+	}
+
+	data, err := json.Marshal(entry.Task)
+	if err != nil {
+		return false
+	}
+	info := gin.H{}
+	if err := json.Unmarshal(data, &info); err != nil {
+		return false
+	}
+	info["archived"] = true
+	info["archived_at"] = entry.ArchivedAt
+	c.JSON(200, info)
+	return true
+}
@@ -6,9 +6,12 @@ import (
 	"net/http"
 )
 
+// jwtDefaultSubject 使用共享密钥登录时签发令牌的主体
+const jwtDefaultSubject = "default"
+
 // LoginRequest 登录请求结构
 type LoginRequest struct {
-	SecretKey string `json:"secret_key" binding:"required"`
+	SecretKey string `json:"secret_key" binding:"required" example:"your-secret-key-here"`
 }
 
 // LoginResponse 登录响应结构
@@ -20,6 +23,15 @@ type LoginResponse struct {
 }
 
 // Login 处理登录请求
+// @Summary 使用访问密钥登录
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param request body LoginRequest true "登录请求"
+// @Success 200 {object} LoginResponse
+// @Failure 400 {object} LoginResponse
+// @Failure 401 {object} LoginResponse
+// @Router /auth/login [post]
 func Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -41,18 +53,76 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	// 签发JWT令牌，登录请求携带的租户ID写入令牌
+	token, err := utils.GenerateToken(jwtDefaultSubject, utils.TenantFromContext(c), utils.JWTTokenTTL())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "签发令牌失败",
+			"code":    500,
+		})
+		return
+	}
+
 	// 设置认证Cookie
-	utils.SetAuthCookie(c, req.SecretKey)
+	utils.SetAuthCookie(c, token)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"message":    "登录成功",
+		"code":       200,
+		"auth_token": token,
+		"expires_in": utils.CurrentConfig().JWTTokenTTL,
+	})
+}
+
+// RefreshToken 使用有效的JWT令牌换取新令牌
+// @Summary 刷新JWT令牌
+// @Tags 认证
+// @Produce json
+// @Success 200 {object} LoginResponse
+// @Failure 401 {object} LoginResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /auth/refresh [post]
+func RefreshToken(c *gin.Context) {
+	claims, err := utils.ValidateToken(utils.GetRequestCredential(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "令牌无效或已过期",
+			"code":    401,
+		})
+		return
+	}
+
+	token, err := utils.GenerateToken(claims.Subject, claims.TenantID, utils.JWTTokenTTL())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "签发令牌失败",
+			"code":    500,
+		})
+		return
+	}
+
+	utils.SetAuthCookie(c, token)
 
 	c.JSON(http.StatusOK, gin.H{
-		"success":   true,
-		"message":   "登录成功",
-		"code":      200,
-		"auth_token": req.SecretKey,
+		"success":    true,
+		"message":    "令牌刷新成功",
+		"code":       200,
+		"auth_token": token,
+		"expires_in": utils.CurrentConfig().JWTTokenTTL,
 	})
 }
 
 // Logout 处理登出请求
+// @Summary 退出登录
+// @Tags 认证
+// @Produce json
+// @Success 200 {object} LoginResponse
+// @Router /auth/logout [post]
 func Logout(c *gin.Context) {
 	// 清除认证Cookie
 	utils.ClearAuthCookie(c)
@@ -65,9 +135,17 @@ func Logout(c *gin.Context) {
 }
 
 // CheckAuth 检查认证状态
+// @Summary 检查认证状态
+// @Tags 认证
+// @Produce json
+// @Success 200 {object} LoginResponse
+// @Failure 401 {object} LoginResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /auth/check [get]
 func CheckAuth(c *gin.Context) {
 	// 如果未启用验证，直接返回成功
-	if !utils.Config.EnableAuth {
+	if !utils.CurrentConfig().EnableAuth {
 		c.JSON(http.StatusOK, gin.H{
 			"success": true,
 			"message": "验证已禁用",
@@ -77,16 +155,8 @@ func CheckAuth(c *gin.Context) {
 		return
 	}
 
-	// 获取密钥
-	secretKey := c.GetHeader("X-Secret-Key")
-	if secretKey == "" {
-		if cookie, err := c.Cookie("secret_key"); err == nil {
-			secretKey = cookie
-		}
-	}
-
-	// 验证密钥
-	if utils.ValidateSecretKey(secretKey) {
+	// 验证凭证
+	if utils.IsRequestAuthenticated(c) {
 		c.JSON(http.StatusOK, gin.H{
 			"success": true,
 			"message": "认证有效",
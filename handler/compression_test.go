@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-uploader/utils"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestResponseCompression(t *testing.T) {
+	initTestStorage(t)
+	utils.Config.EnableResponseCompression = true
+	utils.Config.CompressionMinSizeBytes = 1400
+	utils.Config.DiskWarningThresholdPercent = 100
+	for i := 0; i < 50; i++ {
+		task := &utils.UploadTask{
+			FileID:      fmt.Sprintf("task-%d", i),
+			TaskType:    "file",
+			FileName:    fmt.Sprintf("file-%d.bin", i),
+			TotalChunks: 4,
+			Status:      "pending",
+			Chunks:      make(map[int]utils.ChunkInfo),
+			CreatedAt:   time.Now(),
+		}
+		if err := utils.Storage.SaveTask(task); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(utils.GzipMiddleware([]string{"/upload_chunk"}))
+	r.GET("/health", HealthCheck)
+	r.GET("/tasks", GetAllTasks)
+	r.GET("/upload_chunk", GetAllTasks)
+
+	request := func(path string, acceptGzip bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if acceptGzip {
+			req.Header.Set("Accept-Encoding", "gzip, deflate")
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// 健康检查响应小于最小压缩大小，原样返回
+	health := request("/health", true)
+	if encoding := health.Header().Get("Content-Encoding"); encoding != "" {
+		t.Fatalf("健康检查响应不应压缩: Content-Encoding=%s (%d 字节)", encoding, health.Body.Len())
+	}
+	if health.Body.Len() >= 1400 || !json.Valid(health.Body.Bytes()) {
+		t.Fatalf("健康检查响应 %d 字节，不是未压缩的JSON", health.Body.Len())
+	}
+	if health.Header().Get("Vary") != "Accept-Encoding" {
+		t.Fatal("缺少 Vary: Accept-Encoding")
+	}
+
+	// 任务列表超过最小压缩大小
+	plain := request("/tasks", false)
+	if plain.Header().Get("Content-Encoding") != "" || plain.Body.Len() < 1400 {
+		t.Fatalf("未声明接受gzip时不应压缩: %d 字节", plain.Body.Len())
+	}
+	compressed := request("/tasks", true)
+	if compressed.Header().Get("Content-Encoding") != "gzip" || compressed.Header().Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("任务列表应压缩: %v", compressed.Header())
+	}
+	reader, err := gzip.NewReader(compressed.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 任务顺序不固定，只比较解压后的任务数
+	var response struct {
+		Total int `json:"total"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil || response.Total != 50 {
+		t.Fatalf("解压后的任务列表无效: total=%d err=%v", response.Total, err)
+	}
+
+	if excluded := request("/upload_chunk", true); excluded.Header().Get("Content-Encoding") != "" {
+		t.Fatal("排除的路由不应压缩")
+	}
+}
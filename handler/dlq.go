@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-uploader/utils"
+)
+
+// ListDeadLetters 列出死信队列中的任务
+// @Summary 列出死信队列
+// @Description 分片重试次数耗尽的任务会移出活动任务并进入死信队列，最近失败的排在前面
+// @Tags 任务
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /dlq [get]
+func ListDeadLetters(c *gin.Context) {
+	if utils.DLQ == nil {
+		c.JSON(500, gin.H{"error": "死信队列未初始化"})
+		return
+	}
+
+	entries, err := utils.DLQ.List()
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("读取死信队列失败: %v", err)})
+		return
+	}
+
+	tenantID := utils.TenantFromContext(c)
+	items := make([]gin.H, 0, len(entries))
+	for _, entry := range entries {
+		task := entry.Task
+		if !task.VisibleToTenant(tenantID) {
+			continue
+		}
+		items = append(items, gin.H{
+			"file_id":                   task.FileID,
+			"filename":                  task.FileName,
+			"relative_path":             task.RelativePath,
+			"total_chunks":              task.TotalChunks,
+			"file_size":                 task.FileSize,
+			"failure_reason":            entry.FailureReason,
+			"failed_at":                 entry.FailedAt,
+			"permanently_failed_chunks": task.PermanentlyFailedChunks(),
+			"tags":                      task.Tags,
+		})
+	}
+
+	c.JSON(200, gin.H{
+		"entries": items,
+		"total":   len(items),
+	})
+}
+
+// RetryDeadLetter 将死信队列中的任务重置重试次数后移回活动任务
+// @Summary 重试死信队列中的任务
+// @Description 重置所有分片的重试次数，失败的分片需重新上传
+// @Tags 任务
+// @Produce json
+// @Param file_id path string true "任务ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /dlq/{file_id}/retry [post]
+func RetryDeadLetter(c *gin.Context) {
+	fileID := c.Param("file_id")
+	if fileID == "" {
+		c.JSON(400, gin.H{"error": "缺少file_id参数"})
+		return
+	}
+
+	if utils.Storage == nil {
+		c.JSON(500, gin.H{"error": "存储管理器未初始化"})
+		return
+	}
+	// 其他租户的死信任务视为不存在
+	if utils.DLQ != nil {
+		if entry, err := utils.DLQ.Get(fileID); err == nil && !entry.Task.VisibleToTenant(utils.TenantFromContext(c)) {
+			c.JSON(404, gin.H{"error": utils.ErrDeadLetterNotFound.Error()})
+			return
+		}
+	}
+
+	task, err := utils.Storage.RetryDeadLetter(fileID)
+	if err != nil {
+		switch {
+		case errors.Is(err, utils.ErrDeadLetterNotFound):
+			c.JSON(404, gin.H{"error": err.Error()})
+		case errors.Is(err, utils.ErrTaskAlreadyActive):
+			c.JSON(409, gin.H{"error": err.Error()})
+		default:
+			c.JSON(500, gin.H{"error": fmt.Sprintf("重试任务失败: %v", err)})
+		}
+		return
+	}
+
+	utils.RequestLogger(c).Info("死信队列任务已重新加入活动任务", "file_id", fileID)
+	c.JSON(200, gin.H{
+		"status":          "ok",
+		"message":         "任务已移回活动任务，请重新上传未完成的分片",
+		"file_id":         fileID,
+		"task_status":     task.Status,
+		"uploaded_chunks": len(utils.Storage.GetUploadedChunks(fileID)),
+	})
+}
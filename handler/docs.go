@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+	"go-uploader/docs"
+	"net/http"
+)
+
+// ErrorResponse 错误响应结构（仅用于API文档）
+type ErrorResponse struct {
+	Error string `json:"error" example:"任务不存在"`
+}
+
+// OpenAPISpec 返回由 swag 注释生成的API文档
+func OpenAPISpec(c *gin.Context) {
+	c.Data(200, "application/json; charset=utf-8", []byte(docs.SpecJSON()))
+}
+
+// SwaggerUI 返回Swagger UI处理函数，specURL 为文档JSON的地址
+func SwaggerUI(specURL string) gin.HandlerFunc {
+	ui := ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.URL(specURL))
+	return func(c *gin.Context) {
+		// 访问目录本身时跳转到首页
+		if c.Param("any") == "/" {
+			c.Redirect(http.StatusMovedPermanently, "index.html")
+			return
+		}
+		ui(c)
+	}
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-uploader/utils"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DownloadFile 下载已合并的文件，支持Range断点续传和ETag缓存校验
+// @Summary 下载已合并的文件
+// @Description 支持 Range 断点续传和 If-None-Match 缓存校验
+// @Tags 文件
+// @Produce octet-stream
+// @Param filepath path string true "文件相对路径"
+// @Param Range header string false "下载范围，如 bytes=0-1023"
+// @Success 200 {file} file
+// @Success 206 {file} file
+// @Success 304 "未修改"
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /files/{filepath} [get]
+func DownloadFile(c *gin.Context) {
+	logger := utils.RequestLogger(c)
+	// 与下载共用通配路由
+	if _, number, ok := parseVersionsPath(strings.TrimPrefix(c.Param("filepath"), "/")); ok && number == "" {
+		ListFileVersions(c)
+		return
+	}
+	if !utils.CurrentConfig().EnableDownload {
+		c.JSON(403, gin.H{"error": "文件下载功能未启用"})
+		return
+	}
+
+	relativePath := strings.TrimPrefix(c.Param("filepath"), "/")
+	if relativePath == "" {
+		c.JSON(400, gin.H{"error": "缺少文件路径"})
+		return
+	}
+
+	// 防止目录遍历攻击
+	if strings.Contains(relativePath, "..") {
+		c.JSON(400, gin.H{"error": "无效的文件路径"})
+		return
+	}
+
+	cleanPath := filepath.Clean(filepath.FromSlash(relativePath))
+	if !withinTenantFiles(c, filepath.ToSlash(cleanPath)) {
+		c.JSON(404, gin.H{"error": "文件不存在"})
+		return
+	}
+	fullPath := filepath.Join(utils.CurrentConfig().MergedDir, cleanPath)
+
+	file, err := os.Open(fullPath)
+	if err != nil {
+		c.JSON(404, gin.H{"error": "文件不存在"})
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		c.JSON(404, gin.H{"error": "文件不存在"})
+		return
+	}
+
+	// 使用任务元数据中的MD5作为ETag，ServeContent 会据此处理 If-None-Match
+	if task := findMergedTask(filepath.ToSlash(cleanPath)); task != nil {
+		if task.FileMD5 != "" {
+			c.Header("ETag", fmt.Sprintf("%q", task.FileMD5))
+		}
+		if task.MIMEType != "" {
+			c.Header("Content-Type", task.MIMEType)
+		}
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", info.Name()))
+	c.Header("Accept-Ranges", "bytes")
+
+	http.ServeContent(c.Writer, c.Request, info.Name(), info.ModTime(), file)
+
+	written := c.Writer.Size()
+	if written < 0 {
+		written = 0
+	}
+
+	logger.Info("文件下载",
+		"client_ip", c.ClientIP(),
+		"path", cleanPath,
+		"status", c.Writer.Status(),
+		"bytes", written,
+	)
+}
+
+// findMergedTask 根据合并后的相对路径查找已完成的任务
+func findMergedTask(relativePath string) *utils.UploadTask {
+	for _, task := range utils.Storage.GetAllTasks() {
+		if task.Status == "completed" && task.MergedPath == relativePath {
+			return task
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-uploader/utils"
+)
+
+// APIError 带HTTP状态码的接口错误，HTTP和gRPC接口共用
+type APIError struct {
+	Status  int
+	Message string
+	Fields  gin.H // 响应中的附加字段
+}
+
+// Error 实现 error 接口
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// Body 转换为JSON响应体
+func (e *APIError) Body() gin.H {
+	body := gin.H{"error": e.Message}
+	for key, value := range e.Fields {
+		body[key] = value
+	}
+	return body
+}
+
+// newAPIError 创建接口错误，fields 可为nil
+func newAPIError(status int, fields gin.H, format string, args ...interface{}) *APIError {
+	return &APIError{Status: status, Message: fmt.Sprintf(format, args...), Fields: fields}
+}
+
+// respondError 输出错误响应，非 APIError 按500处理；路由超时导致的服务端错误返回504
+func respondError(c *gin.Context, err error) {
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		apiErr = newAPIError(500, nil, "%s", err.Error())
+	}
+	if apiErr.Status >= 500 && utils.RequestTimedOut(c) {
+		c.JSON(504, gin.H{"error": "请求处理超时", "detail": apiErr.Message})
+		return
+	}
+	c.JSON(apiErr.Status, apiErr.Body())
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-uploader/utils"
+	"time"
+)
+
+// EstimateUploadRequest 上传前的预估请求
+type EstimateUploadRequest struct {
+	FileSize    int64  `json:"file_size" binding:"required" example:"1073741824"`
+	TotalChunks int    `json:"total_chunks" example:"128"`    // 可选：计划的分片数，用于检查分片大小
+	MIMEType    string `json:"mime_type" example:"video/mp4"` // 可选：按 allowed_mime_types / blocked_mime_types 检查
+}
+
+// EstimateUpload 上传开始前检查文件是否符合服务器限制，并给出建议的分片大小和预计的合并耗时
+// @Summary 上传前预估
+// @Description 检查文件大小、分片大小、MIME类型和磁盘可用空间是否满足要求；suggested_chunk_size 为 file_size / (concurrent_uploads * 2)，限制在 [min_chunk_size, max_chunk_size] 内；estimated_merge_time_ms 按最近合并的吞吐量估算
+// @Tags 上传
+// @Accept json
+// @Produce json
+// @Param request body EstimateUploadRequest true "文件信息"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Router /estimate [post]
+func EstimateUpload(c *gin.Context) {
+	var req EstimateUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+	if req.FileSize <= 0 || req.TotalChunks < 0 {
+		c.JSON(400, gin.H{"error": "file_size 必须大于0，total_chunks 不能小于0"})
+		return
+	}
+
+	reasons := make([]string, 0)
+	if req.FileSize > utils.CurrentConfig().MaxFileSize {
+		reasons = append(reasons, "file_too_large")
+	}
+	if req.TotalChunks > 0 {
+		chunkSize := (req.FileSize + int64(req.TotalChunks) - 1) / int64(req.TotalChunks)
+		if chunkSize > utils.CurrentConfig().MaxChunkSize {
+			reasons = append(reasons, "chunk_too_large")
+		} else if req.TotalChunks > 1 && chunkSize < utils.CurrentConfig().MinChunkSize {
+			reasons = append(reasons, "chunk_too_small")
+		}
+	}
+	if req.MIMEType != "" && !utils.IsMIMETypeAllowed(req.MIMEType) {
+		reasons = append(reasons, "mime_type_not_allowed")
+	}
+
+	throughput, samples := utils.Merges.Throughput()
+	response := gin.H{
+		"max_file_size":            utils.CurrentConfig().MaxFileSize,
+		"max_chunk_size":           utils.CurrentConfig().MaxChunkSize,
+		"min_chunk_size":           utils.CurrentConfig().MinChunkSize,
+		"suggested_chunk_size":     utils.SuggestedChunkSize(req.FileSize),
+		"estimated_merge_time_ms":  int64(float64(req.FileSize) / throughput * float64(time.Second/time.Millisecond)),
+		"merge_throughput_bps":     int64(throughput),
+		"merge_throughput_samples": samples,
+	}
+
+	// 合并时要求的可用空间与合并前的检查一致
+	if available, err := utils.AvailableBytes(utils.CurrentConfig().MergedDir); err != nil {
+		utils.RequestLogger(c).Warn("检查磁盘空间失败", "error", err)
+	} else {
+		response["disk_available_bytes"] = available
+		if available < utils.RequiredMergeSpace(req.FileSize) {
+			reasons = append(reasons, "insufficient_disk_space")
+		}
+	}
+
+	response["accepted"] = len(reasons) == 0
+	response["reasons"] = reasons
+	c.JSON(200, response)
+}
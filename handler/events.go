@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-uploader/utils"
+	"time"
+)
+
+// sseKeepAliveInterval SSE心跳间隔
+const sseKeepAliveInterval = 15 * time.Second
+
+// TaskEvents 通过SSE推送任务上传进度
+// @Summary 通过SSE订阅任务进度
+// @Tags 上传
+// @Produce text/event-stream
+// @Param file_id query string false "只订阅指定任务"
+// @Success 200 {string} string "SSE事件流"
+// @Router /events [get]
+func TaskEvents(c *gin.Context) {
+	fileID := c.Query("file_id")
+	if fileID == "" {
+		c.JSON(400, gin.H{"error": "缺少file_id参数"})
+		return
+	}
+
+	if utils.Storage == nil {
+		c.JSON(500, gin.H{"error": "存储管理器未初始化"})
+		return
+	}
+
+	task, exists := tenantTask(c, fileID)
+	if !exists {
+		c.JSON(404, gin.H{"error": "任务不存在"})
+		return
+	}
+
+	events := utils.Events.Subscribe(fileID)
+	defer utils.Events.Unsubscribe(fileID, events)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(200)
+
+	// 先推送当前状态快照
+	if err := writeSSEEvent(c, utils.NewTaskEvent(task)); err != nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := writeSSEEvent(c, event); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(c.Writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// writeSSEEvent 写入一条SSE事件并立即刷新
+func writeSSEEvent(c *gin.Context, event utils.TaskEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(c.Writer, "event: progress\ndata: %s\n\n", data); err != nil {
+		return err
+	}
+	c.Writer.Flush()
+	return nil
+}
@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-uploader/utils"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 文件列表分页参数
+const (
+	defaultFilePageSize = 50
+	maxFilePageSize     = 1000
+)
+
+// MergedFileInfo 已合并文件信息
+type MergedFileInfo struct {
+	Path       string    `json:"path"`
+	Size       int64     `json:"size"`
+	ModifiedAt time.Time `json:"modified_at"`
+	MD5        string    `json:"md5,omitempty"`
+}
+
+// ListFiles 分页列出合并目录下的文件，支持 ?prefix= 限定子目录
+// @Summary 分页列出已合并的文件
+// @Tags 文件
+// @Produce json
+// @Param prefix query string false "子目录前缀"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(50)
+// @Success 200 {object} object{files=[]MergedFileInfo,total=int,page=int,page_size=int}
+// @Failure 400 {object} ErrorResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /files [get]
+func ListFiles(c *gin.Context) {
+	prefix := strings.Trim(c.Query("prefix"), "/")
+	if strings.Contains(prefix, "..") {
+		c.JSON(400, gin.H{"error": "无效的目录前缀"})
+		return
+	}
+	if tenantID := utils.TenantFromContext(c); tenantID != "" && prefix == "" {
+		prefix = tenantID
+	} else if !withinTenantFiles(c, prefix) {
+		c.JSON(403, gin.H{"error": "无权访问其他租户的文件"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultFilePageSize)))
+	if pageSize < 1 {
+		pageSize = defaultFilePageSize
+	}
+	if pageSize > maxFilePageSize {
+		pageSize = maxFilePageSize
+	}
+
+	files, err := listMergedFiles(prefix)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("读取文件列表失败: %v", err)})
+		return
+	}
+
+	total := len(files)
+	start := (page - 1) * pageSize
+	if start > total {
+		start = total
+	}
+	end := start + pageSize
+	if end > total {
+		end = total
+	}
+
+	c.JSON(200, gin.H{
+		"files":     files[start:end],
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// DeleteFile 删除已合并的文件并清理对应的任务元数据
+// @Summary 删除已合并的文件
+// @Tags 文件
+// @Produce json
+// @Param filepath path string true "文件相对路径"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /files/{filepath} [delete]
+func DeleteFile(c *gin.Context) {
+	logger := utils.RequestLogger(c)
+	// 与删除文件共用通配路由
+	if _, number, ok := parseVersionsPath(strings.TrimPrefix(c.Param("filepath"), "/")); ok && number != "" {
+		DeleteFileVersion(c)
+		return
+	}
+	if !utils.CurrentConfig().AllowFileDeletion {
+		c.JSON(403, gin.H{"error": "文件删除功能未启用"})
+		return
+	}
+
+	relativePath := strings.TrimPrefix(c.Param("filepath"), "/")
+	if relativePath == "" || strings.Contains(relativePath, "..") {
+		c.JSON(400, gin.H{"error": "无效的文件路径"})
+		return
+	}
+	cleanPath := filepath.Clean(filepath.FromSlash(relativePath))
+	if !withinTenantFiles(c, filepath.ToSlash(cleanPath)) {
+		c.JSON(404, gin.H{"error": "文件不存在"})
+		return
+	}
+	fullPath := filepath.Join(utils.CurrentConfig().MergedDir, cleanPath)
+
+	// 使用 Lstat 不跟随符号链接，只删除链接本身
+	info, err := os.Lstat(fullPath)
+	if err != nil {
+		c.JSON(404, gin.H{"error": "文件不存在"})
+		return
+	}
+	if info.IsDir() {
+		c.JSON(400, gin.H{"error": "不能删除目录"})
+		return
+	}
+
+	// 上级目录经符号链接解析后仍需位于合并目录内
+	if !isWithinMergedDir(filepath.Dir(fullPath)) {
+		c.JSON(400, gin.H{"error": "无效的文件路径"})
+		return
+	}
+
+	if err := os.Remove(fullPath); err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("删除文件失败: %v", err)})
+		return
+	}
+
+	response := gin.H{
+		"status": "ok",
+		"path":   filepath.ToSlash(cleanPath),
+	}
+	if task := findMergedTask(filepath.ToSlash(cleanPath)); task != nil {
+		if err := utils.Storage.DeleteTask(task.FileID); err != nil {
+			logger.Error("删除文件关联任务失败", "file_id", task.FileID, "error", err)
+		} else {
+			response["deleted_task"] = task.FileID
+		}
+	}
+
+	logger.Info("删除已合并文件", "client_ip", c.ClientIP(), "path", cleanPath, "size", info.Size())
+	c.JSON(200, response)
+}
+
+// listMergedFiles 遍历合并目录，返回按路径排序的普通文件列表
+func listMergedFiles(prefix string) ([]MergedFileInfo, error) {
+	md5ByPath := make(map[string]string)
+	for _, task := range utils.Storage.GetAllTasks() {
+		if task.MergedPath != "" {
+			md5ByPath[task.MergedPath] = task.FileMD5
+		}
+	}
+
+	root := filepath.Join(utils.CurrentConfig().MergedDir, filepath.FromSlash(prefix))
+	files := make([]MergedFileInfo, 0)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(utils.CurrentConfig().MergedDir, path)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		files = append(files, MergedFileInfo{
+			Path:       rel,
+			Size:       info.Size(),
+			ModifiedAt: info.ModTime(),
+			MD5:        md5ByPath[rel],
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// isWithinMergedDir 检查目录解析符号链接后是否位于合并目录内
+func isWithinMergedDir(dir string) bool {
+	base, err := filepath.EvalSymlinks(utils.CurrentConfig().MergedDir)
+	if err != nil {
+		return false
+	}
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(base, resolved)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -1,16 +1,27 @@
 package handler
 
 import (
+	"context"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"go-uploader/utils"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"time"
 )
 
 // HealthCheck 健康检查
+// @Summary 健康检查
+// @Description 开启 health_check_write_test 时在上传、合并和元数据目录中写入并删除32字节的测试文件，写入失败的目录标记为 write_error，结果缓存 health_check_write_cache_ttl 秒
+// @Tags 监控
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /health [get]
 func HealthCheck(c *gin.Context) {
 	status := "healthy"
 	checks := make(map[string]interface{})
@@ -24,7 +35,7 @@ func HealthCheck(c *gin.Context) {
 	}
 	
 	// 检查上传目录
-	if _, err := os.Stat(utils.Config.UploadDir); os.IsNotExist(err) {
+	if _, err := os.Stat(utils.CurrentConfig().UploadDir); os.IsNotExist(err) {
 		status = "unhealthy"
 		checks["upload_dir"] = "目录不存在"
 	} else {
@@ -32,25 +43,59 @@ func HealthCheck(c *gin.Context) {
 	}
 	
 	// 检查合并目录
-	if _, err := os.Stat(utils.Config.MergedDir); os.IsNotExist(err) {
+	if _, err := os.Stat(utils.CurrentConfig().MergedDir); os.IsNotExist(err) {
 		status = "unhealthy"
 		checks["merged_dir"] = "目录不存在"
 	} else {
 		checks["merged_dir"] = "正常"
 	}
+
+	// 写入测试：目录存在但只读或底层文件系统出错时同样视为不健康
+	if utils.CurrentConfig().HealthCheckWriteTest {
+		ttl := time.Duration(utils.CurrentConfig().HealthCheckWriteCacheTTL) * time.Second
+		writeErrors := make(map[string]string)
+		for _, dir := range []struct{ key, path string }{
+			{"upload_dir", utils.CurrentConfig().UploadDir},
+			{"merged_dir", utils.CurrentConfig().MergedDir},
+			{"metadata_dir", filepath.Join(utils.CurrentConfig().UploadDir, ".metadata")},
+		} {
+			if checks[dir.key] == "目录不存在" {
+				continue
+			}
+			if err := utils.DirWriteProbe.Check(dir.path, ttl); err != nil {
+				status = "unhealthy"
+				checks[dir.key] = "write_error"
+				writeErrors[dir.key] = err.Error()
+			} else if _, exists := checks[dir.key]; !exists {
+				checks[dir.key] = "正常"
+			}
+		}
+		if len(writeErrors) > 0 {
+			checks["write_errors"] = writeErrors
+		}
+	}
 	
 	// 检查磁盘空间
-	diskUsage, err := getDiskUsage(utils.Config.UploadDir)
+	diskUsage, err := getDiskUsage(utils.CurrentConfig().UploadDir)
 	if err != nil {
 		checks["disk_space"] = fmt.Sprintf("检查失败: %v", err)
 	} else {
 		checks["disk_space"] = diskUsage
-		// 如果磁盘使用率超过95%，标记为不健康
-		if diskUsage["usage_percent"].(float64) > 95 {
+		// 文件系统使用率超过阈值时标记为警告，不覆盖不健康的状态
+		if diskUsage["usage_percent"].(float64) > utils.CurrentConfig().DiskWarningThresholdPercent && status == "healthy" {
 			status = "warning"
 		}
 	}
 	
+	response := gin.H{
+		"status":    status,
+		"timestamp": time.Now(),
+		"checks":    checks,
+	}
+	if available, err := utils.AvailableBytes(utils.CurrentConfig().MergedDir); err == nil {
+		response["available_bytes"] = available
+	}
+
 	httpStatus := 200
 	if status == "unhealthy" {
 		httpStatus = 503
@@ -58,14 +103,17 @@ func HealthCheck(c *gin.Context) {
 		httpStatus = 200 // 警告状态仍返回200，但在响应中标明
 	}
 	
-	c.JSON(httpStatus, gin.H{
-		"status":    status,
-		"timestamp": time.Now(),
-		"checks":    checks,
-	})
+	c.JSON(httpStatus, response)
 }
 
 // SystemInfo 系统信息
+// @Summary 系统信息
+// @Tags 监控
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /system [get]
 func SystemInfo(c *gin.Context) {
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
@@ -79,7 +127,7 @@ func SystemInfo(c *gin.Context) {
 	}
 	
 	// 获取磁盘使用情况
-	diskUsage, err := getDiskUsage(utils.Config.UploadDir)
+	diskUsage, err := getDiskUsage(utils.CurrentConfig().UploadDir)
 	if err != nil {
 		diskUsage = map[string]interface{}{
 			"error": err.Error(),
@@ -96,13 +144,13 @@ func SystemInfo(c *gin.Context) {
 			"gc_runs":         m.NumGC,
 		},
 		"config": gin.H{
-			"upload_dir":               utils.Config.UploadDir,
-			"merged_dir":               utils.Config.MergedDir,
-			"max_file_size":            utils.Config.MaxFileSize,
-			"max_chunk_size":           utils.Config.MaxChunkSize,
-			"concurrent_uploads":       utils.Config.ConcurrentUploads,
-			"enable_integrity_check":   utils.Config.EnableIntegrityCheck,
-			"enable_atomic_operations": utils.Config.EnableAtomicOperations,
+			"upload_dir":               utils.CurrentConfig().UploadDir,
+			"merged_dir":               utils.CurrentConfig().MergedDir,
+			"max_file_size":            utils.CurrentConfig().MaxFileSize,
+			"max_chunk_size":           utils.CurrentConfig().MaxChunkSize,
+			"concurrent_uploads":       utils.CurrentConfig().ConcurrentUploads,
+			"enable_integrity_check":   utils.CurrentConfig().EnableIntegrityCheck,
+			"enable_atomic_operations": utils.CurrentConfig().EnableAtomicOperations,
 		},
 		"tasks":      taskStats,
 		"disk_usage": diskUsage,
@@ -129,62 +177,65 @@ func getTaskStatistics() map[string]int {
 	return stats
 }
 
-// getDiskUsage 获取磁盘使用情况
+// dirSizeMaxDepth 统计目录大小时的最大遍历深度
+const dirSizeMaxDepth = 32
+
+// getDiskUsage 获取路径所在文件系统的使用情况，以及上传目录和合并目录占用的大小
 func getDiskUsage(path string) (map[string]interface{}, error) {
 	// 获取文件系统信息
-	_, err := os.Stat(path)
+	fsStats, err := utils.GetFilesystemStats(path)
 	if err != nil {
 		return nil, err
 	}
 	
-	// 在Windows和Unix系统上，这个实现会有所不同
-	// 这里提供一个简化的版本
-	uploadDirSize, err := getDirSize(utils.Config.UploadDir)
+	// 目录遍历超时后放弃统计，避免健康检查被大量分片文件拖慢
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(utils.CurrentConfig().HealthCheckTimeout)*time.Second)
+	defer cancel()
+
+	uploadDir, err := utils.GetDirSize(ctx, utils.CurrentConfig().UploadDir, dirSizeMaxDepth)
 	if err != nil {
-		uploadDirSize = 0
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("统计上传目录超时: %v", err)
+		}
+		uploadDir = utils.DirSizeResult{}
 	}
 	
-	mergedDirSize, err := getDirSize(utils.Config.MergedDir)
+	mergedDir, err := utils.GetDirSize(ctx, utils.CurrentConfig().MergedDir, dirSizeMaxDepth)
 	if err != nil {
-		mergedDirSize = 0
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("统计合并目录超时: %v", err)
+		}
+		mergedDir = utils.DirSizeResult{}
 	}
 	
-	// 简化的磁盘使用率计算
-	// 实际应用中应该使用系统调用获取真实的磁盘空间信息
-	totalUsed := uploadDirSize + mergedDirSize
-	
 	return map[string]interface{}{
-		"upload_dir_size":  uploadDirSize,
-		"merged_dir_size":  mergedDirSize,
-		"total_used":       totalUsed,
-		"usage_percent":    float64(totalUsed) / float64(utils.Config.MaxFileSize) * 100, // 简化计算
-		"last_checked":     time.Now(),
+		"upload_dir_size":   uploadDir.Size,
+		"merged_dir_size":   mergedDir.Size,
+		"upload_dir_files":  uploadDir.FileCount,
+		"merged_dir_files":  mergedDir.FileCount,
+		"scan_duration_ms":  (uploadDir.Duration + mergedDir.Duration).Milliseconds(),
+		"total_bytes":       fsStats.TotalBytes,
+		"free_bytes":        fsStats.FreeBytes,
+		"available_bytes":   fsStats.AvailableBytes,
+		"total_used":        fsStats.UsedBytes,
+		"usage_percent":     fsStats.UsagePercent,
+		"last_checked":      time.Now(),
 	}, nil
 }
 
-// getDirSize 计算目录大小
-func getDirSize(path string) (int64, error) {
-	var size int64
-	
-	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if !info.IsDir() {
-			size += info.Size()
-		}
-		return nil
-	})
-	
-	return size, err
-}
-
 // bToMb 字节转MB
 func bToMb(b uint64) uint64 {
 	return b / 1024 / 1024
 }
 
 // GetMetrics 获取性能指标
+// @Summary 性能指标
+// @Tags 监控
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /metrics [get]
 func GetMetrics(c *gin.Context) {
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
@@ -200,13 +251,79 @@ func GetMetrics(c *gin.Context) {
 		}
 	}
 	
+	metrics := gin.H{
+		"goroutines":     runtime.NumGoroutine(),
+		"memory_mb":      bToMb(m.Alloc),
+		"gc_runs":        m.NumGC,
+		"active_tasks":   activeTasks,
+	}
+	// 内容寻址存储节省的空间
+	if utils.CAS != nil {
+		metrics["cas"] = utils.CAS.Stats()
+	}
+
 	c.JSON(200, gin.H{
 		"timestamp": time.Now().Unix(),
-		"metrics": gin.H{
-			"goroutines":     runtime.NumGoroutine(),
-			"memory_mb":      bToMb(m.Alloc),
-			"gc_runs":        m.NumGC,
-			"active_tasks":   activeTasks,
-		},
+		"metrics":   metrics,
 	})
-} 
\ No newline at end of file
+} 
+// GetTaskMetrics 获取上传中任务的速度
+// @Summary 任务速度指标
+// @Description 列出上传中的任务及其当前、峰值和平均速度，按当前速度从高到低排序；速度只保存在内存中，重启后重新统计
+// @Tags 监控
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /metrics/tasks [get]
+func GetTaskMetrics(c *gin.Context) {
+	if utils.Storage == nil {
+		c.JSON(500, gin.H{"error": "存储管理器未初始化"})
+		return
+	}
+
+	tenantID := utils.TenantFromContext(c)
+	type taskMetrics struct {
+		task  *utils.UploadTask
+		speed utils.SpeedStats
+	}
+	active := make([]taskMetrics, 0)
+	var totalSpeed float64
+	for _, task := range utils.Storage.GetAllTasks() {
+		if task.Status != "uploading" || !task.VisibleToTenant(tenantID) {
+			continue
+		}
+		speed := utils.TaskSpeedStats(task.FileID)
+		// 文件夹的速度已汇总子任务，不重复计入总速度
+		if task.TaskType != "folder" {
+			totalSpeed += speed.CurrentBps
+		}
+		active = append(active, taskMetrics{task: task, speed: speed})
+	}
+	sort.Slice(active, func(i, j int) bool {
+		return active[i].speed.CurrentBps > active[j].speed.CurrentBps
+	})
+
+	items := make([]gin.H, 0, len(active))
+	for _, item := range active {
+		items = append(items, gin.H{
+			"file_id":           item.task.FileID,
+			"filename":          item.task.FileName,
+			"task_type":         item.task.TaskType,
+			"parent_task_id":    item.task.ParentTaskID,
+			"current_bytes":     item.task.CurrentBytes,
+			"file_size":         item.task.FileSize,
+			"upload_speed_bps":  item.speed.CurrentBps,
+			"peak_speed_bps":    item.speed.PeakBps,
+			"average_speed_bps": item.speed.AverageBps,
+		})
+	}
+
+	c.JSON(200, gin.H{
+		"timestamp":              time.Now().Unix(),
+		"tasks":                  items,
+		"total":                  len(items),
+		"total_upload_speed_bps": totalSpeed,
+	})
+}
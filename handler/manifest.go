@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-uploader/utils"
+	"io"
+	"strings"
+	"time"
+)
+
+// CreateUploadSessionRequest 创建上传会话请求结构
+type CreateUploadSessionRequest struct {
+	FileID       string                `json:"file_id" binding:"required" example:"video_1700000000000"`
+	FileName     string                `json:"filename" example:"video.mp4"`
+	RelativePath string                `json:"relative_path"`
+	Manifest     []utils.ManifestEntry `json:"manifest" binding:"required"` // 所有分片的索引、校验值和大小
+}
+
+// CreateUploadSession 创建上传会话并保存服务端分片清单
+// @Summary 创建上传会话
+// @Description 清单可由独立的签发方生成，作为上传期间校验分片的依据：设置清单后，上传的分片按清单中的大小和校验值（Config.HashAlgorithm）校验，不一致时返回400；
+// @Description 分片总数和文件大小由清单决定。任务已存在时替换清单，已上传的分片与清单不一致时返回409
+// @Tags 上传
+// @Accept json
+// @Produce json
+// @Param request body CreateUploadSessionRequest true "会话参数"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /upload_session [post]
+func CreateUploadSession(c *gin.Context) {
+	var req CreateUploadSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("参数错误: %v", err)})
+		return
+	}
+
+	if utils.Storage == nil {
+		c.JSON(500, gin.H{"error": "存储管理器未初始化"})
+		return
+	}
+
+	if err := utils.ValidateManifest(req.Manifest); err != nil {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("上传清单无效: %v", err)})
+		return
+	}
+
+	if rejectOtherTenant(c, req.FileID) {
+		return
+	}
+
+	now := time.Now()
+	task, created, err := utils.Storage.SetManifest(&utils.UploadTask{
+		FileID:       req.FileID,
+		FileName:     req.FileName,
+		RelativePath: req.RelativePath,
+		Status:       "pending",
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		TenantID:     utils.TenantFromContext(c),
+		MaxBytes:     utils.RequestedQuotaLimit(c.GetHeader(utils.MaxSizeHeader)),
+	}, req.Manifest)
+	if err != nil {
+		if errors.Is(err, utils.ErrManifestMismatch) {
+			c.JSON(409, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(500, gin.H{"error": fmt.Sprintf("保存上传清单失败: %v", err)})
+		return
+	}
+
+	utils.RequestLogger(c).Info("已保存上传清单", "file_id", task.FileID, "total_chunks", task.TotalChunks, "created", created)
+	c.JSON(200, gin.H{
+		"status":       "ok",
+		"file_id":      task.FileID,
+		"created":      created,
+		"total_chunks": task.TotalChunks,
+		"file_size":    task.FileSize,
+	})
+}
+
+// verifyManifestChunk 按任务的上传清单校验分片，通过后以清单中的校验值作为分片的期望校验值；任务没有清单时不校验
+func verifyManifestChunk(task *utils.UploadTask, upload *ChunkUpload) error {
+	if len(task.Manifest) == 0 {
+		return nil
+	}
+
+	entry, ok := task.ManifestEntry(upload.Index)
+	if !ok {
+		return newAPIError(400, gin.H{"chunk_index": upload.Index}, "上传清单中没有分片 %d", upload.Index)
+	}
+	if upload.MD5 != "" && !strings.EqualFold(upload.MD5, entry.MD5) {
+		return newAPIError(400, gin.H{"chunk_index": upload.Index}, "%v: 分片 %d 提交的校验值为 %s，清单中为 %s",
+			utils.ErrManifestMismatch, upload.Index, upload.MD5, entry.MD5)
+	}
+
+	src, err := upload.Open()
+	if err != nil {
+		return newAPIError(500, nil, "读取分片数据失败: %v", err)
+	}
+	defer src.Close()
+
+	hasher := utils.Hasher.New()
+	size, err := io.Copy(hasher, src)
+	if err != nil {
+		return newAPIError(400, nil, "读取分片数据失败: %v", err)
+	}
+	if err := utils.VerifyManifestChunk(entry, size, hex.EncodeToString(hasher.Sum(nil))); err != nil {
+		return newAPIError(400, gin.H{"chunk_index": upload.Index}, "%s", err.Error())
+	}
+
+	upload.MD5 = strings.ToLower(entry.MD5)
+	return nil
+}
@@ -2,21 +2,55 @@ package handler
 
 import (
 	"context"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"go-uploader/utils"
+	"go.opentelemetry.io/otel/attribute"
 	"io"
-	"log"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
+// @Summary 合并文件分片
+// @Description 已在自动合并队列中的任务返回202及队列状态；已合并完成的任务再次提交时直接返回上次的合并结果（cached=true），不会重复合并
+// @Tags 上传
+// @Accept multipart/form-data
+// @Produce json
+// @Param file_id formData string true "文件ID"
+// @Param filename formData string true "文件名"
+// @Param total_chunks formData int true "分片总数"
+// @Param relative_path formData string false "文件相对路径"
+// @Param expected_md5 formData string false "期望的文件MD5"
+// @Param X-Merge-Timeout header int false "合并超时时间（秒），默认 merge_timeout_seconds，不能超过 max_merge_timeout_seconds"
+// @Param dry_run query bool false "只校验分片是否齐全及MD5和总大小，不生成合并文件，也不改变任务状态"
+// @Success 200 {object} map[string]interface{}
+// @Success 202 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 504 {object} ErrorResponse
+// @Failure 507 {object} map[string]interface{}
+// @Router /merge_chunks [post]
 func MergeChunks(c *gin.Context) {
-	// 创建超时上下文
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Minute)
+	logger := utils.RequestLogger(c)
+	// 登记进行中的操作，优雅关闭时等待其完成
+	done := utils.Inflight.Begin()
+	defer done()
+
+	timeout, err := mergeTimeout(c.GetHeader("X-Merge-Timeout"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	// 客户端断开连接时请求上下文被取消，合并随之中止
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
 	defer cancel()
 	
 	fileID := c.PostForm("file_id")
@@ -26,13 +60,11 @@ func MergeChunks(c *gin.Context) {
 	expectedMD5 := c.PostForm("expected_md5")   // 可选：期望的文件MD5
 
 	// 添加调试日志
-	log.Printf("合并请求参数: fileID=%s, filename=%s, totalChunksStr=%s, relativePath=%s", 
-		fileID, filename, totalChunksStr, relativePath)
+	logger.Debug("合并请求参数", "file_id", fileID, "filename", filename, "total_chunks", totalChunksStr, "relative_path", relativePath)
 
 	// 验证必要参数
 	if fileID == "" || filename == "" || totalChunksStr == "" {
-		log.Printf("合并失败: 缺少必要参数 - fileID=%s, filename=%s, totalChunksStr=%s", 
-			fileID, filename, totalChunksStr)
+		logger.Warn("合并失败: 缺少必要参数", "file_id", fileID, "filename", filename, "total_chunks", totalChunksStr)
 		c.JSON(400, gin.H{"error": "缺少必要参数"})
 		return
 	}
@@ -42,148 +74,586 @@ func MergeChunks(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "无效的分片总数"})
 		return
 	}
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+
+	outcome, err := MergeUpload(ctx, MergeRequest{
+		FileID:       fileID,
+		Filename:     filename,
+		RelativePath: relativePath,
+		TotalChunks:  totalChunks,
+		ExpectedMD5:  expectedMD5,
+		TenantID:     utils.TenantFromContext(c),
+		DryRun:       dryRun,
+	})
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			logger.Warn("合并超时", "file_id", fileID, "timeout_seconds", int64(timeout.Seconds()))
+			c.JSON(504, gin.H{"error": "合并超时", "timeout_seconds": int64(timeout.Seconds())})
+			return
+		}
+		respondError(c, err)
+		return
+	}
+
+	if outcome.DryRun != nil {
+		c.JSON(200, gin.H{
+			"status":              "dry_run_ok",
+			"file_id":             fileID,
+			"chunk_count":         outcome.DryRun.ChunkCount,
+			"total_size":          outcome.DryRun.TotalSize,
+			"expected_size":       outcome.DryRun.ExpectedSize,
+			"size_valid":          outcome.DryRun.SizeValid,
+			"all_checksums_valid": outcome.DryRun.AllChecksumsValid,
+			"invalid_chunks":      outcome.DryRun.InvalidChunks,
+			"missing_chunks":      outcome.DryRun.MissingChunks,
+			"unverified_chunks":   outcome.DryRun.UnverifiedChunks,
+		})
+		return
+	}
+
+	if outcome.Pending {
+		c.JSON(202, gin.H{"status": outcome.Job.State, "file_id": fileID, "merge_status": outcome.Job})
+		return
+	}
+
+	response := gin.H{
+		"status":        "ok",
+		"filePath":      outcome.FilePath,
+		"md5":           outcome.MD5,
+		"relative_path": relativePath,
+		"size":          outcome.Size,
+	}
+	if outcome.Job == nil {
+		response["merge_time"] = outcome.MergeTime
+	}
+	if outcome.StorageURL != "" {
+		response["storage_url"] = outcome.StorageURL
+	}
+	if outcome.Cached {
+		response["cached"] = true
+	}
+	c.JSON(200, response)
+}
+
+// MergeRequest 合并请求，HTTP和gRPC接口共用
+type MergeRequest struct {
+	FileID       string
+	Filename     string
+	RelativePath string
+	TotalChunks  int
+	ExpectedMD5  string // 可选：期望的文件MD5
+	TenantID     string // 请求的租户，其他租户的任务视为不存在
+	DryRun       bool   // 只校验分片，不生成合并文件
+}
+
+// MergeOutcome 合并结果，Job 非nil时表示结果来自自动合并队列
+type MergeOutcome struct {
+	MergeResult
+	Job     *utils.MergeJobStatus
+	Pending bool         // 自动合并队列仍在处理中
+	Cached  bool         // 任务此前已合并完成，结果来自 task.MergeResult
+	DryRun  *MergeDryRun // 试运行的校验结果，非试运行时为nil
+}
+
+// MergeUpload 校验分片和磁盘空间后执行合并，失败时返回 APIError
+func MergeUpload(ctx context.Context, req MergeRequest) (*MergeOutcome, error) {
+	logger := utils.LoggerFromContext(ctx)
+	fileID := req.FileID
 
 	// 获取任务信息
-	task, exists := utils.Storage.GetTask(fileID)
+	task, exists := utils.Storage.GetTenantTask(fileID, req.TenantID)
 	if !exists {
-		log.Printf("合并失败: 任务不存在 - fileID=%s", fileID)
-		c.JSON(404, gin.H{"error": "任务不存在"})
-		return
+		logger.Warn("合并失败: 任务不存在", "file_id", fileID)
+		return nil, newAPIError(404, nil, "任务不存在")
 	}
 	
-	log.Printf("找到任务: fileID=%s, status=%s, totalChunks=%d", 
-		fileID, task.Status, task.TotalChunks)
+	logger.Debug("找到任务", "file_id", fileID, "status", task.Status, "total_chunks", task.TotalChunks)
+
+	// 试运行只读取分片文件，不检查任务状态，也不写入合并目录
+	if req.DryRun {
+		dryRun, err := dryRunMerge(fileID, req.TotalChunks, task)
+		if err != nil {
+			return nil, newAPIError(500, nil, "校验分片失败: %v", err)
+		}
+		logger.Info("合并试运行完成", "file_id", fileID, "chunk_count", dryRun.ChunkCount, "total_size", dryRun.TotalSize,
+			"invalid_chunks", len(dryRun.InvalidChunks), "missing_chunks", len(dryRun.MissingChunks))
+		return &MergeOutcome{DryRun: dryRun}, nil
+	}
+
+	// 已完成的任务重复提交合并（如网络重试）时直接返回上次的合并结果
+	if outcome := cachedMergeOutcome(task); outcome != nil {
+		logger.Info("任务已合并，返回缓存的合并结果", "file_id", fileID)
+		return outcome, nil
+	}
 
 	// 验证所有分片是否已上传
 	uploadedChunks := utils.Storage.GetUploadedChunks(fileID)
-	log.Printf("分片上传验证: fileID=%s, 已上传=%d, 总共需要=%d, 任务中记录的总分片=%d", 
-		fileID, len(uploadedChunks), totalChunks, task.TotalChunks)
+	logger.Debug("分片上传验证", "file_id", fileID, "uploaded", len(uploadedChunks), "required", req.TotalChunks, "task_total_chunks", task.TotalChunks)
 	
-	if len(uploadedChunks) != totalChunks {
-		log.Printf("合并失败: 分片未完全上传 - fileID=%s, uploaded=%d, required=%d", 
-			fileID, len(uploadedChunks), totalChunks)
-		c.JSON(400, gin.H{
-			"error":          "分片未完全上传",
-			"uploaded":       len(uploadedChunks),
-			"total_required": totalChunks,
+	if len(uploadedChunks) != req.TotalChunks {
+		logger.Warn("合并失败: 分片未完全上传", "file_id", fileID, "uploaded", len(uploadedChunks), "required", req.TotalChunks)
+		return nil, newAPIError(400, gin.H{
+			"uploaded":        len(uploadedChunks),
+			"total_required":  req.TotalChunks,
 			"uploaded_chunks": uploadedChunks,
-		})
-		return
+		}, "分片未完全上传")
+	}
+
+	// 暂停等状态不允许直接合并为完成
+	if !utils.CanTransition(task.Status, "completed") {
+		return nil, newAPIError(409, nil, "任务当前状态为 %s，不能合并", task.Status)
+	}
+
+	// 已在自动合并队列中的任务直接返回队列状态
+	if utils.AutoMergeQueue != nil {
+		if job, queued := utils.AutoMergeQueue.Status(fileID); queued {
+			switch job.State {
+			case utils.MergeStateQueued, utils.MergeStateMerging:
+				return &MergeOutcome{Job: &job, Pending: true}, nil
+			case utils.MergeStateDone:
+				return &MergeOutcome{
+					MergeResult: MergeResult{FilePath: job.FilePath, MD5: task.FileMD5, Size: getFileSize(job.FilePath), StorageURL: task.StorageURL},
+					Job:         &job,
+				}, nil
+			}
+		}
+	}
+
+	// 磁盘空间不足时拒绝合并，避免写入中途失败留下损坏的目标文件
+	required := utils.RequiredMergeSpace(mergeSourceSize(task))
+	if available, err := utils.AvailableBytes(utils.CurrentConfig().MergedDir); err != nil {
+		logger.Warn("检查磁盘空间失败，跳过预检", "file_id", fileID, "error", err)
+	} else if available < required {
+		logger.Warn("合并失败: 磁盘空间不足", "file_id", fileID, "available", available, "required", required)
+		return nil, newAPIError(507, gin.H{
+			"available": available,
+			"required":  required,
+		}, "insufficient_disk_space")
+	}
+
+	result, err := runMerge(ctx, fileID, req.Filename, req.RelativePath, req.TotalChunks, req.ExpectedMD5, task)
+	if err == errAlreadyMerged {
+		if latest, exists := utils.Storage.GetTask(fileID); exists {
+			if outcome := cachedMergeOutcome(latest); outcome != nil {
+				logger.Info("任务已由其他请求合并，返回缓存的合并结果", "file_id", fileID)
+				return outcome, nil
+			}
+		}
+		err = errMergeInProgress
+	}
+	if err == errMergeInProgress {
+		return nil, newAPIError(409, nil, "合并操作正在进行中")
+	}
+	if err != nil {
+		return nil, newAPIError(500, gin.H{
+			"file_id": fileID,
+			"retry_count": task.RetryCount,
+			"can_retry": true,
+			"message": "您可以使用恢复功能重新尝试合并",
+		}, "合并文件失败: %v", err)
+	}
+
+	return &MergeOutcome{MergeResult: *result}, nil
+}
+
+// mergeSourceSize 估算合并后的文件大小，未记录文件大小时使用已上传分片的总大小
+func mergeSourceSize(task *utils.UploadTask) int64 {
+	if task.FileSize > task.CurrentBytes {
+		return task.FileSize
+	}
+	return task.CurrentBytes
+}
+
+// mergeTimeout 合并超时时间，默认为 Config.MergeTimeoutSeconds；请求头 X-Merge-Timeout（秒）可覆盖，不能超过 Config.MaxMergeTimeoutSeconds
+func mergeTimeout(header string) (time.Duration, error) {
+	seconds := utils.CurrentConfig().MergeTimeoutSeconds
+	if header != "" {
+		requested, err := strconv.ParseInt(header, 10, 64)
+		if err != nil || requested <= 0 {
+			return 0, fmt.Errorf("X-Merge-Timeout 必须为正整数（秒）")
+		}
+		if requested > utils.CurrentConfig().MaxMergeTimeoutSeconds {
+			return 0, fmt.Errorf("X-Merge-Timeout 不能超过 %d 秒", utils.CurrentConfig().MaxMergeTimeoutSeconds)
+		}
+		seconds = requested
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// errMergeInProgress 合并锁已被占用
+var errMergeInProgress = errors.New("合并操作正在进行中")
+
+// errAlreadyMerged 获取合并锁时任务已由其他请求合并完成
+var errAlreadyMerged = errors.New("任务已合并完成")
+
+// cachedMergeOutcome 任务已合并完成且记录了合并结果时返回该结果，否则返回nil
+func cachedMergeOutcome(task *utils.UploadTask) *MergeOutcome {
+	if task.Status != "completed" || task.MergeResult == nil {
+		return nil
+	}
+
+	cached := task.MergeResult
+	return &MergeOutcome{
+		MergeResult: MergeResult{
+			FilePath:   cached.FilePath,
+			MD5:        cached.MD5,
+			Size:       cached.Size,
+			MergeTime:  cached.MergeTime,
+			StorageURL: cached.StorageURL,
+		},
+		Cached: true,
+	}
+}
+
+// chunkMergeBreaker 分片合并的熔断器，分片缺失和完整性校验失败属于数据问题，不计入失败次数
+var chunkMergeBreaker = utils.RegisterCircuitBreaker("chunk_merge", func(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
 	}
+	msg := err.Error()
+	return !strings.Contains(msg, "分片文件缺失") && !strings.Contains(msg, "完整性验证失败")
+})
 
+// runMerge 加锁执行合并并更新任务状态，供手动合并和自动合并共用
+func runMerge(ctx context.Context, fileID, filename, relativePath string, totalChunks int, expectedMD5 string, task *utils.UploadTask) (*MergeResult, error) {
+	logger := utils.LoggerFromContext(ctx)
 	// 创建文件锁 - 使用安全的文件名
-	safeFileID := utils.SanitizeFileID(fileID)
-	lockPath := filepath.Join(utils.Config.UploadDir, safeFileID+".merge.lock")
+	lockPath := filepath.Join(utils.CurrentConfig().UploadDir, utils.SafeFileKey(fileID)+".merge.lock")
 	lock := utils.NewLockFile(lockPath)
 	if err := lock.Acquire(); err != nil {
-		c.JSON(409, gin.H{"error": "合并操作正在进行中"})
-		return
+		return nil, errMergeInProgress
 	}
 	defer lock.Release()
 
+	// 通过状态检查后、获取锁之前其他请求可能已完成合并，此时分片已清理，不能再次合并
+	if latest, exists := utils.Storage.GetTask(fileID); exists && latest.Status == "completed" && latest.MergeResult != nil {
+		return nil, errAlreadyMerged
+	}
+
+	// 启用版本管理时先把已存在的目标文件保存为历史版本
+	var archive *mergeArchive
+	if utils.CurrentConfig().EnableVersioning && utils.Versions != nil {
+		var err error
+		if archive, err = archiveMergeTarget(ctx, fileID, filename, relativePath, task); err != nil {
+			return nil, err
+		}
+	}
+
 	// 执行合并操作（带重试机制）
 	var result *MergeResult
-	err = utils.RetryWithBackoff(ctx, func() error {
-		var mergeErr error
-		result, mergeErr = mergeChunksWithIntegrityCheck(fileID, filename, relativePath, totalChunks, expectedMD5, task)
-		return mergeErr
+	spanAttrs := []attribute.KeyValue{
+		attribute.String("file_id", fileID),
+		attribute.Int("total_chunks", totalChunks),
+		attribute.Int64("file_size", task.FileSize),
+	}
+	err := utils.RetryWithBackoff(ctx, func() error {
+		return utils.WithSpan(ctx, "mergeChunksWithIntegrityCheck", spanAttrs, func(context.Context) error {
+			return chunkMergeBreaker.Execute(func() error {
+				var mergeErr error
+				result, mergeErr = mergeChunksWithIntegrityCheck(ctx, fileID, filename, relativePath, totalChunks, expectedMD5, task)
+				return mergeErr
+			})
+		})
 	}, utils.DefaultRetryConfig)
 
+	if err == nil {
+		utils.Merges.Record(result.Size, result.MergeTime)
+	}
+
+	// 按实际合并出的内容哈希去重：同一租户已有相同内容时改为指向已有文件的硬链接
+	if err == nil && utils.Dedup != nil {
+		linkDeduplicatedFile(ctx, fileID, task.TenantID, result)
+	}
+
+	// 上传到对象存储，失败时与合并失败一样可重试
+	if err == nil && utils.S3 != nil {
+		err = uploadMergedFileToS3(ctx, task, result)
+	}
+
 	if err != nil {
 		// 更新任务状态为失败，但保留详细错误信息
-		task.Status = "failed"
 		task.RetryCount++
 		
 		// 记录失败原因到任务中（如果需要可以添加ErrorMessage字段）
-		log.Printf("文件合并失败 [%s]: %v, 重试次数: %d", fileID, err, task.RetryCount)
+		if ctx.Err() == context.Canceled {
+			task.FailureReason = utils.FailureReasonClientDisconnected
+			logger.Warn("客户端断开连接，已中止合并", "file_id", fileID, "retry_count", task.RetryCount)
+		} else {
+			logger.Error("文件合并失败", "file_id", fileID, "error", err, "retry_count", task.RetryCount)
+		}
+		archive.restore(ctx)
 		
 		utils.Storage.SaveTask(task)
-		
-		c.JSON(500, gin.H{
-			"error": fmt.Sprintf("合并文件失败: %v", err),
-			"file_id": fileID,
-			"retry_count": task.RetryCount,
-			"can_retry": true,
-			"message": "您可以使用恢复功能重新尝试合并",
-		})
-		return
+		if tErr := utils.Storage.TransitionTask(fileID, "failed"); tErr != nil {
+			logger.Error("更新任务状态失败", "file_id", fileID, "error", tErr)
+		}
+		return nil, err
 	}
 
 	// 更新任务状态为完成
-	task.Status = "completed"
 	task.FileMD5 = result.MD5
+	task.FailureReason = ""
+	task.StorageURL = result.StorageURL
+	task.MergeTime = result.MergeTime
+	task.HashAlgorithm = utils.Hasher.Algorithm()
+	if result.LocalDeleted {
+		task.MergedPath = ""
+	} else if rel, relErr := filepath.Rel(utils.CurrentConfig().MergedDir, result.FilePath); relErr == nil {
+		task.MergedPath = filepath.ToSlash(rel)
+	}
+	if utils.CurrentConfig().EnableVersioning && utils.Versions != nil && task.MergedPath != "" {
+		task.VersionNumber = utils.Versions.Current(task.MergedPath)
+		task.PreviousVersions = utils.Versions.Paths(task.MergedPath)
+	}
+	task.MergeResult = &utils.MergeResult{
+		FilePath:   result.FilePath,
+		MD5:        result.MD5,
+		Size:       result.Size,
+		MergeTime:  result.MergeTime,
+		StorageURL: result.StorageURL,
+		MergedAt:   time.Now(),
+	}
+	task.PendingCleanup = true
 	if err := utils.Storage.SaveTask(task); err != nil {
-		log.Printf("更新任务状态失败: %v", err)
+		logger.Error("更新任务状态失败", "file_id", fileID, "error", err)
+	}
+	if err := utils.Storage.TransitionTask(fileID, "completed"); err != nil {
+		logger.Error("更新任务状态失败", "file_id", fileID, "error", err)
 	}
+	logger.Info("文件合并完成", "file_id", fileID, "path", result.FilePath, "size", result.Size, "duration_ms", result.MergeTime.Milliseconds())
 
-	// 清理临时分片文件（异步执行）
-	go func() {
-		srcDir := filepath.Join(utils.Config.UploadDir, fileID)
-		if err := os.RemoveAll(srcDir); err != nil {
-			log.Printf("清理临时文件失败: %v", err)
+	// 执行合并后处理钩子，本地文件已删除时无法处理
+	if result.LocalDeleted {
+		logger.Debug("本地合并文件已删除，跳过合并后处理钩子", "file_id", fileID)
+	} else {
+		utils.RunPostMergeHooks(task, result.FilePath)
+	}
+
+	// 清理分片文件；清理前进程退出时由启动时的 PendingCleanupTasks 补做
+	if err := utils.Storage.CleanupMergedChunks(fileID); err != nil {
+		logger.Error("清理分片文件失败", "file_id", fileID, "error", err)
+	}
+
+	return result, nil
+}
+
+// mergeArchive 合并前保存的历史版本，合并失败时恢复
+type mergeArchive struct {
+	relPath      string
+	version      *utils.FileVersion
+	previousTask *utils.UploadTask // 原文件对应的任务，其合并路径改为指向历史版本
+}
+
+// archiveMergeTarget 目标文件已存在时将其重命名为历史版本，并让原文件的任务指向历史版本；目标文件不存在时返回nil
+func archiveMergeTarget(ctx context.Context, fileID, filename, relativePath string, task *utils.UploadTask) (*mergeArchive, error) {
+	dstPath, err := resolveMergeTarget(task.TenantID, filename, relativePath)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(utils.CurrentConfig().MergedDir, dstPath)
+	if err != nil {
+		return nil, err
+	}
+	rel = filepath.ToSlash(rel)
+
+	// 查找原文件的任务需在重命名之前
+	previousTask := findMergedTask(rel)
+	version, err := utils.Versions.Archive(rel)
+	if err != nil || version == nil {
+		return nil, err
+	}
+
+	archive := &mergeArchive{relPath: rel, version: version}
+	if previousTask != nil && previousTask.FileID != fileID {
+		previousTask.MergedPath = version.Path
+		if err := utils.Storage.SaveTask(previousTask); err != nil {
+			utils.LoggerFromContext(ctx).Error("更新历史版本任务失败", "file_id", previousTask.FileID, "error", err)
 		}
-	}()
+		archive.previousTask = previousTask
+	}
 
-	c.JSON(200, gin.H{
-		"status":        "ok",
-		"filePath":      result.FilePath,
-		"md5":           result.MD5,
-		"relative_path": relativePath,
-		"size":          result.Size,
-		"merge_time":    result.MergeTime,
-	})
+	utils.LoggerFromContext(ctx).Info("已保存合并文件的历史版本", "file_id", fileID, "path", rel, "version", version.Version, "version_path", version.Path)
+	return archive, nil
+}
+
+// restore 合并失败时把历史版本移回原路径
+func (a *mergeArchive) restore(ctx context.Context) {
+	if a == nil {
+		return
+	}
+
+	logger := utils.LoggerFromContext(ctx)
+	if err := utils.Versions.Restore(a.relPath, a.version); err != nil {
+		logger.Error("恢复历史版本失败", "path", a.relPath, "version_path", a.version.Path, "error", err)
+		return
+	}
+	if a.previousTask != nil {
+		a.previousTask.MergedPath = a.relPath
+		if err := utils.Storage.SaveTask(a.previousTask); err != nil {
+			logger.Error("更新历史版本任务失败", "file_id", a.previousTask.FileID, "error", err)
+		}
+	}
+}
+
+// uploadMergedFileToS3 将合并文件上传到S3，对象键使用文件相对于合并目录的路径
+func uploadMergedFileToS3(ctx context.Context, task *utils.UploadTask, result *MergeResult) error {
+	logger := utils.LoggerFromContext(ctx)
+
+	rel, err := filepath.Rel(utils.CurrentConfig().MergedDir, result.FilePath)
+	if err != nil {
+		return fmt.Errorf("计算对象键失败: %v", err)
+	}
+	key := utils.S3.ObjectKey(filepath.ToSlash(rel))
+
+	start := time.Now()
+	url, err := utils.S3.Upload(ctx, result.FilePath, key, task.MIMEType)
+	if err != nil {
+		return fmt.Errorf("上传到S3失败: %v", err)
+	}
+	result.StorageURL = url
+	logger.Info("合并文件已上传到S3", "file_id", task.FileID, "url", url, "duration_ms", time.Since(start).Milliseconds())
+
+	if utils.CurrentConfig().S3DeleteLocalAfterUpload {
+		if err := os.Remove(result.FilePath); err != nil {
+			logger.Error("删除本地合并文件失败", "file_id", task.FileID, "path", result.FilePath, "error", err)
+		} else {
+			result.LocalDeleted = true
+		}
+	}
+	return nil
+}
+
+// AutoMergeTask 自动合并队列的合并回调
+func AutoMergeTask(task *utils.UploadTask) (string, error) {
+	done := utils.Inflight.Begin()
+	defer done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(utils.CurrentConfig().MergeTimeoutSeconds)*time.Second)
+	defer cancel()
+
+	result, err := runMerge(ctx, task.FileID, task.FileName, task.RelativePath, task.TotalChunks, "", task)
+	if err == errAlreadyMerged {
+		if latest, exists := utils.Storage.GetTask(task.FileID); exists && latest.MergeResult != nil {
+			return latest.MergeResult.FilePath, nil
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	return result.FilePath, nil
+}
+
+// MergeStatus 查询任务在自动合并队列中的状态
+// @Summary 查询自动合并队列状态
+// @Tags 任务
+// @Produce json
+// @Param file_id path string true "任务ID"
+// @Success 200 {object} utils.MergeJobStatus
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /tasks/{file_id}/merge_status [get]
+func MergeStatus(c *gin.Context) {
+	fileID := c.Param("file_id")
+
+	if _, exists := tenantTask(c, fileID); !exists {
+		c.JSON(404, gin.H{"error": "任务不存在"})
+		return
+	}
+
+	if utils.AutoMergeQueue == nil {
+		c.JSON(200, gin.H{"file_id": fileID, "auto_merge_enabled": false})
+		return
+	}
+
+	queued, busy, workers := utils.AutoMergeQueue.Stats()
+	response := gin.H{
+		"file_id":            fileID,
+		"auto_merge_enabled": true,
+		"queue_length":       queued,
+		"busy_workers":       busy,
+		"total_workers":      workers,
+	}
+	if job, exists := utils.AutoMergeQueue.Status(fileID); exists {
+		response["merge_status"] = job
+	}
+
+	c.JSON(200, response)
 }
 
 // MergeResult 合并结果
 type MergeResult struct {
-	FilePath  string
-	MD5       string
-	Size      int64
-	MergeTime time.Duration
+	FilePath     string
+	MD5          string
+	Size         int64
+	MergeTime    time.Duration
+	StorageURL   string // 上传到对象存储后的地址
+	LocalDeleted bool   // 上传到对象存储后已删除本地文件
 }
 
-// mergeChunksWithIntegrityCheck 带完整性检查的分片合并
-func mergeChunksWithIntegrityCheck(fileID, filename, relativePath string, totalChunks int, expectedMD5 string, task *utils.UploadTask) (*MergeResult, error) {
+// mergeChunksWithIntegrityCheck 带完整性检查的分片合并，ctx 结束时中止合并并丢弃未完成的目标文件
+func mergeChunksWithIntegrityCheck(ctx context.Context, fileID, filename, relativePath string, totalChunks int, expectedMD5 string, task *utils.UploadTask) (*MergeResult, error) {
 	startTime := time.Now()
 	
 	// 使用安全的文件ID作为目录名，实现扁平化存储
-	safeFileID := utils.SanitizeFileID(fileID)
-	srcDir := filepath.Join(utils.Config.UploadDir, safeFileID)
+	srcDir := utils.ChunkDir(fileID)
 	
 	// 确定目标路径
-	var dstPath string
-	if relativePath != "" {
-		// 清理路径，防止目录遍历攻击
-		cleanPath := filepath.Clean(relativePath)
-		if strings.Contains(cleanPath, "..") {
-			return nil, fmt.Errorf("无效的相对路径")
-		}
-		dstPath = filepath.Join(utils.Config.MergedDir, cleanPath)
-	} else {
-		dstPath = filepath.Join(utils.Config.MergedDir, filename)
-	}
-	
-	// 确保目标目录存在
-	dstDir := filepath.Dir(dstPath)
-	if err := utils.EnsureDirectory(dstDir); err != nil {
-		return nil, fmt.Errorf("创建目标目录失败: %v", err)
+	dstPath, err := resolveMergeTarget(task.TenantID, filename, relativePath)
+	if err != nil {
+		return nil, err
 	}
 
-	// 验证所有分片文件是否存在
+	// 验证所有分片文件是否存在（自动识别压缩格式）
 	chunkPaths := make([]string, totalChunks)
+	hasEncodedChunks := false
 	for i := 0; i < totalChunks; i++ {
-		chunkName := fmt.Sprintf("%06d.part", i)
-		chunkPath := filepath.Join(srcDir, chunkName)
-		
-		if _, err := os.Stat(chunkPath); err != nil {
-			return nil, fmt.Errorf("分片文件缺失: %s", chunkName)
+		chunkPath, err := findChunkFile(srcDir, i)
+		if err != nil {
+			return nil, err
+		}
+		if utils.IsCompressedChunk(chunkPath) || utils.IsEncryptedChunk(chunkPath) {
+			hasEncodedChunks = true
 		}
 		
 		chunkPaths[i] = chunkPath
 	}
 
+	// 大文件在Linux上使用内存映射合并，其次并发按偏移量合并
+	// （压缩或加密分片无法预先确定偏移量，回退到顺序合并）
+	rawChunks := !hasEncodedChunks
+	useMmap := rawChunks && mmapMergeSupported && utils.CurrentConfig().EnableMmapMerge &&
+		totalChunkSize(chunkPaths) > utils.CurrentConfig().MmapMergeThresholdBytes
+	if useMmap || (rawChunks && utils.CurrentConfig().EnableConcurrentMerge) {
+		var calculatedMD5 string
+		var fileSize int64
+		if useMmap {
+			calculatedMD5, fileSize, err = mergeChunksMmap(chunkPaths, dstPath)
+		} else {
+			calculatedMD5, fileSize, err = mergeChunksConcurrently(ctx, chunkPaths, dstPath)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// 验证文件完整性
+		if expectedMD5 != "" && utils.CurrentConfig().EnableIntegrityCheck {
+			if calculatedMD5 != expectedMD5 {
+				os.Remove(dstPath)
+				return nil, fmt.Errorf("文件完整性验证失败: 期望=%s, 实际=%s", expectedMD5, calculatedMD5)
+			}
+		}
+
+		return &MergeResult{
+			FilePath:  dstPath,
+			MD5:       calculatedMD5,
+			Size:      fileSize,
+			MergeTime: time.Since(startTime),
+		}, nil
+	}
+
 	// 使用原子操作合并文件
-	if utils.Config.EnableAtomicOperations {
+	if utils.CurrentConfig().EnableAtomicOperations {
 		writer, err := utils.NewAtomicWriter(dstPath)
 		if err != nil {
 			return nil, fmt.Errorf("创建原子写入器失败: %v", err)
@@ -191,7 +661,11 @@ func mergeChunksWithIntegrityCheck(fileID, filename, relativePath string, totalC
 
 		// 按顺序合并分片
 		for i, chunkPath := range chunkPaths {
-			chunkFile, err := os.Open(chunkPath)
+			if err := ctx.Err(); err != nil {
+				writer.Rollback()
+				return nil, err
+			}
+			chunkFile, err := utils.OpenChunkReader(chunkPath)
 			if err != nil {
 				writer.Rollback()
 				return nil, fmt.Errorf("打开分片 %d 失败: %v", i, err)
@@ -211,38 +685,17 @@ func mergeChunksWithIntegrityCheck(fileID, filename, relativePath string, totalC
 			return nil, fmt.Errorf("提交合并操作失败: %v", err)
 		}
 		
-		calculatedMD5 := writer.GetMD5()
+		calculatedMD5 := writer.GetHash()
 		fileSize := writer.GetSize()
 		
 		// 验证文件完整性
-		if expectedMD5 != "" && utils.Config.EnableIntegrityCheck {
+		if expectedMD5 != "" && utils.CurrentConfig().EnableIntegrityCheck {
 			if calculatedMD5 != expectedMD5 {
 				os.Remove(dstPath)
 				return nil, fmt.Errorf("文件完整性验证失败: 期望=%s, 实际=%s", expectedMD5, calculatedMD5)
 			}
 		}
 
-		// 合并成功后，异步清理分片文件和锁文件
-		go func() {
-			// 清理分片目录
-			if err := os.RemoveAll(srcDir); err != nil {
-				log.Printf("清理分片目录失败 [%s]: %v", safeFileID, err)
-			} else {
-				log.Printf("成功清理分片目录: %s", srcDir)
-			}
-			
-			// 清理锁文件
-			lockPath := filepath.Join(utils.Config.UploadDir, safeFileID+".lock")
-			if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
-				log.Printf("清理上传锁文件失败 [%s]: %v", safeFileID, err)
-			}
-			
-			mergeLockPath := filepath.Join(utils.Config.UploadDir, safeFileID+".merge.lock")
-			if err := os.Remove(mergeLockPath); err != nil && !os.IsNotExist(err) {
-				log.Printf("清理合并锁文件失败 [%s]: %v", safeFileID, err)
-			}
-		}()
-
 		return &MergeResult{
 			FilePath:  dstPath,
 			MD5:       calculatedMD5,
@@ -260,7 +713,12 @@ func mergeChunksWithIntegrityCheck(fileID, filename, relativePath string, totalC
 
 		// 按顺序合并分片
 		for i, chunkPath := range chunkPaths {
-			srcFile, err := os.Open(chunkPath)
+			if err := ctx.Err(); err != nil {
+				dstFile.Close()
+				os.Remove(dstPath)
+				return nil, err
+			}
+			srcFile, err := utils.OpenChunkReader(chunkPath)
 			if err != nil {
 				return nil, fmt.Errorf("打开分片 %d 失败: %v", i, err)
 			}
@@ -279,13 +737,13 @@ func mergeChunksWithIntegrityCheck(fileID, filename, relativePath string, totalC
 		}
 
 		// 计算MD5
-		md5Hash, err := utils.FileMD5(dstPath)
+		md5Hash, err := utils.Hasher.HashFile(dstPath)
 		if err != nil {
 			return nil, fmt.Errorf("计算MD5失败: %v", err)
 		}
 
 		// 验证文件完整性
-		if expectedMD5 != "" && utils.Config.EnableIntegrityCheck {
+		if expectedMD5 != "" && utils.CurrentConfig().EnableIntegrityCheck {
 			if md5Hash != expectedMD5 {
 				os.Remove(dstPath)
 				return nil, fmt.Errorf("文件完整性验证失败: 期望=%s, 实际=%s", expectedMD5, md5Hash)
@@ -294,27 +752,6 @@ func mergeChunksWithIntegrityCheck(fileID, filename, relativePath string, totalC
 
 		fileInfo, _ := os.Stat(dstPath)
 		
-		// 合并成功后，异步清理分片文件和锁文件
-		go func() {
-			// 清理分片目录
-			if err := os.RemoveAll(srcDir); err != nil {
-				log.Printf("清理分片目录失败 [%s]: %v", safeFileID, err)
-			} else {
-				log.Printf("成功清理分片目录: %s", srcDir)
-			}
-			
-			// 清理锁文件
-			lockPath := filepath.Join(utils.Config.UploadDir, safeFileID+".lock")
-			if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
-				log.Printf("清理上传锁文件失败 [%s]: %v", safeFileID, err)
-			}
-			
-			mergeLockPath := filepath.Join(utils.Config.UploadDir, safeFileID+".merge.lock")
-			if err := os.Remove(mergeLockPath); err != nil && !os.IsNotExist(err) {
-				log.Printf("清理合并锁文件失败 [%s]: %v", safeFileID, err)
-			}
-		}()
-		
 		return &MergeResult{
 			FilePath:  dstPath,
 			MD5:       md5Hash,
@@ -324,6 +761,254 @@ func mergeChunksWithIntegrityCheck(fileID, filename, relativePath string, totalC
 	}
 }
 
+// mergeChunksConcurrently 预分配目标文件后并发按偏移量写入分片，返回合并文件的MD5和大小
+func mergeChunksConcurrently(ctx context.Context, chunkPaths []string, dstPath string) (string, int64, error) {
+	// 计算每个分片在目标文件中的偏移量
+	offsets := make([]int64, len(chunkPaths))
+	var totalSize int64
+	for i, chunkPath := range chunkPaths {
+		info, err := os.Stat(chunkPath)
+		if err != nil {
+			return "", 0, fmt.Errorf("读取分片 %d 信息失败: %v", i, err)
+		}
+		offsets[i] = totalSize
+		totalSize += info.Size()
+	}
+
+	// 写入同目录下的临时文件，完成后原子重命名
+	tempPath := dstPath + ".tmp." + fmt.Sprintf("%d", time.Now().UnixNano())
+	dstFile, err := os.OpenFile(tempPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return "", 0, fmt.Errorf("创建目标文件失败: %v", err)
+	}
+
+	if err := dstFile.Truncate(totalSize); err != nil {
+		dstFile.Close()
+		os.Remove(tempPath)
+		return "", 0, fmt.Errorf("预分配目标文件失败: %v", err)
+	}
+
+	workers := utils.CurrentConfig().ConcurrentMergeWorkers
+	if workers <= 0 {
+		workers = 4
+	}
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+		failed   = make(chan struct{})
+		jobs     = make(chan int)
+	)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if err := writeChunkAt(dstFile, chunkPaths[i], offsets[i]); err != nil {
+					errOnce.Do(func() {
+						firstErr = fmt.Errorf("写入分片 %d 失败: %v", i, err)
+						close(failed)
+					})
+				}
+			}
+		}()
+	}
+
+dispatch:
+	for i := range chunkPaths {
+		select {
+		case jobs <- i:
+		case <-failed:
+			break dispatch
+		case <-ctx.Done():
+			errOnce.Do(func() {
+				firstErr = ctx.Err()
+				close(failed)
+			})
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		dstFile.Close()
+		os.Remove(tempPath)
+		return "", 0, firstErr
+	}
+
+	// 确保数据写入磁盘
+	if err := dstFile.Sync(); err != nil {
+		dstFile.Close()
+		os.Remove(tempPath)
+		return "", 0, fmt.Errorf("同步文件失败: %v", err)
+	}
+	if err := dstFile.Close(); err != nil {
+		os.Remove(tempPath)
+		return "", 0, fmt.Errorf("关闭文件失败: %v", err)
+	}
+
+	// 单次顺序读取计算MD5
+	md5Hash, err := utils.Hasher.HashFile(tempPath)
+	if err != nil {
+		os.Remove(tempPath)
+		return "", 0, fmt.Errorf("计算MD5失败: %v", err)
+	}
+
+	if err := os.Rename(tempPath, dstPath); err != nil {
+		os.Remove(tempPath)
+		return "", 0, fmt.Errorf("原子重命名失败: %v", err)
+	}
+
+	return md5Hash, totalSize, nil
+}
+
+// totalChunkSize 计算分片文件总大小
+func totalChunkSize(chunkPaths []string) int64 {
+	var total int64
+	for _, chunkPath := range chunkPaths {
+		total += getFileSize(chunkPath)
+	}
+	return total
+}
+
+// resolveMergeTarget 确定合并目标路径并确保目录存在，租户的文件位于合并目录下的租户子目录
+func resolveMergeTarget(tenantID, filename, relativePath string) (string, error) {
+	mergedDir := utils.TenantMergedDir(tenantID)
+	var dstPath string
+	if relativePath != "" {
+		// 清理路径，防止目录遍历攻击
+		cleanPath := filepath.Clean(relativePath)
+		if strings.Contains(cleanPath, "..") {
+			return "", fmt.Errorf("无效的相对路径")
+		}
+		dstPath = filepath.Join(mergedDir, cleanPath)
+	} else {
+		dstPath = filepath.Join(mergedDir, filename)
+	}
+
+	// 确保目标目录存在
+	if err := utils.EnsureDirectory(filepath.Dir(dstPath)); err != nil {
+		return "", fmt.Errorf("创建目标目录失败: %v", err)
+	}
+	return dstPath, nil
+}
+
+// linkDeduplicatedFile 合并完成后按文件的实际哈希查找去重索引，同一租户已有相同内容时用硬链接替换新合并的文件，
+// 并登记引用；哈希由服务端根据上传的分片计算，客户端声明的MD5不参与匹配
+func linkDeduplicatedFile(ctx context.Context, fileID, tenantID string, result *MergeResult) {
+	logger := utils.LoggerFromContext(ctx)
+
+	if entry, exists := utils.Dedup.Lookup(tenantID, result.MD5); exists && entry.Path != result.FilePath && entry.Size == result.Size {
+		tempPath := result.FilePath + ".dedup.tmp"
+		os.Remove(tempPath)
+		if err := os.Link(entry.Path, tempPath); err != nil {
+			logger.Warn("创建去重硬链接失败，保留合并的文件", "file_id", fileID, "error", err)
+		} else if err := os.Rename(tempPath, result.FilePath); err != nil {
+			os.Remove(tempPath)
+			logger.Warn("替换为去重硬链接失败，保留合并的文件", "file_id", fileID, "error", err)
+		} else {
+			logger.Info("文件内容已存在，合并的文件已替换为硬链接", "file_id", fileID, "source", entry.Path, "path", result.FilePath)
+		}
+	}
+
+	if err := utils.Dedup.AddReference(tenantID, result.MD5, result.FilePath, result.Size); err != nil {
+		logger.Error("更新去重索引失败", "file_id", fileID, "error", err)
+	}
+}
+
+// MergeDryRun 合并试运行的校验结果
+type MergeDryRun struct {
+	ChunkCount        int   // 找到的分片文件数
+	TotalSize         int64 // 分片解码后的总大小
+	ExpectedSize      int64 // 任务记录的文件大小，未记录时为0
+	SizeValid         bool  // 总大小与任务记录一致，未记录文件大小时为true
+	AllChecksumsValid bool  // 所有记录了MD5的分片校验通过且没有缺失的分片
+	InvalidChunks     []int // MD5不一致或无法读取的分片
+	MissingChunks     []int // 分片文件不存在
+	UnverifiedChunks  []int // 上传时未提供MD5，只统计大小
+}
+
+// dryRunMerge 按合并时的方式读取所有分片（自动解压和解密），校验每个分片的MD5并统计总大小，不写入任何文件
+func dryRunMerge(fileID string, totalChunks int, task *utils.UploadTask) (*MergeDryRun, error) {
+	srcDir := utils.ChunkDir(fileID)
+	result := &MergeDryRun{
+		ExpectedSize:     task.FileSize,
+		InvalidChunks:    []int{},
+		MissingChunks:    []int{},
+		UnverifiedChunks: []int{},
+	}
+
+	for i := 0; i < totalChunks; i++ {
+		chunkPath, err := findChunkFile(srcDir, i)
+		if err != nil {
+			result.MissingChunks = append(result.MissingChunks, i)
+			continue
+		}
+		result.ChunkCount++
+
+		checksum, size, err := hashChunk(chunkPath)
+		if err != nil {
+			utils.Logger.Warn("试运行读取分片失败", "file_id", fileID, "chunk_index", i, "error", err)
+			result.InvalidChunks = append(result.InvalidChunks, i)
+			continue
+		}
+		result.TotalSize += size
+
+		expected := task.Chunks[i].MD5
+		if expected == "" {
+			result.UnverifiedChunks = append(result.UnverifiedChunks, i)
+		} else if !strings.EqualFold(checksum, expected) {
+			result.InvalidChunks = append(result.InvalidChunks, i)
+		}
+	}
+
+	result.SizeValid = task.FileSize <= 0 || result.TotalSize == task.FileSize
+	result.AllChecksumsValid = len(result.InvalidChunks) == 0 && len(result.MissingChunks) == 0
+	return result, nil
+}
+
+// hashChunk 计算分片解码后内容的哈希和大小
+func hashChunk(chunkPath string) (string, int64, error) {
+	reader, err := utils.OpenChunkReader(chunkPath)
+	if err != nil {
+		return "", 0, err
+	}
+	defer reader.Close()
+
+	hasher := utils.Hasher.New()
+	size, err := io.Copy(hasher, reader)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), size, nil
+}
+
+// findChunkFile 查找分片文件，优先原始格式，其次压缩、加密格式
+func findChunkFile(srcDir string, index int) (string, error) {
+	for _, name := range utils.ChunkFileNames(index) {
+		chunkPath := filepath.Join(srcDir, name)
+		if _, err := os.Stat(chunkPath); err == nil {
+			return chunkPath, nil
+		}
+	}
+	return "", fmt.Errorf("分片文件缺失: %s", utils.ChunkFileName(index, false, false))
+}
+
+// writeChunkAt 将分片内容写入目标文件的指定偏移量
+func writeChunkAt(dst *os.File, chunkPath string, offset int64) error {
+	src, err := os.Open(chunkPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	_, err = io.Copy(io.NewOffsetWriter(dst, offset), src)
+	return err
+}
+
 func getFileSize(filePath string) int64 {
 	if info, err := os.Stat(filePath); err == nil {
 		return info.Size()
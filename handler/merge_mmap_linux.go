@@ -0,0 +1,110 @@
+//go:build linux
+
+package handler
+
+import (
+	"encoding/hex"
+	"fmt"
+	"go-uploader/utils"
+	"os"
+	"syscall"
+	"time"
+)
+
+// mmapMergeSupported 当前平台是否支持内存映射合并
+const mmapMergeSupported = true
+
+// mergeChunksMmap 预分配目标文件并通过内存映射逐个拷贝分片，返回合并文件的MD5和大小
+func mergeChunksMmap(chunkPaths []string, dstPath string) (string, int64, error) {
+	sizes := make([]int64, len(chunkPaths))
+	var totalSize int64
+	for i, chunkPath := range chunkPaths {
+		info, err := os.Stat(chunkPath)
+		if err != nil {
+			return "", 0, fmt.Errorf("读取分片 %d 信息失败: %v", i, err)
+		}
+		sizes[i] = info.Size()
+		totalSize += info.Size()
+	}
+	if totalSize == 0 {
+		return "", 0, fmt.Errorf("合并文件大小为0，无法内存映射")
+	}
+
+	// 写入同目录下的临时文件，完成后原子重命名
+	tempPath := dstPath + ".tmp." + fmt.Sprintf("%d", time.Now().UnixNano())
+	dstFile, err := os.OpenFile(tempPath, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0644)
+	if err != nil {
+		return "", 0, fmt.Errorf("创建目标文件失败: %v", err)
+	}
+
+	fail := func(format string, args ...interface{}) (string, int64, error) {
+		dstFile.Close()
+		os.Remove(tempPath)
+		return "", 0, fmt.Errorf(format, args...)
+	}
+
+	// 优先使用 fallocate 真正分配磁盘空间，不支持时退回 Truncate
+	if err := syscall.Fallocate(int(dstFile.Fd()), 0, 0, totalSize); err != nil {
+		if err := dstFile.Truncate(totalSize); err != nil {
+			return fail("预分配目标文件失败: %v", err)
+		}
+	}
+
+	dst, err := syscall.Mmap(int(dstFile.Fd()), 0, int(totalSize), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		return fail("映射目标文件失败: %v", err)
+	}
+
+	hasher := utils.Hasher.New()
+	var offset int64
+	for i, chunkPath := range chunkPaths {
+		if err := copyChunkMmap(dst[offset:offset+sizes[i]], chunkPath, sizes[i], hasher.Write); err != nil {
+			syscall.Munmap(dst)
+			return fail("拷贝分片 %d 失败: %v", i, err)
+		}
+		offset += sizes[i]
+	}
+
+	if err := syscall.Munmap(dst); err != nil {
+		return fail("解除目标文件映射失败: %v", err)
+	}
+
+	// 确保数据写入磁盘
+	if err := dstFile.Sync(); err != nil {
+		return fail("同步文件失败: %v", err)
+	}
+	if err := dstFile.Close(); err != nil {
+		os.Remove(tempPath)
+		return "", 0, fmt.Errorf("关闭文件失败: %v", err)
+	}
+
+	if err := os.Rename(tempPath, dstPath); err != nil {
+		os.Remove(tempPath)
+		return "", 0, fmt.Errorf("原子重命名失败: %v", err)
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), totalSize, nil
+}
+
+// copyChunkMmap 只读映射单个分片并拷贝到目标映射区域，拷贝后立即解除映射
+func copyChunkMmap(dst []byte, chunkPath string, size int64, hash func([]byte) (int, error)) error {
+	if size == 0 {
+		return nil
+	}
+
+	file, err := os.Open(chunkPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	src, err := syscall.Mmap(int(file.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return err
+	}
+	defer syscall.Munmap(src)
+
+	copy(dst, src)
+	hash(src)
+	return nil
+}
@@ -0,0 +1,124 @@
+//go:build linux
+
+package handler
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"go-uploader/utils"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestChunks 在目录下写入 count 个 size 字节的随机分片，返回分片路径和完整内容的MD5
+func writeTestChunks(tb testing.TB, dir string, count, size int) ([]string, string) {
+	tb.Helper()
+	rng := rand.New(rand.NewSource(1))
+	hasher := md5.New()
+	data := make([]byte, size)
+	paths := make([]string, count)
+	for i := range paths {
+		rng.Read(data)
+		hasher.Write(data)
+		paths[i] = filepath.Join(dir, utils.ChunkFileName(i, false, false))
+		if err := os.WriteFile(paths[i], data, 0644); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	return paths, hex.EncodeToString(hasher.Sum(nil))
+}
+
+// mergeChunksSequential 按原有的顺序合并路径逐个 io.Copy 分片，作为对比基准
+func mergeChunksSequential(chunkPaths []string, dstPath string) (string, int64, error) {
+	writer, err := utils.NewAtomicWriter(dstPath)
+	if err != nil {
+		return "", 0, err
+	}
+	for i, chunkPath := range chunkPaths {
+		chunkFile, err := utils.OpenChunkReader(chunkPath)
+		if err != nil {
+			writer.Rollback()
+			return "", 0, fmt.Errorf("打开分片 %d 失败: %v", i, err)
+		}
+		_, err = io.Copy(writer, chunkFile)
+		chunkFile.Close()
+		if err != nil {
+			writer.Rollback()
+			return "", 0, fmt.Errorf("复制分片 %d 失败: %v", i, err)
+		}
+	}
+	if err := writer.Commit(); err != nil {
+		return "", 0, err
+	}
+	return writer.GetHash(), writer.GetSize(), nil
+}
+
+func TestMergeChunksMmap(t *testing.T) {
+	dir := t.TempDir()
+	chunkPaths, wantMD5 := writeTestChunks(t, dir, 5, 64*1024+7)
+	// 空分片不能影响后续分片的偏移量
+	emptyPath := filepath.Join(dir, "empty.part")
+	if err := os.WriteFile(emptyPath, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	chunkPaths = append(chunkPaths[:2], append([]string{emptyPath}, chunkPaths[2:]...)...)
+
+	mmapPath := filepath.Join(dir, "mmap.bin")
+	gotMD5, size, err := mergeChunksMmap(chunkPaths, mmapPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotMD5 != wantMD5 || size != 5*(64*1024+7) {
+		t.Fatalf("md5 = %s size = %d, want md5 = %s", gotMD5, size, wantMD5)
+	}
+
+	sequentialPath := filepath.Join(dir, "sequential.bin")
+	if _, _, err := mergeChunksSequential(chunkPaths, sequentialPath); err != nil {
+		t.Fatal(err)
+	}
+	mmapData, _ := os.ReadFile(mmapPath)
+	sequentialData, _ := os.ReadFile(sequentialPath)
+	if !bytes.Equal(mmapData, sequentialData) {
+		t.Fatal("内存映射合并与顺序合并的结果不一致")
+	}
+	if temps, _ := filepath.Glob(mmapPath + ".tmp.*"); len(temps) != 0 {
+		t.Fatalf("残留临时文件: %v", temps)
+	}
+}
+
+// BenchmarkMergeChunks 1GB文件分100个分片，对比内存映射合并与顺序 io.Copy 合并
+func BenchmarkMergeChunks(b *testing.B) {
+	const chunks, chunkSize = 100, 10 << 20
+	dir := b.TempDir()
+	chunkPaths, wantMD5 := writeTestChunks(b, dir, chunks, chunkSize)
+
+	for _, bc := range []struct {
+		name  string
+		merge func([]string, string) (string, int64, error)
+	}{
+		{"mmap", mergeChunksMmap},
+		{"io_copy", mergeChunksSequential},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			dstPath := filepath.Join(dir, bc.name+".bin")
+			b.SetBytes(chunks * chunkSize)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				gotMD5, _, err := bc.merge(chunkPaths, dstPath)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if gotMD5 != wantMD5 {
+					b.Fatalf("md5 = %s, want %s", gotMD5, wantMD5)
+				}
+			}
+			b.StopTimer()
+			os.Remove(dstPath)
+		})
+	}
+}
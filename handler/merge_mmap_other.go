@@ -0,0 +1,13 @@
+//go:build !linux
+
+package handler
+
+import "fmt"
+
+// mmapMergeSupported 当前平台是否支持内存映射合并
+const mmapMergeSupported = false
+
+// mergeChunksMmap 非Linux平台不支持内存映射合并
+func mergeChunksMmap(chunkPaths []string, dstPath string) (string, int64, error) {
+	return "", 0, fmt.Errorf("当前平台不支持内存映射合并")
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-uploader/utils"
+	"net/http"
+	"time"
+)
+
+// oidcStateCookie 保存授权请求 state 的Cookie名称
+const oidcStateCookie = "oidc_state"
+
+// oidcStateTTL 授权请求 state 的有效期（秒）
+const oidcStateTTL = 600
+
+// OIDCLogin 跳转到OIDC身份提供方进行登录
+// @Summary OIDC登录
+// @Description 生成 state 并重定向到身份提供方的授权地址（需配置 auth_driver 为 oidc）
+// @Tags 认证
+// @Success 302 "重定向到身份提供方"
+// @Failure 404 {object} ErrorResponse
+// @Router /auth/oidc/login [get]
+func OIDCLogin(c *gin.Context) {
+	if utils.OIDC == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "OIDC认证未启用"})
+		return
+	}
+
+	state, err := utils.RandomState()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成登录状态失败"})
+		return
+	}
+
+	c.SetCookie(oidcStateCookie, state, oidcStateTTL, "/go-uploader/auth/oidc", "", false, true)
+	c.Redirect(http.StatusFound, utils.OIDC.AuthCodeURL(state))
+}
+
+// OIDCCallback 处理身份提供方的授权码回调，校验ID令牌后设置认证Cookie
+// @Summary OIDC登录回调
+// @Tags 认证
+// @Param code query string true "授权码"
+// @Param state query string true "登录状态"
+// @Success 302 "登录成功，重定向到首页"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /auth/oidc/callback [get]
+func OIDCCallback(c *gin.Context) {
+	if utils.OIDC == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "OIDC认证未启用"})
+		return
+	}
+
+	// 校验 state 防止CSRF
+	expectedState, err := c.Cookie(oidcStateCookie)
+	if err != nil || expectedState == "" || c.Query("state") != expectedState {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "登录状态无效或已过期"})
+		return
+	}
+	c.SetCookie(oidcStateCookie, "", -1, "/go-uploader/auth/oidc", "", false, true)
+
+	if errParam := c.Query("error"); errParam != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "身份提供方拒绝登录: " + errParam})
+		return
+	}
+	code := c.Query("code")
+	if code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少授权码"})
+		return
+	}
+
+	rawIDToken, subject, expiry, err := utils.OIDC.Exchange(c.Request.Context(), code)
+	if err != nil {
+		utils.RequestLogger(c).Warn("OIDC登录失败", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "OIDC登录失败"})
+		return
+	}
+
+	// ID令牌作为认证凭证，Cookie有效期与令牌一致
+	maxAge := int(time.Until(expiry).Seconds())
+	if maxAge <= 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "ID令牌已过期"})
+		return
+	}
+	utils.SetAuthCookieWithTTL(c, rawIDToken, maxAge)
+
+	c.Set(utils.AuthSubjectKey, subject)
+	utils.RequestLogger(c).Info("OIDC登录成功", "subject", subject)
+	c.Redirect(http.StatusFound, "/go-uploader/")
+}
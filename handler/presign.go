@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-uploader/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 预签名URL有效期
+const (
+	defaultPresignTTL = 15 * time.Minute
+	maxPresignTTL     = 24 * time.Hour
+)
+
+// PresignRequest 预签名上传URL请求结构
+type PresignRequest struct {
+	FileID      string `json:"file_id" binding:"required" example:"file_123"`
+	ChunkIndex  *int   `json:"chunk_index" binding:"required" example:"0"`
+	TTLSeconds  int    `json:"ttl_seconds" example:"900"`   // 有效期（秒），0表示15分钟，最长24小时
+	TotalChunks int    `json:"total_chunks" example:"10"`   // 可选：新建任务时的分片总数
+	FileSize    int64  `json:"file_size" example:"1048576"` // 可选：新建任务时的文件大小
+}
+
+// Presign 签发单个分片的预签名上传URL
+// @Summary 签发预签名上传URL
+// @Description 返回的URL在有效期内无需访问密钥即可上传指定分片，签名使用 hmac_secret 计算
+// @Tags 上传
+// @Accept json
+// @Produce json
+// @Param request body PresignRequest true "预签名参数"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Security SecretKey
+// @Router /presign [post]
+func Presign(c *gin.Context) {
+	secret := utils.CurrentConfig().HMACSecret
+	if secret == "" {
+		c.JSON(503, gin.H{"error": "预签名上传未启用，请配置hmac_secret"})
+		return
+	}
+
+	var req PresignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+	if *req.ChunkIndex < 0 {
+		c.JSON(400, gin.H{"error": "无效的分片索引"})
+		return
+	}
+	if req.TotalChunks < 0 || req.FileSize < 0 {
+		c.JSON(400, gin.H{"error": "total_chunks 和 file_size 不能为负数"})
+		return
+	}
+
+	ttl := defaultPresignTTL
+	if req.TTLSeconds < 0 {
+		c.JSON(400, gin.H{"error": "ttl_seconds 不能为负数"})
+		return
+	} else if req.TTLSeconds > 0 {
+		ttl = time.Duration(req.TTLSeconds) * time.Second
+	}
+	if ttl > maxPresignTTL {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("ttl_seconds 不能超过 %d", int(maxPresignTTL.Seconds()))})
+		return
+	}
+
+	// 新建任务所需的参数一并签名，防止上传时被篡改
+	params := map[string]string{
+		"file_id":     req.FileID,
+		"chunk_index": strconv.Itoa(*req.ChunkIndex),
+	}
+	if req.TotalChunks > 0 {
+		params["total_chunks"] = strconv.Itoa(req.TotalChunks)
+	}
+	if req.FileSize > 0 {
+		params["file_size"] = strconv.FormatInt(req.FileSize, 10)
+	}
+	if tenantID := utils.TenantFromContext(c); tenantID != "" {
+		params["tenant_id"] = tenantID
+	}
+
+	// 与当前路由同一路由组下的签名上传地址
+	uploadPath := strings.TrimSuffix(c.FullPath(), "/presign") + "/upload_chunk_signed"
+	c.JSON(200, gin.H{
+		"url":        uploadPath + "?" + utils.Sign(params, ttl, secret),
+		"method":     "POST",
+		"expires_at": time.Now().Add(ttl).Unix(),
+	})
+}
+
+// UploadChunkSigned 通过预签名URL上传分片
+// @Summary 通过预签名URL上传分片
+// @Description 无需访问密钥，file_id、chunk_index 等参数取自已签名的查询参数；签名无效或已过期时返回403。
+// @Description 分片数据放在请求体中，因此使用POST而不是GET（部分代理和HTTP客户端会丢弃GET请求体）
+// @Tags 上传
+// @Accept multipart/form-data
+// @Produce json
+// @Param file_id query string true "文件ID"
+// @Param chunk_index query int true "分片索引"
+// @Param total_chunks query int false "分片总数"
+// @Param file_size query int false "文件大小"
+// @Param expires query int true "过期时间（Unix秒）"
+// @Param sig query string true "签名"
+// @Param md5 formData string false "分片MD5"
+// @Param chunk formData file true "分片数据"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} map[string]interface{}
+// @Failure 413 {object} ErrorResponse
+// @Failure 429 {object} map[string]interface{}
+// @Failure 500 {object} ErrorResponse
+// @Router /upload_chunk_signed [post]
+func UploadChunkSigned(c *gin.Context) {
+	// 登记进行中的操作，优雅关闭时等待其完成
+	done := utils.Inflight.Begin()
+	defer done()
+
+	release, ok := acquireUploadSlot(c)
+	if !ok {
+		return
+	}
+	defer release()
+
+	ctx := c.Request.Context()
+
+	// 所有查询参数都参与签名，多出或被修改的参数都会导致校验失败
+	params := make(map[string]string)
+	for key, values := range c.Request.URL.Query() {
+		params[key] = values[0]
+	}
+	if err := utils.Verify(params, utils.CurrentConfig().HMACSecret); err != nil {
+		if !errors.Is(err, utils.ErrPresignExpired) {
+			utils.RequestLogger(c).Warn("预签名URL校验失败", "file_id", params["file_id"], "ip", c.ClientIP())
+		}
+		c.JSON(403, gin.H{"error": err.Error()})
+		return
+	}
+
+	fileID, tenantID := params["file_id"], params["tenant_id"]
+	index, err := strconv.Atoi(params["chunk_index"])
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的分片索引"})
+		return
+	}
+
+	// 分片已上传过时直接确认，不再读取分片数据
+	if result, err := precheckChunk(fileID, index, tenantID); err != nil {
+		respondError(c, err)
+		return
+	} else if result != nil {
+		c.JSON(200, alreadyUploadedResponse(fileID, index, ""))
+		return
+	}
+
+	file, err := formChunkFile(c, nil)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	defer file.Remove()
+
+	totalChunks, _ := strconv.Atoi(params["total_chunks"])
+	fileSize, _ := strconv.ParseInt(params["file_size"], 10, 64)
+	chunkMD5 := c.PostForm("md5")
+
+	upload := &ChunkUpload{
+		FileID:         fileID,
+		Index:          index,
+		TotalChunks:    totalChunks,
+		FileSize:       fileSize,
+		FileName:       file.fileName,
+		MD5:            chunkMD5,
+		TenantID:       tenantID,
+		BandwidthLimit: utils.EffectiveBandwidthLimit(c.GetHeader(utils.BandwidthLimitHeader)),
+		Size:           file.size,
+		Open:           file.Open,
+	}
+
+	result, err := StoreChunk(ctx, upload)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if result.AlreadyUploaded {
+		c.JSON(200, alreadyUploadedResponse(fileID, index, ""))
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"status":      "ok",
+		"file_id":     fileID,
+		"chunk_index": index,
+		"md5_checked": chunkMD5 != "",
+		"size":        file.size,
+	})
+}
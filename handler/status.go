@@ -5,10 +5,18 @@ import (
 	"github.com/gin-gonic/gin"
 	"go-uploader/utils"
 	"os"
-	"path/filepath"
 	"strings"
 )
 
+// @Summary 查询上传状态
+// @Description format=ranges 时用 uploaded_ranges（连续已上传分片的闭区间，如 [[0,9],[15,30]]）代替 uploaded_chunks
+// @Tags 上传
+// @Produce json
+// @Param file_id query string true "文件ID"
+// @Param format query string false "ranges：返回已上传分片的区间"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Router /upload_status [get]
 func UploadStatus(c *gin.Context) {
 	fileID := c.Query("file_id")
 	
@@ -19,27 +27,28 @@ func UploadStatus(c *gin.Context) {
 
 	// 优先从存储管理器获取状态
 	if utils.Storage != nil {
+		if rejectOtherTenant(c, fileID) {
+			return
+		}
 		task, exists := utils.Storage.GetTask(fileID)
 		if exists {
 			uploaded := utils.Storage.GetUploadedChunks(fileID)
 			
-			c.JSON(200, gin.H{
-				"uploaded_chunks": uploaded,
+			c.JSON(200, withUploadedChunks(c, gin.H{
 				"total_chunks":    task.TotalChunks,
 				"file_size":       task.FileSize,
 				"status":          task.Status,
 				"created_at":      task.CreatedAt,
 				"updated_at":      task.UpdatedAt,
 				"completion_rate": float64(len(uploaded)) / float64(task.TotalChunks) * 100,
-			})
+			}, uploaded))
 			return
 		}
 	}
 
 	// 回退到文件系统检查（兼容旧版本）
 	// 使用安全的文件ID作为目录名，适应扁平化存储
-	safeFileID := utils.SanitizeFileID(fileID)
-	dir := filepath.Join(utils.Config.UploadDir, safeFileID)
+	dir := utils.ChunkDir(fileID)
 	files, err := os.ReadDir(dir)
 	if err != nil {
 		c.JSON(200, gin.H{
@@ -51,16 +60,67 @@ func UploadStatus(c *gin.Context) {
 
 	uploaded := []int{}
 	for _, f := range files {
-		if strings.HasSuffix(f.Name(), ".part") {
-			name := strings.TrimSuffix(f.Name(), ".part")
+		fileName := strings.TrimSuffix(strings.TrimSuffix(f.Name(), utils.EncryptedChunkSuffix), utils.CompressedChunkSuffix)
+		if strings.HasSuffix(fileName, ".part") {
+			name := strings.TrimSuffix(fileName, ".part")
 			var idx int
 			fmt.Sscanf(name, "%d", &idx)
 			uploaded = append(uploaded, idx)
 		}
 	}
 
+	c.JSON(200, withUploadedChunks(c, gin.H{
+		"status": "uploading",
+	}, uploaded))
+}
+
+// withUploadedChunks 按 format 参数添加已上传分片的索引列表或区间列表
+func withUploadedChunks(c *gin.Context, info gin.H, uploaded []int) gin.H {
+	if c.Query("format") == "ranges" {
+		info["uploaded_ranges"] = utils.ChunkRanges(uploaded)
+	} else {
+		info["uploaded_chunks"] = uploaded
+	}
+	return info
+}
+
+// UploadGaps 查询任务缺失的分片索引，便于客户端断点续传
+// @Summary 查询缺失的分片索引
+// @Tags 上传
+// @Produce json
+// @Param file_id query string true "文件ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /upload_status/gaps [get]
+func UploadGaps(c *gin.Context) {
+	fileID := c.Query("file_id")
+	if fileID == "" {
+		c.JSON(400, gin.H{"error": "缺少file_id参数"})
+		return
+	}
+
+	task, exists := tenantTask(c, fileID)
+	if !exists {
+		c.JSON(404, gin.H{"error": "任务不存在"})
+		return
+	}
+	if task.TotalChunks <= 0 {
+		c.JSON(400, gin.H{"error": "任务分片总数未知"})
+		return
+	}
+
+	missing, err := utils.Storage.GetMissingChunks(fileID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("查询缺失分片失败: %v", err)})
+		return
+	}
+
 	c.JSON(200, gin.H{
-		"uploaded_chunks": uploaded,
-		"status":          "uploading",
+		"file_id":        fileID,
+		"total_chunks":   task.TotalChunks,
+		"missing_chunks": missing,
+		"has_gaps":       len(missing) > 0,
+		"gap_count":      len(missing),
 	})
 }
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-uploader/utils"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxStreamedFieldBytes 流式读取表单时普通字段的总大小上限，与 net/http 解析表单时的限制一致
+const maxStreamedFieldBytes = 10 << 20
+
+// chunkFile 请求中的分片数据，流式读取时 path 为上传目录下的暂存文件
+type chunkFile struct {
+	fileName string
+	size     int64 // 分片数据的实际字节数
+	path     string
+	header   *multipart.FileHeader
+}
+
+// Open 打开分片数据，可多次调用
+func (f *chunkFile) Open() (io.ReadCloser, error) {
+	if f.header != nil {
+		return f.header.Open()
+	}
+	return os.Open(f.path)
+}
+
+// Remove 删除暂存文件
+func (f *chunkFile) Remove() {
+	if f.path != "" {
+		os.Remove(f.path)
+	}
+}
+
+// formChunkFile 读取请求中的 chunk 字段：已流式读取时直接返回 staged；未声明 Content-Length 的 multipart 请求
+// 流式写入暂存文件，调用方需调用 Remove；其余请求按 FormFile 读取
+func formChunkFile(c *gin.Context, staged *chunkFile) (*chunkFile, error) {
+	if staged != nil {
+		return staged, nil
+	}
+	if isStreamedMultipart(c.Request) {
+		return stageStreamedChunk(c)
+	}
+
+	file, err := c.FormFile("chunk")
+	if err != nil {
+		return nil, newAPIError(400, nil, "上传文件错误: %v", err)
+	}
+	return &chunkFile{fileName: file.Filename, size: file.Size, header: file}, nil
+}
+
+// isStreamedMultipart 请求未声明 Content-Length（分块传输编码）且为 multipart 表单
+func isStreamedMultipart(req *http.Request) bool {
+	return req.ContentLength < 0 && strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/")
+}
+
+// stageStreamedChunk 按顺序读取 multipart 的各部分：chunk 字段经 AtomicWriter 边读边写入暂存文件，最多读取 max_chunk_size+1 字节，
+// 超出时回滚并返回413；其余字段写回请求的表单，之后 PostForm 可照常读取
+func stageStreamedChunk(c *gin.Context) (*chunkFile, error) {
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		return nil, newAPIError(400, nil, "上传文件错误: %v", err)
+	}
+
+	values := make(url.Values)
+	var staged *chunkFile
+	var fieldBytes int64
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if staged != nil {
+				staged.Remove()
+			}
+			return nil, streamReadError(err)
+		}
+
+		name := part.FormName()
+		switch {
+		case name == "chunk" && staged == nil:
+			staged, err = writeStreamedChunk(part)
+			if err != nil {
+				part.Close()
+				return nil, err
+			}
+		case name == "" || part.FileName() != "":
+			// 其他文件字段不使用，读取下一部分时自动跳过
+		default:
+			var value bytes.Buffer
+			var n int64
+			n, err = io.Copy(&value, io.LimitReader(part, maxStreamedFieldBytes-fieldBytes+1))
+			fieldBytes += n
+			if err == nil && fieldBytes > maxStreamedFieldBytes {
+				err = newAPIError(400, nil, "表单字段过大")
+			}
+			values.Add(name, value.String())
+		}
+		part.Close()
+		if err != nil {
+			if staged != nil {
+				staged.Remove()
+			}
+			return nil, streamReadError(err)
+		}
+	}
+	if staged == nil {
+		return nil, newAPIError(400, nil, "上传文件错误: %v", http.ErrMissingFile)
+	}
+
+	// 表单已由 MultipartReader 读取，填充解析结果后 gin 不会再次解析请求体
+	req := c.Request
+	req.MultipartForm = &multipart.Form{Value: values, File: make(map[string][]*multipart.FileHeader)}
+	req.PostForm = values
+	req.Form = req.URL.Query()
+	for key, list := range values {
+		req.Form[key] = append(req.Form[key], list...)
+	}
+	return staged, nil
+}
+
+// writeStreamedChunk 把分片数据写入暂存文件，大小以实际写入的字节数为准
+func writeStreamedChunk(part *multipart.Part) (*chunkFile, error) {
+	if err := utils.EnsureDirectory(utils.CurrentConfig().UploadDir); err != nil {
+		return nil, fmt.Errorf("创建上传目录失败: %v", err)
+	}
+	path := filepath.Join(utils.CurrentConfig().UploadDir, fmt.Sprintf(".streamed-chunk-%d", time.Now().UnixNano()))
+	writer, err := utils.NewAtomicWriter(path)
+	if err != nil {
+		return nil, fmt.Errorf("创建原子写入器失败: %v", err)
+	}
+
+	limit := utils.CurrentConfig().MaxChunkSize
+	size, err := io.Copy(writer, io.LimitReader(part, limit+1))
+	if err != nil {
+		writer.Rollback()
+		return nil, streamReadError(err)
+	}
+	if size > limit {
+		writer.Rollback()
+		return nil, newAPIError(413, gin.H{"max_chunk_size": limit}, "分片大小超出限制: 超过 %d 字节", limit)
+	}
+	if err := writer.Commit(); err != nil {
+		return nil, fmt.Errorf("提交分片写入失败: %v", err)
+	}
+	return &chunkFile{fileName: part.FileName(), size: size, path: path}, nil
+}
+
+// streamReadError 读取请求体失败时的错误：超出请求体上限返回413，其余返回400
+func streamReadError(err error) error {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return newAPIError(413, gin.H{"limit": maxBytesErr.Limit}, "请求体超出大小限制: %d 字节", maxBytesErr.Limit)
+	}
+	return newAPIError(400, nil, "读取表单失败: %v", err)
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"go-uploader/utils"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// chunkPart 构造只含一个分片文件字段的 multipart 表单，返回该字段
+func chunkPart(t *testing.T, size int) *multipart.Part {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("chunk", "chunk.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(make([]byte, size))
+	writer.Close()
+
+	filePart, err := multipart.NewReader(&body, writer.Boundary()).NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filePart
+}
+
+func TestWriteStreamedChunkUsesReloadedMaxChunkSize(t *testing.T) {
+	saved := utils.Config
+	defer utils.RestoreConfig(saved)
+	utils.Config = utils.AppConfig{
+		UploadDir:              t.TempDir(),
+		Port:                   "8080",
+		MaxChunkSize:           1 << 20,
+		ConcurrentUploads:      5,
+		LogLevel:               "info",
+		MergeTimeoutSeconds:    60,
+		MaxMergeTimeoutSeconds: 120,
+	}
+
+	const size = 3 << 19
+	_, err := writeStreamedChunk(chunkPart(t, size))
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Status != 413 {
+		t.Fatalf("超过 max_chunk_size 的分片应返回413: %v", err)
+	}
+
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"max_chunk_size": 2097152}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := utils.ReloadConfig(configPath); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新加载后不需要重启，下一个分片按新的上限接收
+	staged, err := writeStreamedChunk(chunkPart(t, size))
+	if err != nil {
+		t.Fatalf("热加载后分片应被接收: %v", err)
+	}
+	defer os.Remove(staged.path)
+	if staged.size != size {
+		t.Fatalf("size = %d, want %d", staged.size, size)
+	}
+}
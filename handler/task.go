@@ -1,20 +1,46 @@
 package handler
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"go-uploader/utils"
 	"strconv"
-	"log"
+	"strings"
+	"time"
 )
 
+// CreateFolderTaskRequest 创建文件夹任务请求结构
+type CreateFolderTaskRequest struct {
+	FolderName string            `json:"folder_name" binding:"required" example:"photos"`
+	Files      []utils.FileInfo  `json:"files" binding:"required"`
+	Tags       map[string]string `json:"tags"`
+
+	ParentFolderTaskID string `json:"parent_folder_task_id,omitempty" example:"folder_photos_1700000000000000000"` // 可选：挂载到已有文件夹任务下
+
+	ProgressWebhookEveryN int `json:"progress_webhook_every_n,omitempty" example:"10"` // 可选：子任务每完成多少个分片触发一次 task.progress Webhook，覆盖全局配置
+
+	Metadata json.RawMessage `json:"metadata,omitempty" swaggertype:"object"` // 可选：文件夹任务的自定义元数据
+}
+
 // CreateFolderTask 创建文件夹任务
+// @Summary 创建文件夹任务
+// @Tags 文件夹任务
+// @Accept json
+// @Produce json
+// @Param request body CreateFolderTaskRequest true "文件夹任务参数"
+// @Param X-Max-Size header int false "文件夹存储配额（字节）"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /folder_tasks [post]
 func CreateFolderTask(c *gin.Context) {
-	var req struct {
-		FolderName string            `json:"folder_name" binding:"required"`
-		Files      []utils.FileInfo  `json:"files" binding:"required"`
-	}
-
+	var req CreateFolderTaskRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(400, gin.H{"error": fmt.Sprintf("参数错误: %v", err)})
 		return
@@ -31,25 +57,205 @@ func CreateFolderTask(c *gin.Context) {
 		return
 	}
 
+	if err := utils.ValidateTags(req.Tags); err != nil {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("标签无效: %v", err)})
+		return
+	}
+
+	tenantID := utils.TenantFromContext(c)
+	if err := utils.Storage.ValidateFileDependencies(req.Files, tenantID); err != nil {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("文件依赖无效: %v", err)})
+		return
+	}
+
+	if rejectDeepPaths(c, req.Files) {
+		return
+	}
+
+	metadata, err := utils.ParseMetadata(req.Metadata)
+	if err != nil {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("元数据无效: %v", err)})
+		return
+	}
+
+	if req.ProgressWebhookEveryN < 0 {
+		c.JSON(400, gin.H{"error": "progress_webhook_every_n 不能小于0"})
+		return
+	}
+
+	if req.ParentFolderTaskID != "" {
+		parent, exists := utils.Storage.GetTenantTask(req.ParentFolderTaskID, tenantID)
+		if !exists || parent.TaskType != "folder" {
+			c.JSON(404, gin.H{"error": "父文件夹任务不存在"})
+			return
+		}
+
+		// 新文件夹的深度为父文件夹深度加1
+		maxDepth := utils.CurrentConfig().MaxFolderDepth
+		if depth := utils.Storage.FolderDepth(parent.FileID) + 1; maxDepth > 0 && depth > maxDepth {
+			c.JSON(400, gin.H{
+				"error":            fmt.Sprintf("文件夹嵌套深度 %d 超过上限 %d", depth, maxDepth),
+				"depth":            depth,
+				"max_folder_depth": maxDepth,
+			})
+			return
+		}
+	}
+
 	// 创建文件夹任务
-	folderTask, err := utils.Storage.CreateFolderTask(req.FolderName, req.Files)
+	folderTask, err := utils.Storage.CreateFolderTask(req.FolderName, req.Files, req.Tags, req.ParentFolderTaskID, tenantID)
 	if err != nil {
 		c.JSON(500, gin.H{"error": fmt.Sprintf("创建文件夹任务失败: %v", err)})
 		return
 	}
 
+	// 文件夹配额由所有子任务共享
+	if maxBytes := utils.RequestedQuotaLimit(c.GetHeader(utils.MaxSizeHeader)); maxBytes > 0 {
+		folderTask.MaxBytes = maxBytes
+		if err := utils.Storage.SaveTask(folderTask); err != nil {
+			c.JSON(500, gin.H{"error": fmt.Sprintf("保存文件夹配额失败: %v", err)})
+			return
+		}
+	}
+
+	if metadata != nil {
+		if folderTask, err = utils.Storage.SetMetadata(folderTask.FileID, metadata); err != nil {
+			c.JSON(500, gin.H{"error": fmt.Sprintf("保存文件夹元数据失败: %v", err)})
+			return
+		}
+	}
+
+	// 子任务的进度Webhook间隔沿上级文件夹查找
+	if req.ProgressWebhookEveryN > 0 {
+		folderTask.ProgressWebhookEveryN = req.ProgressWebhookEveryN
+		if err := utils.Storage.SaveTask(folderTask); err != nil {
+			c.JSON(500, gin.H{"error": fmt.Sprintf("保存进度通知设置失败: %v", err)})
+			return
+		}
+	}
+
 	c.JSON(200, gin.H{
-		"status":         "ok",
-		"message":        "文件夹任务创建成功",
-		"folder_task_id": folderTask.FileID,
-		"folder_name":    folderTask.FolderName,
-		"total_files":    len(folderTask.SubTasks),
-		"total_size":     folderTask.FileSize,
-		"sub_tasks":      folderTask.SubTasks,
+		"status":                "ok",
+		"message":               "文件夹任务创建成功",
+		"folder_task_id":        folderTask.FileID,
+		"folder_name":           folderTask.FolderName,
+		"total_files":           len(folderTask.SubTasks),
+		"total_size":            folderTask.FileSize,
+		"sub_tasks":             folderTask.SubTasks,
+		"parent_folder_task_id": folderTask.ParentTaskID,
+	})
+}
+
+// rejectDeepPaths 文件相对路径的目录层级超过 Config.MaxFolderDepth 时返回400并返回true
+func rejectDeepPaths(c *gin.Context, files []utils.FileInfo) bool {
+	maxDepth := utils.CurrentConfig().MaxFolderDepth
+	paths := utils.PathsExceedingDepth(files, maxDepth)
+	if len(paths) == 0 {
+		return false
+	}
+
+	c.JSON(400, gin.H{
+		"error":            fmt.Sprintf("%d 个文件的相对路径目录层级超过上限 %d: %s", len(paths), maxDepth, strings.Join(paths, ", ")),
+		"offending_paths":  paths,
+		"max_folder_depth": maxDepth,
+	})
+	return true
+}
+
+// AddFolderFilesRequest 向文件夹任务追加文件请求结构
+type AddFolderFilesRequest struct {
+	Files []utils.FileInfo `json:"files" binding:"required"`
+}
+
+// AddFolderFiles 向已有文件夹任务追加文件
+// @Summary 向文件夹任务追加文件
+// @Description 为每个新文件创建子任务并累加文件夹大小，适用于边扫描边上传的场景；
+// @Description 依赖项可以是同一请求中其他文件的相对路径或已有任务的ID
+// @Tags 文件夹任务
+// @Accept json
+// @Produce json
+// @Param folder_task_id path string true "文件夹任务ID"
+// @Param request body AddFolderFilesRequest true "追加的文件"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /folder_tasks/{folder_task_id}/files [patch]
+func AddFolderFiles(c *gin.Context) {
+	folderTaskID := c.Param("folder_task_id")
+
+	var req AddFolderFilesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("参数错误: %v", err)})
+		return
+	}
+	if len(req.Files) == 0 {
+		c.JSON(400, gin.H{"error": "文件列表不能为空"})
+		return
+	}
+
+	if utils.Storage == nil {
+		c.JSON(500, gin.H{"error": "存储管理器未初始化"})
+		return
+	}
+	tenantID := utils.TenantFromContext(c)
+	folderTask, exists := utils.Storage.GetTenantTask(folderTaskID, tenantID)
+	if !exists || folderTask.TaskType != "folder" {
+		c.JSON(404, gin.H{"error": "文件夹任务不存在"})
+		return
+	}
+
+	if err := utils.Storage.ValidateFileDependencies(req.Files, tenantID); err != nil {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("文件依赖无效: %v", err)})
+		return
+	}
+
+	if rejectDeepPaths(c, req.Files) {
+		return
+	}
+
+	subTaskIDs, conflicts, err := utils.Storage.AddFolderFiles(folderTaskID, req.Files, tenantID)
+	if err != nil {
+		switch {
+		case errors.Is(err, utils.ErrFolderPathConflict):
+			c.JSON(409, gin.H{"error": err.Error(), "conflicting_paths": conflicts})
+		case errors.Is(err, utils.ErrFolderCompleted):
+			c.JSON(409, gin.H{"error": err.Error()})
+		default:
+			c.JSON(500, gin.H{"error": fmt.Sprintf("追加文件失败: %v", err)})
+		}
+		return
+	}
+
+	summary, err := utils.Storage.GetFolderTaskSummary(folderTaskID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("获取文件夹任务摘要失败: %v", err)})
+		return
+	}
+
+	utils.RequestLogger(c).Info("文件夹任务追加文件", "folder_task_id", folderTaskID, "added_files", len(subTaskIDs))
+	c.JSON(200, gin.H{
+		"status":          "ok",
+		"folder_task_id":  folderTaskID,
+		"added_sub_tasks": subTaskIDs,
+		"total_files":     summary.TotalFiles,
+		"total_size":      summary.TotalSize,
 	})
 }
 
 // GetFolderTaskSummary 获取文件夹任务摘要
+// @Summary 获取文件夹任务摘要
+// @Tags 文件夹任务
+// @Produce json
+// @Param folder_task_id path string true "文件夹任务ID"
+// @Success 200 {object} utils.FolderTaskSummary
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /folder_tasks/{folder_task_id}/summary [get]
 func GetFolderTaskSummary(c *gin.Context) {
 	folderTaskID := c.Param("folder_task_id")
 	if folderTaskID == "" {
@@ -61,6 +267,9 @@ func GetFolderTaskSummary(c *gin.Context) {
 		c.JSON(500, gin.H{"error": "存储管理器未初始化"})
 		return
 	}
+	if rejectOtherTenant(c, folderTaskID) {
+		return
+	}
 
 	summary, err := utils.Storage.GetFolderTaskSummary(folderTaskID)
 	if err != nil {
@@ -68,7 +277,7 @@ func GetFolderTaskSummary(c *gin.Context) {
 		return
 	}
 
-	c.JSON(200, gin.H{
+	c.JSON(200, withSpeed(gin.H{
 		"folder_task_id":  folderTaskID,
 		"total_files":     summary.TotalFiles,
 		"completed_files": summary.CompletedFiles,
@@ -77,10 +286,20 @@ func GetFolderTaskSummary(c *gin.Context) {
 		"uploaded_size":   summary.UploadedSize,
 		"completion_rate": summary.CompletionRate,
 		"status":          summary.Status,
-	})
+		"nested_folders":  summary.NestedFolders,
+	}, summary.UploadSpeedBps, summary.ETASeconds))
 }
 
 // GetSubTasks 获取文件夹的子任务列表
+// @Summary 获取文件夹子任务列表
+// @Tags 文件夹任务
+// @Produce json
+// @Param folder_task_id path string true "文件夹任务ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /folder_tasks/{folder_task_id}/sub_tasks [get]
 func GetSubTasks(c *gin.Context) {
 	folderTaskID := c.Param("folder_task_id")
 	if folderTaskID == "" {
@@ -92,6 +311,9 @@ func GetSubTasks(c *gin.Context) {
 		c.JSON(500, gin.H{"error": "存储管理器未初始化"})
 		return
 	}
+	if rejectOtherTenant(c, folderTaskID) {
+		return
+	}
 
 	subTasks, err := utils.Storage.GetSubTasks(folderTaskID)
 	if err != nil {
@@ -108,7 +330,7 @@ func GetSubTasks(c *gin.Context) {
 			completionRate = float64(len(uploadedChunks)) / float64(task.TotalChunks) * 100
 		}
 
-		taskList = append(taskList, gin.H{
+		taskList = append(taskList, withTaskSpeed(gin.H{
 			"file_id":         task.FileID,
 			"filename":        task.FileName,
 			"relative_path":   task.RelativePath,
@@ -121,7 +343,7 @@ func GetSubTasks(c *gin.Context) {
 			"completion_rate": completionRate,
 			"retry_count":     task.RetryCount,
 			"parent_task_id":  task.ParentTaskID,
-		})
+		}, task))
 	}
 
 	c.JSON(200, gin.H{
@@ -131,15 +353,153 @@ func GetSubTasks(c *gin.Context) {
 	})
 }
 
+// BatchRetrySubTasksRequest 批量重试子任务请求
+type BatchRetrySubTasksRequest struct {
+	SubTaskIDs []string `json:"sub_task_ids" binding:"required"`
+}
+
+// SubTaskRetryFailure 未能重试的子任务及原因
+type SubTaskRetryFailure struct {
+	FileID string `json:"file_id"`
+	Reason string `json:"reason"`
+}
+
+// BatchRetrySubTasks 只重试文件夹任务中指定的失败子任务
+// @Summary 批量重试文件夹的部分子任务
+// @Description 子任务必须属于该文件夹且状态为 failed；重置后状态为 uploading，失败的分片恢复为 pending，重试次数加1。至少重置一个子任务时，失败或部分失败的文件夹任务恢复为 uploading
+// @Tags 文件夹任务
+// @Accept json
+// @Produce json
+// @Param folder_task_id path string true "文件夹任务ID"
+// @Param request body BatchRetrySubTasksRequest true "要重试的子任务ID"
+// @Success 200 {object} object{status=string,folder_task_id=string,folder_status=string,reset=[]string,failed=[]SubTaskRetryFailure}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /folder_tasks/{folder_task_id}/sub_tasks/batch_retry [post]
+func BatchRetrySubTasks(c *gin.Context) {
+	folderTaskID := c.Param("folder_task_id")
+	logger := utils.RequestLogger(c)
+
+	var req BatchRetrySubTasksRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("参数错误: %v", err)})
+		return
+	}
+	if len(req.SubTaskIDs) == 0 {
+		c.JSON(400, gin.H{"error": "子任务列表不能为空"})
+		return
+	}
+
+	if utils.Storage == nil {
+		c.JSON(500, gin.H{"error": "存储管理器未初始化"})
+		return
+	}
+
+	folderTask, exists := tenantTask(c, folderTaskID)
+	if !exists || folderTask.TaskType != "folder" {
+		c.JSON(404, gin.H{"error": "文件夹任务不存在"})
+		return
+	}
+
+	reset := []string{}
+	failed := []SubTaskRetryFailure{}
+	seen := make(map[string]bool, len(req.SubTaskIDs))
+	for _, subTaskID := range req.SubTaskIDs {
+		if seen[subTaskID] {
+			continue
+		}
+		seen[subTaskID] = true
+
+		subTask, exists := utils.Storage.GetTask(subTaskID)
+		switch {
+		case !exists || subTask.ParentTaskID != folderTaskID:
+			failed = append(failed, SubTaskRetryFailure{FileID: subTaskID, Reason: "子任务不属于该文件夹任务"})
+			continue
+		case subTask.Status != "failed":
+			failed = append(failed, SubTaskRetryFailure{FileID: subTaskID, Reason: fmt.Sprintf("子任务状态为 %s，只有失败的子任务可以重试", subTask.Status)})
+			continue
+		}
+
+		if _, err := resumeTaskState(subTaskID); err != nil {
+			logger.Error("重试子任务失败", "folder_task_id", folderTaskID, "file_id", subTaskID, "error", err)
+			failed = append(failed, SubTaskRetryFailure{FileID: subTaskID, Reason: err.Error()})
+			continue
+		}
+		reset = append(reset, subTaskID)
+	}
+
+	// 至少重置一个子任务时，失败的文件夹任务恢复为上传中
+	folderStatus := folderTask.Status
+	if len(reset) > 0 && (folderStatus == "failed" || folderStatus == "partial_failed") {
+		if err := utils.Storage.TransitionTask(folderTaskID, "uploading"); err != nil {
+			logger.Error("恢复文件夹任务状态失败", "folder_task_id", folderTaskID, "error", err)
+		} else {
+			folderStatus = "uploading"
+		}
+	}
+
+	logger.Info("批量重试子任务", "folder_task_id", folderTaskID, "reset", len(reset), "failed", len(failed))
+	c.JSON(200, gin.H{
+		"status":         "ok",
+		"folder_task_id": folderTaskID,
+		"folder_status":  folderStatus,
+		"reset":          reset,
+		"failed":         failed,
+	})
+}
+
 // GetAllTasks 获取所有主任务（修改为只显示主任务）
+// @Summary 获取所有任务
+// @Tags 任务
+// @Produce json
+// @Param tag query string false "按标签筛选，格式 key:value"
+// @Param filename query string false "按文件名或相对路径包含匹配（不区分大小写）"
+// @Param filename_prefix query string false "按文件名或相对路径前缀匹配（不区分大小写）"
+// @Param status query string false "按任务状态筛选"
+// @Param limit query int false "最多返回的任务数（按更新时间倒序）"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /tasks [get]
 func GetAllTasks(c *gin.Context) {
 	if utils.Storage == nil {
 		c.JSON(500, gin.H{"error": "存储管理器未初始化"})
 		return
 	}
 
-	// 只获取主任务（非子任务）
-	tasks := utils.Storage.GetMainTasks()
+	query := utils.TaskQuery{
+		Filename:   c.Query("filename"),
+		SearchMode: utils.SearchModeContains,
+		Status:     c.Query("status"),
+		TenantID:   utils.TenantFromContext(c),
+	}
+	if prefix := c.Query("filename_prefix"); prefix != "" {
+		query.Filename, query.SearchMode = prefix, utils.SearchModePrefix
+	}
+
+	// 按标签筛选，未指定标签和文件名时只获取主任务（非子任务）
+	if tagFilter := c.Query("tag"); tagFilter != "" {
+		key, value, err := utils.ParseTagFilter(tagFilter)
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		query.TagKey, query.TagValue = key, value
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 0 {
+			c.JSON(400, gin.H{"error": "无效的limit参数"})
+			return
+		}
+		query.Limit = limit
+	}
+
+	tasks := utils.Storage.QueryTasks(query)
 	
 	// 转换为响应格式
 	taskList := make([]gin.H, 0, len(tasks))
@@ -163,6 +523,7 @@ func GetAllTasks(c *gin.Context) {
 					"updated_at":      task.UpdatedAt,
 					"completion_rate": 0.0,
 					"retry_count":     task.RetryCount,
+					"tags":            task.Tags,
 				}
 			} else {
 				taskInfo = gin.H{
@@ -180,6 +541,7 @@ func GetAllTasks(c *gin.Context) {
 					"updated_at":      task.UpdatedAt,
 					"completion_rate": summary.CompletionRate,
 					"retry_count":     task.RetryCount,
+					"tags":            task.Tags,
 				}
 			}
 		} else {
@@ -203,6 +565,7 @@ func GetAllTasks(c *gin.Context) {
 				"updated_at":      task.UpdatedAt,
 				"completion_rate": completionRate,
 				"retry_count":     task.RetryCount,
+				"tags":            task.Tags,
 			}
 		}
 
@@ -216,6 +579,16 @@ func GetAllTasks(c *gin.Context) {
 }
 
 // GetTask 获取单个任务详情
+// @Summary 获取任务详情
+// @Description 活动存储中不存在的任务会在归档中查找，归档的任务返回完整的任务元数据并带 archived=true
+// @Tags 任务
+// @Produce json
+// @Param file_id path string true "任务ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /tasks/{file_id} [get]
 func GetTask(c *gin.Context) {
 	fileID := c.Param("file_id")
 	if fileID == "" {
@@ -228,8 +601,12 @@ func GetTask(c *gin.Context) {
 		return
 	}
 
-	task, exists := utils.Storage.GetTask(fileID)
+	task, exists := tenantTask(c, fileID)
 	if !exists {
+		// 已归档的任务从归档中读取
+		if respondArchivedTask(c, fileID) {
+			return
+		}
 		c.JSON(404, gin.H{"error": "任务不存在"})
 		return
 	}
@@ -257,7 +634,7 @@ func GetTask(c *gin.Context) {
 				completionRate = float64(len(uploadedChunks)) / float64(subTask.TotalChunks) * 100
 			}
 
-			subTaskDetails = append(subTaskDetails, gin.H{
+			subTaskDetails = append(subTaskDetails, withTaskSpeed(gin.H{
 				"file_id":         subTask.FileID,
 				"filename":        subTask.FileName,
 				"relative_path":   subTask.RelativePath,
@@ -269,10 +646,12 @@ func GetTask(c *gin.Context) {
 				"updated_at":      subTask.UpdatedAt,
 				"completion_rate": completionRate,
 				"retry_count":     subTask.RetryCount,
-			})
+				"dependencies":    subTask.DependsOn,
+				"metadata":        subTask.Metadata,
+			}, subTask))
 		}
 
-		c.JSON(200, gin.H{
+		c.JSON(200, withSpeedHistory(withSpeed(gin.H{
 			"task_id":         task.FileID,
 			"task_type":       task.TaskType,
 			"folder_name":     task.FolderName,
@@ -287,8 +666,11 @@ func GetTask(c *gin.Context) {
 			"updated_at":      task.UpdatedAt,
 			"completion_rate": summary.CompletionRate,
 			"retry_count":     task.RetryCount,
+			"tags":            task.Tags,
+			"metadata":        task.Metadata,
+			"cloned_from":     task.ClonedFrom,
 			"sub_tasks":       subTaskDetails,
-		})
+		}, summary.UploadSpeedBps, summary.ETASeconds), task.FileID))
 	} else {
 		// 单文件任务详情
 		uploadedChunks := utils.Storage.GetUploadedChunks(fileID)
@@ -310,28 +692,70 @@ func GetTask(c *gin.Context) {
 			})
 		}
 
-		c.JSON(200, gin.H{
-			"task_id":         task.FileID,
-			"task_type":       task.TaskType,
-			"filename":        task.FileName,
-			"relative_path":   task.RelativePath,
-			"total_chunks":    task.TotalChunks,
-			"uploaded_chunks": uploadedChunks,
-			"file_size":       task.FileSize,
+		c.JSON(200, withSpeedHistory(withTaskSpeed(gin.H{
+			"task_id":           task.FileID,
+			"task_type":         task.TaskType,
+			"filename":          task.FileName,
+			"relative_path":     task.RelativePath,
+			"total_chunks":      task.TotalChunks,
+			"uploaded_chunks":   uploadedChunks,
+			"file_size":         task.FileSize,
 			"file_md5":        task.FileMD5,
-			"status":          task.Status,
-			"created_at":      task.CreatedAt,
-			"updated_at":      task.UpdatedAt,
-			"completion_rate": completionRate,
-			"retry_count":     task.RetryCount,
-			"chunks":          chunkDetails,
-			"parent_task_id":  task.ParentTaskID,
-			"is_sub_task":     task.IsSubTask,
-		})
+			"hash_algorithm":    task.HashAlgorithm,
+			"status":            task.Status,
+			"created_at":        task.CreatedAt,
+			"updated_at":        task.UpdatedAt,
+			"completion_rate":   completionRate,
+			"retry_count":       task.RetryCount,
+			"chunks":            chunkDetails,
+			"parent_task_id":    task.ParentTaskID,
+			"is_sub_task":       task.IsSubTask,
+			"mime_type":         task.MIMEType,
+			"tags":              task.Tags,
+			"metadata":          task.Metadata,
+			"failure_reason":    task.FailureReason,
+			"storage_url":       task.StorageURL,
+			"dependencies":      task.DependsOn,
+			"cloned_from":       task.ClonedFrom,
+			"version_number":    task.VersionNumber,
+			"previous_versions": task.PreviousVersions,
+		}, task), task.FileID))
 	}
 }
 
+// withTaskSpeed 为单文件任务添加上传速度和预计剩余时间
+func withTaskSpeed(info gin.H, task *utils.UploadTask) gin.H {
+	speed, eta := utils.EstimateUpload(task.FileID, task.RemainingBytes())
+	return withSpeed(info, speed, eta)
+}
+
+// withSpeedHistory 添加开始测速以来的峰值速度和平均速度
+func withSpeedHistory(info gin.H, fileID string) gin.H {
+	stats := utils.TaskSpeedStats(fileID)
+	info["peak_speed_bps"] = stats.PeakBps
+	info["average_speed_bps"] = stats.AverageBps
+	return info
+}
+
+// withSpeed 添加上传速度，样本不足时不返回 eta_seconds
+func withSpeed(info gin.H, speed float64, eta *int64) gin.H {
+	info["upload_speed_bps"] = speed
+	if eta != nil {
+		info["eta_seconds"] = *eta
+	}
+	return info
+}
+
 // DeleteTask 删除任务
+// @Summary 删除任务
+// @Tags 任务
+// @Produce json
+// @Param file_id path string true "任务ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /tasks/{file_id} [delete]
 func DeleteTask(c *gin.Context) {
 	fileID := c.Param("file_id")
 	if fileID == "" {
@@ -345,7 +769,7 @@ func DeleteTask(c *gin.Context) {
 	}
 
 	// 检查任务是否存在
-	task, exists := utils.Storage.GetTask(fileID)
+	task, exists := tenantTask(c, fileID)
 	if !exists {
 		c.JSON(404, gin.H{"error": "任务不存在"})
 		return
@@ -369,6 +793,19 @@ func DeleteTask(c *gin.Context) {
 }
 
 // CleanupTasks 清理任务
+// @Summary 清理任务
+// @Description 未指定条件时按配置的 cleanup_policies 清理；dry_run=true 时只返回会被清理的任务
+// @Tags 任务
+// @Produce json
+// @Param status query string false "只清理指定状态的任务"
+// @Param older_than query int false "只清理N天前的任务"
+// @Param task_type query string false "只清理 file 或 folder 任务"
+// @Param dry_run query bool false "只列出会被清理的任务，不删除"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /tasks/cleanup [post]
 func CleanupTasks(c *gin.Context) {
 	if utils.Storage == nil {
 		c.JSON(500, gin.H{"error": "存储管理器未初始化"})
@@ -378,206 +815,381 @@ func CleanupTasks(c *gin.Context) {
 	// 获取查询参数
 	statusFilter := c.Query("status")      // 可选：只清理特定状态的任务
 	olderThanStr := c.Query("older_than")  // 可选：清理N天前的任务
+	taskType := c.Query("task_type")      // 可选：只清理特定类型的任务
+	dryRun := c.Query("dry_run") == "true" // 可选：只预览
+
+	// 携带租户ID时只清理该租户的任务
+	tenantID := utils.TenantFromContext(c)
 
 	var olderThanDays int
 	if olderThanStr != "" {
 		var err error
 		olderThanDays, err = strconv.Atoi(olderThanStr)
-		if err != nil {
+		if err != nil || olderThanDays < 0 {
 			c.JSON(400, gin.H{"error": "无效的older_than参数"})
 			return
 		}
 	}
+	if taskType != "" && taskType != "file" && taskType != "folder" {
+		c.JSON(400, gin.H{"error": "无效的task_type参数"})
+		return
+	}
 
-	cleanedCount := 0
-	
+	var cleaned []utils.CleanedTask
+	var err error
 	if statusFilter == "" && olderThanDays == 0 {
-		// 执行默认清理（过期任务）
-		if err := utils.Storage.CleanupExpiredTasks(); err != nil {
-			c.JSON(500, gin.H{"error": fmt.Sprintf("清理失败: %v", err)})
-			return
-		}
-		cleanedCount = -1 // 表示使用默认清理策略
-	} else {
-		// 根据条件清理 - 只清理主任务
-		tasks := utils.Storage.GetMainTasks()
-		for _, task := range tasks {
-			shouldClean := false
-			
-			// 检查状态过滤器
-			if statusFilter != "" && task.Status == statusFilter {
-				shouldClean = true
-			}
-			
-			// 检查时间过滤器
-			if olderThanDays > 0 {
-				daysDiff := int(task.UpdatedAt.Sub(task.UpdatedAt).Hours() / 24)
-				if daysDiff >= olderThanDays {
-					shouldClean = true
-				}
-			}
-			
-			if shouldClean {
-				if err := utils.Storage.DeleteTask(task.FileID); err == nil {
-					cleanedCount++
+		// 执行默认清理（配置的清理策略）
+		policies := utils.CurrentConfig().CleanupPolicies
+		if taskType != "" || tenantID != "" {
+			policies = make([]utils.CleanupPolicy, len(utils.CurrentConfig().CleanupPolicies))
+			for i, policy := range utils.CurrentConfig().CleanupPolicies {
+				if taskType != "" {
+					policy.TaskType = taskType
 				}
+				policy.TenantID = tenantID
+				policies[i] = policy
 			}
 		}
+		cleaned, err = utils.Storage.ApplyCleanupPolicies(policies, dryRun, true)
+	} else {
+		// 根据条件清理
+		cleaned, err = utils.Storage.ApplyCleanupPolicies([]utils.CleanupPolicy{{
+			Status:         statusFilter,
+			OlderThanHours: olderThanDays * 24,
+			TaskType:       taskType,
+			TenantID:       tenantID,
+		}}, dryRun, false)
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("清理失败: %v", err)})
+		return
 	}
 
-	message := "清理完成"
-	if cleanedCount >= 0 {
-		message = fmt.Sprintf("清理了 %d 个任务", cleanedCount)
+	message := fmt.Sprintf("清理了 %d 个任务", len(cleaned))
+	if dryRun {
+		message = fmt.Sprintf("将清理 %d 个任务", len(cleaned))
 	}
 
 	c.JSON(200, gin.H{
 		"status":        "ok",
 		"message":       message,
-		"cleaned_count": cleanedCount,
+		"dry_run":       dryRun,
+		"cleaned_count": len(cleaned),
+		"tasks":         cleaned,
 	})
 }
 
+// CloneTask 复制任务以便重新上传，原任务保持不变
+// @Summary 克隆任务
+// @Description 以新的任务ID复制任务元数据，清空分片、重试次数和合并结果；文件夹任务连同所有子任务一起复制
+// @Tags 任务
+// @Produce json
+// @Param file_id path string true "任务ID"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /tasks/{file_id}/clone [post]
+func CloneTask(c *gin.Context) {
+	fileID := c.Param("file_id")
+
+	if utils.Storage == nil {
+		c.JSON(500, gin.H{"error": "存储管理器未初始化"})
+		return
+	}
+	if _, exists := tenantTask(c, fileID); !exists {
+		c.JSON(404, gin.H{"error": "任务不存在"})
+		return
+	}
+
+	clone, err := utils.Storage.CloneTask(fileID)
+	if err != nil {
+		if errors.Is(err, utils.ErrCloneSubTask) {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(500, gin.H{"error": fmt.Sprintf("克隆任务失败: %v", err)})
+		return
+	}
+
+	utils.RequestLogger(c).Info("克隆任务", "file_id", fileID, "clone_file_id", clone.FileID)
+	response := gin.H{
+		"status":      "ok",
+		"file_id":     clone.FileID,
+		"cloned_from": fileID,
+		"task_type":   clone.TaskType,
+	}
+	if clone.TaskType == "folder" {
+		response["sub_tasks"] = clone.SubTasks
+		response["nested_sub_folders"] = clone.NestedSubFolders
+	}
+	c.JSON(201, response)
+}
+
 // PauseTask 暂停任务
+// @Summary 暂停任务
+// @Tags 任务
+// @Produce json
+// @Param file_id path string true "任务ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /tasks/{file_id}/pause [post]
 func PauseTask(c *gin.Context) {
 	fileID := c.Param("file_id")
 	if fileID == "" {
 		c.JSON(400, gin.H{"error": "缺少file_id参数"})
 		return
 	}
+	if rejectOtherTenant(c, fileID) {
+		return
+	}
 
-	if utils.Storage == nil {
-		c.JSON(500, gin.H{"error": "存储管理器未初始化"})
+	message, err := PauseUpload(c.Request.Context(), fileID)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
+	c.JSON(200, gin.H{
+		"status":  "ok",
+		"message": message,
+	})
+}
+
+// PauseUpload 暂停任务，文件夹任务同时暂停上传中的子任务，HTTP和WebSocket接口共用
+func PauseUpload(ctx context.Context, fileID string) (string, error) {
+	if utils.Storage == nil {
+		return "", newAPIError(500, nil, "存储管理器未初始化")
+	}
+
 	task, exists := utils.Storage.GetTask(fileID)
 	if !exists {
-		c.JSON(404, gin.H{"error": "任务不存在"})
-		return
+		return "", newAPIError(404, nil, "任务不存在")
 	}
 
 	if task.Status == "completed" {
-		c.JSON(400, gin.H{"error": "已完成的任务不能暂停"})
-		return
+		return "", newAPIError(400, nil, "已完成的任务不能暂停")
 	}
 
 	// 更新任务状态
-	task.Status = "paused"
+	if err := utils.Storage.TransitionTask(fileID, "paused"); err != nil {
+		if errors.Is(err, utils.ErrInvalidTransition) {
+			return "", newAPIError(409, nil, "当前状态不能暂停: %v", err)
+		}
+		return "", newAPIError(500, nil, "暂停任务失败: %v", err)
+	}
 	
 	// 如果是文件夹任务，暂停所有子任务
 	if task.TaskType == "folder" {
 		for _, subTaskID := range task.SubTasks {
 			if subTask, exists := utils.Storage.GetTask(subTaskID); exists && subTask.Status == "uploading" {
-				subTask.Status = "paused"
-				utils.Storage.SaveTask(subTask)
+				utils.Storage.TransitionTask(subTaskID, "paused")
 			}
 		}
 	}
-	
-	if err := utils.Storage.SaveTask(task); err != nil {
-		c.JSON(500, gin.H{"error": fmt.Sprintf("暂停任务失败: %v", err)})
-		return
-	}
 
 	message := "任务已暂停"
 	if task.TaskType == "folder" {
 		message = fmt.Sprintf("文件夹任务 '%s' 及其所有子任务已暂停", task.FolderName)
 	}
-
-	c.JSON(200, gin.H{
-		"status":  "ok",
-		"message": message,
-	})
+	return message, nil
 }
 
 // ResumeTask 恢复任务
+// @Summary 恢复任务
+// @Tags 任务
+// @Produce json
+// @Param file_id path string true "任务ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /tasks/{file_id}/resume [post]
 func ResumeTask(c *gin.Context) {
 	fileID := c.Param("file_id")
 	if fileID == "" {
 		c.JSON(400, gin.H{"error": "缺少file_id参数"})
 		return
 	}
+	if rejectOtherTenant(c, fileID) {
+		return
+	}
 
-	if utils.Storage == nil {
-		c.JSON(500, gin.H{"error": "存储管理器未初始化"})
+	message, err := ResumeUpload(c.Request.Context(), fileID)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
+	c.JSON(200, gin.H{
+		"status":  "ok",
+		"message": message,
+	})
+}
+
+// ResumeUpload 恢复任务，文件夹任务同时恢复暂停或失败的子任务，HTTP和WebSocket接口共用
+func ResumeUpload(ctx context.Context, fileID string) (string, error) {
+	logger := utils.LoggerFromContext(ctx)
+
+	if utils.Storage == nil {
+		return "", newAPIError(500, nil, "存储管理器未初始化")
+	}
+
 	task, exists := utils.Storage.GetTask(fileID)
 	if !exists {
-		c.JSON(404, gin.H{"error": "任务不存在"})
-		return
+		return "", newAPIError(404, nil, "任务不存在")
 	}
 
 	// 支持更多状态的恢复：paused, failed, partial_failed
 	if task.Status != "paused" && task.Status != "failed" && task.Status != "partial_failed" {
-		c.JSON(400, gin.H{"error": "只有暂停、失败或部分失败的任务可以恢复"})
-		return
+		return "", newAPIError(400, nil, "只有暂停、失败或部分失败的任务可以恢复")
+	}
+
+	// 重试次数耗尽的分片需管理员重置后才能恢复
+	if failedChunks := task.PermanentlyFailedChunks(); len(failedChunks) > 0 {
+		return "", newAPIError(409, gin.H{
+			"permanently_failed_chunks": failedChunks,
+		}, "存在重试次数已耗尽的分片，请先重置分片重试次数")
+	}
+
+	// 依赖的任务尚未完成时不能恢复上传
+	if pending := utils.Storage.PendingDependencies(task); len(pending) > 0 {
+		return "", dependencyNotMetError(pending)
 	}
 
 	// 更新任务状态
-	task.Status = "uploading"
-	task.RetryCount++
-	
-	// 重置失败的分片状态
-	if task.Chunks != nil {
-		for index, chunk := range task.Chunks {
-			if chunk.Status == "failed" {
-				chunk.Status = "pending"
-				chunk.RetryCount = 0
-				task.Chunks[index] = chunk
-			}
+	task, err := resumeTaskState(fileID)
+	if err != nil {
+		if errors.Is(err, utils.ErrInvalidTransition) {
+			return "", newAPIError(409, nil, "当前状态不能恢复: %v", err)
 		}
+		return "", newAPIError(500, nil, "恢复任务失败: %v", err)
 	}
 	
 	// 如果是文件夹任务，恢复所有暂停或失败的子任务
 	if task.TaskType == "folder" {
 		for _, subTaskID := range task.SubTasks {
 			if subTask, exists := utils.Storage.GetTask(subTaskID); exists && (subTask.Status == "paused" || subTask.Status == "failed") {
-				subTask.Status = "uploading"
-				subTask.RetryCount++
-				
-				// 重置子任务的失败分片
-				if subTask.Chunks != nil {
-					for index, chunk := range subTask.Chunks {
-						if chunk.Status == "failed" {
-							chunk.Status = "pending"
-							chunk.RetryCount = 0
-							subTask.Chunks[index] = chunk
-						}
-					}
+				if _, err := resumeTaskState(subTaskID); err != nil {
+					logger.Error("恢复子任务失败", "file_id", subTaskID, "error", err)
 				}
-				
-				utils.Storage.SaveTask(subTask)
 			}
 		}
 	}
+
+	message := "任务已恢复"
+	if task.TaskType == "folder" {
+		message = fmt.Sprintf("文件夹任务 '%s' 及其所有子任务已恢复", task.FolderName)
+	}
+	return message, nil
+}
+
+// resumeTaskState 将任务转换为上传中，并重置失败的分片和失败原因
+func resumeTaskState(fileID string) (*utils.UploadTask, error) {
+	if task, exists := utils.Storage.GetTask(fileID); exists {
+		if len(task.PermanentlyFailedChunks()) > 0 {
+			return nil, fmt.Errorf("%w: %v", utils.ErrRetryBudgetExhausted, task.PermanentlyFailedChunks())
+		}
+		// 依赖的任务尚未完成时保持当前状态
+		if pending := utils.Storage.PendingDependencies(task); len(pending) > 0 {
+			return nil, fmt.Errorf("%w: %v", utils.ErrDependencyNotMet, pending)
+		}
+	}
+
+	if err := utils.Storage.TransitionTask(fileID, "uploading"); err != nil {
+		return nil, err
+	}
+
+	task, exists := utils.Storage.GetTask(fileID)
+	if !exists {
+		return nil, fmt.Errorf("任务不存在: %s", fileID)
+	}
+
+	task.FailureReason = ""
+	task.RetryCount++
 	
+	// 重置失败的分片状态，保留重试次数以限制总重试预算
+	if task.Chunks != nil {
+		for index, chunk := range task.Chunks {
+			if chunk.Status == "failed" {
+				chunk.Status = "pending"
+				task.Chunks[index] = chunk
+			}
+		}
+	}
+
 	if err := utils.Storage.SaveTask(task); err != nil {
-		c.JSON(500, gin.H{"error": fmt.Sprintf("恢复任务失败: %v", err)})
+		return nil, err
+	}
+	return task, nil
+}
+
+// ResetChunkRetry 管理员重置单个分片的重试次数
+// @Summary 重置分片重试次数
+// @Description 重试次数耗尽的分片恢复为失败状态，之后可通过恢复接口继续上传
+// @Tags 任务
+// @Produce json
+// @Param file_id path string true "任务ID"
+// @Param index path int true "分片索引"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security AdminKey
+// @Router /tasks/{file_id}/chunks/{index} [delete]
+func ResetChunkRetry(c *gin.Context) {
+	fileID := c.Param("file_id")
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil || index < 0 {
+		c.JSON(400, gin.H{"error": "无效的分片索引"})
 		return
 	}
 
-	message := "任务已恢复"
-	if task.TaskType == "folder" {
-		message = fmt.Sprintf("文件夹任务 '%s' 及其所有子任务已恢复", task.FolderName)
+	if utils.Storage == nil {
+		c.JSON(500, gin.H{"error": "存储管理器未初始化"})
+		return
+	}
+	if rejectOtherTenant(c, fileID) {
+		return
 	}
 
+	task, err := utils.Storage.ResetChunkRetry(fileID, index)
+	if err != nil {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+
+	utils.RequestLogger(c).Info("重置分片重试次数", "file_id", fileID, "chunk_index", index)
 	c.JSON(200, gin.H{
-		"status":  "ok",
-		"message": message,
+		"status":                    "ok",
+		"file_id":                   fileID,
+		"chunk_index":               index,
+		"task_status":               task.Status,
+		"permanently_failed_chunks": task.PermanentlyFailedChunks(),
 	})
-} 
+}
 
 // ResumeAllFailedTasks 批量恢复所有失败的任务
+// @Summary 批量恢复失败的任务
+// @Tags 任务
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /tasks/resume_all_failed [post]
 func ResumeAllFailedTasks(c *gin.Context) {
+	logger := utils.RequestLogger(c)
 	if utils.Storage == nil {
 		c.JSON(500, gin.H{"error": "存储管理器未初始化"})
 		return
 	}
 
 	// 获取所有任务
-	allTasksMap := utils.Storage.GetAllTasks()
+	allTasksMap := utils.Storage.GetTenantTasks(utils.TenantFromContext(c))
 	
 	resumedTasks := []string{}
 	failedToResume := []string{}
@@ -586,52 +1198,24 @@ func ResumeAllFailedTasks(c *gin.Context) {
 		// 只处理失败、暂停或部分失败的任务
 		if task.Status == "failed" || task.Status == "paused" || task.Status == "partial_failed" {
 			// 更新任务状态
-			task.Status = "uploading"
-			task.RetryCount++
-			
-			// 重置失败的分片状态
-			if task.Chunks != nil {
-				for index, chunk := range task.Chunks {
-					if chunk.Status == "failed" {
-						chunk.Status = "pending"
-						chunk.RetryCount = 0
-						task.Chunks[index] = chunk
-					}
-				}
+			if _, err := resumeTaskState(task.FileID); err != nil {
+				logger.Error("恢复任务失败", "file_id", task.FileID, "error", err)
+				failedToResume = append(failedToResume, task.FileID)
+				continue
 			}
 			
 			// 如果是文件夹任务，恢复所有失败的子任务
 			if task.TaskType == "folder" {
 				for _, subTaskID := range task.SubTasks {
 					if subTask, exists := utils.Storage.GetTask(subTaskID); exists && (subTask.Status == "paused" || subTask.Status == "failed") {
-						subTask.Status = "uploading"
-						subTask.RetryCount++
-						
-						// 重置子任务的失败分片
-						if subTask.Chunks != nil {
-							for index, chunk := range subTask.Chunks {
-								if chunk.Status == "failed" {
-									chunk.Status = "pending"
-									chunk.RetryCount = 0
-									subTask.Chunks[index] = chunk
-								}
-							}
-						}
-						
-						if err := utils.Storage.SaveTask(subTask); err != nil {
-							log.Printf("恢复子任务 %s 失败: %v", subTaskID, err)
+						if _, err := resumeTaskState(subTaskID); err != nil {
+							logger.Error("恢复子任务失败", "file_id", subTaskID, "error", err)
 						}
 					}
 				}
 			}
 			
-			// 保存任务
-			if err := utils.Storage.SaveTask(task); err != nil {
-				log.Printf("恢复任务 %s 失败: %v", task.FileID, err)
-				failedToResume = append(failedToResume, task.FileID)
-			} else {
-				resumedTasks = append(resumedTasks, task.FileID)
-			}
+			resumedTasks = append(resumedTasks, task.FileID)
 		}
 	}
 
@@ -652,6 +1236,13 @@ func ResumeAllFailedTasks(c *gin.Context) {
 }
 
 // GetFailedTasks 获取所有失败的任务列表
+// @Summary 获取失败的任务列表
+// @Tags 任务
+// @Produce json
+// @Success 200 {object} object{status=string,failed_tasks=[]utils.UploadTask,total_failed=int,message=string}
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /tasks/failed [get]
 func GetFailedTasks(c *gin.Context) {
 	if utils.Storage == nil {
 		c.JSON(500, gin.H{"error": "存储管理器未初始化"})
@@ -659,7 +1250,7 @@ func GetFailedTasks(c *gin.Context) {
 	}
 
 	// 获取所有任务
-	allTasksMap := utils.Storage.GetAllTasks()
+	allTasksMap := utils.Storage.GetTenantTasks(utils.TenantFromContext(c))
 	
 	failedTasks := []*utils.UploadTask{}
 	
@@ -675,4 +1266,217 @@ func GetFailedTasks(c *gin.Context) {
 		"total_failed": len(failedTasks),
 		"message": fmt.Sprintf("找到 %d 个失败的任务", len(failedTasks)),
 	})
-} 
\ No newline at end of file
+} 
+
+// HeartbeatRequest 心跳请求结构
+type HeartbeatRequest struct {
+	EstimatedCompletionAt *time.Time `json:"estimated_completion_at"`
+}
+
+// TaskHeartbeat 上传会话心跳，刷新任务活跃时间防止被过期清理
+// @Summary 上传会话心跳
+// @Tags 任务
+// @Accept json
+// @Produce json
+// @Param file_id path string true "任务ID"
+// @Param request body HeartbeatRequest false "心跳参数"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /tasks/{file_id}/heartbeat [post]
+func TaskHeartbeat(c *gin.Context) {
+	fileID := c.Param("file_id")
+	if fileID == "" {
+		c.JSON(400, gin.H{"error": "缺少file_id参数"})
+		return
+	}
+
+	if utils.Storage == nil {
+		c.JSON(500, gin.H{"error": "存储管理器未初始化"})
+		return
+	}
+
+	// 请求体可选
+	var req HeartbeatRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, gin.H{"error": "请求参数错误: " + err.Error()})
+			return
+		}
+	}
+
+	task, exists := tenantTask(c, fileID)
+	if !exists {
+		c.JSON(404, gin.H{"error": "任务不存在"})
+		return
+	}
+
+	if req.EstimatedCompletionAt != nil {
+		task.EstimatedCompletionAt = req.EstimatedCompletionAt
+	}
+
+	// SaveTask 会刷新 UpdatedAt
+	if err := utils.Storage.SaveTask(task); err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("更新任务失败: %v", err)})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"status":                  "ok",
+		"file_id":                 fileID,
+		"updated_at":              task.UpdatedAt,
+		"estimated_completion_at": task.EstimatedCompletionAt,
+	})
+}
+
+// UpdateTagsRequest 更新标签请求结构
+type UpdateTagsRequest struct {
+	Tags    map[string]string `json:"tags" binding:"required" example:"project:demo"`
+	Replace bool              `json:"replace"` // 为true时替换全部标签，否则与已有标签合并
+}
+
+// UpdateTaskTags 设置或合并任务标签
+// @Summary 设置或合并任务标签
+// @Tags 任务
+// @Accept json
+// @Produce json
+// @Param file_id path string true "任务ID"
+// @Param request body UpdateTagsRequest true "标签参数"
+// @Success 200 {object} utils.UploadTask
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /tasks/{file_id}/tags [put]
+func UpdateTaskTags(c *gin.Context) {
+	fileID := c.Param("file_id")
+	if fileID == "" {
+		c.JSON(400, gin.H{"error": "缺少file_id参数"})
+		return
+	}
+
+	if utils.Storage == nil {
+		c.JSON(500, gin.H{"error": "存储管理器未初始化"})
+		return
+	}
+
+	var req UpdateTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+
+	if _, exists := tenantTask(c, fileID); !exists {
+		c.JSON(404, gin.H{"error": "任务不存在"})
+		return
+	}
+
+	// 请求中的标签先单独校验，与已有标签合并后的结果由 SetTags 在锁内校验，并发请求不会互相覆盖
+	if err := utils.ValidateTags(req.Tags); err != nil {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("标签无效: %v", err)})
+		return
+	}
+
+	task, err := utils.Storage.SetTags(fileID, req.Tags, req.Replace)
+	if errors.Is(err, utils.ErrInvalidTags) {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("更新标签失败: %v", err)})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"status":  "ok",
+		"file_id": fileID,
+		"tags":    task.Tags,
+	})
+}
+
+// UpdateTaskMetadataRequest 更新自定义元数据请求结构
+type UpdateTaskMetadataRequest struct {
+	Metadata json.RawMessage `json:"metadata" swaggertype:"object"` // 替换全部元数据，为 null 时清空
+}
+
+// UpdateTaskMetadata 替换任务的自定义元数据
+// @Summary 替换任务自定义元数据
+// @Description 元数据为任意合法JSON，大小不超过 max_metadata_size_bytes 字节
+// @Tags 任务
+// @Accept json
+// @Produce json
+// @Param file_id path string true "任务ID"
+// @Param request body UpdateTaskMetadataRequest true "元数据"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /tasks/{file_id}/metadata [put]
+func UpdateTaskMetadata(c *gin.Context) {
+	fileID := c.Param("file_id")
+	if fileID == "" {
+		c.JSON(400, gin.H{"error": "缺少file_id参数"})
+		return
+	}
+
+	if utils.Storage == nil {
+		c.JSON(500, gin.H{"error": "存储管理器未初始化"})
+		return
+	}
+
+	var req UpdateTaskMetadataRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+
+	metadata, err := utils.ParseMetadata(req.Metadata)
+	if err != nil {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("元数据无效: %v", err)})
+		return
+	}
+
+	if _, exists := tenantTask(c, fileID); !exists {
+		c.JSON(404, gin.H{"error": "任务不存在"})
+		return
+	}
+
+	task, err := utils.Storage.SetMetadata(fileID, metadata)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("更新元数据失败: %v", err)})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"status":   "ok",
+		"file_id":  fileID,
+		"metadata": task.Metadata,
+	})
+}
+
+// GetTaskQuota 查询任务的存储配额使用情况
+// @Summary 查询存储配额用量
+// @Tags 任务
+// @Produce json
+// @Param file_id path string true "任务ID"
+// @Success 200 {object} utils.QuotaUsage
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /tasks/{file_id}/quota [get]
+func GetTaskQuota(c *gin.Context) {
+	fileID := c.Param("file_id")
+	if rejectOtherTenant(c, fileID) {
+		return
+	}
+
+	usage, err := utils.Storage.GetQuotaUsage(fileID)
+	if err != nil {
+		c.JSON(404, gin.H{"error": "任务不存在"})
+		return
+	}
+
+	c.JSON(200, usage)
+}
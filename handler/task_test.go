@@ -0,0 +1,239 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-uploader/utils"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// initTestStorage 在临时目录中初始化全局存储，测试结束时关闭并恢复配置
+func initTestStorage(t *testing.T) {
+	t.Helper()
+	saved, savedStorage := utils.Config, utils.Storage
+	utils.Config.UploadDir = t.TempDir()
+	utils.Config.MergedDir = t.TempDir()
+	utils.Config.TrashDir = t.TempDir()
+	if err := utils.InitStorage(); err != nil {
+		utils.Config, utils.Storage = saved, savedStorage
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		utils.Storage.Close()
+		utils.Config, utils.Storage = saved, savedStorage
+	})
+}
+
+// serveJSON 发送JSON请求，返回状态码和解析后的响应
+func serveJSON(t *testing.T, r *gin.Engine, method, path string, body interface{}) (int, map[string]interface{}) {
+	t.Helper()
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("响应不是JSON: %s", w.Body.String())
+	}
+	return w.Code, response
+}
+
+// deepPath 返回位于 depth 层目录下的文件相对路径
+func deepPath(depth int) string {
+	return strings.Repeat("d/", depth) + "file.bin"
+}
+
+func TestCreateFolderTaskRejectsDeepPaths(t *testing.T) {
+	initTestStorage(t)
+	utils.Config.MaxFolderDepth = 10
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/folder_tasks", CreateFolderTask)
+
+	files := []utils.FileInfo{
+		{Name: "file.bin", RelativePath: deepPath(10), Size: 1, TotalChunks: 1},
+		{Name: "file.bin", RelativePath: deepPath(11), Size: 1, TotalChunks: 1},
+		{Name: "file.bin", SubDirectory: strings.TrimSuffix(strings.Repeat("s/", 11), "/"), Size: 1, TotalChunks: 1},
+	}
+	code, response := serveJSON(t, r, http.MethodPost, "/folder_tasks", gin.H{"folder_name": "deep", "files": files})
+	if code != 400 {
+		t.Fatalf("11层目录的路径应被拒绝: %d %v", code, response)
+	}
+	offending, _ := response["offending_paths"].([]interface{})
+	if len(offending) != 2 || offending[0] != deepPath(11) || !strings.Contains(response["error"].(string), deepPath(11)) {
+		t.Fatalf("错误信息应列出超出层级的路径: %v", response)
+	}
+
+	code, response = serveJSON(t, r, http.MethodPost, "/folder_tasks", gin.H{"folder_name": "deep", "files": files[:1]})
+	if code != 200 {
+		t.Fatalf("10层目录的路径应被接受: %d %v", code, response)
+	}
+}
+
+func TestCreateFolderTaskRejectsDeepNesting(t *testing.T) {
+	initTestStorage(t)
+	utils.Config.MaxFolderDepth = 10
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/folder_tasks", CreateFolderTask)
+
+	files := []utils.FileInfo{{Name: "file.bin", Size: 1, TotalChunks: 1}}
+	parentID := ""
+	for depth := 1; depth <= 11; depth++ {
+		code, response := serveJSON(t, r, http.MethodPost, "/folder_tasks", gin.H{
+			"folder_name":           "level",
+			"files":                 files,
+			"parent_folder_task_id": parentID,
+		})
+		if depth <= 10 {
+			if code != 200 {
+				t.Fatalf("第%d层文件夹应创建成功: %d %v", depth, code, response)
+			}
+			parentID = response["folder_task_id"].(string)
+			continue
+		}
+		if code != 400 || response["depth"] != float64(11) {
+			t.Fatalf("第11层文件夹应被拒绝: %d %v", code, response)
+		}
+	}
+}
+
+func TestInvalidMetadataRejected(t *testing.T) {
+	initTestStorage(t)
+	utils.Config.MaxMetadataSizeBytes = 4096
+	if err := utils.Storage.SaveTask(&utils.UploadTask{
+		FileID:   "task-1",
+		TaskType: "file",
+		FileName: "a.bin",
+		Status:   "pending",
+		Chunks:   make(map[int]utils.ChunkInfo),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.PUT("/tasks/:file_id/metadata", UpdateTaskMetadata)
+	r.POST("/folder_tasks", CreateFolderTask)
+	r.POST("/upload_chunk", UploadChunk)
+
+	serveRaw := func(method, path, contentType string, body []byte) int {
+		req := httptest.NewRequest(method, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 请求体中的元数据不是合法JSON
+	for _, body := range []string{
+		`{"metadata": {bad}}`,
+		`{"metadata": {"record_id": 42}`,
+		`{"metadata": ` + `"` + strings.Repeat("x", 5000) + `"}`,
+	} {
+		if code := serveRaw(http.MethodPut, "/tasks/task-1/metadata", "application/json", []byte(body)); code != 400 {
+			t.Fatalf("PUT metadata %.40s: status = %d, want 400", body, code)
+		}
+		folder := `{"folder_name": "f", "files": [{"name": "a.bin", "size": 1, "total_chunks": 1}], "metadata": ` +
+			strings.TrimPrefix(body, `{"metadata": `)
+		if code := serveRaw(http.MethodPost, "/folder_tasks", "application/json", []byte(folder)); code != 400 {
+			t.Fatalf("POST folder_tasks %.40s: status = %d, want 400", folder, code)
+		}
+	}
+	if task, _ := utils.Storage.GetTask("task-1"); task.Metadata != nil {
+		t.Fatalf("无效的元数据不应保存: %s", task.Metadata)
+	}
+
+	code, response := serveJSON(t, r, http.MethodPut, "/tasks/task-1/metadata", gin.H{"metadata": gin.H{"record_id": 42}})
+	if code != 200 {
+		t.Fatalf("合法的元数据应保存: %d %v", code, response)
+	}
+	if task, _ := utils.Storage.GetTask("task-1"); string(task.Metadata) != `{"record_id":42}` {
+		t.Fatalf("metadata = %s", task.Metadata)
+	}
+
+	// 分片上传表单中的元数据字段为字符串，需要单独校验
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.WriteField("file_id", "task-2")
+	writer.WriteField("chunk_index", "0")
+	writer.WriteField("total_chunks", "1")
+	writer.WriteField("metadata", `{"record_id": `)
+	part, _ := writer.CreateFormFile("chunk", "chunk.bin")
+	part.Write([]byte("data"))
+	writer.Close()
+	if code := serveRaw(http.MethodPost, "/upload_chunk", writer.FormDataContentType(), body.Bytes()); code != 400 {
+		t.Fatalf("upload_chunk: status = %d, want 400", code)
+	}
+	if _, exists := utils.Storage.GetTask("task-2"); exists {
+		t.Fatal("元数据无效时不应创建任务")
+	}
+}
+
+func TestUpdateTaskTagsConcurrentMerge(t *testing.T) {
+	initTestStorage(t)
+	if err := utils.Storage.SaveTask(&utils.UploadTask{
+		FileID:   "task-1",
+		TaskType: "file",
+		FileName: "a.bin",
+		Status:   "pending",
+		Chunks:   make(map[int]utils.ChunkInfo),
+		Tags:     map[string]string{"project": "demo"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.PUT("/tasks/:file_id/tags", UpdateTaskTags)
+
+	// 并发合并不同的标签，每个请求的标签都应保留
+	const requests = 16
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			body := fmt.Sprintf(`{"tags": {"key%d": "v"}}`, i)
+			req := httptest.NewRequest(http.MethodPut, "/tasks/task-1/tags", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != 200 {
+				t.Errorf("合并标签失败: %d %s", w.Code, w.Body.String())
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	task, _ := utils.Storage.GetTask("task-1")
+	if len(task.Tags) != requests+1 || task.Tags["project"] != "demo" {
+		t.Fatalf("并发合并丢失了标签: %v", task.Tags)
+	}
+
+	// 合并后超出数量限制时返回400，已有标签不变
+	tags := make(map[string]string)
+	for i := 0; i < utils.MaxTagsPerTask; i++ {
+		tags[fmt.Sprintf("extra%d", i)] = "v"
+	}
+	if code, response := serveJSON(t, r, http.MethodPut, "/tasks/task-1/tags", gin.H{"tags": tags}); code != 400 {
+		t.Fatalf("合并后超出数量限制应返回400: %d %v", code, response)
+	}
+	if task, _ := utils.Storage.GetTask("task-1"); len(task.Tags) != requests+1 {
+		t.Fatalf("校验失败后标签被修改: %v", task.Tags)
+	}
+}
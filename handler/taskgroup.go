@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-uploader/utils"
+	"net/http"
+)
+
+// CreateTaskGroupRequest 创建任务组请求结构
+type CreateTaskGroupRequest struct {
+	Name          string   `json:"name" binding:"required" example:"2024-archive"`
+	FolderTaskIDs []string `json:"folder_task_ids" example:"folder_photos_1700000000000000000"` // 可选：创建时加入的文件夹任务
+}
+
+// AddGroupFolderTaskRequest 向任务组添加文件夹任务请求结构
+type AddGroupFolderTaskRequest struct {
+	FolderTaskID string `json:"folder_task_id" binding:"required" example:"folder_photos_1700000000000000000"`
+}
+
+// validateGroupFolderTask 检查文件夹任务存在且属于请求的租户
+func validateGroupFolderTask(folderTaskID, tenantID string) error {
+	task, exists := utils.Storage.GetTenantTask(folderTaskID, tenantID)
+	if !exists || task.TaskType != "folder" {
+		return newAPIError(404, gin.H{"folder_task_id": folderTaskID}, "文件夹任务不存在: %s", folderTaskID)
+	}
+	return nil
+}
+
+// CreateTaskGroup 创建任务组
+// @Summary 创建任务组
+// @Description 任务组把多个文件夹任务作为一个批次跟踪整体进度
+// @Tags 任务组
+// @Accept json
+// @Produce json
+// @Param request body CreateTaskGroupRequest true "任务组参数"
+// @Success 201 {object} utils.TaskGroup
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /task_groups [post]
+func CreateTaskGroup(c *gin.Context) {
+	var req CreateTaskGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("参数错误: %v", err)})
+		return
+	}
+
+	tenantID := utils.TenantFromContext(c)
+	seen := make(map[string]bool, len(req.FolderTaskIDs))
+	for _, folderTaskID := range req.FolderTaskIDs {
+		if seen[folderTaskID] {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("文件夹任务重复: %s", folderTaskID)})
+			return
+		}
+		seen[folderTaskID] = true
+		if err := validateGroupFolderTask(folderTaskID, tenantID); err != nil {
+			respondError(c, err)
+			return
+		}
+	}
+
+	group, err := utils.TaskGroups.Create(req.Name, req.FolderTaskIDs, tenantID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("创建任务组失败: %v", err)})
+		return
+	}
+
+	utils.RequestLogger(c).Info("创建任务组", "group_id", group.GroupID, "folder_tasks", len(group.FolderTaskIDs))
+	c.JSON(http.StatusCreated, group)
+}
+
+// ListTaskGroups 列出任务组
+// @Summary 列出任务组
+// @Tags 任务组
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /task_groups [get]
+func ListTaskGroups(c *gin.Context) {
+	groups := utils.TaskGroups.List(utils.TenantFromContext(c))
+	c.JSON(200, gin.H{
+		"task_groups": groups,
+		"count":       len(groups),
+	})
+}
+
+// GetTaskGroupSummary 获取任务组进度摘要
+// @Summary 获取任务组进度摘要
+// @Description 汇总所有文件夹任务（含嵌套文件夹）的文件数、大小和完成率；已删除的文件夹任务列在 missing_folder_tasks 中
+// @Tags 任务组
+// @Produce json
+// @Param group_id path string true "任务组ID"
+// @Success 200 {object} utils.TaskGroupSummary
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /task_groups/{group_id}/summary [get]
+func GetTaskGroupSummary(c *gin.Context) {
+	group, err := utils.TaskGroups.Get(c.Param("group_id"), utils.TenantFromContext(c))
+	if err != nil {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, utils.Storage.GetTaskGroupSummary(group))
+}
+
+// AddGroupFolderTask 向任务组添加文件夹任务
+// @Summary 向任务组添加文件夹任务
+// @Tags 任务组
+// @Accept json
+// @Produce json
+// @Param group_id path string true "任务组ID"
+// @Param request body AddGroupFolderTaskRequest true "文件夹任务"
+// @Success 200 {object} utils.TaskGroup
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /task_groups/{group_id}/folder_tasks [post]
+func AddGroupFolderTask(c *gin.Context) {
+	var req AddGroupFolderTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("参数错误: %v", err)})
+		return
+	}
+
+	tenantID := utils.TenantFromContext(c)
+	if err := validateGroupFolderTask(req.FolderTaskID, tenantID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	group, err := utils.TaskGroups.AddFolderTask(c.Param("group_id"), req.FolderTaskID, tenantID)
+	if err != nil {
+		switch {
+		case errors.Is(err, utils.ErrTaskGroupNotFound):
+			c.JSON(404, gin.H{"error": err.Error()})
+		case errors.Is(err, utils.ErrFolderTaskInGroup):
+			c.JSON(409, gin.H{"error": err.Error()})
+		default:
+			c.JSON(500, gin.H{"error": fmt.Sprintf("添加文件夹任务失败: %v", err)})
+		}
+		return
+	}
+
+	c.JSON(200, group)
+}
+
+// DeleteTaskGroup 解散任务组
+// @Summary 解散任务组
+// @Description 只删除任务组，其中的文件夹任务保持不变
+// @Tags 任务组
+// @Produce json
+// @Param group_id path string true "任务组ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /task_groups/{group_id} [delete]
+func DeleteTaskGroup(c *gin.Context) {
+	groupID := c.Param("group_id")
+	if err := utils.TaskGroups.Delete(groupID, utils.TenantFromContext(c)); err != nil {
+		if errors.Is(err, utils.ErrTaskGroupNotFound) {
+			c.JSON(404, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(500, gin.H{"error": fmt.Sprintf("解散任务组失败: %v", err)})
+		return
+	}
+
+	utils.RequestLogger(c).Info("解散任务组", "group_id", groupID)
+	c.JSON(200, gin.H{
+		"status":   "ok",
+		"group_id": groupID,
+	})
+}
@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-uploader/utils"
+	"strings"
+)
+
+// tenantTask 按请求的租户查找任务，属于其他租户的任务视为不存在
+func tenantTask(c *gin.Context, fileID string) (*utils.UploadTask, bool) {
+	return utils.Storage.GetTenantTask(fileID, utils.TenantFromContext(c))
+}
+
+// rejectOtherTenant 任务属于其他租户时返回404并返回true，任务不存在时交由后续逻辑处理
+func rejectOtherTenant(c *gin.Context, fileID string) bool {
+	task, exists := utils.Storage.GetTask(fileID)
+	if exists && !task.VisibleToTenant(utils.TenantFromContext(c)) {
+		c.JSON(404, gin.H{"error": "任务不存在"})
+		return true
+	}
+	return false
+}
+
+// withinTenantFiles 文件接口的路径相对于合并目录，携带租户ID的请求只能访问租户子目录下的文件
+func withinTenantFiles(c *gin.Context, relativePath string) bool {
+	tenantID := utils.TenantFromContext(c)
+	return tenantID == "" || relativePath == tenantID || strings.HasPrefix(relativePath, tenantID+"/")
+}
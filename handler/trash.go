@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-uploader/utils"
+	"time"
+)
+
+// ListTrash 列出回收站中的条目
+// @Summary 列出回收站
+// @Description 启用 enable_trash 后删除和过期清理的任务会移入回收站，最近删除的排在前面
+// @Tags 回收站
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /trash [get]
+func ListTrash(c *gin.Context) {
+	if utils.Trash == nil {
+		c.JSON(500, gin.H{"error": "回收站未初始化"})
+		return
+	}
+
+	entries, err := utils.Trash.List()
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("读取回收站失败: %v", err)})
+		return
+	}
+
+	tenantID := utils.TenantFromContext(c)
+	items := make([]*utils.TrashEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Task.VisibleToTenant(tenantID) {
+			items = append(items, entry)
+		}
+	}
+
+	c.JSON(200, gin.H{
+		"entries":        items,
+		"total":          len(items),
+		"retention_days": utils.CurrentConfig().TrashRetentionDays,
+	})
+}
+
+// RestoreTrashEntry 将回收站中的任务移回活动任务
+// @Summary 从回收站恢复任务
+// @Description 文件夹任务会同时恢复回收站中属于它的子任务和嵌套文件夹
+// @Tags 回收站
+// @Produce json
+// @Param entry path string true "回收站条目名"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /trash/{entry}/restore [post]
+func RestoreTrashEntry(c *gin.Context) {
+	name := c.Param("entry")
+
+	if utils.Storage == nil {
+		c.JSON(500, gin.H{"error": "存储管理器未初始化"})
+		return
+	}
+	// 其他租户的条目视为不存在
+	if utils.Trash != nil {
+		if entry, err := utils.Trash.Get(name); err == nil && !entry.Task.VisibleToTenant(utils.TenantFromContext(c)) {
+			c.JSON(404, gin.H{"error": utils.ErrTrashEntryNotFound.Error()})
+			return
+		}
+	}
+
+	task, restored, err := utils.Storage.RestoreTrashEntry(name)
+	if err != nil {
+		switch {
+		case errors.Is(err, utils.ErrTrashEntryNotFound):
+			c.JSON(404, gin.H{"error": err.Error()})
+		case errors.Is(err, utils.ErrTaskAlreadyActive):
+			c.JSON(409, gin.H{"error": err.Error()})
+		default:
+			c.JSON(500, gin.H{"error": fmt.Sprintf("恢复任务失败: %v", err)})
+		}
+		return
+	}
+
+	utils.RequestLogger(c).Info("已从回收站恢复任务", "entry", name, "file_id", task.FileID, "restored_contents", restored)
+	c.JSON(200, gin.H{
+		"status":            "ok",
+		"file_id":           task.FileID,
+		"task_type":         task.TaskType,
+		"task_status":       task.Status,
+		"restored_contents": restored,
+	})
+}
+
+// EmptyTrash 永久删除超过保留期的回收站条目
+// @Summary 清空回收站
+// @Description 永久删除早于 trash_retention_days 天前移入回收站的条目，保留期为0时删除全部条目
+// @Tags 回收站
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /trash/empty [post]
+func EmptyTrash(c *gin.Context) {
+	if utils.Storage == nil {
+		c.JSON(500, gin.H{"error": "存储管理器未初始化"})
+		return
+	}
+
+	retentionDays := utils.CurrentConfig().TrashRetentionDays
+	cutoff := time.Now().AddDate(0, 0, -retentionDays)
+	deleted, err := utils.Storage.EmptyTrash(cutoff, utils.TenantFromContext(c))
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("清空回收站失败: %v", err)})
+		return
+	}
+
+	utils.RequestLogger(c).Info("已清空回收站", "deleted", deleted, "retention_days", retentionDays)
+	c.JSON(200, gin.H{
+		"status":         "ok",
+		"deleted":        deleted,
+		"retention_days": retentionDays,
+	})
+}
@@ -1,30 +1,126 @@
 package handler
 
 import (
+	"bytes"
 	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"go-uploader/utils"
+	"go.opentelemetry.io/otel/attribute"
 	"io"
-	"log"
-	"mime/multipart"
+	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 )
 
+// @Summary 上传文件分片
+// @Tags 上传
+// @Accept multipart/form-data
+// @Produce json
+// @Param file_id formData string false "文件ID（也可通过查询参数传递），指定 folder_task_id 时可省略"
+// @Param folder_task_id formData string false "文件夹任务ID（也可通过查询参数传递），按 relative_path 定位子任务"
+// @Param chunk_index formData int true "分片索引（也可通过查询参数传递）"
+// @Param total_chunks formData int false "分片总数"
+// @Param file_size formData int false "文件大小"
+// @Param relative_path formData string false "文件相对路径，指定 folder_task_id 时必填"
+// @Param md5 formData string false "分片MD5"
+// @Param tags formData string false "JSON格式的任务标签" example({"project":"demo"})
+// @Param metadata formData string false "JSON格式的自定义元数据，新建任务时记录，已有任务时整体替换" example({"record_id":42})
+// @Param chunk formData file true "分片数据"
+// @Param X-File-ID header string false "文件ID，与查询参数 file_id 一样可在读取请求体之前确认已上传的分片"
+// @Param X-Chunk-Index header int false "分片索引，与查询参数 chunk_index 一样可在读取请求体之前确认已上传的分片"
+// @Param X-Bandwidth-Limit header int false "带宽限制（字节/秒）"
+// @Param X-Max-Size header int false "会话存储配额（字节）"
+// @Param Content-Encoding header string false "分片数据的压缩格式：zstd 或 gzip，服务端解压后校验MD5并存储" Enums(zstd, gzip, identity)
+// @Description 开启 accept_compressed_chunks 后，客户端可压缩 chunk 字段的数据并通过 Content-Encoding 声明格式，
+// @Description md5 和分片大小限制均针对解压后的数据。服务端在响应头 Accept-Encoding 中返回支持的格式；
+// @Description 未开启或格式不支持时返回 415，客户端应按 Accept-Encoding 退回未压缩上传。
+// @Description 未声明 Content-Length 的请求（分块传输编码）流式写入分片数据，大小按实际写入的字节数计算，超过 max_chunk_size 时返回 413。
+// @Success 200 {object} map[string]interface{}
+// @Header 200,415 {string} Accept-Encoding "服务端接受的分片压缩格式"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} map[string]interface{}
+// @Failure 413 {object} ErrorResponse
+// @Failure 429 {object} map[string]interface{}
+// @Failure 415 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /upload_chunk [post]
 func UploadChunk(c *gin.Context) {
-	// 创建超时上下文
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
-	defer cancel()
-	
-	fileID := c.PostForm("file_id")
-	chunkIndex := c.PostForm("chunk_index")
+	// 登记进行中的操作，优雅关闭时等待其完成
+	done := utils.Inflight.Begin()
+	defer done()
+
+	// 单个客户端占满上传名额时拒绝，避免挤占其他客户端
+	release, ok := acquireUploadSlot(c)
+	if !ok {
+		return
+	}
+	defer release()
+
+	// 超时时间由路由超时中间件设置
+	ctx := c.Request.Context()
+
+	tenantID := utils.TenantFromContext(c)
+
+	// 协商分片压缩格式，不接受时在读取请求体之前拒绝
+	encoding := utils.ParseContentEncoding(c.GetHeader("Content-Encoding"))
+	if utils.CurrentConfig().AcceptCompressedChunks {
+		c.Header("Accept-Encoding", utils.AcceptedChunkEncodings)
+	}
+	if encoding != "" && (!utils.CurrentConfig().AcceptCompressedChunks || !utils.IsSupportedChunkEncoding(encoding)) {
+		if !utils.CurrentConfig().AcceptCompressedChunks {
+			c.Header("Accept-Encoding", "identity")
+		}
+		c.JSON(415, gin.H{"error": fmt.Sprintf("不支持的分片压缩格式: %s", encoding)})
+		return
+	}
+
+	// file_id 和 chunk_index 通过查询参数或请求头传递时，已上传的分片在读取请求体之前直接确认
+	if handled := precheckChunkBeforeBody(c, tenantID); handled {
+		return
+	}
+
+	// 未声明 Content-Length 的请求无法预知分片大小，先流式读取表单，分片数据边读边写入暂存文件
+	var streamed *chunkFile
+	if isStreamedMultipart(c.Request) {
+		staged, err := stageStreamedChunk(c)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		defer staged.Remove()
+		streamed = staged
+	}
+
+	// 以下字段需要解析请求体
+	fileID := chunkParam(c, "file_id", fileIDHeader)
+	chunkIndex := chunkParam(c, "chunk_index", chunkIndexHeader)
 	chunkMD5 := c.PostForm("md5") // 可选
 	relativePath := c.PostForm("relative_path") // 新增：文件相对路径
 	totalChunks := c.PostForm("total_chunks")
 	fileSize := c.PostForm("file_size")
+	tagsJSON := c.PostForm("tags") // 可选：JSON格式的任务标签
+	metadataJSON := c.PostForm("metadata") // 可选：JSON格式的自定义元数据
+
+	// 上传到已有文件夹任务时按相对路径定位子任务，客户端无需记录每个文件的ID
+	if folderTaskID := queryOrPostForm(c, "folder_task_id"); folderTaskID != "" {
+		relativePath = queryOrPostForm(c, "relative_path")
+		subTaskID, err := resolveFolderSubTask(folderTaskID, relativePath, fileID, tenantID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		fileID = subTaskID
+	}
 
 	// 验证必要参数
 	if fileID == "" || chunkIndex == "" {
@@ -32,36 +128,329 @@ func UploadChunk(c *gin.Context) {
 		return
 	}
 
-	file, err := c.FormFile("chunk")
+	index, err := strconv.Atoi(chunkIndex)
 	if err != nil {
-		c.JSON(400, gin.H{"error": fmt.Sprintf("上传文件错误: %v", err)})
+		c.JSON(400, gin.H{"error": "无效的分片索引"})
 		return
 	}
 
-	// 验证分片大小
-	if file.Size > utils.Config.MaxChunkSize {
-		c.JSON(400, gin.H{"error": fmt.Sprintf("分片大小超出限制: %d > %d", file.Size, utils.Config.MaxChunkSize)})
+	// file_id 或 chunk_index 在表单中时，在保存分片之前确认
+	if result, err := precheckChunk(fileID, index, tenantID); err != nil {
+		respondError(c, err)
+		return
+	} else if result != nil {
+		c.JSON(200, alreadyUploadedResponse(fileID, index, relativePath))
 		return
 	}
 
-	index, err := strconv.Atoi(chunkIndex)
+	file, err := formChunkFile(c, streamed)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "无效的分片索引"})
+		respondError(c, err)
+		return
+	}
+
+	var tags map[string]string
+	if tagsJSON != "" {
+		if err := json.Unmarshal([]byte(tagsJSON), &tags); err != nil {
+			c.JSON(400, gin.H{"error": "无效的标签格式，应为JSON对象"})
+			return
+		}
+	}
+
+	metadata, err := utils.ParseMetadata([]byte(metadataJSON))
+	if err != nil {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("元数据无效: %v", err)})
+		return
+	}
+
+	totalChunksInt, _ := strconv.Atoi(totalChunks)
+	fileSizeInt, _ := strconv.ParseInt(fileSize, 10, 64)
+
+	upload := &ChunkUpload{
+		FileID:         fileID,
+		Index:          index,
+		TotalChunks:    totalChunksInt,
+		FileSize:       fileSizeInt,
+		FileName:       file.fileName,
+		RelativePath:   relativePath,
+		MD5:            chunkMD5,
+		Tags:           tags,
+		Metadata:       metadata,
+		TenantID:       tenantID,
+		MaxBytes:       utils.RequestedQuotaLimit(c.GetHeader(utils.MaxSizeHeader)),
+		BandwidthLimit: utils.EffectiveBandwidthLimit(c.GetHeader(utils.BandwidthLimitHeader)),
+		Size:           file.size,
+		Open:           file.Open,
+	}
+
+	// 压缩的分片先解压到临时文件，MD5校验、大小限制和存储都基于解压后的数据
+	if encoding != "" {
+		decodedPath, err := decodeCompressedChunk(encoding, upload)
+		if err != nil {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("解压分片失败: %v", err)})
+			return
+		}
+		defer os.Remove(decodedPath)
+	}
+
+	result, err := StoreChunk(ctx, upload)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if result.AlreadyUploaded {
+		c.JSON(200, alreadyUploadedResponse(fileID, index, relativePath))
+		return
+	}
+
+	pushUploadStatus(c, fileID)
+	c.JSON(200, gin.H{
+		"status":        "ok",
+		"file_id":       fileID,
+		"chunk_index":   index,
+		"md5_checked":   chunkMD5 != "",
+		"relative_path": relativePath,
+		"size":          upload.Size,
+	})
+}
+
+// decodeCompressedChunk 解压分片数据并让上传改为读取解压后的临时文件，返回临时文件路径供调用方删除
+func decodeCompressedChunk(encoding string, upload *ChunkUpload) (string, error) {
+	src, err := upload.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	decodedPath, size, err := utils.DecodeChunkToTempFile(encoding, src, utils.CurrentConfig().MaxChunkSize)
+	if err != nil {
+		return "", err
+	}
+	upload.Size = size
+	upload.Open = func() (io.ReadCloser, error) {
+		return os.Open(decodedPath)
+	}
+	return decodedPath, nil
+}
+
+// acquireUploadSlot 按客户端IP占用一个上传名额，超出 max_concurrent_uploads_per_client 时返回429
+func acquireUploadSlot(c *gin.Context) (func(), bool) {
+	limit := utils.CurrentConfig().MaxConcurrentUploadsPerClient
+	release, ok := utils.UploadSlots.TryAcquire(c.ClientIP(), limit)
+	if !ok {
+		c.Header("Retry-After", "1")
+		c.JSON(429, gin.H{
+			"error":       "该客户端同时上传的分片数已达上限",
+			"limit":       limit,
+			"retry_after": 1,
+		})
+		return nil, false
+	}
+	return release, true
+}
+
+// pushedHeaders 推送上传状态时沿用的原请求头，使推送的请求通过相同的认证和租户校验
+var pushedHeaders = []string{"Authorization", "X-Secret-Key", "Cookie", utils.TenantHeader}
+
+// pushUploadStatus 启用 enable_http2_push 且客户端使用HTTP/2时推送最新的上传状态，省去客户端再次查询的往返；
+// 需在写入响应前调用，不支持推送时静默跳过
+func pushUploadStatus(c *gin.Context, fileID string) {
+	if !utils.CurrentConfig().EnableHTTP2Push || c.Request.ProtoMajor < 2 {
 		return
 	}
+	pusher := c.Writer.Pusher()
+	if pusher == nil {
+		return
+	}
+
+	header := http.Header{}
+	for _, key := range pushedHeaders {
+		if value := c.GetHeader(key); value != "" {
+			header.Set(key, value)
+		}
+	}
+	target := "/go-uploader/upload_status?file_id=" + url.QueryEscape(fileID)
+	if err := pusher.Push(target, &http.PushOptions{Header: header}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		utils.RequestLogger(c).Debug("推送上传状态失败", "file_id", fileID, "error", err)
+	}
+}
+
+// alreadyUploadedResponse 分片已上传时的响应
+func alreadyUploadedResponse(fileID string, index int, relativePath string) gin.H {
+	return gin.H{
+		"status":           "ok",
+		"file_id":          fileID,
+		"chunk_index":      index,
+		"already_uploaded": true,
+		"relative_path":    relativePath,
+	}
+}
+
+// resolveFolderSubTask 按相对路径查找租户文件夹任务的子任务，同时提供 file_id 时必须与子任务一致
+func resolveFolderSubTask(folderTaskID, relativePath, fileID, tenantID string) (string, error) {
+	if relativePath == "" {
+		return "", newAPIError(400, nil, "指定folder_task_id时必须提供relative_path")
+	}
+
+	folderTask, exists := utils.Storage.GetTenantTask(folderTaskID, tenantID)
+	if !exists || folderTask.TaskType != "folder" {
+		return "", newAPIError(404, nil, "文件夹任务不存在")
+	}
+	subTasks, err := utils.Storage.GetSubTasks(folderTaskID)
+	if err != nil {
+		return "", newAPIError(404, nil, "%v", err)
+	}
+
+	target := normalizeRelativePath(relativePath)
+	for _, subTask := range subTasks {
+		// 创建时未提供相对路径的文件按文件名匹配
+		subTaskPath := subTask.RelativePath
+		if subTaskPath == "" {
+			subTaskPath = subTask.FileName
+		}
+		if normalizeRelativePath(subTaskPath) != target {
+			continue
+		}
+		if fileID != "" && fileID != subTask.FileID {
+			return "", newAPIError(400, gin.H{"sub_task_id": subTask.FileID}, "file_id与文件夹中该路径的子任务不一致")
+		}
+		return subTask.FileID, nil
+	}
+	return "", newAPIError(404, gin.H{"relative_path": relativePath}, "文件夹任务中不存在该路径的文件")
+}
+
+// normalizeRelativePath 统一路径分隔符并去除首尾的斜杠，便于比较相对路径
+func normalizeRelativePath(relativePath string) string {
+	relativePath = strings.ReplaceAll(relativePath, "\\", "/")
+	return strings.Trim(path.Clean("/"+relativePath), "/")
+}
+
+// ChunkUpload 分片上传请求，HTTP和gRPC接口共用
+type ChunkUpload struct {
+	FileID         string
+	Index          int
+	TotalChunks    int
+	FileSize       int64
+	FileName       string
+	RelativePath   string
+	MD5            string // 可选：分片MD5
+	Tags           map[string]string
+	Metadata       json.RawMessage // 可选：任务自定义元数据，已校验
+	TenantID       string // 请求的租户，新建任务时记录到任务中
+	MaxBytes       int64  // 新建任务时的会话存储配额
+	BandwidthLimit int64
+	Size           int64                         // 分片数据大小
+	Open           func() (io.ReadCloser, error) // 打开分片数据，可多次调用
+}
+
+// ChunkUploadResult 分片上传结果
+type ChunkUploadResult struct {
+	Index           int
+	Size            int64
+	AlreadyUploaded bool
+}
+
+// precheckChunk 检查分片是否已上传或已耗尽重试次数，已上传时返回结果
+func precheckChunk(fileID string, index int, tenantID string) (*ChunkUploadResult, error) {
+	task, exists := utils.Storage.GetTask(fileID)
+	if !exists {
+		// 移入死信队列的任务已从活动存储删除，不能由后续分片重新创建，否则无法再重试
+		if utils.DLQ.Contains(fileID) {
+			return nil, deadLetterError(fileID)
+		}
+		return nil, nil
+	}
+	if !task.VisibleToTenant(tenantID) {
+		return nil, newAPIError(409, nil, "file_id已被其他租户使用")
+	}
+	if task.IsChunkUploaded(index) {
+		return &ChunkUploadResult{Index: index, AlreadyUploaded: true}, nil
+	}
+	if task.IsChunkPermanentlyFailed(index) {
+		return nil, newAPIError(409, gin.H{"chunk_index": index}, "分片重试次数已耗尽，请联系管理员重置")
+	}
+	// 依赖的任务全部完成后才接受分片
+	if pending := utils.Storage.PendingDependencies(task); len(pending) > 0 {
+		return nil, dependencyNotMetError(pending)
+	}
+	return nil, nil
+}
+
+// deadLetterError 任务在死信队列中的错误
+func deadLetterError(fileID string) *APIError {
+	return newAPIError(409, gin.H{"file_id": fileID}, "%s", utils.ErrTaskInDeadLetter.Error())
+}
+
+// dependencyNotMetError 依赖任务尚未完成的错误，error 字段为固定错误码便于客户端识别
+func dependencyNotMetError(pending []string) *APIError {
+	return newAPIError(409, gin.H{
+		"message":              utils.ErrDependencyNotMet.Error(),
+		"pending_dependencies": pending,
+	}, utils.DependencyNotMet)
+}
+
+// outOfSequenceError 分片未按顺序上传的错误，error 字段为固定错误码便于客户端识别
+func outOfSequenceError(index int) *APIError {
+	return newAPIError(409, gin.H{
+		"expected_next": index - 1,
+		"received":      index,
+	}, utils.OutOfSequence)
+}
+
+// StoreChunk 校验并保存分片，创建或更新任务记录，失败时返回 APIError
+func StoreChunk(ctx context.Context, upload *ChunkUpload) (*ChunkUploadResult, error) {
+	logger := utils.LoggerFromContext(ctx)
+	fileID, index := upload.FileID, upload.Index
+
+	if fileID == "" {
+		return nil, newAPIError(400, nil, "缺少必要参数: file_id 或 chunk_index")
+	}
+	if index < 0 {
+		return nil, newAPIError(400, nil, "无效的分片索引")
+	}
+	if result, err := precheckChunk(fileID, index, upload.TenantID); result != nil || err != nil {
+		return result, err
+	}
+
+	// 验证分片大小
+	if upload.Size > utils.CurrentConfig().MaxChunkSize {
+		return nil, newAPIError(400, nil, "分片大小超出限制: %d > %d", upload.Size, utils.CurrentConfig().MaxChunkSize)
+	}
+	// 最后一个分片允许小于最小分片大小，未提供分片总数时无法判断是否为最后一个分片，不做检查
+	if utils.CurrentConfig().MinChunkSize > 0 && upload.TotalChunks > 0 && index != upload.TotalChunks-1 &&
+		upload.Size < utils.CurrentConfig().MinChunkSize {
+		return nil, newAPIError(400, nil, "分片大小低于下限: %d < %d（只有最后一个分片可以小于该值）", upload.Size, utils.CurrentConfig().MinChunkSize)
+	}
+
+	if len(upload.Tags) > 0 {
+		if err := utils.ValidateTags(upload.Tags); err != nil {
+			return nil, newAPIError(400, nil, "标签无效: %v", err)
+		}
+	}
+
+	// 首个分片探测MIME类型并按黑白名单校验
+	var mimeType string
+	if index == 0 {
+		var err error
+		mimeType, err = detectChunkMIMEType(ctx, upload)
+		if err != nil {
+			return nil, newAPIError(400, nil, "读取分片数据失败: %v", err)
+		}
+		if !utils.IsMIMETypeAllowed(mimeType) {
+			return nil, newAPIError(415, gin.H{"mime_type": mimeType}, "不支持的文件类型: %s", mimeType)
+		}
+	}
 
 	// 创建文件锁防止并发冲突 - 使用安全的文件名
-	safeFileID := utils.SanitizeFileID(fileID)
-	lockPath := filepath.Join(utils.Config.UploadDir, safeFileID+".lock")
+	lockPath := filepath.Join(utils.CurrentConfig().UploadDir, utils.SafeFileKey(fileID)+".lock")
 	// 确保锁文件目录存在
 	if err := utils.EnsureDirectory(filepath.Dir(lockPath)); err != nil {
-		log.Printf("创建锁文件目录失败: %v", err)
-		c.JSON(500, gin.H{"error": fmt.Sprintf("创建锁文件目录失败: %v", err)})
-		return
+		logger.Error("创建锁文件目录失败", "file_id", fileID, "error", err)
+		return nil, newAPIError(500, nil, "创建锁文件目录失败: %v", err)
 	}
 	lock := utils.NewLockFile(lockPath)
 	if err := lock.Acquire(); err != nil {
-		log.Printf("获取文件锁失败: %v", err)
+		logger.Warn("获取文件锁失败", "file_id", fileID, "chunk_index", index, "error", err)
 		// 继续执行，但要小心处理
 	} else {
 		defer lock.Release()
@@ -70,132 +459,464 @@ func UploadChunk(c *gin.Context) {
 	// 检查或创建任务记录
 	task, exists := utils.Storage.GetTask(fileID)
 	if !exists {
+		// 预检后任务可能刚移入死信队列
+		if utils.DLQ.Contains(fileID) {
+			return nil, deadLetterError(fileID)
+		}
+
 		// 创建新任务
-		totalChunksInt, _ := strconv.Atoi(totalChunks)
-		fileSizeInt, _ := strconv.ParseInt(fileSize, 10, 64)
-		
 		task = &utils.UploadTask{
 			FileID:       fileID,
-			FileName:     file.Filename,
-			RelativePath: relativePath,
-			TotalChunks:  totalChunksInt,
-			FileSize:     fileSizeInt,
+			FileName:     upload.FileName,
+			RelativePath: upload.RelativePath,
+			TotalChunks:  upload.TotalChunks,
+			FileSize:     upload.FileSize,
 			Status:       "uploading",
 			CreatedAt:    time.Now(),
 			UpdatedAt:    time.Now(),
 			Chunks:       make(map[int]utils.ChunkInfo),
+			Tags:         upload.Tags,
+			Metadata:     upload.Metadata,
+			MaxBytes:     upload.MaxBytes,
+			TenantID:     upload.TenantID,
 		}
 		
 		if err := utils.Storage.SaveTask(task); err != nil {
-			c.JSON(500, gin.H{"error": fmt.Sprintf("保存任务失败: %v", err)})
-			return
+			return nil, newAPIError(500, nil, "保存任务失败: %v", err)
 		}
 	}
 
+	// 已有任务合并新提交的标签
+	if exists && len(upload.Tags) > 0 {
+		if merged := utils.MergeTags(task.Tags, upload.Tags); !utils.TagsEqual(merged, task.Tags) {
+			updated, err := utils.Storage.SetTags(fileID, upload.Tags, false)
+			if errors.Is(err, utils.ErrInvalidTags) {
+				return nil, newAPIError(400, nil, "%v", err)
+			}
+			if err != nil {
+				logger.Error("保存任务标签失败", "file_id", fileID, "error", err)
+			} else {
+				task = updated
+			}
+		}
+	}
+
+	// 已有任务提交了新的元数据时整体替换
+	if exists && upload.Metadata != nil && !bytes.Equal(upload.Metadata, task.Metadata) {
+		if updated, err := utils.Storage.SetMetadata(fileID, upload.Metadata); err != nil {
+			logger.Error("保存任务元数据失败", "file_id", fileID, "error", err)
+		} else {
+			task = updated
+		}
+	}
+
+	// 记录探测到的MIME类型
+	if mimeType != "" && task.MIMEType != mimeType {
+		task.MIMEType = mimeType
+		if err := utils.Storage.SaveTask(task); err != nil {
+			logger.Error("保存MIME类型失败", "file_id", fileID, "error", err)
+		}
+	}
+
+	// 存在服务端上传清单时以清单为准校验分片，而不只是客户端提交的MD5
+	if err := verifyManifestChunk(task, upload); err != nil {
+		logger.Warn("分片与上传清单不一致", "file_id", fileID, "chunk_index", index, "error", err)
+		return nil, err
+	}
+
+	// 要求顺序上传时前一个分片必须已完成，文件锁保证同一任务的分片依次检查
+	if err := utils.Storage.CheckChunkSequence(fileID, index); err != nil {
+		if errors.Is(err, utils.ErrOutOfSequence) {
+			logger.Warn("分片未按顺序上传", "file_id", fileID, "chunk_index", index)
+			return nil, outOfSequenceError(index)
+		}
+		return nil, newAPIError(500, nil, "检查分片顺序失败: %v", err)
+	}
+
+	// 写入分片前检查存储配额
+	if usage, err := utils.Storage.CheckQuota(fileID, index, upload.Size); err != nil {
+		if errors.Is(err, utils.ErrQuotaExceeded) {
+			return nil, newAPIError(413, gin.H{"quota": usage}, "%s", err.Error())
+		}
+		return nil, newAPIError(500, nil, "检查存储配额失败: %v", err)
+	}
+
 	// 执行上传操作（带重试机制）
-	err = utils.RetryWithBackoff(ctx, func() error {
-		return uploadChunkWithAtomicOperation(fileID, index, file, chunkMD5, relativePath)
+	var casKey string
+	spanAttrs := []attribute.KeyValue{
+		attribute.String("file_id", fileID),
+		attribute.Int("chunk_index", index),
+		attribute.Int64("file_size", upload.FileSize),
+	}
+	err := utils.RetryWithBackoff(ctx, func() error {
+		return utils.WithSpan(ctx, "uploadChunkWithAtomicOperation", spanAttrs, func(ctx context.Context) error {
+			uploadErr := chunkWriteBreaker.Execute(func() error {
+				key, writeErr := uploadChunkWithAtomicOperation(ctx, upload)
+				casKey = key
+				return writeErr
+			})
+			return uploadErr
+		})
 	}, utils.DefaultRetryConfig)
 
 	if err != nil {
 		// 更新分片状态为失败
 		chunkInfo := utils.ChunkInfo{
 			Index:  index,
-			Size:   file.Size,
+			Size:   upload.Size,
 			Status: "failed",
 		}
 		utils.Storage.UpdateChunk(fileID, index, chunkInfo)
 		
-		c.JSON(500, gin.H{"error": fmt.Sprintf("上传分片失败: %v", err)})
-		return
+		if strings.Contains(err.Error(), utils.ErrCircuitOpen.Error()) {
+			return nil, newAPIError(503, nil, "上传分片失败: %v", err)
+		}
+		return nil, newAPIError(500, nil, "上传分片失败: %v", err)
 	}
 
 	// 更新分片状态为成功
 	chunkInfo := utils.ChunkInfo{
 		Index:  index,
-		Size:   file.Size,
-		MD5:    chunkMD5,
+		Size:   upload.Size,
+		MD5:    upload.MD5,
 		Status: "completed",
+		CASKey: casKey,
 	}
 	
 	if err := utils.Storage.UpdateChunk(fileID, index, chunkInfo); err != nil {
-		log.Printf("更新分片状态失败: %v", err)
+		logger.Error("更新分片状态失败", "file_id", fileID, "chunk_index", index, "error", err)
 	}
+	logger.Debug("分片上传完成", "file_id", fileID, "chunk_index", index, "size", upload.Size)
 
-	c.JSON(200, gin.H{
-		"status":        "ok",
-		"chunk_index":   index,
-		"md5_checked":   chunkMD5 != "",
-		"relative_path": relativePath,
-		"size":          file.Size,
-	})
+	return &ChunkUploadResult{Index: index, Size: upload.Size}, nil
 }
 
-// uploadChunkWithAtomicOperation 使用原子操作上传分片
-func uploadChunkWithAtomicOperation(fileID string, index int, file *multipart.FileHeader, chunkMD5, relativePath string) error {
+// chunkWriteBreaker 分片写入的熔断器，客户端造成的错误（校验失败、请求体中断、取消请求）不计入失败次数
+var chunkWriteBreaker = utils.RegisterCircuitBreaker("chunk_write", func(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	msg := err.Error()
+	for _, clientErr := range []string{"MD5校验失败", "unexpected EOF", "request body too large"} {
+		if strings.Contains(msg, clientErr) {
+			return false
+		}
+	}
+	return true
+})
+
+// uploadChunkWithAtomicOperation 使用原子操作上传分片，启用内容寻址存储时返回分片的对象键
+func uploadChunkWithAtomicOperation(ctx context.Context, upload *ChunkUpload) (string, error) {
+	fileID, index, chunkMD5 := upload.FileID, upload.Index, upload.MD5
+
 	// 使用安全的文件ID作为目录名，实现扁平化存储
-	safeFileID := utils.SanitizeFileID(fileID)
-	saveDir := filepath.Join(utils.Config.UploadDir, safeFileID)
+	saveDir := utils.ChunkDir(fileID)
 	if err := utils.EnsureDirectory(saveDir); err != nil {
-		return fmt.Errorf("创建上传目录失败: %v", err)
+		return "", fmt.Errorf("创建上传目录失败: %v", err)
 	}
 
-	chunkName := fmt.Sprintf("%06d.part", index)
+	compressed := utils.CurrentConfig().EnableChunkCompression
+	encrypted := utils.CurrentConfig().EnableEncryption
+	chunkName := utils.ChunkFileName(index, compressed, encrypted)
 	savePath := filepath.Join(saveDir, chunkName)
 
-	// 检查分片是否已存在且完整
-	if info, err := os.Stat(savePath); err == nil && info.Size() == file.Size {
+	// 检查分片是否已存在且完整（压缩或加密分片无法通过文件大小判断）
+	if info, err := os.Stat(savePath); err == nil && (compressed || encrypted || info.Size() == upload.Size) {
 		// 分片已存在，验证MD5
 		if chunkMD5 != "" {
-			existingMD5, err := utils.FileMD5(savePath)
+			existingMD5, err := utils.ChunkFileHash(savePath)
 			if err == nil && existingMD5 == chunkMD5 {
-				return nil // 分片已存在且正确
+				return "", nil // 分片已存在且正确
 			}
 		} else {
-			return nil // 没有MD5校验，认为已存在
+			return "", nil // 没有MD5校验，认为已存在
+		}
+	}
+
+	// 相同内容的分片已存在时直接创建硬链接，无需再次写入
+	var casKey string
+	if utils.CAS != nil {
+		key, linked, err := linkChunkFromCAS(upload, savePath, compressed, encrypted)
+		if err != nil {
+			utils.LoggerFromContext(ctx).Warn("内容寻址存储复用分片失败，回退到正常写入", "file_id", fileID, "chunk_index", index, "error", err)
+		} else if linked {
+			removeOtherChunkFormats(saveDir, index, chunkName)
+			return key, nil
 		}
+		casKey = key
 	}
 
-	src, err := file.Open()
+	src, err := upload.Open()
 	if err != nil {
-		return fmt.Errorf("打开上传文件失败: %v", err)
+		return "", fmt.Errorf("打开上传文件失败: %v", err)
 	}
 	defer src.Close()
 
-	data, err := io.ReadAll(src)
+	reader := utils.NewThrottledReader(ctx, src, upload.BandwidthLimit)
+
+	// 经写入队列限制同时写磁盘的分片数；加密需要完整明文生成GCM密文，只有加密分片整体读入内存
+	err = utils.ChunkWriteQueue.Do(ctx, savePath, func() error {
+		if encrypted {
+			return writeEncryptedChunk(reader, savePath, chunkMD5, compressed)
+		}
+		return streamChunkToFile(reader, savePath, chunkMD5, compressed)
+	})
 	if err != nil {
-		return fmt.Errorf("读取分片数据失败: %v", err)
+		return "", err
 	}
 
-	// 校验 MD5（如果提供）
-	if chunkMD5 != "" && utils.Config.EnableIntegrityCheck {
-		calculated := utils.BytesMD5(data)
-		if calculated != chunkMD5 {
-			return fmt.Errorf("MD5校验失败: 期望=%s, 实际=%s", chunkMD5, calculated)
+	// 移除其他格式的旧分片，避免合并时格式歧义
+	removeOtherChunkFormats(saveDir, index, chunkName)
+
+	// 新写入的分片登记为内容对象，供后续相同内容的分片复用
+	if casKey != "" {
+		if err := utils.CAS.Adopt(casKey, savePath); err != nil {
+			utils.LoggerFromContext(ctx).Warn("登记内容对象失败", "file_id", fileID, "chunk_index", index, "error", err)
+			casKey = ""
 		}
 	}
 
-	// 使用原子操作写入文件
-	if utils.Config.EnableAtomicOperations {
+	return casKey, nil
+}
+
+// removeOtherChunkFormats 删除分片其他存储格式的文件，切换压缩或加密配置后重新上传的分片只保留新格式
+func removeOtherChunkFormats(saveDir string, index int, keep string) {
+	for _, name := range utils.ChunkFileNames(index) {
+		if name != keep {
+			os.Remove(filepath.Join(saveDir, name))
+		}
+	}
+}
+
+// linkChunkFromCAS 计算分片内容的对象键并尝试从内容寻址存储链接，提供MD5时先校验原始数据
+func linkChunkFromCAS(upload *ChunkUpload, savePath string, compressed, encrypted bool) (string, bool, error) {
+	src, err := upload.Open()
+	if err != nil {
+		return "", false, fmt.Errorf("打开上传文件失败: %v", err)
+	}
+	defer src.Close()
+
+	md5Hasher := utils.Hasher.New()
+	key, err := utils.CASKey(io.TeeReader(src, md5Hasher), compressed, encrypted)
+	if err != nil {
+		return "", false, err
+	}
+	// MD5不一致时交由正常写入流程报告校验错误
+	if upload.MD5 != "" && utils.CurrentConfig().EnableIntegrityCheck && hex.EncodeToString(md5Hasher.Sum(nil)) != upload.MD5 {
+		return key, false, nil
+	}
+
+	linked, err := utils.CAS.LinkExisting(key, savePath)
+	return key, linked, err
+}
+
+// chunkSink 分片写入目标，校验通过后提交，失败时回滚
+type chunkSink interface {
+	io.Writer
+	Commit() error
+	Rollback() error
+}
+
+// plainChunkFile 未启用原子操作时直接写入目标文件
+type plainChunkFile struct {
+	*os.File
+}
+
+// Commit 同步并关闭文件
+func (f *plainChunkFile) Commit() error {
+	if err := f.Sync(); err != nil {
+		f.Rollback()
+		return fmt.Errorf("同步文件失败: %v", err)
+	}
+	return f.Close()
+}
+
+// Rollback 关闭并删除未完成的文件
+func (f *plainChunkFile) Rollback() error {
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// openChunkSink 打开分片写入目标
+func openChunkSink(savePath string) (chunkSink, error) {
+	if utils.CurrentConfig().EnableAtomicOperations {
 		writer, err := utils.NewAtomicWriter(savePath)
 		if err != nil {
-			return fmt.Errorf("创建原子写入器失败: %v", err)
+			return nil, fmt.Errorf("创建原子写入器失败: %v", err)
 		}
+		return writer, nil
+	}
 
-		if _, err := writer.Write(data); err != nil {
-			writer.Rollback()
-			return fmt.Errorf("写入分片数据失败: %v", err)
+	// 分片路径可能是指向内容对象的硬链接，先删除再创建，截断会破坏共享该对象的其他分片
+	if err := os.Remove(savePath); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("删除旧分片文件失败: %v", err)
+	}
+	file, err := os.Create(savePath)
+	if err != nil {
+		return nil, fmt.Errorf("创建分片文件失败: %v", err)
+	}
+	return &plainChunkFile{File: file}, nil
+}
+
+// streamChunkToFile 边读边写分片数据，同时计算原始数据MD5，写入完成后校验
+func streamChunkToFile(reader io.Reader, savePath, chunkMD5 string, compressed bool) error {
+	sink, err := openChunkSink(savePath)
+	if err != nil {
+		return err
+	}
+
+	hasher := utils.Hasher.New()
+	source := io.TeeReader(reader, hasher)
+
+	// 压缩分片（MD5基于压缩前的原始数据）
+	var dst io.Writer = sink
+	var encoder io.WriteCloser
+	if compressed {
+		encoder, err = utils.NewCompressWriter(sink, utils.CurrentConfig().ChunkCompressionLevel)
+		if err != nil {
+			sink.Rollback()
+			return fmt.Errorf("压缩分片数据失败: %v", err)
 		}
+		dst = encoder
+	}
 
-		if err := writer.Commit(); err != nil {
-			return fmt.Errorf("提交原子操作失败: %v", err)
+	if _, err := io.Copy(dst, source); err != nil {
+		if encoder != nil {
+			encoder.Close()
 		}
-	} else {
-		// 普通文件写入
-		if err := os.WriteFile(savePath, data, 0644); err != nil {
-			return fmt.Errorf("写入分片文件失败: %v", err)
+		sink.Rollback()
+		return fmt.Errorf("写入分片数据失败: %v", err)
+	}
+	if encoder != nil {
+		if err := encoder.Close(); err != nil {
+			sink.Rollback()
+			return fmt.Errorf("压缩分片数据失败: %v", err)
+		}
+	}
+
+	// 校验 MD5（如果提供）
+	if chunkMD5 != "" && utils.CurrentConfig().EnableIntegrityCheck {
+		calculated := hex.EncodeToString(hasher.Sum(nil))
+		if calculated != chunkMD5 {
+			sink.Rollback()
+			return fmt.Errorf("MD5校验失败: 期望=%s, 实际=%s", chunkMD5, calculated)
 		}
 	}
 
+	if err := sink.Commit(); err != nil {
+		return fmt.Errorf("提交分片写入失败: %v", err)
+	}
 	return nil
 }
+
+// writeEncryptedChunk 读取完整分片数据，校验、压缩并加密后写入
+func writeEncryptedChunk(reader io.Reader, savePath, chunkMD5 string, compressed bool) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("读取分片数据失败: %v", err)
+	}
+
+	// 校验 MD5（如果提供）
+	if chunkMD5 != "" && utils.CurrentConfig().EnableIntegrityCheck {
+		calculated := utils.Hasher.HashBytes(data)
+		if calculated != chunkMD5 {
+			return fmt.Errorf("MD5校验失败: 期望=%s, 实际=%s", chunkMD5, calculated)
+		}
+	}
+
+	// 压缩分片数据（MD5基于压缩前的原始数据）
+	if compressed {
+		data, err = utils.CompressChunk(data, utils.CurrentConfig().ChunkCompressionLevel)
+		if err != nil {
+			return fmt.Errorf("压缩分片数据失败: %v", err)
+		}
+	}
+
+	// 加密分片数据（在MD5校验和压缩之后）
+	data, err = utils.EncryptChunk(data, utils.EncryptionKey())
+	if err != nil {
+		return fmt.Errorf("加密分片数据失败: %v", err)
+	}
+
+	sink, err := openChunkSink(savePath)
+	if err != nil {
+		return err
+	}
+	if _, err := sink.Write(data); err != nil {
+		sink.Rollback()
+		return fmt.Errorf("写入分片数据失败: %v", err)
+	}
+	if err := sink.Commit(); err != nil {
+		return fmt.Errorf("提交分片写入失败: %v", err)
+	}
+	return nil
+}
+
+// detectChunkMIMEType 探测分片内容的MIME类型，无法识别时记录警告
+func detectChunkMIMEType(ctx context.Context, upload *ChunkUpload) (string, error) {
+	src, err := upload.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	mimeType, inconclusive, err := utils.DetectMIMEType(src)
+	if err != nil {
+		return "", err
+	}
+	if inconclusive {
+		utils.LoggerFromContext(ctx).Warn("无法识别文件的MIME类型", "filename", upload.FileName, "mime_type", mimeType)
+	}
+	return mimeType, nil
+}
+
+// 在请求头中传递 file_id 和 chunk_index，与查询参数一样无需解析请求体即可确认已上传的分片
+const (
+	fileIDHeader     = "X-File-ID"
+	chunkIndexHeader = "X-Chunk-Index"
+)
+
+// precheckChunkBeforeBody 只用查询参数和请求头中的 file_id、chunk_index 做上传前检查，不读取请求体；
+// 已响应（分片已上传或检查失败）时返回true。通过 folder_task_id 定位子任务或参数不全时留到解析表单后检查
+func precheckChunkBeforeBody(c *gin.Context, tenantID string) bool {
+	fileID := queryOrHeader(c, "file_id", fileIDHeader)
+	index, err := strconv.Atoi(queryOrHeader(c, "chunk_index", chunkIndexHeader))
+	if fileID == "" || err != nil || c.Query("folder_task_id") != "" {
+		return false
+	}
+
+	result, err := precheckChunk(fileID, index, tenantID)
+	if err != nil {
+		respondError(c, err)
+		return true
+	}
+	if result != nil {
+		c.JSON(200, alreadyUploadedResponse(fileID, index, c.Query("relative_path")))
+		return true
+	}
+	return false
+}
+
+// queryOrHeader 优先读取查询参数，不存在时读取请求头
+func queryOrHeader(c *gin.Context, key, header string) string {
+	if value := c.Query(key); value != "" {
+		return value
+	}
+	return c.GetHeader(header)
+}
+
+// chunkParam 依次读取查询参数、请求头和表单字段
+func chunkParam(c *gin.Context, key, header string) string {
+	if value := queryOrHeader(c, key, header); value != "" {
+		return value
+	}
+	return c.PostForm(key)
+}
+
+// queryOrPostForm 优先读取查询参数，不存在时读取表单字段
+func queryOrPostForm(c *gin.Context, key string) string {
+	if value := c.Query(key); value != "" {
+		return value
+	}
+	return c.PostForm(key)
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"bytes"
+	"github.com/gin-gonic/gin"
+	"go-uploader/utils"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenChunkSinkKeepsHardLinkedObject(t *testing.T) {
+	for _, atomic := range []bool{false, true} {
+		saved := utils.Config
+		utils.Config.EnableAtomicOperations = atomic
+
+		dir := t.TempDir()
+		objectPath := filepath.Join(dir, "object")
+		chunkPath := filepath.Join(dir, "000000.part")
+		if err := os.WriteFile(objectPath, []byte("shared"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Link(objectPath, chunkPath); err != nil {
+			t.Skipf("文件系统不支持硬链接: %v", err)
+		}
+
+		sink, err := openChunkSink(chunkPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := sink.Write([]byte("rewritten")); err != nil {
+			t.Fatal(err)
+		}
+		if err := sink.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		utils.Config = saved
+
+		// 重写的分片不能修改共享的内容对象
+		if data, _ := os.ReadFile(objectPath); string(data) != "shared" {
+			t.Fatalf("atomic=%v: 内容对象被修改为 %q", atomic, data)
+		}
+		if data, _ := os.ReadFile(chunkPath); string(data) != "rewritten" {
+			t.Fatalf("atomic=%v: 分片内容为 %q", atomic, data)
+		}
+	}
+}
+
+func TestUploadRejectedWhileTaskInDeadLetter(t *testing.T) {
+	initTestStorage(t)
+	utils.Config.MaxRetryCount = 1
+
+	task := &utils.UploadTask{
+		FileID:      "dlq-task",
+		TaskType:    "file",
+		FileName:    "a.bin",
+		TotalChunks: 2,
+		Status:      "uploading",
+		Chunks:      make(map[int]utils.ChunkInfo),
+	}
+	if err := utils.Storage.SaveTask(task); err != nil {
+		t.Fatal(err)
+	}
+	// 分片重试次数耗尽后任务移入死信队列
+	for i := 0; i < 3 && !utils.DLQ.Contains(task.FileID); i++ {
+		utils.Storage.UpdateChunk(task.FileID, 1, utils.ChunkInfo{Index: 1, Size: 4, Status: "failed"})
+	}
+	if !utils.DLQ.Contains(task.FileID) {
+		t.Fatal("任务未移入死信队列")
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/upload_chunk", UploadChunk)
+	upload := func() *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		writer.WriteField("file_id", task.FileID)
+		writer.WriteField("chunk_index", "0")
+		writer.WriteField("total_chunks", "2")
+		part, _ := writer.CreateFormFile("chunk", "chunk.bin")
+		part.Write([]byte("data"))
+		writer.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/upload_chunk", &body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := upload(); w.Code != 409 {
+		t.Fatalf("死信队列中的任务上传分片应返回409: %d %s", w.Code, w.Body.String())
+	}
+	if _, exists := utils.Storage.GetTask(task.FileID); exists {
+		t.Fatal("上传不应重新创建死信队列中的任务")
+	}
+
+	// 重试后任务移回活动存储，可以继续上传
+	if _, err := utils.Storage.RetryDeadLetter(task.FileID); err != nil {
+		t.Fatalf("重试死信队列任务失败: %v", err)
+	}
+	if w := upload(); w.Code != 200 {
+		t.Fatalf("重试后上传分片失败: %d %s", w.Code, w.Body.String())
+	}
+}
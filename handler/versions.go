@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-uploader/utils"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// versionsPathSegment 文件路径后表示版本列表的路径段
+const versionsPathSegment = "/versions"
+
+// parseVersionsPath 识别 <文件路径>/versions 和 <文件路径>/versions/<n>，返回文件路径和版本号（列表请求时为空）；
+// 只有文件路径是已合并的文件或有版本记录时才视为版本请求，避免与名为 versions 的普通文件冲突
+func parseVersionsPath(relativePath string) (string, string, bool) {
+	filePath, number := "", ""
+	if base, found := strings.CutSuffix(relativePath, versionsPathSegment); found {
+		filePath = base
+	} else if i := strings.LastIndex(relativePath, versionsPathSegment+"/"); i > 0 {
+		number = relativePath[i+len(versionsPathSegment)+1:]
+		if _, err := strconv.Atoi(number); err != nil {
+			return "", "", false
+		}
+		filePath = relativePath[:i]
+	}
+	if filePath == "" || strings.Contains(filePath, "..") || utils.Versions == nil {
+		return "", "", false
+	}
+
+	filePath = filepath.ToSlash(filepath.Clean(filepath.FromSlash(filePath)))
+	if utils.Versions.Has(filePath) {
+		return filePath, number, true
+	}
+	if info, err := os.Stat(filepath.Join(utils.CurrentConfig().MergedDir, filepath.FromSlash(filePath))); err == nil && info.Mode().IsRegular() {
+		return filePath, number, true
+	}
+	return "", "", false
+}
+
+// ListFileVersions 列出合并文件的历史版本
+// @Summary 列出文件的历史版本
+// @Description 启用 enable_versioning 后合并到已存在的路径时，原文件保存为 <路径>.v<N>
+// @Tags 文件
+// @Produce json
+// @Param filepath path string true "文件相对路径"
+// @Success 200 {object} object{path=string,current_version=int,versions=[]utils.FileVersion}
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /files/{filepath}/versions [get]
+func ListFileVersions(c *gin.Context) {
+	filePath, _, ok := parseVersionsPath(strings.TrimPrefix(c.Param("filepath"), "/"))
+	if !ok || !withinTenantFiles(c, filePath) {
+		c.JSON(404, gin.H{"error": "文件不存在"})
+		return
+	}
+
+	versions := utils.Versions.List(filePath)
+	c.JSON(200, gin.H{
+		"path":            filePath,
+		"current_version": utils.Versions.Current(filePath),
+		"versions":        versions,
+		"total":           len(versions),
+	})
+}
+
+// DeleteFileVersion 删除合并文件的一个历史版本
+// @Summary 删除文件的历史版本
+// @Description 需要开启 allow_file_deletion，当前版本的文件不受影响
+// @Tags 文件
+// @Produce json
+// @Param filepath path string true "文件相对路径"
+// @Param n path int true "版本号"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /files/{filepath}/versions/{n} [delete]
+func DeleteFileVersion(c *gin.Context) {
+	if !utils.CurrentConfig().AllowFileDeletion {
+		c.JSON(403, gin.H{"error": "文件删除功能未启用"})
+		return
+	}
+
+	filePath, number, ok := parseVersionsPath(strings.TrimPrefix(c.Param("filepath"), "/"))
+	if !ok || number == "" || !withinTenantFiles(c, filePath) {
+		c.JSON(404, gin.H{"error": utils.ErrVersionNotFound.Error()})
+		return
+	}
+	n, _ := strconv.Atoi(number)
+
+	version, err := utils.Versions.Remove(filePath, n)
+	if err != nil {
+		if errors.Is(err, utils.ErrVersionNotFound) {
+			c.JSON(404, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(500, gin.H{"error": fmt.Sprintf("删除历史版本失败: %v", err)})
+		return
+	}
+
+	utils.RequestLogger(c).Info("删除文件历史版本", "client_ip", c.ClientIP(), "path", filePath, "version", n, "size", version.Size)
+	c.JSON(200, gin.H{
+		"status":  "ok",
+		"path":    filePath,
+		"version": version,
+	})
+}
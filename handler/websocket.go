@@ -0,0 +1,265 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go-uploader/utils"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// WebSocket连接参数
+const (
+	wsWriteTimeout     = 10 * time.Second
+	wsPongTimeout      = 60 * time.Second
+	wsPingInterval     = wsPongTimeout * 9 / 10
+	wsMaxMessageSize   = 4096
+	wsMaxSubscriptions = 100
+	wsSendBufferSize   = 64
+)
+
+// WebSocket客户端指令
+const (
+	wsActionSubscribe   = "subscribe"
+	wsActionUnsubscribe = "unsubscribe"
+	wsActionPause       = "pause"
+	wsActionResume      = "resume"
+)
+
+// wsUpgrader 同源请求或跨域配置允许的来源才能建立连接
+var wsUpgrader = websocket.Upgrader{
+	Subprotocols: []string{utils.WebSocketSubprotocol},
+	CheckOrigin: func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		if parsed, err := url.Parse(origin); err == nil && parsed.Host == r.Host {
+			return true
+		}
+		return utils.IsOriginAllowed(origin)
+	},
+}
+
+// wsCommand 客户端发送的指令
+type wsCommand struct {
+	Action string `json:"action"`
+	FileID string `json:"file_id"`
+}
+
+// wsMessage 服务端推送的消息：progress 事件的 data 与SSE接口相同，指令结果为 ack 或 error
+type wsMessage struct {
+	Event   string           `json:"event"`
+	Action  string           `json:"action,omitempty"`
+	FileID  string           `json:"file_id,omitempty"`
+	Message string           `json:"message,omitempty"`
+	Error   string           `json:"error,omitempty"`
+	Data    *utils.TaskEvent `json:"data,omitempty"`
+}
+
+// wsSession 单个WebSocket连接，一个连接可订阅多个任务
+type wsSession struct {
+	conn     *websocket.Conn
+	ctx      context.Context
+	send     chan wsMessage
+	done     chan struct{}
+	tenantID string // 连接建立时请求的租户，只能操作该租户的任务
+
+	mutex         sync.Mutex
+	subscriptions map[string]<-chan utils.TaskEvent
+}
+
+// TaskSocket 通过WebSocket订阅任务进度并暂停或恢复任务
+// @Summary WebSocket任务控制
+// @Description 客户端发送 {"action": "subscribe|unsubscribe|pause|resume", "file_id": "..."}，服务端推送 {"event": "progress", "data": ...} 以及指令结果 ack/error；浏览器可通过 Sec-WebSocket-Protocol: go-uploader, <凭证> 传递凭证
+// @Tags 上传
+// @Success 101 {string} string "切换到WebSocket协议"
+// @Security BearerAuth
+// @Security SecretKey
+// @Router /ws [get]
+func TaskSocket(c *gin.Context) {
+	if utils.Storage == nil {
+		c.JSON(500, gin.H{"error": "存储管理器未初始化"})
+		return
+	}
+
+	conn, err := wsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// 握手失败时 Upgrader 已写入错误响应
+		utils.RequestLogger(c).Warn("WebSocket握手失败", "error", err)
+		return
+	}
+
+	session := &wsSession{
+		conn:          conn,
+		ctx:           c.Request.Context(),
+		send:          make(chan wsMessage, wsSendBufferSize),
+		done:          make(chan struct{}),
+		tenantID:      utils.TenantFromContext(c),
+		subscriptions: make(map[string]<-chan utils.TaskEvent),
+	}
+	go session.writeLoop()
+	session.readLoop()
+	session.close()
+}
+
+// readLoop 读取并处理客户端指令，连接断开或出错时返回
+func (s *wsSession) readLoop() {
+	s.conn.SetReadLimit(wsMaxMessageSize)
+	s.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	})
+
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		// 无法解析的消息只返回错误，不断开连接
+		var command wsCommand
+		if err := json.Unmarshal(data, &command); err != nil {
+			s.reply(wsMessage{Event: "error", Error: "无效的指令格式"})
+			continue
+		}
+		s.handle(command)
+	}
+}
+
+// handle 执行单条指令
+func (s *wsSession) handle(command wsCommand) {
+	if command.FileID == "" {
+		s.reply(wsMessage{Event: "error", Action: command.Action, Error: "缺少file_id参数"})
+		return
+	}
+
+	// 其他租户的任务视为不存在
+	if task, exists := utils.Storage.GetTask(command.FileID); exists && !task.VisibleToTenant(s.tenantID) {
+		s.reply(wsMessage{Event: "error", Action: command.Action, FileID: command.FileID, Error: "任务不存在"})
+		return
+	}
+
+	var message string
+	var err error
+	switch command.Action {
+	case wsActionSubscribe:
+		message, err = s.subscribe(command.FileID)
+	case wsActionUnsubscribe:
+		message = s.unsubscribe(command.FileID)
+	case wsActionPause:
+		message, err = PauseUpload(s.ctx, command.FileID)
+	case wsActionResume:
+		message, err = ResumeUpload(s.ctx, command.FileID)
+	default:
+		s.reply(wsMessage{Event: "error", Action: command.Action, FileID: command.FileID, Error: "不支持的操作"})
+		return
+	}
+
+	if err != nil {
+		s.reply(wsMessage{Event: "error", Action: command.Action, FileID: command.FileID, Error: err.Error()})
+		return
+	}
+	s.reply(wsMessage{Event: "ack", Action: command.Action, FileID: command.FileID, Message: message})
+}
+
+// subscribe 订阅任务事件，先推送当前状态快照
+func (s *wsSession) subscribe(fileID string) (string, error) {
+	task, exists := utils.Storage.GetTask(fileID)
+	if !exists {
+		return "", newAPIError(404, nil, "任务不存在")
+	}
+
+	s.mutex.Lock()
+	if _, subscribed := s.subscriptions[fileID]; subscribed {
+		s.mutex.Unlock()
+		return "已订阅", nil
+	}
+	if len(s.subscriptions) >= wsMaxSubscriptions {
+		s.mutex.Unlock()
+		return "", newAPIError(400, nil, "订阅数量超出限制: %d", wsMaxSubscriptions)
+	}
+	events := utils.Events.Subscribe(fileID)
+	s.subscriptions[fileID] = events
+	s.mutex.Unlock()
+
+	snapshot := utils.NewTaskEvent(task)
+	s.reply(wsMessage{Event: "progress", FileID: fileID, Data: &snapshot})
+	go s.forward(fileID, events)
+	return "订阅成功", nil
+}
+
+// unsubscribe 取消订阅，关闭事件通道后转发协程自动退出
+func (s *wsSession) unsubscribe(fileID string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	events, subscribed := s.subscriptions[fileID]
+	if !subscribed {
+		return "未订阅"
+	}
+	delete(s.subscriptions, fileID)
+	utils.Events.Unsubscribe(fileID, events)
+	return "已取消订阅"
+}
+
+// forward 将任务事件转发到发送队列
+func (s *wsSession) forward(fileID string, events <-chan utils.TaskEvent) {
+	for event := range events {
+		event := event
+		if !s.reply(wsMessage{Event: "progress", FileID: fileID, Data: &event}) {
+			return
+		}
+	}
+}
+
+// reply 将消息放入发送队列，连接已关闭时返回false
+func (s *wsSession) reply(message wsMessage) bool {
+	select {
+	case s.send <- message:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+// writeLoop 串行写入消息并定期发送ping，写入失败时关闭连接使读取循环退出
+func (s *wsSession) writeLoop() {
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+	defer s.conn.Close()
+
+	for {
+		select {
+		case <-s.done:
+			s.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(wsWriteTimeout))
+			return
+		case message := <-s.send:
+			s.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := s.conn.WriteJSON(message); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// close 客户端断开后取消所有订阅并停止写入
+func (s *wsSession) close() {
+	s.mutex.Lock()
+	for fileID, events := range s.subscriptions {
+		utils.Events.Unsubscribe(fileID, events)
+	}
+	s.subscriptions = nil
+	s.mutex.Unlock()
+
+	close(s.done)
+}
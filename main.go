@@ -1,42 +1,172 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/robfig/cron/v3"
+	"go-uploader/grpc"
 	"go-uploader/handler"
 	"go-uploader/utils"
-	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 )
 
 const configFile = "./config.json"
 
+// @title go-uploader API
+// @version 1.0
+// @description 支持分片上传、断点续传和文件夹上传的文件上传服务
+// @BasePath /go-uploader
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description 登录获取的JWT令牌或API密钥，格式为 "Bearer <token>"
+// @securityDefinitions.apikey SecretKey
+// @in header
+// @name X-Secret-Key
+// @description 访问密钥
+// @securityDefinitions.apikey AdminKey
+// @in header
+// @name X-Admin-Key
+// @description 管理员密钥，仅用于API密钥管理接口
 func main() {
 	// 加载配置文件
 	if err := utils.LoadConfig(configFile); err != nil {
-		log.Printf("加载配置文件失败: %v，将使用默认配置", err)
+		utils.Logger.Warn("加载配置文件失败，将使用默认配置", "error", err)
+	}
+
+	// 初始化日志，需在gin启动前完成
+	if err := utils.InitLogger(); err != nil {
+		utils.Fatal("初始化日志失败", "error", err)
+	}
+
+	// 使用OIDC认证时获取身份提供方的发现文档
+	if err := utils.InitOIDC(context.Background()); err != nil {
+		utils.Fatal("初始化OIDC认证失败", "error", err)
+	}
+
+	// 合并文件存储到S3时创建对象存储客户端
+	if err := utils.InitStorageTarget(context.Background()); err != nil {
+		utils.Fatal("初始化存储目标失败", "error", err)
+	}
+
+	// 启用链路追踪时创建OTLP导出器
+	if err := utils.InitTracing(context.Background()); err != nil {
+		utils.Fatal("初始化链路追踪失败", "error", err)
+	}
+
+	// 解析客户端IP允许列表和阻止列表，配置无效时直接退出
+	if err := utils.InitIPFilter(); err != nil {
+		utils.Fatal("IP过滤配置无效", "error", err)
+	}
+
+	// 校验TLS配置，证书不可用时直接退出
+	if err := utils.ValidateTLSConfig(); err != nil {
+		utils.Fatal("TLS配置无效", "error", err)
 	}
 	
 	// 初始化配置目录
 	if err := utils.InitDirectories(); err != nil {
-		log.Fatalf("初始化目录失败: %v", err)
+		utils.Fatal("初始化目录失败", "error", err)
 	}
 	
 	// 初始化存储管理器
 	if err := utils.InitStorage(); err != nil {
-		log.Fatalf("初始化存储管理器失败: %v", err)
+		utils.Fatal("初始化存储管理器失败", "error", err)
+	}
+
+	// 注册合并后处理钩子
+	if err := utils.RegisterPostMergeHooks(utils.CurrentConfig().PostMergeHooks); err != nil {
+		utils.Fatal("注册合并后处理钩子失败", "error", err)
+	}
+
+	// 初始化审计日志
+	if err := utils.InitAuditLog(); err != nil {
+		utils.Fatal("初始化审计日志失败", "error", err)
 	}
 	
+	// 解析定期清理的cron表达式，无效时直接退出
+	var cleanupSchedule cron.Schedule
+	if expr := utils.CurrentConfig().CleanupCronExpression; expr != "" {
+		schedule, err := cron.ParseStandard(expr)
+		if err != nil {
+			utils.Fatal("清理计划cron表达式无效", "cleanup_cron_expression", expr, "error", err)
+		}
+		cleanupSchedule = schedule
+	}
+
+	// 后台任务上下文，关闭时取消
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+
 	// 启动清理任务
-	go startCleanupRoutine()
+	go startCleanupRoutine(bgCtx, cleanupSchedule)
+
+	// 启动无活动超时检查
+	if utils.CurrentConfig().InactivityTimeoutSeconds > 0 {
+		go startInactivityChecker(bgCtx)
+	}
+
+	// 启动合并文件后台校验
+	if utils.CurrentConfig().EnableBackgroundVerification && utils.CurrentConfig().VerificationIntervalHours > 0 {
+		verifier := utils.NewBackgroundVerifier(time.Duration(utils.CurrentConfig().VerificationIntervalHours) * time.Hour)
+		go verifier.Run(bgCtx)
+	}
+
+	// 收到 SIGHUP 时重新加载配置
+	go watchConfigReload(bgCtx)
+
+	// 启动自动合并队列
+	if utils.CurrentConfig().EnableAutoMerge {
+		utils.AutoMergeQueue = utils.StartMergeQueue(utils.CurrentConfig().AutoMergeWorkers, handler.AutoMergeTask)
+	}
+
+	// 启动分片写入队列
+	if utils.CurrentConfig().ChunkWriteWorkers > 0 {
+		utils.ChunkWriteQueue = utils.StartWriteQueue(utils.CurrentConfig().ChunkWriteWorkers)
+	}
 	
-	r := gin.Default()
+	// 使用结构化日志替代gin默认日志
+	r := gin.New()
+	r.Use(utils.RequestIDMiddleware(), utils.GinLogger(), utils.AuditMiddleware(), gin.Recovery())
+	if utils.CurrentConfig().OpenTelemetryEnabled {
+		r.Use(utils.TracingMiddleware())
+	}
+
+	// 跨域中间件需在所有路由组之前注册
+	r.Use(utils.CORSMiddleware(utils.CurrentConfig().CORS))
+	r.Use(utils.SecurityHeadersMiddleware(utils.CurrentConfig().SecurityHeaders))
+
+	// 读取请求体之前限制大小，分片上传按分片上限放宽，导入接口流式读取不限制
+	r.Use(utils.BodyLimitMiddleware(
+		[]string{"/go-uploader/upload_chunk", "/go-uploader/upload_chunk_signed"},
+		[]string{"/go-uploader/admin/import"},
+	))
+
+	// 压缩JSON响应，分片上传、合并和流式接口不压缩
+	r.Use(utils.GzipMiddleware([]string{
+		"/go-uploader/upload_chunk",
+		"/go-uploader/upload_chunk_signed",
+		"/go-uploader/merge_chunks",
+		"/go-uploader/events",
+		"/go-uploader/ws",
+		"/go-uploader/admin/export",
+		"/go-uploader/files/*filepath",
+	}))
 	
 	// 配置HTML模板
 	r.LoadHTMLGlob("static/*.html")
 
+	// 路由超时中间件按路由注册，SSE、WebSocket和文件下载等长连接路由不设置超时
+	timeout := utils.TimeoutMiddleware(utils.CurrentConfig().RouteTimeouts)
+
 	// 创建 go-uploader 路由组
 	goUploader := r.Group("/go-uploader")
+	// 所有路由读取 X-Tenant-ID 请求头，任务和合并文件按租户隔离
+	goUploader.Use(utils.TenantMiddleware())
 	{
 		// 配置静态文件服务
 		goUploader.Static("/static", "./static")
@@ -50,59 +180,319 @@ func main() {
 		goUploader.POST("/auth/login", handler.Login)
 		goUploader.POST("/auth/logout", handler.Logout)
 		goUploader.GET("/auth/check", handler.CheckAuth)
-		goUploader.POST("/upload_chunk", handler.UploadChunk)
-		goUploader.POST("/merge_chunks", handler.MergeChunks)
-		goUploader.GET("/upload_status", handler.UploadStatus)
+		goUploader.POST("/auth/refresh", handler.RefreshToken)
+		goUploader.GET("/auth/oidc/login", handler.OIDCLogin)
+		goUploader.GET("/auth/oidc/callback", handler.OIDCCallback)
+		goUploader.POST("/upload_chunk", utils.IPFilterMiddleware(), timeout, utils.RateLimitMiddleware(), handler.UploadChunk)
+		goUploader.POST("/upload_chunk_signed", utils.IPFilterMiddleware(), timeout, utils.RateLimitMiddleware(), handler.UploadChunkSigned) // 凭预签名URL上传
+		goUploader.POST("/merge_chunks", utils.IPFilterMiddleware(), handler.MergeChunks) // 超时由 merge_timeout_seconds 控制
+		goUploader.POST("/estimate", utils.IPFilterMiddleware(), utils.RateLimitMiddleware(), handler.EstimateUpload) // 上传前预估，无需认证
+		goUploader.GET("/upload_status", timeout, handler.UploadStatus)
+		goUploader.GET("/upload_status/gaps", timeout, handler.UploadGaps)
+		goUploader.GET("/events", handler.TaskEvents)
+
+		// API文档
+		if utils.CurrentConfig().EnableDocs {
+			goUploader.GET("/openapi.json", handler.OpenAPISpec)
+			goUploader.GET("/docs/*any", handler.SwaggerUI("/go-uploader/openapi.json"))
+		}
+
+		// API密钥管理路由（需要管理员密钥）
+		adminKeys := goUploader.Group("/auth/keys")
+		adminKeys.Use(utils.AdminAuthMiddleware())
+		{
+			adminKeys.POST("", handler.CreateAPIKey)
+			adminKeys.GET("", handler.ListAPIKeys)
+			adminKeys.DELETE("/:key_id", handler.RevokeAPIKey)
+		}
+
+		// 任务导出和导入（需要管理员密钥）
+		admin := goUploader.Group("/admin")
+		admin.Use(utils.AdminAuthMiddleware())
+		{
+			admin.GET("/export", handler.ExportTasks)
+			admin.POST("/import", handler.ImportTasks)
+			admin.POST("/checkpoint", handler.Checkpoint)
+			admin.POST("/compact", handler.CompactMetadata)
+			admin.GET("/collisions", handler.ListFileIDCollisions)
+			admin.GET("/circuit_breakers", handler.ListCircuitBreakers)
+			admin.POST("/circuit_breakers/:name/reset", handler.ResetCircuitBreaker)
+			admin.GET("/archive", handler.ListArchive)
+			admin.POST("/archive/:file_id/restore", handler.RestoreArchivedTask)
+			admin.POST("/gc", handler.CollectTempFiles)
+		}
 
 		// 应用认证中间件到所有其他API路由
 		api := goUploader.Group("")
 		api.Use(utils.AuthMiddleware())
 		{	
+			// 上传会话：保存服务端分片清单
+			api.POST("/upload_session", timeout, handler.CreateUploadSession)
+
 			// 任务管理API
-			api.GET("/tasks", handler.GetAllTasks)
-			api.GET("/tasks/:file_id", handler.GetTask)
-			api.DELETE("/tasks/:file_id", handler.DeleteTask)
-			api.POST("/tasks/:file_id/pause", handler.PauseTask)
-			api.POST("/tasks/:file_id/resume", handler.ResumeTask)
-			api.POST("/tasks/cleanup", handler.CleanupTasks)
-			api.POST("/tasks/resume_all_failed", handler.ResumeAllFailedTasks)
-			api.GET("/tasks/failed", handler.GetFailedTasks)
+			api.GET("/tasks", timeout, handler.GetAllTasks)
+			api.GET("/tasks/:file_id", timeout, handler.GetTask)
+			api.GET("/tasks/:file_id/merge_status", timeout, handler.MergeStatus)
+			api.POST("/tasks/:file_id/heartbeat", timeout, handler.TaskHeartbeat)
+			api.PUT("/tasks/:file_id/tags", timeout, handler.UpdateTaskTags)
+			api.PUT("/tasks/:file_id/metadata", timeout, handler.UpdateTaskMetadata)
+			api.GET("/tasks/:file_id/quota", timeout, handler.GetTaskQuota)
+			api.DELETE("/tasks/:file_id", timeout, handler.DeleteTask)
+			api.POST("/tasks/:file_id/pause", timeout, handler.PauseTask)
+			api.POST("/tasks/:file_id/resume", timeout, handler.ResumeTask)
+			api.POST("/tasks/:file_id/clone", timeout, handler.CloneTask)
+			api.DELETE("/tasks/:file_id/chunks/:index", timeout, utils.AdminAuthMiddleware(), handler.ResetChunkRetry)
+			api.POST("/tasks/cleanup", timeout, handler.CleanupTasks)
+			api.POST("/tasks/resume_all_failed", timeout, handler.ResumeAllFailedTasks)
+			api.GET("/tasks/failed", timeout, handler.GetFailedTasks)
+
+			// WebSocket任务控制
+			api.GET("/ws", handler.TaskSocket)
+
+			// 死信队列
+			api.GET("/dlq", timeout, handler.ListDeadLetters)
+			api.POST("/dlq/:file_id/retry", timeout, handler.RetryDeadLetter)
+
+			// 回收站
+			api.GET("/trash", timeout, handler.ListTrash)
+			api.POST("/trash/empty", timeout, handler.EmptyTrash)
+			api.POST("/trash/:entry/restore", timeout, handler.RestoreTrashEntry)
+
+			// 已合并文件API
+			api.GET("/files", timeout, handler.ListFiles)
+			api.GET("/files/*filepath", handler.DownloadFile)
+			api.DELETE("/files/*filepath", timeout, handler.DeleteFile)
+
+			// 预签名上传API
+			api.POST("/presign", timeout, handler.Presign)
 			
 			// 文件夹任务API
-			api.POST("/folder_tasks", handler.CreateFolderTask)
-			api.GET("/folder_tasks/:folder_task_id/summary", handler.GetFolderTaskSummary)
-			api.GET("/folder_tasks/:folder_task_id/sub_tasks", handler.GetSubTasks)
+			api.POST("/folder_tasks", timeout, handler.CreateFolderTask)
+			api.PATCH("/folder_tasks/:folder_task_id/files", timeout, handler.AddFolderFiles)
+			api.GET("/folder_tasks/:folder_task_id/summary", timeout, handler.GetFolderTaskSummary)
+			api.GET("/folder_tasks/:folder_task_id/sub_tasks", timeout, handler.GetSubTasks)
+			api.POST("/folder_tasks/:folder_task_id/sub_tasks/batch_retry", timeout, handler.BatchRetrySubTasks)
+			api.POST("/task_groups", timeout, handler.CreateTaskGroup)
+			api.GET("/task_groups", timeout, handler.ListTaskGroups)
+			api.GET("/task_groups/:group_id/summary", timeout, handler.GetTaskGroupSummary)
+			api.POST("/task_groups/:group_id/folder_tasks", timeout, handler.AddGroupFolderTask)
+			api.DELETE("/task_groups/:group_id", timeout, handler.DeleteTaskGroup)
 			
 			// 监控和健康检查API
-			api.GET("/health", handler.HealthCheck)
-			api.GET("/system", handler.SystemInfo)
-			api.GET("/metrics", handler.GetMetrics)
+			api.GET("/health", timeout, handler.HealthCheck)
+			api.GET("/system", timeout, handler.SystemInfo)
+			api.GET("/metrics", timeout, handler.GetMetrics)
+			api.GET("/metrics/tasks", timeout, handler.GetTaskMetrics)
+			api.GET("/admin/dashboard", timeout, handler.GetDashboard)
 		}
 	}
 
 	// 使用配置中的端口
-	port := fmt.Sprintf(":%s", utils.Config.Port)
-	log.Printf("服务器启动，监听端口: %s", utils.Config.Port)
-	if utils.Config.EnableAuth {
-		log.Printf("密钥验证已启用，密钥: %s", utils.Config.SecretKey)
+	port := fmt.Sprintf(":%s", utils.CurrentConfig().Port)
+	utils.Logger.Info("服务器启动", "port", utils.CurrentConfig().Port)
+	if utils.CurrentConfig().EnableAuth {
+		utils.Logger.Info("密钥验证已启用", "secret_key", utils.CurrentConfig().SecretKey)
 	} else {
-		log.Printf("密钥验证已禁用")
+		utils.Logger.Info("密钥验证已禁用")
+	}
+
+	server := &http.Server{
+		Addr:    port,
+		Handler: r,
+	}
+
+	go func() {
+		if err := startServer(server); err != nil && err != http.ErrServerClosed {
+			utils.Fatal("服务器启动失败", "error", err)
+		}
+	}()
+
+	// 配置gRPC端口时同时启动gRPC服务，与HTTP服务共用任务存储
+	var grpcServer *grpc.Server
+	if utils.CurrentConfig().GRPCPort != "" {
+		var err error
+		grpcServer, err = grpc.NewServer()
+		if err != nil {
+			utils.Fatal("gRPC服务器启动失败", "error", err)
+		}
+		utils.Logger.Info("gRPC服务器启动", "port", utils.CurrentConfig().GRPCPort)
+
+		go func() {
+			if err := grpcServer.Serve(); err != nil {
+				utils.Fatal("gRPC服务器启动失败", "error", err)
+			}
+		}()
 	}
-	r.Run(port) // 监听端口
+
+	waitForShutdown(server, grpcServer, stopBackground)
 }
 
-// startCleanupRoutine 启动定期清理任务
-func startCleanupRoutine() {
-	ticker := time.NewTicker(time.Duration(utils.Config.CleanupInterval) * time.Second)
+// startServer 根据TLS配置以HTTP或HTTPS方式启动服务器
+func startServer(server *http.Server) error {
+	if !utils.CurrentConfig().TLSEnabled {
+		return server.ListenAndServe()
+	}
+
+	if utils.CurrentConfig().TLSAutoTLS {
+		manager, err := utils.NewAutocertManager()
+		if err != nil {
+			return err
+		}
+		server.TLSConfig = manager.TLSConfig()
+
+		// HTTP-01 验证需要监听80端口
+		go func() {
+			if err := http.ListenAndServe(":80", manager.HTTPHandler(nil)); err != nil {
+				utils.Logger.Error("ACME验证服务启动失败", "error", err)
+			}
+		}()
+
+		utils.Logger.Info("已启用自动TLS", "domain", utils.CurrentConfig().TLSACMEDomain)
+		return server.ListenAndServeTLS("", "")
+	}
+
+	utils.Logger.Info("已启用TLS", "cert_file", utils.CurrentConfig().TLSCertFile)
+	return server.ListenAndServeTLS(utils.CurrentConfig().TLSCertFile, utils.CurrentConfig().TLSKeyFile)
+}
+
+// waitForShutdown 等待退出信号并优雅关闭服务器
+func waitForShutdown(server *http.Server, grpcServer *grpc.Server, stopBackground context.CancelFunc) {
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-quit
+
+	utils.Logger.Info("收到信号，开始优雅关闭", "signal", sig.String())
+	stopBackground()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(utils.CurrentConfig().ShutdownTimeout)*time.Second)
+	defer cancel()
+
+	active := utils.Inflight.Count()
+	utils.Logger.Info("等待进行中的上传/合并操作完成", "active", active)
+
+	if err := server.Shutdown(ctx); err != nil {
+		utils.Logger.Error("关闭HTTP服务器失败", "error", err)
+	}
+	if grpcServer != nil {
+		if err := grpcServer.Shutdown(ctx); err != nil {
+			utils.Logger.Error("关闭gRPC服务器失败", "error", err)
+		}
+	}
+
+	// 处理完队列中剩余的自动合并
+	if utils.AutoMergeQueue != nil {
+		if err := utils.AutoMergeQueue.Shutdown(ctx); err != nil {
+			utils.Fatal("优雅关闭超时，自动合并队列未处理完毕")
+		}
+	}
+
+	if err := utils.Inflight.Wait(ctx); err != nil {
+		utils.Fatal("优雅关闭超时，仍有操作未完成", "active", utils.Inflight.Count())
+	}
+	utils.Logger.Info("已完成进行中的操作", "completed", active)
+
+	if utils.ChunkWriteQueue != nil {
+		if err := utils.ChunkWriteQueue.Shutdown(ctx); err != nil {
+			utils.Logger.Error("关闭分片写入队列超时", "error", err)
+		}
+	}
+
+	if err := utils.Storage.Close(); err != nil {
+		utils.Logger.Error("关闭存储后端失败", "error", err)
+	}
+	if err := utils.ShutdownTracing(ctx); err != nil {
+		utils.Logger.Error("导出剩余的追踪数据失败", "error", err)
+	}
+	if utils.Audit != nil {
+		if err := utils.Audit.Close(); err != nil {
+			utils.Logger.Error("关闭审计日志失败", "error", err)
+		}
+	}
+	utils.Logger.Info("服务器已关闭")
+}
+
+// watchConfigReload 监听 SIGHUP 信号并热加载配置文件
+func watchConfigReload(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			if err := utils.ReloadConfig(configFile); err != nil {
+				utils.Logger.Error("重新加载配置失败，继续使用当前配置", "error", err)
+			}
+		}
+	}
+}
+
+// startCleanupRoutine 启动定期清理任务：schedule 不为空时按cron计划执行，否则每隔 CleanupInterval 秒执行，ctx 取消时停止
+func startCleanupRoutine(ctx context.Context, schedule cron.Schedule) {
+	if schedule != nil {
+		scheduler := cron.New()
+		scheduler.Schedule(schedule, cron.FuncJob(runCleanup))
+		scheduler.Start()
+		utils.Logger.Info("已按cron计划启动定期清理", "cleanup_cron_expression", utils.CurrentConfig().CleanupCronExpression,
+			"next_run", schedule.Next(time.Now()))
+
+		<-ctx.Done()
+		// 等待正在执行的清理结束
+		<-scheduler.Stop().Done()
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(utils.CurrentConfig().CleanupInterval) * time.Second)
 	defer ticker.Stop()
 	
 	for {
 		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			runCleanup()
+		}
+	}
+}
+
+// runCleanup 执行一次定期清理：过期任务、遗留的临时文件和长时间未更新的已完成任务归档
+func runCleanup() {
+	if cleaned, err := utils.Storage.CleanupExpiredTasks(false); err != nil {
+		utils.Logger.Error("清理过期任务失败", "error", err)
+	} else {
+		utils.Logger.Info("定期清理任务完成", "cleaned", len(cleaned))
+	}
+
+	// 删除原子写入中断遗留的临时文件
+	if removed, err := utils.TempFileGC(utils.CurrentConfig().UploadDir, utils.DefaultTempFileMaxAge); err != nil {
+		utils.Logger.Error("清理遗留的临时文件失败", "error", err)
+	} else if removed > 0 {
+		utils.Logger.Info("已清理遗留的临时文件", "removed", removed)
+	}
+
+	// 归档长时间未更新的已完成任务
+	if days := utils.CurrentConfig().ArchiveAfterDays; days > 0 {
+		if archived := utils.Storage.ArchiveIdleTasks(time.Now().AddDate(0, 0, -days)); archived > 0 {
+			utils.Logger.Info("已归档长时间未更新的已完成任务", "archived", archived)
+		}
+	}
+}
+
+// startInactivityChecker 定期将长时间未收到分片的上传任务标记为失败
+func startInactivityChecker(ctx context.Context) {
+	ticker := time.NewTicker(60 * time.Second)
+	defer ticker.Stop()
+
+	timeout := time.Duration(utils.CurrentConfig().InactivityTimeoutSeconds) * time.Second
+	for {
+		select {
+		case <-ctx.Done():
+			return
 		case <-ticker.C:
-			if err := utils.Storage.CleanupExpiredTasks(); err != nil {
-				log.Printf("清理过期任务失败: %v", err)
-			} else {
-				log.Printf("定期清理任务完成")
+			if count := utils.Storage.FailInactiveTasks(timeout); count > 0 {
+				utils.Logger.Info("已将无活动的上传任务标记为失败", "count", count)
 			}
 		}
 	}
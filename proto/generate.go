@@ -0,0 +1,4 @@
+// Package uploaderpb 由 uploader.proto 生成的gRPC接口定义
+package uploaderpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative uploader.proto
@@ -0,0 +1,244 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: uploader.proto
+
+package uploaderpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	UploaderService_UploadChunk_FullMethodName = "/uploader.v1.UploaderService/UploadChunk"
+	UploaderService_MergeChunks_FullMethodName = "/uploader.v1.UploaderService/MergeChunks"
+	UploaderService_GetTask_FullMethodName     = "/uploader.v1.UploaderService/GetTask"
+	UploaderService_ListTasks_FullMethodName   = "/uploader.v1.UploaderService/ListTasks"
+)
+
+// UploaderServiceClient is the client API for UploaderService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// UploaderService 分片上传服务，与REST接口共用任务存储
+type UploaderServiceClient interface {
+	// UploadChunk 流式上传单个分片：首条消息携带分片元数据，后续消息只需携带数据
+	UploadChunk(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ChunkRequest, ChunkResponse], error)
+	// MergeChunks 合并已上传的分片
+	MergeChunks(ctx context.Context, in *MergeRequest, opts ...grpc.CallOption) (*MergeResponse, error)
+	// GetTask 获取任务详情
+	GetTask(ctx context.Context, in *TaskRequest, opts ...grpc.CallOption) (*TaskResponse, error)
+	// ListTasks 按条件列出任务
+	ListTasks(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TaskResponse], error)
+}
+
+type uploaderServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewUploaderServiceClient(cc grpc.ClientConnInterface) UploaderServiceClient {
+	return &uploaderServiceClient{cc}
+}
+
+func (c *uploaderServiceClient) UploadChunk(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ChunkRequest, ChunkResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &UploaderService_ServiceDesc.Streams[0], UploaderService_UploadChunk_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ChunkRequest, ChunkResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type UploaderService_UploadChunkClient = grpc.ClientStreamingClient[ChunkRequest, ChunkResponse]
+
+func (c *uploaderServiceClient) MergeChunks(ctx context.Context, in *MergeRequest, opts ...grpc.CallOption) (*MergeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MergeResponse)
+	err := c.cc.Invoke(ctx, UploaderService_MergeChunks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uploaderServiceClient) GetTask(ctx context.Context, in *TaskRequest, opts ...grpc.CallOption) (*TaskResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TaskResponse)
+	err := c.cc.Invoke(ctx, UploaderService_GetTask_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uploaderServiceClient) ListTasks(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TaskResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &UploaderService_ServiceDesc.Streams[1], UploaderService_ListTasks_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListRequest, TaskResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type UploaderService_ListTasksClient = grpc.ServerStreamingClient[TaskResponse]
+
+// UploaderServiceServer is the server API for UploaderService service.
+// All implementations must embed UnimplementedUploaderServiceServer
+// for forward compatibility.
+//
+// UploaderService 分片上传服务，与REST接口共用任务存储
+type UploaderServiceServer interface {
+	// UploadChunk 流式上传单个分片：首条消息携带分片元数据，后续消息只需携带数据
+	UploadChunk(grpc.ClientStreamingServer[ChunkRequest, ChunkResponse]) error
+	// MergeChunks 合并已上传的分片
+	MergeChunks(context.Context, *MergeRequest) (*MergeResponse, error)
+	// GetTask 获取任务详情
+	GetTask(context.Context, *TaskRequest) (*TaskResponse, error)
+	// ListTasks 按条件列出任务
+	ListTasks(*ListRequest, grpc.ServerStreamingServer[TaskResponse]) error
+	mustEmbedUnimplementedUploaderServiceServer()
+}
+
+// UnimplementedUploaderServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedUploaderServiceServer struct{}
+
+func (UnimplementedUploaderServiceServer) UploadChunk(grpc.ClientStreamingServer[ChunkRequest, ChunkResponse]) error {
+	return status.Error(codes.Unimplemented, "method UploadChunk not implemented")
+}
+func (UnimplementedUploaderServiceServer) MergeChunks(context.Context, *MergeRequest) (*MergeResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method MergeChunks not implemented")
+}
+func (UnimplementedUploaderServiceServer) GetTask(context.Context, *TaskRequest) (*TaskResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetTask not implemented")
+}
+func (UnimplementedUploaderServiceServer) ListTasks(*ListRequest, grpc.ServerStreamingServer[TaskResponse]) error {
+	return status.Error(codes.Unimplemented, "method ListTasks not implemented")
+}
+func (UnimplementedUploaderServiceServer) mustEmbedUnimplementedUploaderServiceServer() {}
+func (UnimplementedUploaderServiceServer) testEmbeddedByValue()                         {}
+
+// UnsafeUploaderServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to UploaderServiceServer will
+// result in compilation errors.
+type UnsafeUploaderServiceServer interface {
+	mustEmbedUnimplementedUploaderServiceServer()
+}
+
+func RegisterUploaderServiceServer(s grpc.ServiceRegistrar, srv UploaderServiceServer) {
+	// If the following call panics, it indicates UnimplementedUploaderServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&UploaderService_ServiceDesc, srv)
+}
+
+func _UploaderService_UploadChunk_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(UploaderServiceServer).UploadChunk(&grpc.GenericServerStream[ChunkRequest, ChunkResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type UploaderService_UploadChunkServer = grpc.ClientStreamingServer[ChunkRequest, ChunkResponse]
+
+func _UploaderService_MergeChunks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MergeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UploaderServiceServer).MergeChunks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UploaderService_MergeChunks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UploaderServiceServer).MergeChunks(ctx, req.(*MergeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UploaderService_GetTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UploaderServiceServer).GetTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UploaderService_GetTask_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UploaderServiceServer).GetTask(ctx, req.(*TaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UploaderService_ListTasks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(UploaderServiceServer).ListTasks(m, &grpc.GenericServerStream[ListRequest, TaskResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type UploaderService_ListTasksServer = grpc.ServerStreamingServer[TaskResponse]
+
+// UploaderService_ServiceDesc is the grpc.ServiceDesc for UploaderService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var UploaderService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "uploader.v1.UploaderService",
+	HandlerType: (*UploaderServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "MergeChunks",
+			Handler:    _UploaderService_MergeChunks_Handler,
+		},
+		{
+			MethodName: "GetTask",
+			Handler:    _UploaderService_GetTask_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "UploadChunk",
+			Handler:       _UploaderService_UploadChunk_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ListTasks",
+			Handler:       _UploaderService_ListTasks_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "uploader.proto",
+}
@@ -0,0 +1,199 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// apiKeyPrefix 生成的API密钥前缀，便于识别
+const apiKeyPrefix = "gu_"
+
+// ErrAPIKeyNotFound API密钥不存在
+var ErrAPIKeyNotFound = errors.New("API密钥不存在")
+
+// APIKey API密钥记录，只保存密钥的SHA-256哈希
+type APIKey struct {
+	ID        string     `json:"id"`
+	HashedKey string     `json:"hashed_key"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // 为空表示永不过期
+	Label     string     `json:"label"`
+}
+
+// IsExpired 检查密钥是否已过期
+func (k *APIKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// APIKeyStore API密钥存储，持久化为JSON文件
+type APIKeyStore struct {
+	path  string
+	mutex sync.RWMutex
+	keys  map[string]*APIKey
+}
+
+// APIKeys 全局API密钥存储
+var APIKeys *APIKeyStore
+
+// NewAPIKeyStore 创建API密钥存储并加载已有密钥
+func NewAPIKeyStore(path string) (*APIKeyStore, error) {
+	store := &APIKeyStore{
+		path: path,
+		keys: make(map[string]*APIKey),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, fmt.Errorf("读取API密钥文件失败: %v", err)
+	}
+
+	if err := json.Unmarshal(data, &store.keys); err != nil {
+		return nil, fmt.Errorf("解析API密钥文件失败: %v", err)
+	}
+	return store, nil
+}
+
+// Create 生成新的API密钥，明文密钥只在此处返回一次
+func (s *APIKeyStore) Create(label string, ttl time.Duration) (string, APIKey, error) {
+	id, err := randomHex(8)
+	if err != nil {
+		return "", APIKey{}, err
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return "", APIKey{}, err
+	}
+	rawKey := apiKeyPrefix + secret
+
+	now := time.Now()
+	key := &APIKey{
+		ID:        id,
+		HashedKey: hashAPIKey(rawKey),
+		CreatedAt: now,
+		Label:     label,
+	}
+	if ttl > 0 {
+		expiresAt := now.Add(ttl)
+		key.ExpiresAt = &expiresAt
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.keys[id] = key
+	if err := s.persist(); err != nil {
+		delete(s.keys, id)
+		return "", APIKey{}, err
+	}
+	return rawKey, *key, nil
+}
+
+// List 按创建时间列出所有密钥
+func (s *APIKeyStore) List() []APIKey {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	keys := make([]APIKey, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, *key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+	return keys
+}
+
+// Revoke 吊销指定密钥
+func (s *APIKeyStore) Revoke(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key, exists := s.keys[id]
+	if !exists {
+		return ErrAPIKeyNotFound
+	}
+
+	delete(s.keys, id)
+	if err := s.persist(); err != nil {
+		s.keys[id] = key
+		return err
+	}
+	return nil
+}
+
+// Validate 校验密钥是否有效，使用常量时间比较哈希并拒绝过期密钥
+func (s *APIKeyStore) Validate(rawKey string) bool {
+	if rawKey == "" {
+		return false
+	}
+	hashed := []byte(hashAPIKey(rawKey))
+	now := time.Now()
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	valid := false
+	for _, key := range s.keys {
+		// 遍历全部密钥，避免提前返回泄露匹配位置
+		if subtle.ConstantTimeCompare(hashed, []byte(key.HashedKey)) == 1 && !key.IsExpired(now) {
+			valid = true
+		}
+	}
+	return valid
+}
+
+// persist 原子写入密钥文件，调用方需持有锁
+func (s *APIKeyStore) persist() error {
+	data, err := json.MarshalIndent(s.keys, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	writer, err := NewAtomicWriter(s.path)
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(data); err != nil {
+		writer.Rollback()
+		return fmt.Errorf("写入API密钥文件失败: %v", err)
+	}
+	return writer.Commit()
+}
+
+// initAPIKeys 初始化全局API密钥存储
+func initAPIKeys(storageDir string) error {
+	store, err := NewAPIKeyStore(filepath.Join(storageDir, "apikeys.json"))
+	if err != nil {
+		return err
+	}
+	APIKeys = store
+	return nil
+}
+
+// hashAPIKey 计算密钥的SHA-256哈希
+func hashAPIKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomHex 生成指定字节数的随机十六进制字符串
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成随机数失败: %v", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,294 @@
+package utils
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrArchivedTaskNotFound 归档中不存在该任务
+var ErrArchivedTaskNotFound = errors.New("归档中不存在该任务")
+
+// archiveFileSuffix 归档文件的扩展名
+const archiveFileSuffix = ".json.gz"
+
+// ArchiveEntry 归档的任务
+type ArchiveEntry struct {
+	Path       string      `json:"path"` // 相对于归档目录的路径：<年>/<月>/<安全的文件ID>.json.gz
+	Task       *UploadTask `json:"task"` // 归档时的任务快照
+	ArchivedAt time.Time   `json:"archived_at"`
+}
+
+// ArchiveStore 已完成任务的冷存储，任务元数据以gzip压缩的JSON保存，按任务最后更新时间的年月分目录
+type ArchiveStore struct {
+	dir   string
+	mutex sync.Mutex
+}
+
+// Archive 全局归档存储
+var Archive *ArchiveStore
+
+// NewArchiveStore 创建归档目录
+func NewArchiveStore(dir string) (*ArchiveStore, error) {
+	if err := EnsureDirectory(dir); err != nil {
+		return nil, fmt.Errorf("创建归档目录失败: %v", err)
+	}
+	return &ArchiveStore{dir: dir}, nil
+}
+
+// Add 压缩保存任务快照
+func (a *ArchiveStore) Add(task *UploadTask) (*ArchiveEntry, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	monthDir := filepath.Join(task.UpdatedAt.Format("2006"), task.UpdatedAt.Format("01"))
+	if err := EnsureDirectory(filepath.Join(a.dir, monthDir)); err != nil {
+		return nil, fmt.Errorf("创建归档目录失败: %v", err)
+	}
+
+	entry := &ArchiveEntry{
+		Path:       filepath.ToSlash(filepath.Join(monthDir, SafeFileKey(task.FileID)+archiveFileSuffix)),
+		Task:       task,
+		ArchivedAt: time.Now(),
+	}
+	if err := writeArchiveEntry(filepath.Join(a.dir, filepath.FromSlash(entry.Path)), entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Find 在所有年月目录中查找任务
+func (a *ArchiveStore) Find(fileID string) (*ArchiveEntry, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	months, err := a.monthDirs(0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// 升级前归档的任务以短哈希命名
+	names := []string{SafeFileKey(fileID) + archiveFileSuffix, SanitizeFileID(fileID) + archiveFileSuffix}
+	for _, monthDir := range months {
+		for _, name := range names {
+			entry, err := readArchiveEntry(filepath.Join(monthDir, name))
+			if err != nil {
+				if !os.IsNotExist(err) {
+					Logger.Warn("跳过无法读取的归档文件", "path", filepath.Join(monthDir, name), "error", err)
+				}
+				continue
+			}
+			if entry.Task.FileID == fileID {
+				entry.Path = a.relativePath(filepath.Join(monthDir, name))
+				return entry, nil
+			}
+		}
+	}
+	return nil, ErrArchivedTaskNotFound
+}
+
+// List 列出归档的任务，year 或 month 为0时不按该项过滤，最近归档的排在前面
+func (a *ArchiveStore) List(year, month int) ([]*ArchiveEntry, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	months, err := a.monthDirs(year, month)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*ArchiveEntry, 0)
+	for _, monthDir := range months {
+		files, err := os.ReadDir(monthDir)
+		if err != nil {
+			return nil, fmt.Errorf("读取归档目录失败: %v", err)
+		}
+		for _, file := range files {
+			if file.IsDir() || !strings.HasSuffix(file.Name(), archiveFileSuffix) {
+				continue
+			}
+			path := filepath.Join(monthDir, file.Name())
+			entry, err := readArchiveEntry(path)
+			if err != nil {
+				Logger.Warn("跳过无法读取的归档文件", "path", path, "error", err)
+				continue
+			}
+			entry.Path = a.relativePath(path)
+			entries = append(entries, entry)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ArchivedAt.After(entries[j].ArchivedAt)
+	})
+	return entries, nil
+}
+
+// Remove 删除归档文件，年月目录为空时一并删除
+func (a *ArchiveStore) Remove(entry *ArchiveEntry) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	path := filepath.Join(a.dir, filepath.FromSlash(entry.Path))
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("删除归档文件失败: %v", err)
+	}
+	if os.Remove(filepath.Dir(path)) == nil {
+		os.Remove(filepath.Dir(filepath.Dir(path)))
+	}
+	return nil
+}
+
+// monthDirs 返回匹配年月的月份目录，调用方需持有锁
+func (a *ArchiveStore) monthDirs(year, month int) ([]string, error) {
+	yearPattern, monthPattern := "[0-9][0-9][0-9][0-9]", "[0-9][0-9]"
+	if year > 0 {
+		yearPattern = fmt.Sprintf("%04d", year)
+	}
+	if month > 0 {
+		monthPattern = fmt.Sprintf("%02d", month)
+	}
+
+	dirs, err := filepath.Glob(filepath.Join(a.dir, yearPattern, monthPattern))
+	if err != nil {
+		return nil, fmt.Errorf("读取归档目录失败: %v", err)
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+// relativePath 归档文件相对于归档目录的路径
+func (a *ArchiveStore) relativePath(path string) string {
+	if rel, err := filepath.Rel(a.dir, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(path)
+}
+
+// writeArchiveEntry 原子写入gzip压缩的归档文件
+func writeArchiveEntry(path string, entry *ArchiveEntry) error {
+	writer, err := NewAtomicWriter(path)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(writer)
+	if err := json.NewEncoder(gz).Encode(entry); err != nil {
+		writer.Rollback()
+		return fmt.Errorf("写入归档文件失败: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		writer.Rollback()
+		return fmt.Errorf("写入归档文件失败: %v", err)
+	}
+	return writer.Commit()
+}
+
+// readArchiveEntry 解压并解析归档文件
+func readArchiveEntry(path string) (*ArchiveEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("解压归档文件失败: %v", err)
+	}
+	defer gz.Close()
+
+	var entry ArchiveEntry
+	if err := json.NewDecoder(gz).Decode(&entry); err != nil {
+		return nil, fmt.Errorf("解析归档文件失败: %v", err)
+	}
+	if entry.Task == nil {
+		return nil, fmt.Errorf("归档文件缺少任务信息")
+	}
+	normalizeTask(entry.Task)
+	return &entry, nil
+}
+
+// ArchiveIdleTasks 将最后更新早于 cutoff 的已完成单文件任务移入归档并从活动存储中移除，返回归档的任务数；
+// 文件夹任务及其子任务、分片尚未清理的任务不归档，合并文件和去重引用保持不变
+func (s *TaskStorage) ArchiveIdleTasks(cutoff time.Time) int {
+	if Archive == nil {
+		return 0
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	archived := 0
+	for fileID, task := range s.backend.GetAllTasks() {
+		if task.Status != "completed" || task.TaskType == "folder" || task.IsSubTask || task.PendingCleanup || !task.UpdatedAt.Before(cutoff) {
+			continue
+		}
+
+		entry, err := Archive.Add(task)
+		if err != nil {
+			Logger.Error("归档任务失败", "file_id", fileID, "error", err)
+			continue
+		}
+		if err := s.backend.DeleteTask(fileID); err != nil {
+			Logger.Error("归档后移除任务失败", "file_id", fileID, "error", err)
+			Archive.Remove(entry)
+			continue
+		}
+
+		if AutoMergeQueue != nil {
+			AutoMergeQueue.Forget(fileID)
+		}
+		speedometers.remove(fileID)
+		Logger.Debug("已归档任务", "file_id", fileID, "path", entry.Path)
+		archived++
+	}
+	return archived
+}
+
+// RestoreArchivedTask 将归档的任务移回活动存储
+func (s *TaskStorage) RestoreArchivedTask(fileID string) (*UploadTask, error) {
+	if Archive == nil {
+		return nil, ErrArchivedTaskNotFound
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.backend.GetTask(fileID); exists {
+		return nil, ErrTaskAlreadyActive
+	}
+	entry, err := Archive.Find(fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	task := entry.Task
+	if err := s.backend.SaveTask(task); err != nil {
+		return nil, err
+	}
+	if err := Archive.Remove(entry); err != nil {
+		Logger.Error("删除归档文件失败", "file_id", fileID, "path", entry.Path, "error", err)
+	}
+
+	Logger.Info("已从归档恢复任务", "file_id", fileID, "path", entry.Path)
+	Events.Publish(NewTaskEvent(task))
+	return task, nil
+}
+
+// initArchive 初始化归档存储
+func initArchive() error {
+	store, err := NewArchiveStore(filepath.Join(CurrentConfig().UploadDir, ".archive"))
+	if err != nil {
+		return err
+	}
+	Archive = store
+	return nil
+}
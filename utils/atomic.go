@@ -1,7 +1,6 @@
 package utils
 
 import (
-	"crypto/md5"
 	"encoding/hex"
 	"fmt"
 	"io"
@@ -35,7 +34,7 @@ func NewAtomicWriter(targetPath string) (*AtomicWriter, error) {
 		return nil, fmt.Errorf("创建临时文件失败: %v", err)
 	}
 	
-	hasher := md5.New()
+	hasher := Hasher.New()
 	
 	return &AtomicWriter{
 		targetPath: targetPath,
@@ -91,8 +90,8 @@ func (aw *AtomicWriter) Rollback() error {
 	return os.Remove(aw.tempPath)
 }
 
-// GetMD5 获取当前内容的MD5
-func (aw *AtomicWriter) GetMD5() string {
+// GetHash 获取当前内容的哈希，算法由 hash_algorithm 配置决定
+func (aw *AtomicWriter) GetHash() string {
 	if hasher, ok := aw.hash.(interface{ Sum([]byte) []byte }); ok {
 		return hex.EncodeToString(hasher.Sum(nil))
 	}
@@ -198,15 +197,15 @@ func VerifyFileIntegrity(filePath string, expectedMD5 string, expectedSize int64
 		return fmt.Errorf("文件大小不匹配: 期望=%d, 实际=%d", expectedSize, fileInfo.Size())
 	}
 	
-	// 验证MD5（如果提供）
+	// 验证校验值（如果提供）
 	if expectedMD5 != "" {
-		actualMD5, err := FileMD5(filePath)
+		actualMD5, err := Hasher.HashFile(filePath)
 		if err != nil {
-			return fmt.Errorf("计算文件MD5失败: %v", err)
+			return fmt.Errorf("计算文件%s失败: %v", Hasher.Algorithm(), err)
 		}
 		
 		if actualMD5 != expectedMD5 {
-			return fmt.Errorf("文件MD5不匹配: 期望=%s, 实际=%s", expectedMD5, actualMD5)
+			return fmt.Errorf("文件%s不匹配: 期望=%s, 实际=%s", Hasher.Algorithm(), expectedMD5, actualMD5)
 		}
 	}
 	
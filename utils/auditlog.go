@@ -0,0 +1,197 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"os"
+	"sync"
+	"time"
+)
+
+// AuditEntry 审计日志条目，每个请求一行JSON
+type AuditEntry struct {
+	Timestamp    time.Time `json:"timestamp"`
+	RequestID    string    `json:"request_id"`
+	Method       string    `json:"method"`
+	Path         string    `json:"path"`
+	ClientIP     string    `json:"client_ip"`
+	StatusCode   int       `json:"status_code"`
+	DurationMs   int64     `json:"duration_ms"`
+	UserIdentity string    `json:"user_identity"`
+	FileID       string    `json:"file_id,omitempty"`
+}
+
+// AuditLogger 以追加方式写入审计日志，超过大小限制时轮转
+type AuditLogger struct {
+	mutex    sync.Mutex
+	path     string
+	maxBytes int64
+	file     *os.File
+	size     int64
+}
+
+// Audit 全局审计日志记录器，未启用时为nil
+var Audit *AuditLogger
+
+// InitAuditLog 根据配置初始化审计日志
+func InitAuditLog() error {
+	if !CurrentConfig().AuditLogEnabled {
+		return nil
+	}
+	if CurrentConfig().AuditLogFile == "" {
+		return fmt.Errorf("启用审计日志时必须配置 audit_log_file")
+	}
+
+	logger, err := NewAuditLogger(CurrentConfig().AuditLogFile, CurrentConfig().AuditLogMaxSizeMB)
+	if err != nil {
+		return err
+	}
+	Audit = logger
+	return nil
+}
+
+// NewAuditLogger 打开审计日志文件，maxSizeMB 为0时不轮转
+func NewAuditLogger(path string, maxSizeMB int) (*AuditLogger, error) {
+	al := &AuditLogger{
+		path:     path,
+		maxBytes: int64(maxSizeMB) * 1024 * 1024,
+	}
+	if err := al.open(); err != nil {
+		return nil, err
+	}
+	return al, nil
+}
+
+// open 以追加和同步写入模式打开日志文件
+func (al *AuditLogger) open() error {
+	file, err := os.OpenFile(al.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND|os.O_SYNC, 0600)
+	if err != nil {
+		return fmt.Errorf("打开审计日志失败: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("读取审计日志信息失败: %v", err)
+	}
+	al.file = file
+	al.size = info.Size()
+	return nil
+}
+
+// Write 写入一条审计日志
+func (al *AuditLogger) Write(entry AuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	al.mutex.Lock()
+	defer al.mutex.Unlock()
+
+	if al.file == nil {
+		return fmt.Errorf("审计日志已关闭")
+	}
+	if al.maxBytes > 0 && al.size > 0 && al.size+int64(len(data)) > al.maxBytes {
+		if err := al.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := al.file.Write(data)
+	al.size += int64(n)
+	return err
+}
+
+// rotate 将当前日志重命名为带时间戳的归档文件并重新打开，调用方需持有锁
+func (al *AuditLogger) rotate() error {
+	if err := al.file.Close(); err != nil {
+		return fmt.Errorf("关闭审计日志失败: %v", err)
+	}
+	al.file = nil
+
+	archived := fmt.Sprintf("%s.%s", al.path, time.Now().Format("20060102-150405.000000000"))
+	if err := os.Rename(al.path, archived); err != nil {
+		return fmt.Errorf("轮转审计日志失败: %v", err)
+	}
+	return al.open()
+}
+
+// Close 关闭审计日志文件
+func (al *AuditLogger) Close() error {
+	al.mutex.Lock()
+	defer al.mutex.Unlock()
+
+	if al.file == nil {
+		return nil
+	}
+	err := al.file.Close()
+	al.file = nil
+	return err
+}
+
+// AuditMiddleware 记录每个请求的审计日志，需在请求ID中间件之后注册
+func AuditMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+
+		c.Next()
+
+		if Audit == nil {
+			return
+		}
+
+		entry := AuditEntry{
+			Timestamp:    start,
+			RequestID:    c.GetString(RequestIDKey),
+			Method:       c.Request.Method,
+			Path:         path,
+			ClientIP:     c.ClientIP(),
+			StatusCode:   c.Writer.Status(),
+			DurationMs:   time.Since(start).Milliseconds(),
+			UserIdentity: RequestIdentity(c),
+			FileID:       auditFileID(c),
+		}
+		if err := Audit.Write(entry); err != nil {
+			Logger.Error("写入审计日志失败", "request_id", entry.RequestID, "error", err)
+		}
+	}
+}
+
+// RequestIdentity 获取请求的用户身份：JWT令牌返回主体，密钥只记录哈希前缀，不暴露原始值
+func RequestIdentity(c *gin.Context) string {
+	if subject := c.GetString(AuthSubjectKey); subject != "" {
+		return "oidc:" + subject
+	}
+	if adminKey := c.GetHeader("X-Admin-Key"); adminKey != "" {
+		return "admin:" + hashAPIKey(adminKey)[:16]
+	}
+
+	credential := GetRequestCredential(c)
+	if credential == "" {
+		return "anonymous"
+	}
+	if claims, err := ValidateToken(credential); err == nil {
+		return "jwt:" + claims.Subject
+	}
+	return "key:" + hashAPIKey(credential)[:16]
+}
+
+// auditFileID 从路径参数、查询参数或已解析的表单中提取任务ID
+func auditFileID(c *gin.Context) string {
+	for _, param := range []string{"file_id", "folder_task_id"} {
+		if value := c.Param(param); value != "" {
+			return value
+		}
+	}
+	if value := c.Query("file_id"); value != "" {
+		return value
+	}
+	// 只读取处理函数已解析过的表单，避免再次读取请求体
+	if c.Request.PostForm != nil {
+		return c.Request.PostForm.Get("file_id")
+	}
+	return ""
+}
@@ -1,6 +1,9 @@
 package utils
 
 import (
+	"context"
+	"crypto/subtle"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strings"
@@ -9,8 +12,13 @@ import (
 // AuthMiddleware 密钥验证中间件
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// IP过滤在所有凭证检查之前进行，未启用验证时同样生效
+		if rejectFilteredIP(c) {
+			return
+		}
+
 		// 如果未启用验证，直接通过
-		if !Config.EnableAuth {
+		if !CurrentConfig().EnableAuth {
 			c.Next()
 			return
 		}
@@ -27,51 +35,233 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// 获取密钥，支持多种方式
-		secretKey := ""
-		
-		// 1. 从请求头获取
-		secretKey = c.GetHeader("X-Secret-Key")
-		
-		// 2. 从查询参数获取
-		if secretKey == "" {
-			secretKey = c.Query("secret_key")
+		// OIDC认证：校验身份提供方签发的ID令牌，并记录主体供审计使用
+		if CurrentConfig().AuthDriver == AuthDriverOIDC {
+			subject, ok := authenticateOIDCRequest(c)
+			if !ok {
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"error":   "未授权访问",
+					"message": "请提供有效的OIDC令牌",
+					"code":    401,
+				})
+				c.Abort()
+				return
+			}
+			if subject != "" {
+				c.Set(AuthSubjectKey, subject)
+			}
+		} else if !IsRequestAuthenticated(c) {
+			// 验证凭证（JWT令牌或密钥）
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "未授权访问",
+				"message": "请提供有效的访问密钥",
+				"code":    401,
+			})
+			c.Abort()
+			return
+		}
+
+		// JWT或OIDC令牌中的租户必须与请求的租户一致
+		if CurrentConfig().EnforceTenantClaim && !tenantMatchesClaim(c) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "租户不匹配",
+				"message": "请求的租户与令牌中的租户不一致",
+				"code":    403,
+			})
+			c.Abort()
+			return
+		}
+
+		// 验证通过，继续处理请求
+		c.Next()
+	}
+}
+
+// tenantMatchesClaim 检查令牌中的租户与请求头中的租户是否一致；
+// 访问密钥和API密钥不携带租户，视为运维凭证不做限制
+func tenantMatchesClaim(c *gin.Context) bool {
+	tenantID, ok := CredentialTenant(c.Request.Context(), GetRequestCredential(c))
+	return !ok || tenantID == TenantFromContext(c)
+}
+
+// CredentialTenant 返回令牌中的租户：OIDC模式下为ID令牌的 tenant_id 声明，否则为JWT令牌签发时的租户；
+// 凭证不是令牌（访问密钥或API密钥）时返回false，供HTTP中间件和gRPC拦截器共用
+func CredentialTenant(ctx context.Context, credential string) (string, bool) {
+	if CurrentConfig().AuthDriver == AuthDriverOIDC {
+		if OIDC == nil || (APIKeys != nil && APIKeys.Validate(credential)) {
+			return "", false
+		}
+		_, tenantID, err := OIDC.VerifyToken(ctx, credential)
+		if err != nil {
+			return "", false
 		}
-		
-		// 3. 从Cookie获取
-		if secretKey == "" {
-			if cookie, err := c.Cookie("secret_key"); err == nil {
-				secretKey = cookie
+		return tenantID, true
+	}
+
+	claims, err := ValidateToken(credential)
+	if err != nil {
+		return "", false
+	}
+	return claims.TenantID, true
+}
+
+// GetRequestCredential 获取请求携带的凭证，支持多种方式
+func GetRequestCredential(c *gin.Context) string {
+	// 1. 从Authorization头获取Bearer令牌
+	if authHeader := c.GetHeader("Authorization"); strings.HasPrefix(authHeader, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer "))
+	}
+
+	// 2. 从请求头获取
+	if secretKey := c.GetHeader("X-Secret-Key"); secretKey != "" {
+		return secretKey
+	}
+
+	// 3. 从查询参数获取
+	if secretKey := c.Query("secret_key"); secretKey != "" {
+		return secretKey
+	}
+
+	// 4. 从Cookie获取
+	if cookie, err := c.Cookie("secret_key"); err == nil {
+		return cookie
+	}
+
+	// 5. 从WebSocket子协议获取，浏览器建立WebSocket连接时不能设置请求头
+	if credential := websocketProtocolCredential(c.Request); credential != "" {
+		return credential
+	}
+
+	return ""
+}
+
+// WebSocketSubprotocol WebSocket子协议名，客户端以 Sec-WebSocket-Protocol: go-uploader, <凭证> 传递凭证
+const WebSocketSubprotocol = "go-uploader"
+
+// websocketProtocolCredential 读取 Sec-WebSocket-Protocol 中子协议名之外的值作为凭证
+func websocketProtocolCredential(r *http.Request) string {
+	for _, header := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for _, protocol := range strings.Split(header, ",") {
+			if protocol = strings.TrimSpace(protocol); protocol != "" && protocol != WebSocketSubprotocol {
+				return protocol
 			}
 		}
+	}
+	return ""
+}
+
+// ValidateCredential 验证凭证，接受JWT令牌、API密钥或原始密钥（向后兼容）
+func ValidateCredential(credential string) bool {
+	if credential == "" {
+		return false
+	}
+	if CurrentConfig().AuthDriver == AuthDriverOIDC {
+		return validateOIDCCredential(context.Background(), credential) == nil
+	}
+	if CurrentConfig().SecretKey != "" && credential == CurrentConfig().SecretKey {
+		return true
+	}
+	if APIKeys != nil && APIKeys.Validate(credential) {
+		return true
+	}
+	_, err := ValidateToken(credential)
+	return err == nil
+}
+
+// AdminAuthMiddleware 管理接口验证中间件，要求提供管理员密钥
+func AdminAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if CurrentConfig().AdminSecretKey == "" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "管理接口未启用",
+				"message": "请在配置中设置 admin_secret_key",
+				"code":    403,
+			})
+			c.Abort()
+			return
+		}
+
+		credential := c.GetHeader("X-Admin-Key")
+		if authHeader := c.GetHeader("Authorization"); credential == "" && strings.HasPrefix(authHeader, "Bearer ") {
+			credential = strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer "))
+		}
 
-		// 验证密钥
-		if secretKey == "" || secretKey != Config.SecretKey {
+		if subtle.ConstantTimeCompare([]byte(credential), []byte(CurrentConfig().AdminSecretKey)) != 1 {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error":   "未授权访问",
-				"message": "请提供有效的访问密钥",
+				"message": "请提供有效的管理员密钥",
 				"code":    401,
 			})
 			c.Abort()
 			return
 		}
 
-		// 验证通过，继续处理请求
 		c.Next()
 	}
 }
 
+// authenticateOIDCRequest 校验请求中的OIDC令牌，返回令牌主体；API密钥同样有效但没有主体
+func authenticateOIDCRequest(c *gin.Context) (string, bool) {
+	return AuthenticateCredential(c.Request.Context(), GetRequestCredential(c))
+}
+
+// AuthenticateCredential 按认证驱动校验凭证，OIDC令牌返回其主体，供HTTP中间件和gRPC拦截器共用
+func AuthenticateCredential(ctx context.Context, credential string) (string, bool) {
+	if credential == "" {
+		return "", false
+	}
+	if CurrentConfig().AuthDriver != AuthDriverOIDC {
+		return "", ValidateCredential(credential)
+	}
+	if APIKeys != nil && APIKeys.Validate(credential) {
+		return "", true
+	}
+	if OIDC == nil {
+		return "", false
+	}
+	subject, _, err := OIDC.VerifyToken(ctx, credential)
+	if err != nil {
+		return "", false
+	}
+	return subject, true
+}
+
+// validateOIDCCredential OIDC模式下校验凭证（ID令牌或API密钥）
+func validateOIDCCredential(ctx context.Context, credential string) error {
+	if APIKeys != nil && APIKeys.Validate(credential) {
+		return nil
+	}
+	if OIDC == nil {
+		return fmt.Errorf("OIDC认证未初始化")
+	}
+	_, _, err := OIDC.VerifyToken(ctx, credential)
+	return err
+}
+
+// IsRequestAuthenticated 检查请求是否携带有效凭证
+func IsRequestAuthenticated(c *gin.Context) bool {
+	if !CurrentConfig().EnableAuth {
+		return true
+	}
+	return ValidateCredential(GetRequestCredential(c))
+}
+
 // ValidateSecretKey 验证密钥是否有效
 func ValidateSecretKey(key string) bool {
-	if !Config.EnableAuth {
+	if !CurrentConfig().EnableAuth {
 		return true
 	}
-	return key == Config.SecretKey
+	return key == CurrentConfig().SecretKey
+}
+
+// SetAuthCookie 设置认证Cookie（值为JWT令牌）
+func SetAuthCookie(c *gin.Context, token string) {
+	SetAuthCookieWithTTL(c, token, int(CurrentConfig().JWTTokenTTL))
 }
 
-// SetAuthCookie 设置认证Cookie
-func SetAuthCookie(c *gin.Context, secretKey string) {
-	c.SetCookie("secret_key", secretKey, 24*60*60, "/go-uploader", "", false, true) // 24小时过期
+// SetAuthCookieWithTTL 设置指定有效期（秒）的认证Cookie
+func SetAuthCookieWithTTL(c *gin.Context, token string, maxAge int) {
+	c.SetCookie("secret_key", token, maxAge, "/go-uploader", "", false, true)
 }
 
 // ClearAuthCookie 清除认证Cookie
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newTestOIDCProvider 创建使用固定RSA公钥校验ID令牌的OIDC提供方，返回签发ID令牌的函数
+func newTestOIDCProvider(t *testing.T) (*OIDCProvider, func(claims jwt.MapClaims) string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const issuer, clientID = "https://idp.example.com", "go-uploader"
+	keySet := &oidc.StaticKeySet{PublicKeys: []crypto.PublicKey{&key.PublicKey}}
+	provider := &OIDCProvider{verifier: oidc.NewVerifier(issuer, keySet, &oidc.Config{ClientID: clientID})}
+
+	sign := func(claims jwt.MapClaims) string {
+		claims["iss"] = issuer
+		claims["aud"] = clientID
+		claims["exp"] = time.Now().Add(time.Minute).Unix()
+		token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	return provider, sign
+}
+
+func TestAuthMiddlewareEnforcesOIDCTenantClaim(t *testing.T) {
+	saved, savedOIDC, savedAPIKeys := Config, OIDC, APIKeys
+	defer func() { Config, OIDC, APIKeys = saved, savedOIDC, savedAPIKeys }()
+	Config.EnableAuth = true
+	Config.EnforceTenantClaim = true
+	Config.AuthDriver = AuthDriverOIDC
+	APIKeys = nil
+
+	provider, sign := newTestOIDCProvider(t)
+	OIDC = provider
+	tenantToken := sign(jwt.MapClaims{"sub": "alice", "tenant_id": "tenant-a"})
+	plainToken := sign(jwt.MapClaims{"sub": "bob"})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(TenantMiddleware(), AuthMiddleware())
+	r.GET("/tasks", func(c *gin.Context) { c.String(http.StatusOK, c.GetString(AuthSubjectKey)) })
+
+	tests := []struct {
+		name     string
+		token    string
+		tenantID string
+		want     int
+	}{
+		{"令牌租户一致", tenantToken, "tenant-a", http.StatusOK},
+		{"令牌租户不一致", tenantToken, "tenant-b", http.StatusForbidden},
+		{"令牌有租户但请求未指定", tenantToken, "", http.StatusForbidden},
+		{"令牌无租户时不能指定租户", plainToken, "tenant-a", http.StatusForbidden},
+		{"令牌无租户且请求未指定", plainToken, "", http.StatusOK},
+		{"无效的令牌", "invalid", "tenant-a", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		req.Header.Set("Authorization", "Bearer "+tt.token)
+		if tt.tenantID != "" {
+			req.Header.Set(TenantHeader, tt.tenantID)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Fatalf("%s: status = %d, want %d (%s)", tt.name, w.Code, tt.want, w.Body.String())
+		}
+	}
+
+	// 未开启 enforce_tenant_claim 时不校验租户
+	Config.EnforceTenantClaim = false
+	req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+	req.Header.Set("Authorization", "Bearer "+tenantToken)
+	req.Header.Set(TenantHeader, "tenant-b")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "alice" {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+}
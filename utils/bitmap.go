@@ -0,0 +1,57 @@
+package utils
+
+// IsChunkUploaded 检查分片位图中是否已标记该分片
+func (t *UploadTask) IsChunkUploaded(index int) bool {
+	if index < 0 || index/8 >= len(t.UploadedChunks) {
+		return false
+	}
+	return t.UploadedChunks[index/8]&(1<<uint(index%8)) != 0
+}
+
+// markChunkUploaded 在分片位图中标记分片已上传
+func (t *UploadTask) markChunkUploaded(index int) {
+	if index < 0 {
+		return
+	}
+	if need := index/8 + 1; need > len(t.UploadedChunks) {
+		bitmap := make([]byte, need)
+		copy(bitmap, t.UploadedChunks)
+		t.UploadedChunks = bitmap
+	}
+	t.UploadedChunks[index/8] |= 1 << uint(index%8)
+}
+
+// clearChunkUploaded 清除分片位图中的标记
+func (t *UploadTask) clearChunkUploaded(index int) {
+	if index < 0 || index/8 >= len(t.UploadedChunks) {
+		return
+	}
+	t.UploadedChunks[index/8] &^= 1 << uint(index%8)
+}
+
+// rebuildChunkBitmap 根据分片记录重建位图（兼容没有位图的旧任务）
+func (t *UploadTask) rebuildChunkBitmap() {
+	t.UploadedChunks = nil
+	for index, chunk := range t.Chunks {
+		if chunk.Status == "completed" {
+			t.markChunkUploaded(index)
+		}
+	}
+}
+
+// missingChunks 扫描分片位图，返回 0..TotalChunks-1 中尚未上传的分片索引
+func (t *UploadTask) missingChunks() []int {
+	missing := make([]int, 0)
+	for base := 0; base < t.TotalChunks; base += 8 {
+		// 整字节已满时直接跳过
+		if byteIndex := base / 8; byteIndex < len(t.UploadedChunks) && t.UploadedChunks[byteIndex] == 0xFF {
+			continue
+		}
+		for index := base; index < base+8 && index < t.TotalChunks; index++ {
+			if !t.IsChunkUploaded(index) {
+				missing = append(missing, index)
+			}
+		}
+	}
+	return missing
+}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+)
+
+// multipartOverhead 分片上传请求中 multipart 边界和表单字段的额外开销
+const multipartOverhead = 1 << 20
+
+// ChunkBodyLimit 分片上传请求体的上限
+func ChunkBodyLimit() int64 {
+	return CurrentConfig().MaxChunkSize + multipartOverhead
+}
+
+// BodyLimitMiddleware 按路由限制请求体大小：chunkRoutes 使用 ChunkBodyLimit，unlimitedRoutes 不限制，
+// 其余路由使用 CurrentConfig().MaxRequestBodySize。超出上限时在处理函数执行前返回413
+func BodyLimitMiddleware(chunkRoutes, unlimitedRoutes []string) gin.HandlerFunc {
+	chunk := make(map[string]bool, len(chunkRoutes))
+	for _, route := range chunkRoutes {
+		chunk[route] = true
+	}
+	unlimited := make(map[string]bool, len(unlimitedRoutes))
+	for _, route := range unlimitedRoutes {
+		unlimited[route] = true
+	}
+
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if c.Request.Body == nil || c.Request.ContentLength == 0 || unlimited[route] {
+			c.Next()
+			return
+		}
+
+		limit := CurrentConfig().MaxRequestBodySize
+		if chunk[route] {
+			limit = ChunkBodyLimit()
+		}
+		if limit <= 0 {
+			c.Next()
+			return
+		}
+
+		// 声明的长度超出上限时无需读取请求体
+		if c.Request.ContentLength > limit {
+			abortBodyTooLarge(c, limit)
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+
+		// 未声明长度的请求体只有读取时才知道是否超限：分片请求由处理函数流式读取并返回413，其余请求读入内存
+		if c.Request.ContentLength < 0 && !chunk[route] {
+			data, err := io.ReadAll(c.Request.Body)
+			c.Request.Body = io.NopCloser(bytes.NewReader(data))
+
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				abortBodyTooLarge(c, limit)
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// abortBodyTooLarge 返回413并终止请求
+func abortBodyTooLarge(c *gin.Context, limit int64) {
+	c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+		"error": fmt.Sprintf("请求体超出大小限制: %d 字节", limit),
+		"limit": limit,
+	})
+	c.Abort()
+}
@@ -0,0 +1,238 @@
+package utils
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// LRUTaskCache 位于持久化后端之前的任务缓存，按最近使用淘汰；
+// 写入只更新缓存并记录任务快照，由后台写回协程定期批量持久化，淘汰未写回的任务时立即持久化
+type LRUTaskCache struct {
+	backend  StorageBackend
+	capacity int
+	interval time.Duration
+
+	mutex sync.Mutex
+	order *list.List               // 最近使用的任务在前
+	items map[string]*list.Element // 任务ID到链表节点
+	dirty map[string]*UploadTask   // 尚未持久化的任务快照
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewLRUTaskCache 创建任务缓存并启动写回协程
+func NewLRUTaskCache(backend StorageBackend, capacity int, interval time.Duration) *LRUTaskCache {
+	c := &LRUTaskCache{
+		backend:  backend,
+		capacity: capacity,
+		interval: interval,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+		dirty:    make(map[string]*UploadTask),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go c.writeBehind()
+	return c
+}
+
+// SaveTask 更新缓存并等待写回；调用方可能在锁外继续修改任务，
+// 因此写回的是调用时的快照，与直接写入后端时序列化的内容一致
+func (c *LRUTaskCache) SaveTask(task *UploadTask) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.dirty[task.FileID] = snapshotTask(task)
+	return c.putInternal(task)
+}
+
+// GetTask 优先从缓存读取，未命中时从后端加载并放入缓存
+func (c *LRUTaskCache) GetTask(fileID string) (*UploadTask, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, exists := c.items[fileID]; exists {
+		c.order.MoveToFront(element)
+		return element.Value.(*UploadTask), true
+	}
+
+	task, exists := c.backend.GetTask(fileID)
+	if !exists {
+		return nil, false
+	}
+	if err := c.putInternal(task); err != nil {
+		Logger.Error("写回被淘汰的任务失败", "error", err)
+	}
+	return task, true
+}
+
+// DeleteTask 从缓存和后端删除任务，未写回的修改一并丢弃
+func (c *LRUTaskCache) DeleteTask(fileID string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, exists := c.items[fileID]; exists {
+		c.order.Remove(element)
+		delete(c.items, fileID)
+	}
+	delete(c.dirty, fileID)
+	return c.backend.DeleteTask(fileID)
+}
+
+// GetAllTasks 获取所有任务，缓存中的任务比后端更新，优先返回
+func (c *LRUTaskCache) GetAllTasks() map[string]*UploadTask {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	tasks := c.backend.GetAllTasks()
+	for fileID, element := range c.items {
+		tasks[fileID] = element.Value.(*UploadTask)
+	}
+	return tasks
+}
+
+// Close 停止写回协程，持久化所有未写回的任务后关闭后端
+func (c *LRUTaskCache) Close() error {
+	close(c.stop)
+	<-c.done
+
+	err := c.Flush()
+	if closeErr := c.backend.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Checkpoint 写回所有未写回的任务后由底层后端写入检查点
+func (c *LRUTaskCache) Checkpoint() error {
+	if err := c.Flush(); err != nil {
+		return err
+	}
+	if cp, ok := c.backend.(checkpointer); ok {
+		return cp.Checkpoint()
+	}
+	return nil
+}
+
+// planCompaction 写回所有未写回的任务后由底层后端生成元数据压缩计划
+func (c *LRUTaskCache) planCompaction() (*compactionPlan, error) {
+	compactor, ok := c.backend.(metadataCompactor)
+	if !ok {
+		return nil, ErrCompactionUnsupported
+	}
+	if err := c.Flush(); err != nil {
+		return nil, err
+	}
+	return compactor.planCompaction()
+}
+
+// applyCompaction 由底层后端执行元数据压缩
+func (c *LRUTaskCache) applyCompaction(plan *compactionPlan, dryRun bool) (*CompactionResult, error) {
+	compactor, ok := c.backend.(metadataCompactor)
+	if !ok {
+		return nil, ErrCompactionUnsupported
+	}
+	return compactor.applyCompaction(plan, dryRun)
+}
+
+// FileIDCollisions 返回底层后端检测到的安全文件名冲突
+func (c *LRUTaskCache) FileIDCollisions() []FileIDCollision {
+	if reporter, ok := c.backend.(collisionReporter); ok {
+		return reporter.FileIDCollisions()
+	}
+	return []FileIDCollision{}
+}
+
+// Flush 立即持久化所有未写回的任务
+func (c *LRUTaskCache) Flush() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.flushInternal()
+}
+
+// putInternal 放入缓存并淘汰超出容量的最久未使用任务，调用方需持有锁
+func (c *LRUTaskCache) putInternal(task *UploadTask) error {
+	if element, exists := c.items[task.FileID]; exists {
+		element.Value = task
+		c.order.MoveToFront(element)
+		return nil
+	}
+	c.items[task.FileID] = c.order.PushFront(task)
+
+	var firstErr error
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		evictedID := oldest.Value.(*UploadTask).FileID
+		c.order.Remove(oldest)
+		delete(c.items, evictedID)
+
+		// 被淘汰的任务不再留在内存中，未写回的修改必须立即持久化
+		if snapshot, isDirty := c.dirty[evictedID]; isDirty {
+			delete(c.dirty, evictedID)
+			if err := c.backend.SaveTask(snapshot); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// flushInternal 持久化所有未写回的任务，失败的任务保留到下一轮重试，调用方需持有锁
+func (c *LRUTaskCache) flushInternal() error {
+	var firstErr error
+	for fileID, task := range c.dirty {
+		if err := c.backend.SaveTask(task); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		delete(c.dirty, fileID)
+	}
+	return firstErr
+}
+
+// writeBehind 每隔 interval 写回一次脏任务，直到缓存关闭
+func (c *LRUTaskCache) writeBehind() {
+	defer close(c.done)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			if err := c.Flush(); err != nil {
+				Logger.Error("写回任务缓存失败", "error", err)
+			}
+		}
+	}
+}
+
+// snapshotTask 复制任务及其引用的集合，写回时不受调用方后续修改的影响
+func snapshotTask(task *UploadTask) *UploadTask {
+	snapshot := *task
+	snapshot.Chunks = make(map[int]ChunkInfo, len(task.Chunks))
+	for index, chunk := range task.Chunks {
+		snapshot.Chunks[index] = chunk
+	}
+	snapshot.UploadedChunks = append([]byte(nil), task.UploadedChunks...)
+	if task.SubTasks != nil {
+		snapshot.SubTasks = append(make([]string, 0, len(task.SubTasks)), task.SubTasks...)
+	}
+	snapshot.NestedSubFolders = append([]string(nil), task.NestedSubFolders...)
+	snapshot.DependsOn = append([]string(nil), task.DependsOn...)
+	snapshot.PreviousVersions = append([]string(nil), task.PreviousVersions...)
+	if task.Tags != nil {
+		snapshot.Tags = MergeTags(nil, task.Tags)
+	}
+	if task.EstimatedCompletionAt != nil {
+		estimated := *task.EstimatedCompletionAt
+		snapshot.EstimatedCompletionAt = &estimated
+	}
+	return &snapshot
+}
@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestTask 创建 totalChunks 个分片的待上传任务
+func newTestTask(fileID string, totalChunks int) *UploadTask {
+	return &UploadTask{
+		FileID:      fileID,
+		TaskType:    "file",
+		FileName:    fileID + ".bin",
+		TotalChunks: totalChunks,
+		Status:      "pending",
+		Chunks:      make(map[int]ChunkInfo),
+		CreatedAt:   time.Now(),
+	}
+}
+
+func TestLRUTaskCacheConcurrentPersistence(t *testing.T) {
+	const tasks, chunks, chunkSize = 8, 40, 1024
+	dir := t.TempDir()
+
+	backend, err := NewFileBackend(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 容量小于任务数，写入期间会不断淘汰并立即写回未持久化的任务
+	cache := NewLRUTaskCache(backend, 3, 2*time.Millisecond)
+	storage := &TaskStorage{storageDir: dir, backend: cache}
+
+	for i := 0; i < tasks; i++ {
+		if err := storage.SaveTask(newTestTask(fmt.Sprintf("task-%d", i), chunks)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var writers sync.WaitGroup
+	for i := 0; i < tasks; i++ {
+		fileID := fmt.Sprintf("task-%d", i)
+		// 每个任务由4个协程交错上传分片
+		for w := 0; w < 4; w++ {
+			writers.Add(1)
+			go func(fileID string, w int) {
+				defer writers.Done()
+				for index := w; index < chunks; index += 4 {
+					info := ChunkInfo{Index: index, Size: chunkSize, Status: "completed"}
+					if err := storage.UpdateChunk(fileID, index, info); err != nil {
+						t.Error(err)
+						return
+					}
+				}
+			}(fileID, w)
+		}
+	}
+
+	stop := make(chan struct{})
+	var readers sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		readers.Add(1)
+		go func(r int) {
+			defer readers.Done()
+			for n := 0; ; n++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				fileID := fmt.Sprintf("task-%d", (n+r)%tasks)
+				if _, exists := storage.GetTask(fileID); !exists {
+					t.Errorf("任务丢失: %s", fileID)
+					return
+				}
+				if uploaded := storage.GetUploadedChunks(fileID); len(uploaded) > chunks {
+					t.Errorf("%s: 已上传分片数 %d 超过总数", fileID, len(uploaded))
+					return
+				}
+			}
+		}(r)
+	}
+
+	writers.Wait()
+	close(stop)
+	readers.Wait()
+
+	if err := cache.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新从磁盘加载，确认写回的状态完整
+	reloaded, err := NewFileBackend(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reloaded.Close()
+
+	for i := 0; i < tasks; i++ {
+		fileID := fmt.Sprintf("task-%d", i)
+		task, exists := reloaded.GetTask(fileID)
+		if !exists {
+			t.Fatalf("%s 未持久化", fileID)
+		}
+		if len(task.Chunks) != chunks {
+			t.Fatalf("%s: 持久化的分片数 = %d, want %d", fileID, len(task.Chunks), chunks)
+		}
+		for index := 0; index < chunks; index++ {
+			if !task.IsChunkUploaded(index) {
+				t.Fatalf("%s: 分片 %d 未标记为已上传", fileID, index)
+			}
+		}
+		if task.CurrentBytes != chunks*chunkSize {
+			t.Fatalf("%s: current_bytes = %d, want %d", fileID, task.CurrentBytes, chunks*chunkSize)
+		}
+		if task.Status != "completed" {
+			t.Fatalf("%s: status = %s, want completed", fileID, task.Status)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// CASObject 内容寻址存储中的分片对象
+type CASObject struct {
+	Size     int64 `json:"size"`      // 对象在磁盘上的大小
+	RefCount int   `json:"ref_count"` // 链接到该对象的分片数
+}
+
+// CASStats 内容寻址存储统计信息
+type CASStats struct {
+	Objects      int   `json:"objects"`
+	References   int   `json:"references"`
+	StoredBytes  int64 `json:"stored_bytes"`  // 实际占用的磁盘空间
+	LogicalBytes int64 `json:"logical_bytes"` // 不去重时需要的磁盘空间
+	SavedBytes   int64 `json:"saved_bytes"`
+}
+
+// casLogFileName 引用计数变更日志文件名，位于存储根目录
+const casLogFileName = "index.wal"
+
+// casLogMaxSize 变更日志超过该大小时写入索引快照并清空日志
+const casLogMaxSize = 4 << 20
+
+// CASStore 按分片内容SHA-256寻址的存储，相同内容的分片通过硬链接共享数据；
+// 引用计数的变更追加到日志，只在日志过大或关闭时重写完整索引
+type CASStore struct {
+	root      string
+	indexPath string
+	mutex     sync.Mutex
+	objects   map[string]*CASObject
+	log       *WriteAheadLog
+}
+
+// CAS 全局内容寻址分片存储（未启用时为nil）
+var CAS *CASStore
+
+// NewCASStore 创建内容寻址存储，加载索引快照并回放变更日志
+func NewCASStore(root string) (*CASStore, error) {
+	if err := EnsureDirectory(root); err != nil {
+		return nil, fmt.Errorf("创建内容寻址存储目录失败: %v", err)
+	}
+
+	store := &CASStore{
+		root:      root,
+		indexPath: filepath.Join(root, "index.json"),
+		objects:   make(map[string]*CASObject),
+	}
+
+	data, err := os.ReadFile(store.indexPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("读取内容寻址索引失败: %v", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &store.objects); err != nil {
+			return nil, fmt.Errorf("解析内容寻址索引失败: %v", err)
+		}
+	}
+
+	log, err := OpenWriteAheadLog(filepath.Join(root, casLogFileName))
+	if err != nil {
+		return nil, err
+	}
+	// 日志记录的 TaskID 为对象键，save 记录的内容为对象的最新状态
+	if _, err := log.Replay(func(entry WALEntry) error {
+		if entry.Op == WALOpDelete {
+			delete(store.objects, entry.TaskID)
+			return nil
+		}
+		var object CASObject
+		if err := json.Unmarshal(entry.Payload, &object); err != nil {
+			return fmt.Errorf("解析内容寻址日志失败: %v", err)
+		}
+		store.objects[entry.TaskID] = &object
+		return nil
+	}); err != nil {
+		log.Close()
+		return nil, err
+	}
+	store.log = log
+	return store, nil
+}
+
+// CASKey 计算分片内容的寻址键，压缩和加密格式的分片分开存放
+func CASKey(r io.Reader, compressed, encrypted bool) (string, error) {
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, r); err != nil {
+		return "", fmt.Errorf("计算分片SHA-256失败: %v", err)
+	}
+
+	key := hex.EncodeToString(hasher.Sum(nil))
+	if compressed {
+		key += CompressedChunkSuffix
+	}
+	if encrypted {
+		key += EncryptedChunkSuffix
+	}
+	return key, nil
+}
+
+// objectPath 对象路径：<root>/<key前两位>/<key>
+func (s *CASStore) objectPath(key string) string {
+	return filepath.Join(s.root, key[:2], key)
+}
+
+// LinkExisting 对象已存在时在分片路径创建硬链接并增加引用计数，返回是否复用成功
+func (s *CASStore) LinkExisting(key, chunkPath string) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	object, exists := s.objects[key]
+	if !exists {
+		return false, nil
+	}
+	objectPath := s.objectPath(key)
+	if _, err := os.Stat(objectPath); err != nil {
+		// 对象文件丢失，丢弃索引记录
+		delete(s.objects, key)
+		return false, s.recordInternal(key)
+	}
+
+	if err := linkReplace(objectPath, chunkPath); err != nil {
+		return false, err
+	}
+
+	object.RefCount++
+	return true, s.recordInternal(key)
+}
+
+// Adopt 将新写入的分片登记为内容对象，对象已存在时改为链接到已有对象
+func (s *CASStore) Adopt(key, chunkPath string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	objectPath := s.objectPath(key)
+	if object, exists := s.objects[key]; exists {
+		if _, err := os.Stat(objectPath); err == nil {
+			if err := linkReplace(objectPath, chunkPath); err != nil {
+				return err
+			}
+			object.RefCount++
+			return s.recordInternal(key)
+		}
+	}
+
+	info, err := os.Stat(chunkPath)
+	if err != nil {
+		return fmt.Errorf("读取分片文件失败: %v", err)
+	}
+	if err := EnsureDirectory(filepath.Dir(objectPath)); err != nil {
+		return fmt.Errorf("创建内容对象目录失败: %v", err)
+	}
+	if err := linkReplace(chunkPath, objectPath); err != nil {
+		return err
+	}
+
+	s.objects[key] = &CASObject{Size: info.Size(), RefCount: 1}
+	return s.recordInternal(key)
+}
+
+// Release 减少对象引用计数，计数归零时删除对象文件
+func (s *CASStore) Release(key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	object, exists := s.objects[key]
+	if !exists {
+		return nil
+	}
+
+	object.RefCount--
+	if object.RefCount <= 0 {
+		if err := os.Remove(s.objectPath(key)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("删除内容对象失败: %v", err)
+		}
+		delete(s.objects, key)
+	}
+	return s.recordInternal(key)
+}
+
+// Stats 统计对象数量和节省的空间
+func (s *CASStore) Stats() CASStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var stats CASStats
+	for _, object := range s.objects {
+		stats.Objects++
+		stats.References += object.RefCount
+		stats.StoredBytes += object.Size
+		stats.LogicalBytes += object.Size * int64(object.RefCount)
+	}
+	stats.SavedBytes = stats.LogicalBytes - stats.StoredBytes
+	return stats
+}
+
+// Close 写入索引快照并关闭变更日志，重复调用时不做任何操作
+func (s *CASStore) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.log == nil {
+		return nil
+	}
+	if err := s.checkpointInternal(); err != nil {
+		return err
+	}
+	err := s.log.Close()
+	s.log = nil
+	return err
+}
+
+// recordInternal 将对象的最新状态追加到变更日志，日志过大时写入快照，调用方需持有锁
+func (s *CASStore) recordInternal(key string) error {
+	if object, exists := s.objects[key]; exists {
+		payload, err := json.Marshal(object)
+		if err != nil {
+			return err
+		}
+		if err := s.log.Append(WALOpSave, key, payload); err != nil {
+			return err
+		}
+	} else if err := s.log.Append(WALOpDelete, key, nil); err != nil {
+		return err
+	}
+
+	if s.log.Size() > casLogMaxSize {
+		return s.checkpointInternal()
+	}
+	return nil
+}
+
+// checkpointInternal 原子写入完整的引用计数索引并清空变更日志，调用方需持有锁
+func (s *CASStore) checkpointInternal() error {
+	data, err := json.Marshal(s.objects)
+	if err != nil {
+		return err
+	}
+
+	writer, err := NewAtomicWriter(s.indexPath)
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(data); err != nil {
+		writer.Rollback()
+		return fmt.Errorf("写入内容寻址索引失败: %v", err)
+	}
+	if err := writer.Commit(); err != nil {
+		return err
+	}
+	return s.log.Reset()
+}
+
+// linkReplace 创建指向 src 的硬链接并原子替换 dst
+func linkReplace(src, dst string) error {
+	tmpPath := dst + ".link.tmp"
+	os.Remove(tmpPath)
+	if err := os.Link(src, tmpPath); err != nil {
+		return fmt.Errorf("创建硬链接失败: %v", err)
+	}
+	if err := os.Rename(tmpPath, dst); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("替换分片文件失败: %v", err)
+	}
+	return nil
+}
+
+// initCAS 根据配置初始化内容寻址分片存储
+func initCAS() error {
+	if !CurrentConfig().ContentAddressableChunks {
+		CAS = nil
+		return nil
+	}
+
+	store, err := NewCASStore(filepath.Join(CurrentConfig().UploadDir, ".cas"))
+	if err != nil {
+		return err
+	}
+	CAS = store
+	return nil
+}
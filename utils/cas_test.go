@@ -0,0 +1,209 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// casCorpus 生成测试语料：files 个文件，每个 chunks 个分片，约 sharedRatio 的分片取自公共分片池
+func casCorpus(seed int64, files, chunks, chunkSize int, sharedRatio float64) [][][]byte {
+	rng := rand.New(rand.NewSource(seed))
+	pool := make([][]byte, 8)
+	for i := range pool {
+		pool[i] = make([]byte, chunkSize)
+		rng.Read(pool[i])
+	}
+
+	corpus := make([][][]byte, files)
+	for f := range corpus {
+		corpus[f] = make([][]byte, chunks)
+		for c := range corpus[f] {
+			if rng.Float64() < sharedRatio {
+				corpus[f][c] = pool[rng.Intn(len(pool))]
+				continue
+			}
+			data := make([]byte, chunkSize)
+			rng.Read(data)
+			corpus[f][c] = data
+		}
+	}
+	return corpus
+}
+
+// storeCASCorpus 按分片上传的流程写入语料：对象已存在时链接，否则写入后登记为对象
+func storeCASCorpus(tb testing.TB, store *CASStore, dir string, corpus [][][]byte) {
+	tb.Helper()
+	for f, chunks := range corpus {
+		fileDir := filepath.Join(dir, fmt.Sprintf("file%d", f))
+		if err := os.MkdirAll(fileDir, 0755); err != nil {
+			tb.Fatal(err)
+		}
+		for c, data := range chunks {
+			chunkPath := filepath.Join(fileDir, ChunkFileName(c, false, false))
+			key, err := CASKey(bytes.NewReader(data), false, false)
+			if err != nil {
+				tb.Fatal(err)
+			}
+			linked, err := store.LinkExisting(key, chunkPath)
+			if err != nil {
+				tb.Fatal(err)
+			}
+			if linked {
+				continue
+			}
+			if err := os.WriteFile(chunkPath, data, 0644); err != nil {
+				tb.Fatal(err)
+			}
+			if err := store.Adopt(key, chunkPath); err != nil {
+				tb.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestCASStoreSharesIdenticalChunks(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewCASStore(filepath.Join(dir, ".cas"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	corpus := casCorpus(1, 10, 8, 1024, 0.3)
+	storeCASCorpus(t, store, dir, corpus)
+
+	stats := store.Stats()
+	if stats.References != 80 {
+		t.Fatalf("references = %d, want 80", stats.References)
+	}
+	if stats.SavedBytes <= 0 {
+		t.Fatalf("共享分片应节省空间: %+v", stats)
+	}
+
+	// 链接的分片内容与原数据一致
+	for f, chunks := range corpus {
+		for c, data := range chunks {
+			stored, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("file%d", f), ChunkFileName(c, false, false)))
+			if err != nil || !bytes.Equal(stored, data) {
+				t.Fatalf("file%d chunk %d 内容不一致: %v", f, c, err)
+			}
+		}
+	}
+}
+
+func TestCASStoreRestoresFromLog(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, ".cas")
+	store, err := NewCASStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storeCASCorpus(t, store, dir, casCorpus(2, 5, 8, 1024, 0.3))
+	want := store.Stats()
+
+	// 分片写入只追加变更日志，不重写完整索引
+	if _, err := os.Stat(filepath.Join(root, "index.json")); !os.IsNotExist(err) {
+		t.Fatalf("未关闭前不应写入索引快照: %v", err)
+	}
+
+	// 未关闭（进程崩溃）时从日志恢复引用计数
+	reopened, err := NewCASStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reopened.Stats(); got != want {
+		t.Fatalf("回放日志后 stats = %+v, want %+v", got, want)
+	}
+	reopened.Close()
+
+	// 关闭时写入快照并清空日志
+	if info, err := os.Stat(filepath.Join(root, casLogFileName)); err != nil || info.Size() != 0 {
+		t.Fatalf("关闭后日志应为空: %v", err)
+	}
+	restored, err := NewCASStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer restored.Close()
+	if got := restored.Stats(); got != want {
+		t.Fatalf("从快照加载后 stats = %+v, want %+v", got, want)
+	}
+}
+
+func TestUpdateChunkReleasesRelinkedCASKey(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewCASStore(filepath.Join(dir, ".cas"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	savedCAS := CAS
+	defer func() { CAS = savedCAS }()
+	CAS = store
+
+	storage := newTestFolderStorage(t)
+	if err := storage.SaveTask(&UploadTask{FileID: "task-1", TaskType: "file", TotalChunks: 1, Status: "uploading", Chunks: make(map[int]ChunkInfo)}); err != nil {
+		t.Fatal(err)
+	}
+
+	data := []byte("chunk data")
+	key, err := CASKey(bytes.NewReader(data), false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunkPath := filepath.Join(dir, "chunk_0")
+	if err := os.WriteFile(chunkPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Adopt(key, chunkPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.UpdateChunk("task-1", 0, ChunkInfo{Index: 0, Size: int64(len(data)), Status: "failed", CASKey: key}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重传的分片链接到同一对象，旧条目的引用应释放
+	for i := 0; i < 3; i++ {
+		if linked, err := store.LinkExisting(key, chunkPath); err != nil || !linked {
+			t.Fatalf("链接已有对象失败: %v", err)
+		}
+		if err := storage.UpdateChunk("task-1", 0, ChunkInfo{Index: 0, Size: int64(len(data)), Status: "completed", CASKey: key}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if stats := store.Stats(); stats.Objects != 1 || stats.References != 1 {
+		t.Fatalf("重新链接后引用计数应为1: %+v", stats)
+	}
+}
+
+// BenchmarkCASStorageSavings 约30%分片相同的语料上的写入耗时和节省的存储空间
+func BenchmarkCASStorageSavings(b *testing.B) {
+	corpus := casCorpus(1, 40, 16, 64*1024, 0.3)
+
+	var stats CASStats
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		dir := b.TempDir()
+		store, err := NewCASStore(filepath.Join(dir, ".cas"))
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+
+		storeCASCorpus(b, store, dir, corpus)
+		stats = store.Stats()
+
+		b.StopTimer()
+		store.Close()
+		b.StartTimer()
+	}
+
+	b.ReportMetric(float64(stats.LogicalBytes)/(1<<20), "logical_MB")
+	b.ReportMetric(float64(stats.StoredBytes)/(1<<20), "stored_MB")
+	b.ReportMetric(float64(stats.SavedBytes)/float64(stats.LogicalBytes)*100, "saved_%")
+}
@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+)
+
+// OutOfSequence 分片未按顺序上传时接口返回的错误码
+const OutOfSequence = "out_of_sequence"
+
+// ErrOutOfSequence 前一个分片尚未上传完成
+var ErrOutOfSequence = errors.New("分片未按顺序上传")
+
+// CheckChunkSequence 开启 CurrentConfig().RequireSequentialChunks 时检查前一个分片是否已完成，未完成时返回 ErrOutOfSequence；
+// 调用方需持有该任务的文件锁，保证同一任务的分片依次检查和写入
+func (s *TaskStorage) CheckChunkSequence(fileID string, index int) error {
+	if !CurrentConfig().RequireSequentialChunks || index <= 0 {
+		return nil
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	task, exists := s.backend.GetTask(fileID)
+	if !exists {
+		return fmt.Errorf("任务不存在: %s", fileID)
+	}
+	if chunk, exists := task.Chunks[index-1]; !exists || chunk.Status != "completed" {
+		return fmt.Errorf("%w: 分片 %d 尚未完成，收到分片 %d", ErrOutOfSequence, index-1, index)
+	}
+	return nil
+}
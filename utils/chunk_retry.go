@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ChunkStatusPermanentlyFailed 分片重试次数耗尽，需管理员重置后才能继续上传
+const ChunkStatusPermanentlyFailed = "permanently_failed"
+
+// FailureReasonRetryBudgetExhausted 存在重试次数耗尽的分片导致任务失败
+const FailureReasonRetryBudgetExhausted = "retry_budget_exhausted"
+
+// ErrRetryBudgetExhausted 任务存在重试次数耗尽的分片
+var ErrRetryBudgetExhausted = errors.New("分片重试次数已耗尽")
+
+// PermanentlyFailedChunks 返回重试次数耗尽的分片索引（升序）
+func (t *UploadTask) PermanentlyFailedChunks() []int {
+	indexes := make([]int, 0)
+	for index, chunk := range t.Chunks {
+		if chunk.Status == ChunkStatusPermanentlyFailed {
+			indexes = append(indexes, index)
+		}
+	}
+	sort.Ints(indexes)
+	return indexes
+}
+
+// IsChunkPermanentlyFailed 检查分片是否已耗尽重试次数
+func (t *UploadTask) IsChunkPermanentlyFailed(index int) bool {
+	chunk, exists := t.Chunks[index]
+	return exists && chunk.Status == ChunkStatusPermanentlyFailed
+}
+
+// applyChunkRetryBudget 沿用分片已有的重试次数，失败时累加，超过 MaxRetryCount 时标记为永久失败
+func applyChunkRetryBudget(previous ChunkInfo, chunkInfo *ChunkInfo) {
+	chunkInfo.RetryCount = previous.RetryCount
+	if chunkInfo.Status != "failed" {
+		return
+	}
+
+	chunkInfo.RetryCount++
+	if CurrentConfig().MaxRetryCount > 0 && chunkInfo.RetryCount >= CurrentConfig().MaxRetryCount {
+		chunkInfo.Status = ChunkStatusPermanentlyFailed
+	}
+}
+
+// ResetChunkRetry 重置单个分片的重试次数，永久失败的分片恢复为失败状态以便重新上传
+func (s *TaskStorage) ResetChunkRetry(fileID string, index int) (*UploadTask, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	task, exists := s.backend.GetTask(fileID)
+	if !exists {
+		return nil, fmt.Errorf("任务不存在: %s", fileID)
+	}
+	chunk, exists := task.Chunks[index]
+	if !exists {
+		return nil, fmt.Errorf("分片不存在: %d", index)
+	}
+
+	chunk.RetryCount = 0
+	if chunk.Status == ChunkStatusPermanentlyFailed {
+		chunk.Status = "failed"
+	}
+	task.Chunks[index] = chunk
+	task.UpdatedAt = time.Now()
+
+	if err := s.backend.SaveTask(task); err != nil {
+		return nil, err
+	}
+
+	Events.Publish(NewTaskEvent(task))
+	return task, nil
+}
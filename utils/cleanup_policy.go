@@ -0,0 +1,63 @@
+package utils
+
+import "time"
+
+// CleanupPolicy 过期任务清理规则，每条规则独立匹配
+type CleanupPolicy struct {
+	Status         string `json:"status"`              // 匹配的任务状态，为空时匹配所有状态
+	OlderThanHours int    `json:"older_than_hours"`    // 最后活动时间早于N小时前，0表示不限制
+	TaskType       string `json:"task_type,omitempty"` // 可选：只匹配 file 或 folder 任务
+	TenantID       string `json:"tenant_id,omitempty"` // 可选：只匹配该租户的任务
+}
+
+// valid 状态和时间都未限制的规则会匹配所有任务，视为无效
+func (p CleanupPolicy) valid() bool {
+	return (p.Status != "" || p.OlderThanHours > 0) && p.OlderThanHours >= 0
+}
+
+// Matches 检查任务是否符合规则，客户端上报的预计完成时间未到时不视为过期
+func (p CleanupPolicy) Matches(task *UploadTask, now time.Time) bool {
+	if p.Status != "" && task.Status != p.Status {
+		return false
+	}
+	if p.TaskType != "" && taskTypeOf(task) != p.TaskType {
+		return false
+	}
+	if !task.VisibleToTenant(p.TenantID) {
+		return false
+	}
+	if p.OlderThanHours > 0 && !task.isExpired(now.Add(-time.Duration(p.OlderThanHours)*time.Hour)) {
+		return false
+	}
+	return true
+}
+
+// taskTypeOf 早期版本创建的任务没有 task_type，视为单文件任务
+func taskTypeOf(task *UploadTask) string {
+	if task.TaskType == "" {
+		return "file"
+	}
+	return task.TaskType
+}
+
+// CleanedTask 被清理策略匹配的任务
+type CleanedTask struct {
+	FileID    string    `json:"file_id"`
+	FileName  string    `json:"filename"`
+	TaskType  string    `json:"task_type"`
+	Status    string    `json:"status"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Policy    int       `json:"policy"` // 匹配的策略下标
+}
+
+// newCleanedTask 记录被匹配的任务
+func newCleanedTask(task *UploadTask, policy int) CleanedTask {
+	return CleanedTask{
+		FileID:    task.FileID,
+		FileName:  task.FileName,
+		TaskType:  taskTypeOf(task),
+		Status:    task.Status,
+		UpdatedAt: task.UpdatedAt,
+		Policy:    policy,
+	}
+}
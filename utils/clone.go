@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrCloneSubTask 子任务不能单独克隆
+var ErrCloneSubTask = errors.New("子任务不能单独克隆，请克隆所属的文件夹任务")
+
+// CloneTask 以新的任务ID复制任务，保留元数据但清空分片和合并结果，原任务保持不变；
+// 文件夹任务连同子任务和嵌套文件夹一起复制
+func (s *TaskStorage) CloneTask(fileID string) (*UploadTask, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	original, exists := s.backend.GetTask(fileID)
+	if !exists {
+		return nil, fmt.Errorf("任务不存在: %s", fileID)
+	}
+	if original.IsSubTask {
+		return nil, ErrCloneSubTask
+	}
+
+	// 先生成所有新任务，全部成功后再保存，避免留下不完整的文件夹
+	now := time.Now()
+	idMapping := make(map[string]string)
+	clones := make([]*UploadTask, 0, 1)
+	root, err := s.cloneTaskInternal(original, "", now, idMapping, &clones)
+	if err != nil {
+		return nil, err
+	}
+
+	// 依赖同一文件夹中的其他文件时指向对应的新任务
+	for _, clone := range clones {
+		for i, dependency := range clone.DependsOn {
+			if clonedID, exists := idMapping[dependency]; exists {
+				clone.DependsOn[i] = clonedID
+			}
+		}
+	}
+
+	for _, clone := range clones {
+		if err := s.backend.SaveTask(clone); err != nil {
+			return nil, fmt.Errorf("保存克隆任务失败: %v", err)
+		}
+	}
+	return root, nil
+}
+
+// cloneTaskInternal 复制单个任务并递归复制文件夹内容，parentID 为新的父任务ID，调用方需持有锁
+func (s *TaskStorage) cloneTaskInternal(task *UploadTask, parentID string, now time.Time, idMapping map[string]string, clones *[]*UploadTask) (*UploadTask, error) {
+	if _, visited := idMapping[task.FileID]; visited {
+		return nil, fmt.Errorf("文件夹存在循环引用: %s", task.FileID)
+	}
+
+	clone := *task
+	clone.FileID = fmt.Sprintf("%s_clone_%d", task.FileID, now.UnixNano())
+	clone.ClonedFrom = task.FileID
+	clone.CreatedAt = now
+	clone.UpdatedAt = now
+	clone.RetryCount = 0
+	clone.FailureReason = ""
+	clone.EstimatedCompletionAt = nil
+	if task.Tags != nil {
+		clone.Tags = MergeTags(nil, task.Tags)
+	}
+	clone.DependsOn = append([]string(nil), task.DependsOn...)
+	clone.persistedStatus = ""
+
+	// 分片需重新上传
+	clone.Chunks = make(map[int]ChunkInfo)
+	clone.UploadedChunks = nil
+	clone.CurrentBytes = 0
+
+	// 新任务尚未合并
+	clone.FileMD5 = ""
+	clone.MergedPath = ""
+	clone.StorageURL = ""
+	clone.MergeTime = 0
+	clone.MergeResult = nil
+	clone.HashAlgorithm = ""
+	clone.VersionNumber = 0
+	clone.PreviousVersions = nil
+	clone.PendingCleanup = false
+
+	// 文件夹状态由子任务推进，与新建文件夹任务一样从 uploading 开始
+	clone.Status = "pending"
+	if task.TaskType == "folder" {
+		clone.Status = "uploading"
+	}
+	if parentID != "" {
+		clone.ParentTaskID = parentID
+	}
+
+	idMapping[task.FileID] = clone.FileID
+	*clones = append(*clones, &clone)
+
+	if task.TaskType != "folder" {
+		return &clone, nil
+	}
+
+	clone.SubTasks = make([]string, 0, len(task.SubTasks))
+	for _, subTaskID := range task.SubTasks {
+		subTask, exists := s.backend.GetTask(subTaskID)
+		if !exists {
+			continue
+		}
+		clonedSubTask, err := s.cloneTaskInternal(subTask, clone.FileID, now, idMapping, clones)
+		if err != nil {
+			return nil, err
+		}
+		clone.SubTasks = append(clone.SubTasks, clonedSubTask.FileID)
+	}
+
+	clone.NestedSubFolders = nil
+	for _, nestedID := range task.NestedSubFolders {
+		nested, exists := s.backend.GetTask(nestedID)
+		if !exists {
+			continue
+		}
+		clonedNested, err := s.cloneTaskInternal(nested, clone.FileID, now, idMapping, clones)
+		if err != nil {
+			return nil, err
+		}
+		clone.NestedSubFolders = append(clone.NestedSubFolders, clonedNested.FileID)
+	}
+	return &clone, nil
+}
@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// collisionHashLength 检测到冲突后安全文件名使用的哈希长度
+const collisionHashLength = 16
+
+// FileIDCollision 两个不同的任务ID生成了相同的安全文件名
+type FileIDCollision struct {
+	SafeName     string    `json:"safe_name"`     // 冲突的安全文件名
+	ExistingID   string    `json:"existing_id"`   // 先占用该文件名的任务ID
+	FileID       string    `json:"file_id"`       // 后出现的任务ID
+	ResolvedName string    `json:"resolved_name"` // 后出现的任务改用的文件名
+	DetectedAt   time.Time `json:"detected_at"`
+}
+
+// collisionReporter 能报告文件名冲突的存储后端
+type collisionReporter interface {
+	FileIDCollisions() []FileIDCollision
+}
+
+// CollisionRegistry 记录安全文件名与原始任务ID的对应关系，文件名已被其他任务占用时改用更长的哈希
+type CollisionRegistry struct {
+	mutex      sync.Mutex
+	names      map[string]string // 安全文件名 -> 原始任务ID
+	ids        map[string]string // 原始任务ID -> 安全文件名
+	collisions map[string]FileIDCollision
+}
+
+// NewCollisionRegistry 创建空的冲突登记表
+func NewCollisionRegistry() *CollisionRegistry {
+	return &CollisionRegistry{
+		names:      make(map[string]string),
+		ids:        make(map[string]string),
+		collisions: make(map[string]FileIDCollision),
+	}
+}
+
+// Register 登记已存在于磁盘上的文件名，加载任务时调用
+func (r *CollisionRegistry) Register(name, fileID string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.names[name] = fileID
+	r.ids[fileID] = name
+}
+
+// DetectRegistered 在所有文件登记后找出使用长哈希文件名的任务，恢复重启前检测到的冲突
+func (r *CollisionRegistry) DetectRegistered() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for fileID, name := range r.ids {
+		short := SanitizeFileID(fileID)
+		if name == short {
+			continue
+		}
+		if owner, exists := r.names[short]; exists && owner != fileID {
+			r.recordInternal(short, owner, fileID, name)
+		}
+	}
+}
+
+// Resolve 返回任务使用的安全文件名，默认文件名已属于其他任务时改用长哈希并记录冲突
+func (r *CollisionRegistry) Resolve(fileID string) string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if name, exists := r.ids[fileID]; exists {
+		return name
+	}
+
+	name := SanitizeFileID(fileID)
+	if owner, exists := r.names[name]; exists && owner != fileID {
+		resolved := sanitizeFileIDWithHash(fileID, collisionHashLength)
+		r.recordInternal(name, owner, fileID, resolved)
+		Logger.Warn("任务ID的安全文件名冲突，已改用更长的哈希", "safe_name", name, "existing_id", owner, "file_id", fileID, "resolved_name", resolved)
+		name = resolved
+	}
+
+	r.names[name] = fileID
+	r.ids[fileID] = name
+	return name
+}
+
+// Forget 任务删除后释放其文件名
+func (r *CollisionRegistry) Forget(fileID string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if name, exists := r.ids[fileID]; exists {
+		delete(r.names, name)
+		delete(r.ids, fileID)
+	}
+}
+
+// Collisions 返回检测到的所有冲突，按检测时间排序
+func (r *CollisionRegistry) Collisions() []FileIDCollision {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	collisions := make([]FileIDCollision, 0, len(r.collisions))
+	for _, collision := range r.collisions {
+		collisions = append(collisions, collision)
+	}
+	sort.Slice(collisions, func(i, j int) bool {
+		return collisions[i].DetectedAt.Before(collisions[j].DetectedAt)
+	})
+	return collisions
+}
+
+// recordInternal 记录一对冲突，同一对ID只记录一次，调用方需持有锁
+func (r *CollisionRegistry) recordInternal(name, existingID, fileID, resolved string) {
+	key := existingID + "\x00" + fileID
+	if _, exists := r.collisions[key]; exists {
+		return
+	}
+	r.collisions[key] = FileIDCollision{
+		SafeName:     name,
+		ExistingID:   existingID,
+		FileID:       fileID,
+		ResolvedName: resolved,
+		DetectedAt:   time.Now(),
+	}
+}
+
+// sanitizeFileIDWithHash 与 SanitizeFileID 相同，但使用指定长度的哈希后缀
+func sanitizeFileIDWithHash(fileID string, hashLength int) string {
+	hasher := md5.New()
+	hasher.Write([]byte(fileID))
+	hash := hex.EncodeToString(hasher.Sum(nil))
+
+	short := SanitizeFileID(fileID)
+	readablePart := strings.TrimSuffix(short, hash[:8])
+	return readablePart + hash[:hashLength]
+}
+
+// SafeFileKey 分片目录、锁文件和死信、归档条目按任务ID命名时使用的键，固定使用长哈希，
+// 不依赖任务文件的冲突登记表，任何存储后端下都能得到相同的结果
+func SafeFileKey(fileID string) string {
+	return sanitizeFileIDWithHash(fileID, collisionHashLength)
+}
+
+// legacySafePath 返回 dir 下以任务ID命名的路径；升级前以短哈希命名的文件仍存在而新路径不存在时返回旧路径，
+// 保证升级时正在上传的任务能找到已有的分片
+func legacySafePath(dir, fileID, suffix string) string {
+	path := filepath.Join(dir, SafeFileKey(fileID)+suffix)
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+	legacy := filepath.Join(dir, SanitizeFileID(fileID)+suffix)
+	if _, err := os.Stat(legacy); err == nil {
+		return legacy
+	}
+	return path
+}
+
+// ChunkDir 返回任务的分片目录
+func ChunkDir(fileID string) string {
+	return legacySafePath(CurrentConfig().UploadDir, fileID, "")
+}
+
+// FileIDCollisions 返回存储后端检测到的安全文件名冲突，不使用文件存储后端时为空
+func (s *TaskStorage) FileIDCollisions() []FileIDCollision {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if reporter, ok := s.backend.(collisionReporter); ok {
+		return reporter.FileIDCollisions()
+	}
+	return []FileIDCollision{}
+}
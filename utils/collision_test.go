@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// findShortHashCollision 找出两个生成相同短哈希安全文件名的任务ID，可读部分超过50个字符后只有哈希不同
+func findShortHashCollision(t *testing.T) (string, string) {
+	t.Helper()
+	prefix := strings.Repeat("p", 50)
+	seen := make(map[string]string)
+	for i := 0; i < 1<<22; i++ {
+		fileID := prefix + strconv.Itoa(i)
+		name := SanitizeFileID(fileID)
+		if other, exists := seen[name]; exists {
+			return other, fileID
+		}
+		seen[name] = fileID
+	}
+	t.Fatal("未找到短哈希冲突")
+	return "", ""
+}
+
+func TestChunkDirUsesLongHash(t *testing.T) {
+	saved := Config
+	defer func() { Config = saved }()
+	Config.UploadDir = t.TempDir()
+
+	first, second := findShortHashCollision(t)
+	if SafeFileKey(first) == SafeFileKey(second) {
+		t.Fatalf("长哈希不应冲突: %s %s", first, second)
+	}
+	if ChunkDir(first) == ChunkDir(second) {
+		t.Fatalf("短哈希冲突的任务不应共用分片目录: %s", ChunkDir(first))
+	}
+	if want := filepath.Join(Config.UploadDir, SafeFileKey(first)); ChunkDir(first) != want {
+		t.Fatalf("ChunkDir = %s, want %s", ChunkDir(first), want)
+	}
+}
+
+func TestChunkDirFallsBackToLegacyDir(t *testing.T) {
+	saved := Config
+	defer func() { Config = saved }()
+	Config.UploadDir = t.TempDir()
+
+	fileID := "folder/a.bin"
+	legacy := filepath.Join(Config.UploadDir, SanitizeFileID(fileID))
+	if err := os.Mkdir(legacy, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if ChunkDir(fileID) != legacy {
+		t.Fatalf("升级前创建的分片目录应继续使用: %s", ChunkDir(fileID))
+	}
+
+	long := filepath.Join(Config.UploadDir, SafeFileKey(fileID))
+	if err := os.Mkdir(long, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if ChunkDir(fileID) != long {
+		t.Fatalf("长哈希目录存在时应优先使用: %s", ChunkDir(fileID))
+	}
+}
+
+func TestDeadLetterQueueFindsLegacyEntry(t *testing.T) {
+	queue, err := NewDeadLetterQueue(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	task := &UploadTask{FileID: "folder/a.bin", Status: "failed"}
+	if _, err := queue.Add(task, "重试次数耗尽"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(queue.entryPath(task.FileID), filepath.Join(queue.dir, SanitizeFileID(task.FileID)+".json")); err != nil {
+		t.Fatal(err)
+	}
+	entry, err := queue.Get(task.FileID)
+	if err != nil || entry.Task.FileID != task.FileID {
+		t.Fatalf("应读取升级前以短哈希命名的条目: %v %v", entry, err)
+	}
+	if err := queue.Remove(task.FileID); err != nil || queue.Contains(task.FileID) {
+		t.Fatalf("旧条目应能删除: %v", err)
+	}
+}
@@ -0,0 +1,332 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ErrCompactionUnsupported 存储后端不使用任务元数据文件，无法压缩
+var ErrCompactionUnsupported = errors.New("当前存储后端不支持元数据压缩")
+
+// CompactionResult 任务元数据压缩结果，试运行时为预计的结果
+type CompactionResult struct {
+	Compacted      int   `json:"compacted"`       // 合并到父文件夹任务文件中的子任务文件数
+	Rewritten      int   `json:"rewritten"`       // 内容有变化而重写的任务文件数
+	RemovedOrphans int   `json:"removed_orphans"` // 删除的孤立任务文件数（内存中不存在该任务或文件名已过期）
+	BytesSaved     int64 `json:"bytes_saved"`     // 压缩前后任务文件总大小之差
+	Skipped        int   `json:"skipped"`         // 生成计划后任务又有更新而跳过的文件数
+	DryRun         bool  `json:"dry_run"`
+	DurationMs     int64 `json:"duration_ms"`
+}
+
+// metadataCompactor 支持元数据压缩的存储后端
+type metadataCompactor interface {
+	planCompaction() (*compactionPlan, error)
+	applyCompaction(plan *compactionPlan, dryRun bool) (*CompactionResult, error)
+}
+
+// taskFileRecord 任务文件的内容，压缩后文件夹任务的文件在 embedded_sub_tasks 中保存其子任务
+type taskFileRecord struct {
+	*UploadTask
+	EmbeddedSubTasks []*UploadTask `json:"embedded_sub_tasks,omitempty"`
+}
+
+// compactionPlan 按内存中的任务生成的目标文件内容
+type compactionPlan struct {
+	files     map[string][]byte   // 文件名 -> 新内容
+	members   map[string][]string // 文件名 -> 内容包含的任务ID，第一个为文件对应的任务
+	embedded  map[string]string   // 子任务ID -> 父文件夹任务ID
+	revisions map[string]uint64   // 任务ID -> 生成计划时的修改序号
+}
+
+// unchanged 生成计划后这些任务是否都没有更新或删除，调用方需持有后端的锁
+func (p *compactionPlan) unchanged(fb *FileBackend, fileIDs []string) bool {
+	for _, fileID := range fileIDs {
+		if _, alive := fb.tasks[fileID]; !alive || fb.revisions[fileID] != p.revisions[fileID] {
+			return false
+		}
+	}
+	return true
+}
+
+// existingTaskFile 元数据目录中已有的任务文件
+type existingTaskFile struct {
+	fileID string
+	data   []byte
+}
+
+// stagedTaskFile 已写入临时文件、等待重命名的任务文件
+type stagedTaskFile struct {
+	name     string
+	tempPath string
+	oldSize  int64
+	newSize  int64
+}
+
+// CompactMetadata 压缩元数据目录：删除孤立的任务文件，把文件夹的子任务合并到父任务文件，只重写内容有变化的文件。
+// 只在生成计划时持有读锁；新文件在锁外写入临时文件并同步到磁盘，后端持锁时只做重命名和删除
+func (s *TaskStorage) CompactMetadata(dryRun bool) (*CompactionResult, error) {
+	compactor, ok := s.backend.(metadataCompactor)
+	if !ok {
+		return nil, ErrCompactionUnsupported
+	}
+
+	start := time.Now()
+	s.mutex.RLock()
+	plan, err := compactor.planCompaction()
+	s.mutex.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := compactor.applyCompaction(plan, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	result.DurationMs = time.Since(start).Milliseconds()
+	Logger.Info("任务元数据压缩完成", "dry_run", dryRun, "compacted", result.Compacted, "rewritten", result.Rewritten,
+		"removed_orphans", result.RemovedOrphans, "bytes_saved", result.BytesSaved, "skipped", result.Skipped, "duration_ms", result.DurationMs)
+	return result, nil
+}
+
+// planCompaction 生成所有任务文件的目标内容，单文件子任务合并到父文件夹任务的文件中
+func (fb *FileBackend) planCompaction() (*compactionPlan, error) {
+	fb.mutex.RLock()
+	defer fb.mutex.RUnlock()
+
+	plan := &compactionPlan{
+		files:     make(map[string][]byte, len(fb.tasks)),
+		members:   make(map[string][]string, len(fb.tasks)),
+		embedded:  make(map[string]string),
+		revisions: make(map[string]uint64, len(fb.tasks)),
+	}
+
+	children := make(map[string][]*UploadTask)
+	for fileID, task := range fb.tasks {
+		plan.revisions[fileID] = fb.revisions[fileID]
+		if parent, exists := fb.tasks[task.ParentTaskID]; exists && embeddableSubTask(task, parent) {
+			children[parent.FileID] = append(children[parent.FileID], task)
+			plan.embedded[fileID] = parent.FileID
+		}
+	}
+
+	for fileID, task := range fb.tasks {
+		if _, embedded := plan.embedded[fileID]; embedded {
+			continue
+		}
+
+		subTasks := children[fileID]
+		sort.Slice(subTasks, func(i, j int) bool {
+			return subTasks[i].FileID < subTasks[j].FileID
+		})
+		data, err := marshalTaskRecord(&taskFileRecord{UploadTask: task, EmbeddedSubTasks: subTasks})
+		if err != nil {
+			return nil, fmt.Errorf("序列化任务失败 %s: %v", fileID, err)
+		}
+
+		name := fb.names.Resolve(fileID) + ".json"
+		members := []string{fileID}
+		for _, sub := range subTasks {
+			members = append(members, sub.FileID)
+		}
+		plan.files[name] = data
+		plan.members[name] = members
+	}
+	return plan, nil
+}
+
+// applyCompaction 在锁外读取现有文件并写入临时文件，持锁时确认相关任务在生成计划后没有更新，再重命名和删除
+func (fb *FileBackend) applyCompaction(plan *compactionPlan, dryRun bool) (*CompactionResult, error) {
+	existing, err := fb.readTaskFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CompactionResult{DryRun: dryRun}
+	staged := make([]stagedTaskFile, 0)
+	current := make(map[string]bool) // 内容已是目标内容的文件
+	defer func() {
+		for _, file := range staged {
+			if file.tempPath != "" {
+				os.Remove(file.tempPath)
+			}
+		}
+	}()
+
+	for name, data := range plan.files {
+		file, exists := existing[name]
+		if exists && bytes.Equal(file.data, data) {
+			current[name] = true
+			continue
+		}
+
+		stage := stagedTaskFile{name: name, oldSize: int64(len(file.data)), newSize: int64(len(data))}
+		if !dryRun {
+			tempPath, err := stageTaskFile(filepath.Join(fb.storageDir, name), data)
+			if err != nil {
+				return nil, err
+			}
+			stage.tempPath = tempPath
+		}
+		staged = append(staged, stage)
+	}
+
+	// 不在计划中的任务文件：已合并到父任务的子任务文件，或内存中不存在对应任务的孤立文件
+	leftovers := make([]string, 0)
+	for name := range existing {
+		if _, planned := plan.files[name]; !planned {
+			leftovers = append(leftovers, name)
+		}
+	}
+	sort.Strings(leftovers)
+
+	if dryRun {
+		for _, file := range staged {
+			result.Rewritten++
+			result.BytesSaved += file.oldSize - file.newSize
+		}
+		for _, name := range leftovers {
+			file := existing[name]
+			if _, embedded := plan.embedded[file.fileID]; embedded {
+				result.Compacted++
+			} else {
+				result.RemovedOrphans++
+			}
+			result.BytesSaved += int64(len(file.data))
+		}
+		return result, nil
+	}
+
+	fb.mutex.Lock()
+	defer fb.mutex.Unlock()
+
+	committed := make(map[string]bool) // 文件内容已包含计划中子任务的父任务ID
+	for name := range current {
+		members := plan.members[name]
+		if plan.unchanged(fb, members) {
+			committed[members[0]] = true
+			delete(fb.embedded, members[0])
+		}
+	}
+	for i, file := range staged {
+		members := plan.members[file.name]
+		if !plan.unchanged(fb, members) {
+			result.Skipped++
+			continue
+		}
+		if err := os.Rename(file.tempPath, filepath.Join(fb.storageDir, file.name)); err != nil {
+			Logger.Error("重命名压缩后的任务文件失败", "file", file.name, "error", err)
+			result.Skipped++
+			continue
+		}
+		staged[i].tempPath = ""
+		committed[members[0]] = true
+		delete(fb.embedded, members[0])
+		result.Rewritten++
+		result.BytesSaved += file.oldSize - file.newSize
+	}
+
+	// 父任务文件已写入合并内容的子任务，之后随父任务一起保存
+	for subID, parentID := range plan.embedded {
+		if committed[parentID] && plan.unchanged(fb, []string{subID}) {
+			fb.embedded[subID] = parentID
+		}
+	}
+
+	for _, name := range leftovers {
+		file := existing[name]
+		if parentID, embedded := fb.embedded[file.fileID]; embedded && plan.embedded[file.fileID] == parentID {
+			if err := os.Remove(filepath.Join(fb.storageDir, name)); err != nil && !os.IsNotExist(err) {
+				Logger.Warn("删除已合并的子任务文件失败", "file", name, "error", err)
+				continue
+			}
+			result.Compacted++
+			result.BytesSaved += int64(len(file.data))
+			continue
+		}
+
+		// 生成计划后新建的任务或尚未合并的子任务，文件仍在使用
+		if _, alive := fb.tasks[file.fileID]; alive && fb.names.Resolve(file.fileID)+".json" == name {
+			continue
+		}
+		if err := os.Remove(filepath.Join(fb.storageDir, name)); err != nil && !os.IsNotExist(err) {
+			Logger.Warn("删除孤立的任务文件失败", "file", name, "error", err)
+			continue
+		}
+		Logger.Debug("已删除孤立的任务文件", "file", name, "file_id", file.fileID)
+		result.RemovedOrphans++
+		result.BytesSaved += int64(len(file.data))
+	}
+	return result, nil
+}
+
+// readTaskFiles 读取元数据目录中的任务文件，跳过无法解析或非任务的JSON文件（如索引文件）
+func (fb *FileBackend) readTaskFiles() (map[string]existingTaskFile, error) {
+	entries, err := os.ReadDir(fb.storageDir)
+	if err != nil {
+		return nil, fmt.Errorf("读取元数据目录失败: %v", err)
+	}
+
+	files := make(map[string]existingTaskFile)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(fb.storageDir, entry.Name()))
+		if err != nil {
+			continue
+		}
+
+		var header struct {
+			FileID string `json:"file_id"`
+		}
+		if err := json.Unmarshal(data, &header); err != nil || header.FileID == "" {
+			continue
+		}
+		files[entry.Name()] = existingTaskFile{fileID: header.FileID, data: data}
+	}
+	return files, nil
+}
+
+// marshalTaskRecord 序列化任务文件内容，包含合并的子任务时不缩进以减小文件
+func marshalTaskRecord(record *taskFileRecord) ([]byte, error) {
+	if len(record.EmbeddedSubTasks) > 0 {
+		return json.Marshal(record)
+	}
+	return json.MarshalIndent(record, "", "  ")
+}
+
+// embeddableSubTask 单文件子任务可合并到父文件夹任务的文件中，子文件夹保留独立的文件
+func embeddableSubTask(task, parent *UploadTask) bool {
+	return task.IsSubTask && task.TaskType != "folder" && parent.TaskType == "folder"
+}
+
+// stageTaskFile 把内容写入目标文件同目录下的临时文件并同步到磁盘，返回临时文件路径
+func stageTaskFile(path string, data []byte) (string, error) {
+	tempPath := path + ".tmp." + fmt.Sprintf("%d", time.Now().UnixNano())
+	file, err := os.Create(tempPath)
+	if err != nil {
+		return "", fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		os.Remove(tempPath)
+		return "", fmt.Errorf("写入临时文件失败: %v", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		os.Remove(tempPath)
+		return "", fmt.Errorf("同步文件失败: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tempPath)
+		return "", fmt.Errorf("关闭文件失败: %v", err)
+	}
+	return tempPath, nil
+}
@@ -0,0 +1,168 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// CompressedChunkSuffix 压缩分片文件后缀
+const CompressedChunkSuffix = ".zst"
+
+// EncryptedChunkSuffix 加密分片文件后缀，读取时按后缀而不是当前的 enable_encryption 配置决定是否解密
+const EncryptedChunkSuffix = ".enc"
+
+var (
+	zstdEncoders sync.Map // 压缩级别 -> *zstd.Encoder
+	zstdDecoder  *zstd.Decoder
+	decoderOnce  sync.Once
+	decoderErr   error
+)
+
+// ChunkFileName 生成分片文件名，压缩时追加.zst后缀，加密时再追加.enc后缀
+func ChunkFileName(index int, compressed, encrypted bool) string {
+	name := fmt.Sprintf("%06d.part", index)
+	if compressed {
+		name += CompressedChunkSuffix
+	}
+	if encrypted {
+		name += EncryptedChunkSuffix
+	}
+	return name
+}
+
+// ChunkFileNames 分片所有存储格式的文件名，原始格式在前
+func ChunkFileNames(index int) []string {
+	names := make([]string, 0, 4)
+	for _, encrypted := range []bool{false, true} {
+		for _, compressed := range []bool{false, true} {
+			names = append(names, ChunkFileName(index, compressed, encrypted))
+		}
+	}
+	return names
+}
+
+// IsCompressedChunk 判断分片文件是否为压缩格式
+func IsCompressedChunk(path string) bool {
+	return strings.HasSuffix(strings.TrimSuffix(path, EncryptedChunkSuffix), CompressedChunkSuffix)
+}
+
+// IsEncryptedChunk 判断分片文件是否为加密格式
+func IsEncryptedChunk(path string) bool {
+	return strings.HasSuffix(path, EncryptedChunkSuffix)
+}
+
+// getEncoder 获取指定级别的zstd编码器（可并发使用）
+func getEncoder(level int) (*zstd.Encoder, error) {
+	if enc, ok := zstdEncoders.Load(level); ok {
+		return enc.(*zstd.Encoder), nil
+	}
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := zstdEncoders.LoadOrStore(level, enc)
+	return actual.(*zstd.Encoder), nil
+}
+
+// CompressChunk 使用zstd压缩分片数据
+func CompressChunk(data []byte, level int) ([]byte, error) {
+	enc, err := getEncoder(level)
+	if err != nil {
+		return nil, fmt.Errorf("创建zstd编码器失败: %v", err)
+	}
+	return enc.EncodeAll(data, make([]byte, 0, len(data)/2)), nil
+}
+
+// NewCompressWriter 创建流式zstd压缩写入器，关闭时写入剩余数据但不关闭底层写入器
+func NewCompressWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	enc, err := zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)), zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return nil, fmt.Errorf("创建zstd编码器失败: %v", err)
+	}
+	return enc, nil
+}
+
+// DecompressChunk 解压zstd分片数据
+func DecompressChunk(data []byte) ([]byte, error) {
+	decoderOnce.Do(func() {
+		zstdDecoder, decoderErr = zstd.NewReader(nil)
+	})
+	if decoderErr != nil {
+		return nil, fmt.Errorf("创建zstd解码器失败: %v", decoderErr)
+	}
+	return zstdDecoder.DecodeAll(data, nil)
+}
+
+// zstdReadCloser 关闭时同时释放解码器和底层文件
+type zstdReadCloser struct {
+	decoder *zstd.Decoder
+	file    *os.File
+}
+
+func (z *zstdReadCloser) Read(p []byte) (int, error) {
+	return z.decoder.Read(p)
+}
+
+func (z *zstdReadCloser) Close() error {
+	z.decoder.Close()
+	return z.file.Close()
+}
+
+// OpenChunkReader 打开分片文件，加密分片自动解密、压缩分片自动解压
+func OpenChunkReader(path string) (io.ReadCloser, error) {
+	// 加密分片需要整体解密（GCM校验依赖完整密文）
+	if IsEncryptedChunk(path) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		plain, err := DecryptChunk(data, EncryptionKey())
+		if err != nil {
+			return nil, err
+		}
+		if IsCompressedChunk(path) {
+			if plain, err = DecompressChunk(plain); err != nil {
+				return nil, fmt.Errorf("解压分片失败: %v", err)
+			}
+		}
+		return io.NopCloser(bytes.NewReader(plain)), nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !IsCompressedChunk(path) {
+		return file, nil
+	}
+
+	decoder, err := zstd.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("创建zstd解码器失败: %v", err)
+	}
+	return &zstdReadCloser{decoder: decoder, file: file}, nil
+}
+
+// ChunkFileHash 计算分片解密、解压后内容的哈希
+func ChunkFileHash(path string) (string, error) {
+	reader, err := OpenChunkReader(path)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	hash := Hasher.New()
+	if _, err := io.Copy(hash, reader); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
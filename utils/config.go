@@ -2,36 +2,153 @@ package utils
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 // AppConfig 存储应用程序配置
 type AppConfig struct {
-	UploadDir              string `json:"upload_dir"`               // 上传临时目录
-	MergedDir              string `json:"merged_dir"`               // 合并后文件存储目录
-	Port                   string `json:"port"`                     // 服务器监听端口
-	MaxFileSize            int64  `json:"max_file_size"`            // 最大文件大小（字节）
-	MaxChunkSize           int64  `json:"max_chunk_size"`           // 最大分片大小（字节）
-	CleanupInterval        int64  `json:"cleanup_interval"`         // 清理间隔（秒）
-	RetryMaxAttempts       int    `json:"retry_max_attempts"`       // 最大重试次数
-	RetryInitialDelay      int64  `json:"retry_initial_delay"`      // 初始重试延迟（毫秒）
-	ConcurrentUploads      int    `json:"concurrent_uploads"`       // 并发上传数
-	EnableIntegrityCheck   bool   `json:"enable_integrity_check"`   // 启用完整性检查
-	EnableAtomicOperations bool   `json:"enable_atomic_operations"` // 启用原子操作
-	LogLevel               string `json:"log_level"`                // 日志级别
-	SecretKey              string `json:"secret_key"`               // 访问密钥
-	EnableAuth             bool   `json:"enable_auth"`              // 是否启用密钥验证
+	UploadDir                     string                `json:"upload_dir"`                        // 上传临时目录
+	MergedDir                     string                `json:"merged_dir"`                        // 合并后文件存储目录
+	Port                          string                `json:"port"`                              // 服务器监听端口
+	GRPCPort                      string                `json:"grpc_port"`                         // gRPC服务监听端口，为空时不启动
+	MaxFileSize                   int64                 `json:"max_file_size"`                     // 最大文件大小（字节）
+	MaxChunkSize                  int64                 `json:"max_chunk_size"`                    // 最大分片大小（字节）
+	CleanupInterval               int64                 `json:"cleanup_interval"`                  // 清理间隔（秒）
+	CleanupPolicies               []CleanupPolicy       `json:"cleanup_policies"`                  // 过期任务清理规则
+	RetryMaxAttempts              int                   `json:"retry_max_attempts"`                // 最大重试次数
+	RetryInitialDelay             int64                 `json:"retry_initial_delay"`               // 初始重试延迟（毫秒）
+	ConcurrentUploads             int                   `json:"concurrent_uploads"`                // 并发上传数
+	EnableIntegrityCheck          bool                  `json:"enable_integrity_check"`            // 启用完整性检查
+	EnableAtomicOperations        bool                  `json:"enable_atomic_operations"`          // 启用原子操作
+	LogLevel                      string                `json:"log_level"`                         // 日志级别：debug、info、warn、error
+	SecretKey                     string                `json:"secret_key"`                        // 访问密钥
+	EnableAuth                    bool                  `json:"enable_auth"`                       // 是否启用密钥验证
+	StorageDriver                 string                `json:"storage_driver"`                    // 任务存储驱动: file、redis 或 sqlite
+	RedisAddr                     string                `json:"redis_addr"`                        // Redis地址
+	RedisPassword                 string                `json:"redis_password"`                    // Redis密码
+	RedisDB                       int                   `json:"redis_db"`                          // Redis数据库编号
+	JWTSecret                     string                `json:"jwt_secret"`                        // JWT签名密钥（为空时使用secret_key）
+	JWTTokenTTL                   int64                 `json:"jwt_token_ttl"`                     // JWT令牌有效期（秒）
+	RateLimitRPS                  float64               `json:"rate_limit_rps"`                    // 分片上传每客户端每秒请求数（0表示不限流）
+	RateLimitBurst                int                   `json:"rate_limit_burst"`                  // 分片上传突发请求数
+	EnableConcurrentMerge         bool                  `json:"enable_concurrent_merge"`           // 启用并发合并
+	ConcurrentMergeWorkers        int                   `json:"concurrent_merge_workers"`          // 并发合并工作协程数
+	EnableChunkCompression        bool                  `json:"enable_chunk_compression"`          // 启用分片zstd压缩存储
+	ChunkCompressionLevel         int                   `json:"chunk_compression_level"`           // zstd压缩级别（1-22）
+	EnableEncryption              bool                  `json:"enable_encryption"`                 // 启用分片AES-256-GCM加密存储
+	EncryptionKey                 string                `json:"encryption_key"`                    // 十六进制编码的32字节加密密钥
+	ShutdownTimeout               int64                 `json:"shutdown_timeout"`                  // 优雅关闭超时（秒）
+	EnableDeduplication           bool                  `json:"enable_deduplication"`              // 启用合并文件内容去重
+	Webhooks                      []WebhookConfig       `json:"webhooks"`                          // 任务事件Webhook回调
+	CORS                          CORSConfig            `json:"cors"`                              // 跨域配置
+	TLSEnabled                    bool                  `json:"tls_enabled"`                       // 启用HTTPS
+	TLSCertFile                   string                `json:"tls_cert_file"`                     // TLS证书文件路径
+	TLSKeyFile                    string                `json:"tls_key_file"`                      // TLS私钥文件路径
+	TLSAutoTLS                    bool                  `json:"tls_auto_tls"`                      // 通过Let's Encrypt自动申请证书
+	TLSACMEDomain                 string                `json:"tls_acme_domain"`                   // 自动证书的域名
+	AdminSecretKey                string                `json:"admin_secret_key"`                  // 管理接口密钥，为空时禁用管理接口
+	BandwidthLimitBytesPerSec     int64                 `json:"bandwidth_limit_bytes_per_sec"`     // 单次分片上传带宽限制（字节/秒），0表示不限速
+	AllowedMIMETypes              []string              `json:"allowed_mime_types"`                // 允许上传的MIME类型，为空表示不限制
+	BlockedMIMETypes              []string              `json:"blocked_mime_types"`                // 禁止上传的MIME类型
+	EnableAutoMerge               bool                  `json:"enable_auto_merge"`                 // 所有分片上传完成后自动合并
+	AutoMergeWorkers              int                   `json:"auto_merge_workers"`                // 自动合并工作协程数
+	HealthCheckTimeout            int64                 `json:"health_check_timeout"`              // 健康检查统计目录大小的超时（秒）
+	HealthCheckWriteTest          bool                  `json:"health_check_write_test"`           // 健康检查时在上传、合并和元数据目录中写入测试文件
+	HealthCheckWriteCacheTTL      int                   `json:"health_check_write_cache_ttl"`      // 写入测试结果的缓存时间（秒）
+	EnableMmapMerge               bool                  `json:"enable_mmap_merge"`                 // 大文件使用内存映射合并（仅Linux）
+	MmapMergeThresholdBytes       int64                 `json:"mmap_merge_threshold_bytes"`        // 使用内存映射合并的文件大小阈值
+	SQLitePath                    string                `json:"sqlite_path"`                       // SQLite数据库路径，为空时使用元数据目录下的 tasks.db
+	InactivityTimeoutSeconds      int64                 `json:"inactivity_timeout_seconds"`        // 上传中任务无活动超时（秒），0表示禁用
+	LogFormat                     string                `json:"log_format"`                        // 日志格式：text 或 json
+	LogFile                       string                `json:"log_file"`                          // 日志文件路径，为空时输出到标准错误
+	EnableDownload                bool                  `json:"enable_download"`                   // 是否允许通过 /files 下载已合并的文件
+	AllowFileDeletion             bool                  `json:"allow_file_deletion"`               // 是否允许通过 API 删除已合并的文件
+	MaxTotalUploadBytes           int64                 `json:"max_total_upload_bytes"`            // 单个上传会话的最大存储字节数，0表示不限制
+	EnableDocs                    bool                  `json:"enable_docs"`                       // 是否提供 /openapi.json 和 Swagger UI
+	AuditLogEnabled               bool                  `json:"audit_log_enabled"`                 // 是否记录审计日志
+	AuditLogFile                  string                `json:"audit_log_file"`                    // 审计日志文件路径（JSON Lines）
+	AuditLogMaxSizeMB             int                   `json:"audit_log_max_size_mb"`             // 审计日志轮转大小（MB），0表示不轮转
+	MaxRetryCount                 int                   `json:"max_retry_count"`                   // 单个分片允许失败的最大次数，达到后标记为永久失败，0表示不限制
+	DLQRetentionDays              int                   `json:"dlq_retention_days"`                // 死信队列条目保留天数，0表示永久保留
+	SpeedometerWindowSize         int                   `json:"speedometer_window_size"`           // 计算上传速度时使用的最近分片数
+	EnableBackgroundVerification  bool                  `json:"enable_background_verification"`    // 定期重新校验合并文件的MD5
+	VerificationIntervalHours     int                   `json:"verification_interval_hours"`       // 后台校验间隔（小时）
+	ContentAddressableChunks      bool                  `json:"content_addressable_chunks"`        // 按内容SHA-256存储分片，相同内容的分片通过硬链接共享
+	AuthDriver                    string                `json:"auth_driver"`                       // 认证驱动：secret（共享密钥/JWT）或 oidc
+	OIDCIssuer                    string                `json:"oidc_issuer"`                       // OIDC身份提供方地址
+	OIDCClientID                  string                `json:"oidc_client_id"`                    // OIDC客户端ID
+	OIDCClientSecret              string                `json:"oidc_client_secret"`                // OIDC客户端密钥
+	OIDCRedirectURL               string                `json:"oidc_redirect_url"`                 // OIDC回调地址，如 https://host/go-uploader/auth/oidc/callback
+	StorageTarget                 string                `json:"storage_target"`                    // 合并文件存储目标：local 或 s3
+	S3Bucket                      string                `json:"s3_bucket"`                         // S3存储桶
+	S3Region                      string                `json:"s3_region"`                         // S3区域
+	S3Endpoint                    string                `json:"s3_endpoint"`                       // 自定义S3兼容端点（如MinIO），为空时使用AWS
+	S3AccessKey                   string                `json:"s3_access_key"`                     // S3访问密钥ID，为空时使用默认凭证链
+	S3SecretKey                   string                `json:"s3_secret_key"`                     // S3访问密钥
+	S3KeyPrefix                   string                `json:"s3_key_prefix"`                     // 对象键前缀
+	S3DeleteLocalAfterUpload      bool                  `json:"s3_delete_local_after_upload"`      // 上传到S3成功后删除本地合并文件
+	RouteTimeouts                 map[string]int        `json:"route_timeouts"`                    // 路由请求超时（秒），键为路由模式，0表示不限制
+	HashAlgorithm                 string                `json:"hash_algorithm"`                    // 文件和分片校验算法：md5、sha256 或 blake3
+	PostMergeHooks                []PostMergeHookConfig `json:"post_merge_hooks"`                  // 文件合并成功后执行的处理命令
+	ParallelHooks                 bool                  `json:"parallel_hooks"`                    // 并发执行匹配的钩子，默认依次执行
+	HMACSecret                    string                `json:"hmac_secret"`                       // 预签名上传URL的签名密钥，为空时禁用预签名上传
+	EnforceTenantClaim            bool                  `json:"enforce_tenant_claim"`              // 要求JWT令牌中的租户与 X-Tenant-ID 请求头一致
+	AcceptCompressedChunks        bool                  `json:"accept_compressed_chunks"`          // 接受 zstd/gzip 压缩的分片数据（Content-Encoding）
+	CacheSize                     int                   `json:"cache_size"`                        // 任务缓存的最大任务数，为0时不启用缓存
+	WriteBehindIntervalMs         int                   `json:"write_behind_interval_ms"`          // 任务缓存写回后端的间隔（毫秒）
+	OpenTelemetryEnabled          bool                  `json:"opentelemetry_enabled"`             // 启用OpenTelemetry链路追踪
+	OTLPEndpoint                  string                `json:"otlp_endpoint"`                     // OTLP gRPC导出地址，http:// 为明文连接，https:// 使用TLS
+	OTLPServiceName               string                `json:"otlp_service_name"`                 // 上报的服务名称
+	ChunkWriteWorkers             int                   `json:"chunk_write_workers"`               // 同时写磁盘的分片数，0表示不限制
+	DiskWarningThresholdPercent   float64               `json:"disk_warning_threshold_percent"`    // 上传目录所在文件系统使用率超过该百分比时健康检查返回 warning
+	EnableTrash                   bool                  `json:"enable_trash"`                      // 删除任务时先移入回收站，可恢复
+	TrashDir                      string                `json:"trash_dir"`                         // 回收站目录
+	TrashRetentionDays            int                   `json:"trash_retention_days"`              // 清空回收站时保留最近多少天内删除的条目
+	EnableWAL                     bool                  `json:"enable_wal"`                        // 文件存储后端先将任务更新追加到预写日志，检查点时再写入JSON快照
+	WALMaxSizeMB                  int                   `json:"wal_max_size_mb"`                   // 预写日志超过该大小（MB）时自动写入检查点，0表示只在关闭时写入
+	MaxRequestBodySize            int64                 `json:"max_request_body_size"`             // 非分片上传请求的请求体上限（字节），0表示不限制；分片上传为 max_chunk_size 加1MB
+	EnableVersioning              bool                  `json:"enable_versioning"`                 // 合并目标文件已存在时将其保存为 <路径>.v<N> 历史版本，而不是直接覆盖
+	MaxConcurrentUploadsPerClient int                   `json:"max_concurrent_uploads_per_client"` // 单个客户端IP同时上传的分片数上限，超出时返回429，0表示不限制
+	ArchiveAfterDays              int                   `json:"archive_after_days"`                // 已完成任务超过多少天未更新后归档到上传目录下的 .archive，0表示不归档
+	EnableResponseCompression     bool                  `json:"enable_response_compression"`       // 客户端接受gzip时压缩JSON响应
+	CompressionLevel              int                   `json:"compression_level"`                 // gzip压缩级别（1-9）
+	CompressionMinSizeBytes       int                   `json:"compression_min_size_bytes"`        // 小于该大小的响应不压缩
+	MaxFolderDepth                int                   `json:"max_folder_depth"`                  // 文件夹任务的最大嵌套深度，同时限制文件相对路径的目录层级，0表示不限制
+	ProgressWebhookEveryN         int                   `json:"progress_webhook_every_n"`          // 每完成多少个分片触发一次 task.progress Webhook，0表示不触发
+	MinChunkSize                  int64                 `json:"min_chunk_size"`                    // 最小分片大小（字节），最后一个分片除外，0表示不限制
+	RequireSequentialChunks       bool                  `json:"require_sequential_chunks"`         // 要求分片按索引顺序上传，前一个分片未完成时拒绝
+	CleanupCronExpression         string                `json:"cleanup_cron_expression"`           // 定期清理的cron表达式（标准5段格式），为空时按 cleanup_interval 间隔清理
+	MaxMetadataSizeBytes          int                   `json:"max_metadata_size_bytes"`           // 任务自定义元数据的最大字节数，0表示不限制
+	SummaryWorkers                int                   `json:"summary_workers"`                   // 统计文件夹摘要时并行读取子任务的协程数，0表示使用CPU核数
+	MergeTimeoutSeconds           int64                 `json:"merge_timeout_seconds"`             // 合并超时时间（秒），客户端可通过 X-Merge-Timeout 请求头覆盖
+	MaxMergeTimeoutSeconds        int64                 `json:"max_merge_timeout_seconds"`         // X-Merge-Timeout 请求头允许的最大值（秒）
+	IPAllowlist                   []string              `json:"ip_allowlist"`                      // 允许访问的客户端IP或CIDR，非空时拒绝列表外的IP
+	IPBlocklist                   []string              `json:"ip_blocklist"`                      // 拒绝访问的客户端IP或CIDR，优先于允许列表
+	SecurityHeaders               SecurityHeadersConfig `json:"security_headers"`                  // 安全响应头配置
+	EnableHTTP2Push               bool                  `json:"enable_http2_push"`                 // 分片上传成功后通过HTTP/2服务器推送上传状态，需启用TLS，客户端不支持时自动跳过
 }
 
-// Config 全局配置实例
+// Config 启动时加载的全局配置实例，运行时通过 CurrentConfig 读取，热加载不会修改它
 var Config = AppConfig{
-	UploadDir:              "./upload",
-	MergedDir:              "./merged",
-	Port:                   "9876",
-	MaxFileSize:            10 * 1024 * 1024 * 1024, // 10GB
-	MaxChunkSize:           100 * 1024 * 1024,       // 100MB
-	CleanupInterval:        3600,                    // 1小时
+	UploadDir:       "./upload",
+	MergedDir:       "./merged",
+	Port:            "9876",
+	GRPCPort:        "",
+	MaxFileSize:     10 * 1024 * 1024 * 1024, // 10GB
+	MaxChunkSize:    100 * 1024 * 1024,       // 100MB
+	CleanupInterval: 3600,                    // 1小时
+	CleanupPolicies: []CleanupPolicy{ // 默认清理7天前的失败和暂停任务
+		{Status: "failed", OlderThanHours: 7 * 24},
+		{Status: "paused", OlderThanHours: 7 * 24},
+	},
 	RetryMaxAttempts:       3,
 	RetryInitialDelay:      1000, // 1秒
 	ConcurrentUploads:      5,
@@ -40,6 +157,122 @@ var Config = AppConfig{
 	LogLevel:               "info",
 	SecretKey:              "your-secret-key-here", // 默认密钥
 	EnableAuth:             true,                   // 默认启用验证
+	StorageDriver:          "file",
+	RedisAddr:              "localhost:6379",
+	RedisPassword:          "",
+	RedisDB:                0,
+	JWTSecret:              "",
+	JWTTokenTTL:            24 * 60 * 60, // 24小时
+	RateLimitRPS:           20,
+	RateLimitBurst:         40,
+	EnableConcurrentMerge:  false,
+	ConcurrentMergeWorkers: 4,
+	EnableChunkCompression: false,
+	ChunkCompressionLevel:  3,
+	EnableEncryption:       false,
+	EncryptionKey:          "",
+	ShutdownTimeout:        60,
+	EnableDeduplication:    false,
+	Webhooks:               []WebhookConfig{},
+	CORS: CORSConfig{
+		AllowedOrigins:   []string{},
+		AllowCredentials: false,
+		MaxAge:           600,
+	},
+	TLSEnabled:                   false,
+	TLSCertFile:                  "",
+	TLSKeyFile:                   "",
+	TLSAutoTLS:                   false,
+	TLSACMEDomain:                "",
+	AdminSecretKey:               "",
+	BandwidthLimitBytesPerSec:    0,
+	AllowedMIMETypes:             []string{},
+	BlockedMIMETypes:             []string{},
+	EnableAutoMerge:              false,
+	AutoMergeWorkers:             2,
+	HealthCheckTimeout:           5,
+	HealthCheckWriteTest:         true,
+	HealthCheckWriteCacheTTL:     30,
+	EnableMmapMerge:              false,
+	MmapMergeThresholdBytes:      500 * 1024 * 1024, // 500MB
+	SQLitePath:                   "",
+	InactivityTimeoutSeconds:     0,
+	LogFormat:                    "text",
+	LogFile:                      "",
+	EnableDownload:               false,
+	AllowFileDeletion:            false,
+	MaxTotalUploadBytes:          0,
+	EnableDocs:                   false,
+	AuditLogEnabled:              false,
+	AuditLogFile:                 "audit.log",
+	AuditLogMaxSizeMB:            100,
+	MaxRetryCount:                5,
+	DLQRetentionDays:             30,
+	SpeedometerWindowSize:        20,
+	EnableBackgroundVerification: false,
+	VerificationIntervalHours:    24,
+	ContentAddressableChunks:     false,
+	AuthDriver:                   AuthDriverSecret,
+	OIDCIssuer:                   "",
+	OIDCClientID:                 "",
+	OIDCClientSecret:             "",
+	OIDCRedirectURL:              "",
+	StorageTarget:                StorageTargetLocal,
+	S3Bucket:                     "",
+	S3Region:                     "",
+	S3Endpoint:                   "",
+	S3AccessKey:                  "",
+	S3SecretKey:                  "",
+	S3KeyPrefix:                  "",
+	S3DeleteLocalAfterUpload:     false,
+	RouteTimeouts: map[string]int{
+		"/upload_chunk":        30,
+		"/upload_chunk_signed": 30,
+	},
+	HashAlgorithm:                 "md5",
+	PostMergeHooks:                []PostMergeHookConfig{},
+	ParallelHooks:                 false,
+	HMACSecret:                    "",
+	EnforceTenantClaim:            false,
+	AcceptCompressedChunks:        false,
+	CacheSize:                     0,
+	WriteBehindIntervalMs:         1000,
+	OpenTelemetryEnabled:          false,
+	OTLPEndpoint:                  "http://localhost:4317",
+	OTLPServiceName:               "go-uploader",
+	ChunkWriteWorkers:             4,
+	DiskWarningThresholdPercent:   85,
+	EnableTrash:                   false,
+	TrashDir:                      "./trash",
+	TrashRetentionDays:            7,
+	EnableWAL:                     false,
+	WALMaxSizeMB:                  64,
+	MaxRequestBodySize:            1024 * 1024,
+	EnableHTTP2Push:               false,
+	EnableVersioning:              false,
+	MaxConcurrentUploadsPerClient: 0,
+	ArchiveAfterDays:              0,
+	EnableResponseCompression:     false,
+	CompressionLevel:              6,
+	CompressionMinSizeBytes:       1400,
+	MaxFolderDepth:                10,
+	ProgressWebhookEveryN:         0,
+	MinChunkSize:                  0,
+	RequireSequentialChunks:       false,
+	CleanupCronExpression:         "0 3 * * *", // 每天凌晨3点
+	MaxMetadataSizeBytes:          4096,
+	SummaryWorkers:                runtime.NumCPU(),
+	MergeTimeoutSeconds:           300,         // 5分钟
+	MaxMergeTimeoutSeconds:        6 * 60 * 60, // 6小时
+	IPAllowlist:                   []string{},
+	IPBlocklist:                   []string{},
+	SecurityHeaders: SecurityHeadersConfig{
+		HSTSEnabled:         false,
+		HSTSMaxAge:          31536000,
+		XFrameOptions:       "SAMEORIGIN",
+		XContentTypeOptions: "nosniff",
+		ReferrerPolicy:      "strict-origin-when-cross-origin",
+	},
 }
 
 // LoadConfig 从配置文件加载配置
@@ -74,10 +307,327 @@ func LoadConfig(configPath string) error {
 			return err
 		}
 	}
+
+	// 环境变量优先于配置文件
+	applyEnvOverrides(&Config)
 	
 	return nil
 }
 
+// envPrefix 配置项环境变量前缀
+const envPrefix = "GO_UPLOADER_"
+
+// EnvironmentVars 返回环境变量名到配置字段名的映射，如 GO_UPLOADER_MAX_CHUNK_SIZE -> MaxChunkSize
+func EnvironmentVars() map[string]string {
+	configType := reflect.TypeOf(AppConfig{})
+	vars := make(map[string]string, configType.NumField())
+	for i := 0; i < configType.NumField(); i++ {
+		field := configType.Field(i)
+		vars[envVarName(field)] = field.Name
+	}
+	return vars
+}
+
+// envVarName 根据字段的json标签生成环境变量名
+func envVarName(field reflect.StructField) string {
+	key := strings.Split(field.Tag.Get("json"), ",")[0]
+	if key == "" || key == "-" {
+		key = field.Name
+	}
+	return envPrefix + strings.ToUpper(key)
+}
+
+// applyEnvOverrides 使用环境变量覆盖配置，解析失败时保留原值并记录警告
+func applyEnvOverrides(cfg *AppConfig) {
+	configValue := reflect.ValueOf(cfg).Elem()
+	configType := configValue.Type()
+
+	for i := 0; i < configType.NumField(); i++ {
+		name := envVarName(configType.Field(i))
+		raw, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+
+		if err := setFieldFromEnv(configValue.Field(i), raw); err != nil {
+			Logger.Warn("环境变量解析失败，保留配置文件中的值", "env", name, "error", err)
+		}
+	}
+}
+
+// setFieldFromEnv 按字段类型解析环境变量值；字符串切片使用逗号分隔，其他复合类型使用JSON
+func setFieldFromEnv(field reflect.Value, raw string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		switch strings.ToLower(strings.TrimSpace(raw)) {
+		case "true", "1":
+			field.SetBool(true)
+		case "false", "0":
+			field.SetBool(false)
+		default:
+			return fmt.Errorf("无效的布尔值: %s", raw)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+		if err != nil {
+			return fmt.Errorf("无效的整数: %s", raw)
+		}
+		if field.OverflowInt(value) {
+			return fmt.Errorf("整数超出范围: %s", raw)
+		}
+		field.SetInt(value)
+	case reflect.Float32, reflect.Float64:
+		value, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil {
+			return fmt.Errorf("无效的数值: %s", raw)
+		}
+		field.SetFloat(value)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() == reflect.String && !strings.HasPrefix(strings.TrimSpace(raw), "[") {
+			items := make([]string, 0)
+			for _, item := range strings.Split(raw, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+			field.Set(reflect.ValueOf(items))
+			return nil
+		}
+		return setFieldFromJSON(field, raw)
+	default:
+		return setFieldFromJSON(field, raw)
+	}
+	return nil
+}
+
+// setFieldFromJSON 将JSON格式的环境变量解析到字段
+func setFieldFromJSON(field reflect.Value, raw string) error {
+	value := reflect.New(field.Type())
+	if err := json.Unmarshal([]byte(raw), value.Interface()); err != nil {
+		return fmt.Errorf("无效的JSON: %v", err)
+	}
+	field.Set(value.Elem())
+	return nil
+}
+
+// configMutex 串行化配置热加载
+var configMutex sync.Mutex
+
+// reloadedConfig 热加载后生效的配置，每次热加载替换为新的实例，已发布的实例不再修改
+var reloadedConfig atomic.Pointer[AppConfig]
+
+// CurrentConfig 返回当前生效的配置，尚未热加载时为 Config；返回值只读，同一请求中需要一致的配置时应保存返回的指针
+func CurrentConfig() *AppConfig {
+	if cfg := reloadedConfig.Load(); cfg != nil {
+		return cfg
+	}
+	return &Config
+}
+
+// RestoreConfig 丢弃热加载的配置并恢复启动配置，供测试在热加载后还原全局配置
+func RestoreConfig(cfg AppConfig) {
+	Config = cfg
+	reloadedConfig.Store(nil)
+}
+
+// immutableConfigFields 运行时不能修改的配置项，热加载时忽略其变化
+var immutableConfigFields = map[string]bool{
+	"UploadDir":     true,
+	"MergedDir":     true,
+	"Port":          true,
+	"StorageDriver": true,
+	"RedisAddr":     true,
+	"RedisPassword": true,
+	"RedisDB":       true,
+	"SQLitePath":    true,
+	"TLSEnabled":    true,
+	"TLSCertFile":   true,
+	"TLSKeyFile":    true,
+	"TLSAutoTLS":    true,
+	"TLSACMEDomain": true,
+
+	// 审计日志文件在启动时打开
+	"AuditLogEnabled":   true,
+	"AuditLogFile":      true,
+	"AuditLogMaxSizeMB": true,
+
+	// 内容寻址存储在启动时初始化
+	"ContentAddressableChunks": true,
+
+	// gRPC监听器在启动时创建
+	"GRPCPort": true,
+
+	// OIDC提供方在启动时初始化
+	"AuthDriver":       true,
+	"OIDCIssuer":       true,
+	"OIDCClientID":     true,
+	"OIDCClientSecret": true,
+	"OIDCRedirectURL":  true,
+
+	// 对象存储客户端在启动时创建
+	"StorageTarget": true,
+	"S3Bucket":      true,
+	"S3Region":      true,
+	"S3Endpoint":    true,
+	"S3AccessKey":   true,
+	"S3SecretKey":   true,
+	"S3KeyPrefix":   true,
+
+	// 后台校验任务在启动时创建
+	"EnableBackgroundVerification": true,
+	"VerificationIntervalHours":    true,
+
+	// 路由超时中间件在注册路由时创建
+	"RouteTimeouts": true,
+
+	// 任务缓存在初始化存储时创建
+	"CacheSize":             true,
+	"WriteBehindIntervalMs": true,
+
+	// 追踪导出器在启动时创建
+	"OpenTelemetryEnabled": true,
+	"OTLPEndpoint":         true,
+	"OTLPServiceName":      true,
+
+	// 分片写入队列在启动时创建
+	"ChunkWriteWorkers": true,
+
+	// 回收站目录在初始化存储时创建
+	"TrashDir": true,
+
+	// 预写日志在初始化存储后端时打开
+	"EnableWAL": true,
+
+	// 校验算法在启动时确定，运行时修改会导致新旧分片校验值不一致
+	"HashAlgorithm": true,
+
+	// 合并后处理钩子在启动时注册
+	"PostMergeHooks": true,
+
+	// 安全响应头中间件在注册路由时创建
+	"SecurityHeaders": true,
+
+	// IP过滤列表在启动时解析
+	"IPAllowlist": true,
+	"IPBlocklist": true,
+	// 清理计划在启动时解析
+	"CleanupCronExpression": true,
+}
+
+// ReloadConfig 重新读取配置文件并应用可在运行时修改的配置项
+func ReloadConfig(configPath string) error {
+	configMutex.Lock()
+	defer configMutex.Unlock()
+
+	configData, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("读取配置文件失败: %v", err)
+	}
+
+	// 以当前配置的副本为基础解析，文件中缺失的配置项保持不变；
+	// 副本经JSON复制，解析时不会改动正在使用的切片和映射
+	current := CurrentConfig()
+	currentData, err := json.Marshal(current)
+	if err != nil {
+		return fmt.Errorf("复制当前配置失败: %v", err)
+	}
+	var next AppConfig
+	if err := json.Unmarshal(currentData, &next); err != nil {
+		return fmt.Errorf("复制当前配置失败: %v", err)
+	}
+	if err := json.Unmarshal(configData, &next); err != nil {
+		return fmt.Errorf("解析配置文件失败: %v", err)
+	}
+	applyEnvOverrides(&next)
+	if err := validateReloadedConfig(&next); err != nil {
+		return err
+	}
+
+	changed, ignored := diffConfig(current, &next)
+	if len(ignored) > 0 {
+		Logger.Warn("以下配置项不能在运行时修改，已忽略", "keys", strings.Join(ignored, ","))
+	}
+	if len(changed) == 0 {
+		Logger.Info("配置已重新加载，没有变化")
+		return nil
+	}
+
+	loggingChanged := next.LogLevel != current.LogLevel || next.LogFormat != current.LogFormat || next.LogFile != current.LogFile
+	// 整体替换配置实例，读取方拿到的要么是旧配置要么是新配置
+	reloadedConfig.Store(&next)
+
+	if loggingChanged {
+		if err := InitLogger(); err != nil {
+			Logger.Error("重新初始化日志失败", "error", err)
+		}
+	}
+
+	Logger.Info("配置已重新加载", "changed_keys", strings.Join(changed, ","))
+	return nil
+}
+
+// validateReloadedConfig 校验热加载的配置值
+func validateReloadedConfig(cfg *AppConfig) error {
+	if cfg.MaxChunkSize <= 0 {
+		return fmt.Errorf("max_chunk_size 必须大于0")
+	}
+	if cfg.SummaryWorkers < 0 {
+		return fmt.Errorf("summary_workers 不能小于0")
+	}
+	if cfg.HealthCheckWriteCacheTTL < 0 {
+		return fmt.Errorf("health_check_write_cache_ttl 不能小于0")
+	}
+	if cfg.MergeTimeoutSeconds <= 0 {
+		return fmt.Errorf("merge_timeout_seconds 必须大于0")
+	}
+	if cfg.MaxMergeTimeoutSeconds < cfg.MergeTimeoutSeconds {
+		return fmt.Errorf("max_merge_timeout_seconds 不能小于 merge_timeout_seconds")
+	}
+	if cfg.ProgressWebhookEveryN < 0 {
+		return fmt.Errorf("progress_webhook_every_n 不能小于0")
+	}
+	if cfg.MinChunkSize < 0 || cfg.MinChunkSize > cfg.MaxChunkSize {
+		return fmt.Errorf("min_chunk_size 必须在0到 max_chunk_size 之间")
+	}
+	if cfg.ConcurrentUploads <= 0 {
+		return fmt.Errorf("concurrent_uploads 必须大于0")
+	}
+	if _, err := parseLogLevel(cfg.LogLevel); err != nil {
+		return err
+	}
+	switch strings.ToLower(cfg.LogFormat) {
+	case "", "text", "json":
+	default:
+		return fmt.Errorf("不支持的日志格式: %s", cfg.LogFormat)
+	}
+	return nil
+}
+
+// diffConfig 比较新旧配置，返回变化的配置项；不可修改的配置项恢复为旧值并单独返回
+func diffConfig(current, next *AppConfig) (changed, ignored []string) {
+	currentValue := reflect.ValueOf(current).Elem()
+	nextValue := reflect.ValueOf(next).Elem()
+	configType := currentValue.Type()
+
+	for i := 0; i < configType.NumField(); i++ {
+		field := configType.Field(i)
+		if reflect.DeepEqual(currentValue.Field(i).Interface(), nextValue.Field(i).Interface()) {
+			continue
+		}
+
+		key := strings.Split(field.Tag.Get("json"), ",")[0]
+		if immutableConfigFields[field.Name] {
+			nextValue.Field(i).Set(currentValue.Field(i))
+			ignored = append(ignored, key)
+			continue
+		}
+		changed = append(changed, key)
+	}
+	return changed, ignored
+}
+
 // InitDirectories 初始化所有配置的目录
 func InitDirectories() error {
 	dirs := []string{
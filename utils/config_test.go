@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// reloadableTestConfig 可通过热加载校验的最小配置
+func reloadableTestConfig() AppConfig {
+	return AppConfig{
+		UploadDir:              "uploads",
+		MergedDir:              "merged",
+		Port:                   "8080",
+		MaxChunkSize:           1 << 20,
+		ConcurrentUploads:      5,
+		LogLevel:               "info",
+		MergeTimeoutSeconds:    60,
+		MaxMergeTimeoutSeconds: 120,
+	}
+}
+
+// writeTestConfigFile 将配置内容写入临时目录下的配置文件
+func writeTestConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReloadConfigAppliesMutableFields(t *testing.T) {
+	saved := Config
+	defer RestoreConfig(saved)
+	Config = reloadableTestConfig()
+
+	Config.RouteTimeouts = map[string]int{"/upload_chunk": 30}
+
+	path := writeTestConfigFile(t, `{"max_chunk_size": 4194304, "concurrent_uploads": 8, "upload_dir": "elsewhere", "port": "9090", "route_timeouts": {"/upload_chunk": 5, "/merge": 10}}`)
+	if err := ReloadConfig(path); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := CurrentConfig()
+	if cfg.MaxChunkSize != 4<<20 || cfg.ConcurrentUploads != 8 {
+		t.Fatalf("可修改的配置未生效: max_chunk_size=%d concurrent_uploads=%d", cfg.MaxChunkSize, cfg.ConcurrentUploads)
+	}
+	// 分片请求体上限按当前配置计算，后续上传立即使用新的上限
+	if ChunkBodyLimit() != 4<<20+multipartOverhead {
+		t.Fatalf("chunk body limit = %d", ChunkBodyLimit())
+	}
+	if cfg.UploadDir != "uploads" || cfg.Port != "8080" {
+		t.Fatalf("不可修改的配置被修改: upload_dir=%s port=%s", cfg.UploadDir, cfg.Port)
+	}
+	// 路由超时中间件在启动时复制超时配置，热加载不能修改
+	if !reflect.DeepEqual(cfg.RouteTimeouts, map[string]int{"/upload_chunk": 30}) {
+		t.Fatalf("route_timeouts 被修改: %v", cfg.RouteTimeouts)
+	}
+	// 文件中未出现的配置项保持不变
+	if cfg.MergeTimeoutSeconds != 60 {
+		t.Fatalf("merge_timeout_seconds = %d, want 60", cfg.MergeTimeoutSeconds)
+	}
+	// 热加载替换配置实例，启动配置中的映射不被解析过程修改
+	if !reflect.DeepEqual(Config.RouteTimeouts, map[string]int{"/upload_chunk": 30}) || Config.MaxChunkSize != 1<<20 {
+		t.Fatalf("启动配置被修改: %+v", Config)
+	}
+}
+
+func TestReloadConfigConcurrentReads(t *testing.T) {
+	saved := Config
+	defer RestoreConfig(saved)
+	Config = reloadableTestConfig()
+
+	// -race 下检测热加载与请求处理读取配置之间的数据竞争
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if cfg := CurrentConfig(); cfg.MaxChunkSize <= 0 || ChunkBodyLimit() <= 0 {
+					t.Errorf("读取到无效配置: %d", cfg.MaxChunkSize)
+					return
+				}
+			}
+		}()
+	}
+	for i := 1; i <= 20; i++ {
+		path := writeTestConfigFile(t, fmt.Sprintf(`{"max_chunk_size": %d}`, i<<20))
+		if err := ReloadConfig(path); err != nil {
+			t.Error(err)
+			break
+		}
+	}
+	close(done)
+	wg.Wait()
+
+	if CurrentConfig().MaxChunkSize != 20<<20 {
+		t.Fatalf("max_chunk_size = %d, want %d", CurrentConfig().MaxChunkSize, 20<<20)
+	}
+}
+
+func TestReloadConfigRejectsInvalidValues(t *testing.T) {
+	saved := Config
+	defer RestoreConfig(saved)
+
+	for _, content := range []string{
+		`{"max_chunk_size": 0}`,
+		`{"concurrent_uploads": -1}`,
+		`{"log_level": "verbose"}`,
+		`{"max_chunk_size": `,
+	} {
+		RestoreConfig(reloadableTestConfig())
+		if err := ReloadConfig(writeTestConfigFile(t, content)); err == nil {
+			t.Fatalf("%s: 应拒绝无效配置", content)
+		}
+		if !reflect.DeepEqual(*CurrentConfig(), reloadableTestConfig()) {
+			t.Fatalf("%s: 校验失败后配置被修改", content)
+		}
+	}
+
+	if err := ReloadConfig(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatal("配置文件不存在时应返回错误")
+	}
+}
@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// CORSConfig 跨域资源共享配置
+type CORSConfig struct {
+	AllowedOrigins   []string `json:"allowed_origins"`   // 允许的来源，支持 * 通配符
+	AllowCredentials bool     `json:"allow_credentials"` // 是否允许携带凭证
+	MaxAge           int      `json:"max_age"`           // 预检结果缓存时间（秒）
+}
+
+// corsAllowedMethods 允许的跨域请求方法
+const corsAllowedMethods = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+
+// corsDefaultHeaders 默认允许的跨域请求头
+const corsDefaultHeaders = "Content-Type, Authorization, X-Secret-Key, X-Requested-With, X-Request-Id, X-Tenant-ID"
+
+// CORSMiddleware 根据配置校验Origin并设置跨域响应头
+func CORSMiddleware(cfg CORSConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" || len(cfg.AllowedOrigins) == 0 {
+			c.Next()
+			return
+		}
+
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+
+		if !isOriginAllowed(origin, cfg.AllowedOrigins) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		// 允许凭证时不能返回 *，需回显具体来源
+		if containsString(cfg.AllowedOrigins, "*") && !cfg.AllowCredentials {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Header("Access-Control-Allow-Origin", origin)
+			c.Writer.Header().Add("Vary", "Origin")
+		}
+		if cfg.AllowCredentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+
+		if preflight {
+			allowHeaders := c.GetHeader("Access-Control-Request-Headers")
+			if allowHeaders == "" {
+				allowHeaders = corsDefaultHeaders
+			}
+			c.Header("Access-Control-Allow-Methods", corsAllowedMethods)
+			c.Header("Access-Control-Allow-Headers", allowHeaders)
+			if cfg.MaxAge > 0 {
+				c.Header("Access-Control-Max-Age", strconv.Itoa(cfg.MaxAge))
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		c.Header("Access-Control-Allow-Methods", corsAllowedMethods)
+		c.Header("Access-Control-Allow-Headers", corsDefaultHeaders)
+		// 允许前端读取请求ID以便关联服务端日志，读取 Accept-Encoding 以协商分片压缩格式
+		c.Header("Access-Control-Expose-Headers", RequestIDHeader+", Accept-Encoding")
+		c.Next()
+	}
+}
+
+// IsOriginAllowed 检查来源是否在跨域配置的允许列表中
+func IsOriginAllowed(origin string) bool {
+	return isOriginAllowed(origin, CurrentConfig().CORS.AllowedOrigins)
+}
+
+// isOriginAllowed 检查来源是否在允许列表中
+func isOriginAllowed(origin string, allowed []string) bool {
+	for _, pattern := range allowed {
+		if pattern == "*" || pattern == origin {
+			return true
+		}
+
+		// 支持 https://*.example.com 形式的通配符
+		if idx := strings.Index(pattern, "*"); idx >= 0 {
+			prefix, suffix := pattern[:idx], pattern[idx+1:]
+			if len(origin) >= len(prefix)+len(suffix) &&
+				strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
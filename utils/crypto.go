@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// encryptionKeySize AES-256密钥长度
+const encryptionKeySize = 32
+
+// encryptionKey 启动时解析的分片加密密钥
+var encryptionKey []byte
+
+// initEncryptionKey 校验并解析配置中的加密密钥；关闭加密后仍加载已配置的密钥，之前加密存储的分片可以继续合并
+func initEncryptionKey() error {
+	encryptionKey = nil
+	if !CurrentConfig().EnableEncryption && CurrentConfig().EncryptionKey == "" {
+		return nil
+	}
+
+	key, err := parseEncryptionKey(CurrentConfig().EncryptionKey)
+	if err != nil {
+		if !CurrentConfig().EnableEncryption {
+			Logger.Warn("加密密钥无效，已加密存储的分片将无法读取", "error", err)
+			return nil
+		}
+		return err
+	}
+
+	encryptionKey = key
+	return nil
+}
+
+// parseEncryptionKey 解析十六进制编码的32字节密钥
+func parseEncryptionKey(value string) ([]byte, error) {
+	key, err := hex.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("加密密钥不是有效的十六进制字符串: %v", err)
+	}
+	if len(key) != encryptionKeySize {
+		return nil, fmt.Errorf("加密密钥长度无效: 期望%d字节, 实际%d字节", encryptionKeySize, len(key))
+	}
+	return key, nil
+}
+
+// EncryptionKey 获取分片加密密钥
+func EncryptionKey() []byte {
+	return encryptionKey
+}
+
+// EncryptChunk 使用AES-256-GCM加密分片，随机nonce前置于密文
+func EncryptChunk(data []byte, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("生成nonce失败: %v", err)
+	}
+
+	return gcm.Seal(nonce, nonce, data, nil), nil
+}
+
+// DecryptChunk 解密由EncryptChunk生成的分片数据
+func DecryptChunk(data []byte, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonceSize := gcm.NonceSize()
+	if len(data) < nonceSize {
+		return nil, fmt.Errorf("密文长度不足")
+	}
+
+	plain, err := gcm.Open(nil, data[:nonceSize], data[nonceSize:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("解密分片失败: %v", err)
+	}
+	return plain, nil
+}
+
+// newGCM 创建AES-GCM实例
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != encryptionKeySize {
+		return nil, fmt.Errorf("加密密钥长度无效: %d", len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("创建AES密码器失败: %v", err)
+	}
+	return cipher.NewGCM(block)
+}
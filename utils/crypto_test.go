@@ -0,0 +1,160 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testEncryptionKey 生成随机密钥并设为当前分片加密密钥，测试结束时恢复
+func testEncryptionKey(t *testing.T) []byte {
+	t.Helper()
+	key := make([]byte, encryptionKeySize)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	saved := encryptionKey
+	encryptionKey = key
+	t.Cleanup(func() { encryptionKey = saved })
+	return key
+}
+
+func TestEncryptChunkRoundtrip(t *testing.T) {
+	key := testEncryptionKey(t)
+
+	for _, size := range []int{0, 1, 4096, 1 << 20} {
+		plain := make([]byte, size)
+		rand.Read(plain)
+
+		sealed, err := EncryptChunk(plain, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 过短的明文可能恰好出现在随机密文中
+		if size >= 16 && bytes.Contains(sealed, plain) {
+			t.Fatalf("size=%d: 密文中包含明文", size)
+		}
+		opened, err := DecryptChunk(sealed, key)
+		if err != nil {
+			t.Fatalf("size=%d: %v", size, err)
+		}
+		if !bytes.Equal(opened, plain) {
+			t.Fatalf("size=%d: 解密结果与原文不一致", size)
+		}
+	}
+}
+
+func TestDecryptChunkDetectsTampering(t *testing.T) {
+	key := testEncryptionKey(t)
+	sealed, err := EncryptChunk([]byte("chunk data"), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range sealed {
+		tampered := append([]byte(nil), sealed...)
+		tampered[i] ^= 0x01
+		if _, err := DecryptChunk(tampered, key); err == nil {
+			t.Fatalf("修改第%d字节后解密应失败", i)
+		}
+	}
+	if _, err := DecryptChunk(sealed[:len(sealed)-1], key); err == nil {
+		t.Fatal("截断的密文解密应失败")
+	}
+
+	otherKey := make([]byte, encryptionKeySize)
+	if _, err := DecryptChunk(sealed, otherKey); err == nil {
+		t.Fatal("使用其他密钥解密应失败")
+	}
+}
+
+func TestOpenChunkReaderUsesStoredFormat(t *testing.T) {
+	key := testEncryptionKey(t)
+	saved := Config
+	defer func() { Config = saved }()
+
+	dir := t.TempDir()
+	plain := []byte("plain chunk content")
+
+	sealed, err := EncryptChunk(plain, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compressed, err := CompressChunk(plain, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealedCompressed, err := EncryptChunk(compressed, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string][]byte{
+		ChunkFileName(0, false, false): plain,
+		ChunkFileName(1, false, true):  sealed,
+		ChunkFileName(2, true, true):   sealedCompressed,
+		ChunkFileName(3, true, false):  compressed,
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 读取结果只取决于分片的存储格式，与当前是否开启加密无关
+	for _, enabled := range []bool{false, true} {
+		Config.EnableEncryption = enabled
+		for name := range files {
+			reader, err := OpenChunkReader(filepath.Join(dir, name))
+			if err != nil {
+				t.Fatalf("enable_encryption=%v %s: %v", enabled, name, err)
+			}
+			data, err := io.ReadAll(reader)
+			reader.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(data, plain) {
+				t.Fatalf("enable_encryption=%v %s: 读取内容 %q", enabled, name, data)
+			}
+		}
+	}
+
+	// 加密分片被修改时读取失败，不会把密文当作明文合并
+	tampered := append([]byte(nil), sealed...)
+	tampered[len(tampered)-1] ^= 0x01
+	path := filepath.Join(dir, ChunkFileName(4, false, true))
+	if err := os.WriteFile(path, tampered, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenChunkReader(path); err == nil {
+		t.Fatal("被修改的加密分片应读取失败")
+	}
+}
+
+func TestChunkFileFormat(t *testing.T) {
+	tests := []struct {
+		name       string
+		compressed bool
+		encrypted  bool
+	}{
+		{"000007.part", false, false},
+		{"000007.part.zst", true, false},
+		{"000007.part.enc", false, true},
+		{"000007.part.zst.enc", true, true},
+	}
+	for _, tt := range tests {
+		if name := ChunkFileName(7, tt.compressed, tt.encrypted); name != tt.name {
+			t.Fatalf("ChunkFileName = %q, want %q", name, tt.name)
+		}
+		if IsCompressedChunk(tt.name) != tt.compressed || IsEncryptedChunk(tt.name) != tt.encrypted {
+			t.Fatalf("%s: compressed=%v encrypted=%v", tt.name, IsCompressedChunk(tt.name), IsEncryptedChunk(tt.name))
+		}
+	}
+	if names := ChunkFileNames(7); len(names) != 4 || names[0] != "000007.part" {
+		t.Fatalf("ChunkFileNames = %v", names)
+	}
+}
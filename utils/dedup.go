@@ -0,0 +1,163 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// DedupEntry 去重索引条目
+type DedupEntry struct {
+	MD5      string   `json:"md5"`
+	Path     string   `json:"path"`      // 首次合并生成的物理文件路径
+	Size     int64    `json:"size"`
+	RefCount int      `json:"ref_count"` // 引用该内容的任务数
+	Links    []string `json:"links"`     // 指向同一内容的硬链接路径
+}
+
+// DeduplicationIndex 基于文件MD5的内容去重索引，按租户隔离：不同租户的相同内容不共享文件
+type DeduplicationIndex struct {
+	indexPath string
+	mutex     sync.Mutex
+	entries   map[string]*DedupEntry
+}
+
+// Dedup 全局去重索引（未启用去重时为nil）
+var Dedup *DeduplicationIndex
+
+// NewDeduplicationIndex 创建去重索引并加载已有数据
+func NewDeduplicationIndex(indexPath string) (*DeduplicationIndex, error) {
+	idx := &DeduplicationIndex{
+		indexPath: indexPath,
+		entries:   make(map[string]*DedupEntry),
+	}
+
+	data, err := os.ReadFile(indexPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return idx, nil
+		}
+		return nil, fmt.Errorf("读取去重索引失败: %v", err)
+	}
+
+	if err := json.Unmarshal(data, &idx.entries); err != nil {
+		return nil, fmt.Errorf("解析去重索引失败: %v", err)
+	}
+	return idx, nil
+}
+
+// dedupKey 索引键，未设置租户时为MD5本身，兼容已有的索引文件
+func dedupKey(tenantID, md5 string) string {
+	if tenantID == "" {
+		return md5
+	}
+	return tenantID + ":" + md5
+}
+
+// Lookup 查找租户内容对应的已存在文件，文件丢失时返回false
+func (d *DeduplicationIndex) Lookup(tenantID, md5 string) (DedupEntry, bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	entry, exists := d.entries[dedupKey(tenantID, md5)]
+	if !exists {
+		return DedupEntry{}, false
+	}
+	if _, err := os.Stat(entry.Path); err != nil {
+		return DedupEntry{}, false
+	}
+	return *entry, true
+}
+
+// AddReference 增加租户内容的引用计数
+func (d *DeduplicationIndex) AddReference(tenantID, md5, path string, size int64) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	key := dedupKey(tenantID, md5)
+	entry, exists := d.entries[key]
+	if !exists {
+		entry = &DedupEntry{
+			MD5:  md5,
+			Path: path,
+			Size: size,
+		}
+		d.entries[key] = entry
+	}
+
+	entry.RefCount++
+	if !containsString(entry.Links, path) {
+		entry.Links = append(entry.Links, path)
+	}
+
+	return d.persist()
+}
+
+// Release 减少租户内容的引用计数，计数归零时删除物理文件
+func (d *DeduplicationIndex) Release(tenantID, md5 string) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	key := dedupKey(tenantID, md5)
+	entry, exists := d.entries[key]
+	if !exists {
+		return nil
+	}
+
+	entry.RefCount--
+	if entry.RefCount <= 0 {
+		for _, link := range entry.Links {
+			if err := os.Remove(link); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("删除去重文件失败: %v", err)
+			}
+		}
+		delete(d.entries, key)
+	}
+
+	return d.persist()
+}
+
+// persist 原子写入索引文件，调用方需持有锁
+func (d *DeduplicationIndex) persist() error {
+	data, err := json.MarshalIndent(d.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	writer, err := NewAtomicWriter(d.indexPath)
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(data); err != nil {
+		writer.Rollback()
+		return fmt.Errorf("写入去重索引失败: %v", err)
+	}
+	return writer.Commit()
+}
+
+// initDeduplication 根据配置初始化去重索引
+func initDeduplication(storageDir string) error {
+	if !CurrentConfig().EnableDeduplication {
+		Dedup = nil
+		return nil
+	}
+
+	idx, err := NewDeduplicationIndex(filepath.Join(storageDir, "dedup.json"))
+	if err != nil {
+		return err
+	}
+	Dedup = idx
+	return nil
+}
+
+// containsString 判断切片中是否包含指定字符串
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDeduplicationIndexScopedByTenant(t *testing.T) {
+	dir := t.TempDir()
+	idx, err := NewDeduplicationIndex(filepath.Join(dir, "dedup.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "a.bin")
+	if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.AddReference("tenant-a", "hash", path, 7); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, exists := idx.Lookup("tenant-b", "hash"); exists {
+		t.Fatal("其他租户不应命中去重索引")
+	}
+	if _, exists := idx.Lookup("", "hash"); exists {
+		t.Fatal("未设置租户时不应命中租户的去重索引")
+	}
+	entry, exists := idx.Lookup("tenant-a", "hash")
+	if !exists || entry.Path != path {
+		t.Fatalf("Lookup = %+v, %v", entry, exists)
+	}
+
+	// 重新加载后索引仍按租户隔离
+	reloaded, err := NewDeduplicationIndex(filepath.Join(dir, "dedup.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := reloaded.Lookup("tenant-b", "hash"); exists {
+		t.Fatal("重新加载后其他租户不应命中去重索引")
+	}
+
+	if err := idx.Release("tenant-b", "hash"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatal("释放其他租户的引用不应删除文件")
+	}
+	if err := idx.Release("tenant-a", "hash"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatal("引用归零后应删除文件")
+	}
+}
@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DependencyNotMet 依赖任务尚未完成时接口返回的错误码
+const DependencyNotMet = "dependency_not_met"
+
+// ErrDependencyNotMet 依赖的任务尚未完成
+var ErrDependencyNotMet = errors.New("依赖的任务尚未完成")
+
+// PendingDependencies 返回任务尚未完成的依赖任务ID，已被删除的依赖任务同样视为未完成
+func (s *TaskStorage) PendingDependencies(task *UploadTask) []string {
+	if len(task.DependsOn) == 0 {
+		return nil
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	pending := make([]string, 0)
+	for _, dependencyID := range task.DependsOn {
+		dependency, exists := s.backend.GetTask(dependencyID)
+		if !exists || dependency.Status != "completed" {
+			pending = append(pending, dependencyID)
+		}
+	}
+	return pending
+}
+
+// ValidateFileDependencies 校验文件夹任务中文件的依赖：依赖项为同一请求中其他文件的相对路径或同一租户已有任务的ID，
+// 同一请求内的依赖不能形成环
+func (s *TaskStorage) ValidateFileDependencies(files []FileInfo, tenantID string) error {
+	// 相对路径到文件下标，路径重复时无法确定依赖的是哪个文件
+	indexByPath := make(map[string]int, len(files))
+	for i, file := range files {
+		relativePath := file.resolvedRelativePath()
+		if _, duplicated := indexByPath[relativePath]; duplicated {
+			indexByPath[relativePath] = -1
+			continue
+		}
+		indexByPath[relativePath] = i
+	}
+
+	edges := make([][]int, len(files))
+	for i, file := range files {
+		for _, dependency := range file.DependsOn {
+			if j, exists := indexByPath[dependency]; exists {
+				if j < 0 {
+					return fmt.Errorf("依赖的文件路径不唯一: %s", dependency)
+				}
+				if j == i {
+					return fmt.Errorf("文件不能依赖自身: %s", dependency)
+				}
+				edges[i] = append(edges[i], j)
+				continue
+			}
+			if _, exists := s.GetTenantTask(dependency, tenantID); !exists {
+				return fmt.Errorf("依赖的任务不存在: %s", dependency)
+			}
+		}
+	}
+
+	if cycle := findDependencyCycle(edges); cycle != nil {
+		paths := make([]string, len(cycle))
+		for i, index := range cycle {
+			paths[i] = files[index].resolvedRelativePath()
+		}
+		return fmt.Errorf("存在循环依赖: %v", paths)
+	}
+	return nil
+}
+
+// findDependencyCycle 深度优先搜索依赖图，返回发现的第一个环（首尾为同一节点），无环时返回nil
+func findDependencyCycle(edges [][]int) []int {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(edges))
+	stack := make([]int, 0, len(edges))
+
+	var visit func(node int) []int
+	visit = func(node int) []int {
+		state[node] = visiting
+		stack = append(stack, node)
+		for _, next := range edges[node] {
+			switch state[next] {
+			case visiting:
+				// 从栈中找到环的起点
+				for i, n := range stack {
+					if n == next {
+						return append(append([]int{}, stack[i:]...), next)
+					}
+				}
+			case unvisited:
+				if cycle := visit(next); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[node] = visited
+		return nil
+	}
+
+	for node := range edges {
+		if state[node] == unvisited {
+			if cycle := visit(node); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package utils
+
+// mergeSpaceMargin 合并前要求的磁盘空间余量（文件大小的110%）
+const mergeSpaceMargin = 1.1
+
+// FilesystemStats 路径所在文件系统的空间统计
+type FilesystemStats struct {
+	TotalBytes     int64   `json:"total_bytes"`     // 文件系统总容量
+	FreeBytes      int64   `json:"free_bytes"`      // 空闲空间（含为特权用户保留的部分）
+	AvailableBytes int64   `json:"available_bytes"` // 对当前用户可用的空间
+	UsedBytes      int64   `json:"used_bytes"`      // 已使用空间
+	UsagePercent   float64 `json:"usage_percent"`   // 已使用空间占总容量的百分比
+}
+
+// newFilesystemStats 根据总容量和空闲空间计算已使用空间和使用率
+func newFilesystemStats(total, free, available int64) FilesystemStats {
+	stats := FilesystemStats{
+		TotalBytes:     total,
+		FreeBytes:      free,
+		AvailableBytes: available,
+		UsedBytes:      total - free,
+	}
+	if total > 0 {
+		stats.UsagePercent = float64(stats.UsedBytes) / float64(total) * 100
+	}
+	return stats
+}
+
+// AvailableBytes 获取路径所在文件系统对当前用户可用的字节数
+func AvailableBytes(path string) (int64, error) {
+	stats, err := GetFilesystemStats(path)
+	if err != nil {
+		return 0, err
+	}
+	return stats.AvailableBytes, nil
+}
+
+// RequiredMergeSpace 计算合并指定大小的文件所需的磁盘空间
+func RequiredMergeSpace(fileSize int64) int64 {
+	return int64(float64(fileSize) * mergeSpaceMargin)
+}
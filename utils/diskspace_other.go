@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package utils
+
+import "fmt"
+
+// GetFilesystemStats 当前平台不支持查询磁盘空间
+func GetFilesystemStats(path string) (FilesystemStats, error) {
+	return FilesystemStats{}, fmt.Errorf("当前平台不支持查询磁盘空间")
+}
@@ -0,0 +1,18 @@
+//go:build linux || darwin || freebsd
+
+package utils
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// GetFilesystemStats 通过 statfs 获取路径所在文件系统的空间统计，可用空间不含为root保留的块
+func GetFilesystemStats(path string) (FilesystemStats, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return FilesystemStats{}, fmt.Errorf("获取磁盘空间失败: %v", err)
+	}
+	blockSize := int64(stat.Bsize)
+	return newFilesystemStats(int64(stat.Blocks)*blockSize, int64(stat.Bfree)*blockSize, int64(stat.Bavail)*blockSize), nil
+}
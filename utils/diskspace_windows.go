@@ -0,0 +1,17 @@
+//go:build windows
+
+package utils
+
+import (
+	"fmt"
+	"golang.org/x/sys/windows"
+)
+
+// GetFilesystemStats 获取路径所在卷的空间统计，可用空间受当前用户的磁盘配额限制
+func GetFilesystemStats(path string) (FilesystemStats, error) {
+	var freeBytesAvailable, totalBytes, totalFreeBytes uint64
+	if err := windows.GetDiskFreeSpaceEx(windows.StringToUTF16Ptr(path), &freeBytesAvailable, &totalBytes, &totalFreeBytes); err != nil {
+		return FilesystemStats{}, fmt.Errorf("获取磁盘空间失败: %v", err)
+	}
+	return newFilesystemStats(int64(totalBytes), int64(totalFreeBytes), int64(freeBytesAvailable)), nil
+}
@@ -0,0 +1,274 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WebhookEventTaskDLQ 任务重试次数耗尽并移入死信队列
+const WebhookEventTaskDLQ = "task.dlq"
+
+// ErrDeadLetterNotFound 死信队列中不存在该任务
+var ErrDeadLetterNotFound = errors.New("死信队列中不存在该任务")
+
+// ErrTaskAlreadyActive 活动任务中已存在相同ID的任务
+var ErrTaskAlreadyActive = errors.New("活动任务中已存在相同ID的任务")
+
+// ErrTaskInDeadLetter 任务在死信队列中，重试移回活动任务之前不接受该ID的分片
+var ErrTaskInDeadLetter = errors.New("任务已移入死信队列，请先调用 POST /dlq/{file_id}/retry 重试")
+
+// DeadLetterEntry 死信队列条目
+type DeadLetterEntry struct {
+	Task          *UploadTask `json:"task"`
+	FailureReason string      `json:"failure_reason"`
+	FailedAt      time.Time   `json:"failed_at"`
+}
+
+// DeadLetterQueue 保存永久失败的任务，每个任务一个JSON文件，分片文件保留以便重试
+type DeadLetterQueue struct {
+	dir   string
+	mutex sync.Mutex
+}
+
+// DLQ 全局死信队列
+var DLQ *DeadLetterQueue
+
+// NewDeadLetterQueue 创建死信队列目录
+func NewDeadLetterQueue(dir string) (*DeadLetterQueue, error) {
+	if err := EnsureDirectory(dir); err != nil {
+		return nil, fmt.Errorf("创建死信队列目录失败: %v", err)
+	}
+	return &DeadLetterQueue{dir: dir}, nil
+}
+
+// entryPath 条目文件路径，文件名使用安全的文件ID，兼容升级前以短哈希命名的条目
+func (q *DeadLetterQueue) entryPath(fileID string) string {
+	return legacySafePath(q.dir, fileID, ".json")
+}
+
+// Add 将任务写入死信队列
+func (q *DeadLetterQueue) Add(task *UploadTask, reason string) (*DeadLetterEntry, error) {
+	entry := &DeadLetterEntry{
+		Task:          task,
+		FailureReason: reason,
+		FailedAt:      time.Now(),
+	}
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	writer, err := NewAtomicWriter(q.entryPath(task.FileID))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(data); err != nil {
+		writer.Rollback()
+		return nil, fmt.Errorf("写入死信队列失败: %v", err)
+	}
+	if err := writer.Commit(); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Get 读取单个条目
+func (q *DeadLetterQueue) Get(fileID string) (*DeadLetterEntry, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return q.readEntry(q.entryPath(fileID))
+}
+
+// List 列出所有条目，最近失败的排在前面
+func (q *DeadLetterQueue) List() ([]*DeadLetterEntry, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	files, err := os.ReadDir(q.dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取死信队列失败: %v", err)
+	}
+
+	entries := make([]*DeadLetterEntry, 0, len(files))
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		entry, err := q.readEntry(filepath.Join(q.dir, file.Name()))
+		if err != nil {
+			Logger.Warn("跳过无法读取的死信队列条目", "file", file.Name(), "error", err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].FailedAt.After(entries[j].FailedAt)
+	})
+	return entries, nil
+}
+
+// Contains 死信队列中是否存在该任务，队列为nil时返回false
+func (q *DeadLetterQueue) Contains(fileID string) bool {
+	if q == nil {
+		return false
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	_, err := os.Stat(q.entryPath(fileID))
+	return err == nil
+}
+
+// Remove 删除条目
+func (q *DeadLetterQueue) Remove(fileID string) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if err := os.Remove(q.entryPath(fileID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("删除死信队列条目失败: %v", err)
+	}
+	return nil
+}
+
+// readEntry 读取并解析条目文件，调用方需持有锁
+func (q *DeadLetterQueue) readEntry(path string) (*DeadLetterEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrDeadLetterNotFound
+		}
+		return nil, err
+	}
+
+	var entry DeadLetterEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("解析死信队列条目失败: %v", err)
+	}
+	if entry.Task == nil {
+		return nil, fmt.Errorf("死信队列条目缺少任务信息")
+	}
+	entry.Task.persistedStatus = entry.Task.Status
+	return &entry, nil
+}
+
+// moveToDeadLetterInternal 将任务移出活动存储并写入死信队列，写入失败时任务保留在活动存储中，调用方需持有锁
+func (s *TaskStorage) moveToDeadLetterInternal(task *UploadTask) {
+	if DLQ == nil {
+		return
+	}
+
+	entry, err := DLQ.Add(task, task.FailureReason)
+	if err != nil {
+		Logger.Error("任务移入死信队列失败", "file_id", task.FileID, "error", err)
+		return
+	}
+	if AutoMergeQueue != nil {
+		AutoMergeQueue.Forget(task.FileID)
+	}
+	speedometers.remove(task.FileID)
+	if err := s.backend.DeleteTask(task.FileID); err != nil {
+		Logger.Error("从活动存储中移除任务失败", "file_id", task.FileID, "error", err)
+	}
+	Logger.Warn("任务已移入死信队列", "file_id", task.FileID, "failure_reason", entry.FailureReason)
+
+	event := NewWebhookEvent(WebhookEventTaskDLQ, task)
+	event.Data = map[string]interface{}{
+		"failure_reason":            entry.FailureReason,
+		"failed_at":                 entry.FailedAt,
+		"permanently_failed_chunks": task.PermanentlyFailedChunks(),
+	}
+	Dispatch(event)
+}
+
+// RetryDeadLetter 将死信队列中的任务重置重试次数后移回活动存储
+func (s *TaskStorage) RetryDeadLetter(fileID string) (*UploadTask, error) {
+	if DLQ == nil {
+		return nil, ErrDeadLetterNotFound
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry, err := DLQ.Get(fileID)
+	if err != nil {
+		return nil, err
+	}
+	if _, exists := s.backend.GetTask(fileID); exists {
+		return nil, ErrTaskAlreadyActive
+	}
+
+	task := entry.Task
+	for index, chunk := range task.Chunks {
+		chunk.RetryCount = 0
+		if chunk.Status == "failed" || chunk.Status == ChunkStatusPermanentlyFailed {
+			chunk.Status = "pending"
+		}
+		task.Chunks[index] = chunk
+	}
+	task.RetryCount = 0
+	task.FailureReason = ""
+	if err := transitionTaskInternal(task, "uploading"); err != nil {
+		return nil, err
+	}
+	task.UpdatedAt = time.Now()
+
+	if err := s.backend.SaveTask(task); err != nil {
+		return nil, err
+	}
+	if err := DLQ.Remove(fileID); err != nil {
+		Logger.Error("删除死信队列条目失败", "file_id", fileID, "error", err)
+	}
+
+	Events.Publish(NewTaskEvent(task))
+	return task, nil
+}
+
+// reapDeadLettersInternal 删除超过保留期的死信队列条目及其分片文件，调用方需持有锁
+func (s *TaskStorage) reapDeadLettersInternal(cutoff time.Time) int {
+	entries, err := DLQ.List()
+	if err != nil {
+		Logger.Error("读取死信队列失败", "error", err)
+		return 0
+	}
+
+	reaped := 0
+	for _, entry := range entries {
+		if !entry.FailedAt.Before(cutoff) {
+			continue
+		}
+		// 相同ID的任务已重新上传时分片目录属于活动任务
+		if _, active := s.backend.GetTask(entry.Task.FileID); !active {
+			releaseChunkObjects(entry.Task)
+			removeTaskArtifacts(entry.Task.FileID)
+		}
+		if err := DLQ.Remove(entry.Task.FileID); err != nil {
+			Logger.Error("删除死信队列条目失败", "file_id", entry.Task.FileID, "error", err)
+			continue
+		}
+		reaped++
+	}
+	return reaped
+}
+
+// initDLQ 初始化死信队列
+func initDLQ() error {
+	queue, err := NewDeadLetterQueue(filepath.Join(CurrentConfig().UploadDir, ".dlq"))
+	if err != nil {
+		return err
+	}
+	DLQ = queue
+	return nil
+}
@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// mergeMetricsWindow 估算合并吞吐量使用的最近合并次数
+const mergeMetricsWindow = 32
+
+// defaultMergeThroughputBps 没有合并记录时假定的合并吞吐量（100MB/s）
+const defaultMergeThroughputBps = 100 * 1024 * 1024
+
+// mergeSample 一次合并的文件大小和耗时
+type mergeSample struct {
+	bytes    int64
+	duration time.Duration
+}
+
+// MergeMetrics 以环形缓冲区保存最近的合并耗时，用于估算合并吞吐量
+type MergeMetrics struct {
+	mutex   sync.Mutex
+	samples []mergeSample
+	next    int
+	count   int
+	seeded  bool // 已用任务中保存的合并耗时填充过
+}
+
+// Merges 全局合并耗时统计
+var Merges = &MergeMetrics{samples: make([]mergeSample, mergeMetricsWindow)}
+
+// Record 记录一次从分片合并的文件
+func (m *MergeMetrics) Record(bytes int64, duration time.Duration) {
+	if bytes <= 0 || duration <= 0 {
+		return
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.recordInternal(mergeSample{bytes: bytes, duration: duration})
+}
+
+// recordInternal 写入环形缓冲区，调用方需持有锁
+func (m *MergeMetrics) recordInternal(sample mergeSample) {
+	m.samples[m.next] = sample
+	m.next = (m.next + 1) % len(m.samples)
+	if m.count < len(m.samples) {
+		m.count++
+	}
+}
+
+// Throughput 返回最近合并的吞吐量（字节/秒）和样本数；重启后尚无记录时先用已完成任务中保存的合并耗时填充，
+// 仍没有样本时返回默认值
+func (m *MergeMetrics) Throughput() (float64, int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.count == 0 && !m.seeded && Storage != nil {
+		for _, sample := range recentTaskMergeSamples(Storage.GetAllTasks()) {
+			m.recordInternal(sample)
+		}
+		m.seeded = true
+	}
+	if m.count == 0 {
+		return defaultMergeThroughputBps, 0
+	}
+
+	var bytes int64
+	var duration time.Duration
+	for i := 0; i < m.count; i++ {
+		bytes += m.samples[i].bytes
+		duration += m.samples[i].duration
+	}
+	return float64(bytes) / duration.Seconds(), m.count
+}
+
+// recentTaskMergeSamples 最近完成的单文件任务中记录的合并耗时
+func recentTaskMergeSamples(tasks map[string]*UploadTask) []mergeSample {
+	merged := make([]*UploadTask, 0)
+	for _, task := range tasks {
+		if task.TaskType != "folder" && task.Status == "completed" && task.MergeTime > 0 && task.FileSize > 0 {
+			merged = append(merged, task)
+		}
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].UpdatedAt.After(merged[j].UpdatedAt)
+	})
+	if len(merged) > mergeMetricsWindow {
+		merged = merged[:mergeMetricsWindow]
+	}
+
+	samples := make([]mergeSample, 0, len(merged))
+	for _, task := range merged {
+		samples = append(samples, mergeSample{bytes: task.FileSize, duration: task.MergeTime})
+	}
+	return samples
+}
+
+// SuggestedChunkSize 按并发上传数建议的分片大小：file_size / (ConcurrentUploads * 2)，限制在 [MinChunkSize, MaxChunkSize] 内
+func SuggestedChunkSize(fileSize int64) int64 {
+	parts := int64(CurrentConfig().ConcurrentUploads) * 2
+	if parts < 1 {
+		parts = 1
+	}
+
+	size := fileSize / parts
+	if minSize := CurrentConfig().MinChunkSize; size < minSize {
+		size = minSize
+	}
+	if size > CurrentConfig().MaxChunkSize {
+		size = CurrentConfig().MaxChunkSize
+	}
+	if size < 1 {
+		size = 1
+	}
+	return size
+}
@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// eventBufferSize 每个订阅者的事件缓冲大小
+const eventBufferSize = 16
+
+// TaskEvent 任务进度事件
+type TaskEvent struct {
+	FileID         string    `json:"file_id"`
+	Status         string    `json:"status"`
+	UploadedChunks int       `json:"uploaded_chunks"`
+	TotalChunks    int       `json:"total_chunks"`
+	CompletionRate float64   `json:"completion_rate"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// EventBus 进程内任务事件发布/订阅总线
+type EventBus struct {
+	mutex       sync.RWMutex
+	subscribers map[string]map[<-chan TaskEvent]chan TaskEvent
+}
+
+// Events 全局事件总线
+var Events = NewEventBus()
+
+// NewEventBus 创建事件总线
+func NewEventBus() *EventBus {
+	return &EventBus{
+		subscribers: make(map[string]map[<-chan TaskEvent]chan TaskEvent),
+	}
+}
+
+// Subscribe 订阅指定任务的事件
+func (b *EventBus) Subscribe(fileID string) <-chan TaskEvent {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	ch := make(chan TaskEvent, eventBufferSize)
+	if b.subscribers[fileID] == nil {
+		b.subscribers[fileID] = make(map[<-chan TaskEvent]chan TaskEvent)
+	}
+	b.subscribers[fileID][ch] = ch
+	return ch
+}
+
+// Unsubscribe 取消订阅并关闭通道
+func (b *EventBus) Unsubscribe(fileID string, ch <-chan TaskEvent) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	subs, exists := b.subscribers[fileID]
+	if !exists {
+		return
+	}
+	if sendCh, ok := subs[ch]; ok {
+		delete(subs, ch)
+		close(sendCh)
+	}
+	if len(subs) == 0 {
+		delete(b.subscribers, fileID)
+	}
+}
+
+// Publish 发布事件，订阅者缓冲已满时丢弃事件，不阻塞调用方
+func (b *EventBus) Publish(event TaskEvent) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	for _, ch := range b.subscribers[event.FileID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// NewTaskEvent 根据任务当前状态构建事件
+func NewTaskEvent(task *UploadTask) TaskEvent {
+	uploaded := len(completedChunkIndexes(task))
+	completionRate := float64(0)
+	if task.TotalChunks > 0 {
+		completionRate = float64(uploaded) / float64(task.TotalChunks) * 100
+	}
+
+	return TaskEvent{
+		FileID:         task.FileID,
+		Status:         task.Status,
+		UploadedChunks: uploaded,
+		TotalChunks:    task.TotalChunks,
+		CompletionRate: completionRate,
+		Timestamp:      time.Now(),
+	}
+}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// SortedTaskIDs 按创建时间排序的所有任务ID，导出时逐个读取任务
+func (s *TaskStorage) SortedTaskIDs() []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	tasks := s.backend.GetAllTasks()
+	ids := make([]string, 0, len(tasks))
+	for id := range tasks {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := tasks[ids[i]], tasks[ids[j]]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+// ValidateImportedTask 校验导入的任务记录
+func ValidateImportedTask(task *UploadTask) error {
+	if task.FileID == "" {
+		return fmt.Errorf("缺少file_id")
+	}
+	switch task.TaskType {
+	case "", "file", "folder":
+	default:
+		return fmt.Errorf("无效的task_type: %s", task.TaskType)
+	}
+	if _, known := transitions[task.Status]; !known {
+		return fmt.Errorf("无效的status: %s", task.Status)
+	}
+	if task.TotalChunks < 0 {
+		return fmt.Errorf("无效的total_chunks: %d", task.TotalChunks)
+	}
+	for index := range task.Chunks {
+		if index < 0 || (task.TotalChunks > 0 && index >= task.TotalChunks) {
+			return fmt.Errorf("分片索引超出范围: %d", index)
+		}
+	}
+	return nil
+}
+
+// ImportTask 按原样保存导入的任务，保留时间戳且不触发状态Webhook；已存在相同ID的任务时返回 ErrTaskAlreadyActive，除非 overwrite 为true
+func (s *TaskStorage) ImportTask(task *UploadTask, overwrite bool) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.backend.GetTask(task.FileID); exists && !overwrite {
+		return ErrTaskAlreadyActive
+	}
+
+	// 缺少时间戳的记录按导入时间处理，避免被立即当作过期任务清理
+	now := time.Now()
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = now
+	}
+	if task.UpdatedAt.IsZero() {
+		task.UpdatedAt = now
+	}
+
+	task.persistedStatus = task.Status
+	if err := s.backend.SaveTask(task); err != nil {
+		return err
+	}
+
+	Events.Publish(NewTaskEvent(task))
+	return nil
+}
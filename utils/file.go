@@ -1,10 +1,90 @@
 package utils
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 func EnsureDir(path string) {
 	os.MkdirAll(filepath.Dir(path), os.ModePerm)
 }
+
+// DirSizeResult 目录大小统计结果
+type DirSizeResult struct {
+	Size      int64         `json:"size"`
+	FileCount int64         `json:"file_count"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// dirEntry 遍历栈中的目录及其深度
+type dirEntry struct {
+	path  string
+	depth int
+}
+
+// GetDirSize 统计目录大小，超过maxDepth的子目录不再遍历，上下文取消时立即返回
+func GetDirSize(ctx context.Context, path string, maxDepth int) (DirSizeResult, error) {
+	type outcome struct {
+		result DirSizeResult
+		err    error
+	}
+
+	start := time.Now()
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := walkDirSize(ctx, path, maxDepth)
+		done <- outcome{result: result, err: err}
+	}()
+
+	select {
+	case out := <-done:
+		out.result.Duration = time.Since(start)
+		return out.result, out.err
+	case <-ctx.Done():
+		return DirSizeResult{Duration: time.Since(start)}, ctx.Err()
+	}
+}
+
+// walkDirSize 使用显式栈遍历目录，避免递归和 filepath.Walk 的排序开销
+func walkDirSize(ctx context.Context, root string, maxDepth int) (DirSizeResult, error) {
+	var result DirSizeResult
+	stack := []dirEntry{{path: root, depth: 0}}
+
+	for len(stack) > 0 {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		entries, err := os.ReadDir(current.path)
+		if err != nil {
+			// 根目录不可读时返回错误，子目录在遍历期间被删除则忽略
+			if current.depth == 0 {
+				return result, err
+			}
+			continue
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() {
+				if maxDepth <= 0 || current.depth < maxDepth {
+					stack = append(stack, dirEntry{path: filepath.Join(current.path, entry.Name()), depth: current.depth + 1})
+				}
+				continue
+			}
+
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			result.Size += info.Size()
+			result.FileCount++
+		}
+	}
+
+	return result, nil
+}
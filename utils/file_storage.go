@@ -0,0 +1,443 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileBackend 基于内存+JSON文件的持久化后端
+type FileBackend struct {
+	storageDir string
+	mutex      sync.RWMutex
+	tasks      map[string]*UploadTask
+	wal        *WriteAheadLog // 启用预写日志时任务更新先追加到日志，检查点时才写入JSON快照
+	names      *CollisionRegistry
+	embedded   map[string]string // 保存在父文件夹任务文件中的子任务ID -> 父任务ID，由元数据压缩生成
+	revisions  map[string]uint64 // 任务ID -> 最近一次保存的修改序号，元数据压缩据此判断任务是否有更新
+	revision   uint64
+}
+
+// NewFileBackend 创建文件持久化后端并加载已存在的任务
+func NewFileBackend(storageDir string) (*FileBackend, error) {
+	if err := EnsureDirectory(storageDir); err != nil {
+		return nil, fmt.Errorf("创建元数据目录失败: %v", err)
+	}
+
+	fb := &FileBackend{
+		storageDir: storageDir,
+		tasks:      make(map[string]*UploadTask),
+		names:      NewCollisionRegistry(),
+		embedded:   make(map[string]string),
+		revisions:  make(map[string]uint64),
+	}
+
+	if CurrentConfig().EnableWAL {
+		wal, err := OpenWriteAheadLog(filepath.Join(storageDir, walFileName))
+		if err != nil {
+			return nil, err
+		}
+		fb.wal = wal
+	}
+
+	if err := fb.loadTasks(); err != nil {
+		return nil, err
+	}
+	return fb, nil
+}
+
+// SaveTask 保存任务到内存和磁盘
+func (fb *FileBackend) SaveTask(task *UploadTask) error {
+	fb.mutex.Lock()
+	defer fb.mutex.Unlock()
+
+	fb.tasks[task.FileID] = task
+	fb.revision++
+	fb.revisions[task.FileID] = fb.revision
+	// 合并在父任务文件中的子任务有更新后改为单独保存，加载时单独的文件优先
+	delete(fb.embedded, task.FileID)
+	if fb.wal == nil {
+		return fb.saveTaskFile(task)
+	}
+
+	data, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+	if err := fb.wal.Append(WALOpSave, task.FileID, data); err != nil {
+		return err
+	}
+	return fb.checkpointIfNeededInternal()
+}
+
+// GetTask 获取任务
+func (fb *FileBackend) GetTask(fileID string) (*UploadTask, bool) {
+	fb.mutex.RLock()
+	defer fb.mutex.RUnlock()
+
+	task, exists := fb.tasks[fileID]
+	return task, exists
+}
+
+// DeleteTask 删除任务及其元数据文件
+func (fb *FileBackend) DeleteTask(fileID string) error {
+	fb.mutex.Lock()
+	defer fb.mutex.Unlock()
+
+	// 先记录删除，避免回放日志时恢复已删除的任务
+	if fb.wal != nil {
+		if err := fb.wal.Append(WALOpDelete, fileID, nil); err != nil {
+			return err
+		}
+	}
+
+	taskFile := filepath.Join(fb.storageDir, fmt.Sprintf("%s.json", fb.names.Resolve(fileID)))
+	if err := os.Remove(taskFile); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	task := fb.tasks[fileID]
+	delete(fb.tasks, fileID)
+	delete(fb.revisions, fileID)
+	fb.names.Forget(fileID)
+	if task != nil {
+		fb.unembedInternal(task)
+	}
+	return nil
+}
+
+// unembedInternal 任务删除后更新合并保存的子任务：子任务被删除时重写父任务文件，避免重启后恢复；
+// 父任务被删除时其余子任务改为单独保存。调用方需持有锁
+func (fb *FileBackend) unembedInternal(task *UploadTask) {
+	if parentID, embedded := fb.embedded[task.FileID]; embedded {
+		delete(fb.embedded, task.FileID)
+		if parent, exists := fb.tasks[parentID]; exists {
+			if err := fb.saveTaskFile(parent); err != nil {
+				Logger.Error("重写父任务文件失败", "file_id", parentID, "error", err)
+			}
+		}
+		return
+	}
+
+	for _, subID := range task.SubTasks {
+		if fb.embedded[subID] != task.FileID {
+			continue
+		}
+		delete(fb.embedded, subID)
+		if sub, exists := fb.tasks[subID]; exists {
+			if err := fb.saveTaskFile(sub); err != nil {
+				Logger.Error("保存子任务文件失败", "file_id", subID, "error", err)
+			}
+		}
+	}
+}
+
+// GetAllTasks 获取所有任务
+func (fb *FileBackend) GetAllTasks() map[string]*UploadTask {
+	fb.mutex.RLock()
+	defer fb.mutex.RUnlock()
+
+	tasks := make(map[string]*UploadTask, len(fb.tasks))
+	for k, v := range fb.tasks {
+		tasks[k] = v
+	}
+	return tasks
+}
+
+// FileIDCollisions 返回检测到的安全文件名冲突
+func (fb *FileBackend) FileIDCollisions() []FileIDCollision {
+	return fb.names.Collisions()
+}
+
+// Close 关闭后端，启用预写日志时先写入检查点
+func (fb *FileBackend) Close() error {
+	if fb.wal == nil {
+		return nil
+	}
+
+	fb.mutex.Lock()
+	defer fb.mutex.Unlock()
+
+	if err := fb.checkpointInternal(); err != nil {
+		fb.wal.Close()
+		return err
+	}
+	return fb.wal.Close()
+}
+
+// Checkpoint 将所有任务写入JSON快照并清空预写日志
+func (fb *FileBackend) Checkpoint() error {
+	if fb.wal == nil {
+		return nil
+	}
+
+	fb.mutex.Lock()
+	defer fb.mutex.Unlock()
+
+	return fb.checkpointInternal()
+}
+
+// checkpointIfNeededInternal 预写日志超过 WALMaxSizeMB 时写入检查点，调用方需持有锁
+func (fb *FileBackend) checkpointIfNeededInternal() error {
+	if CurrentConfig().WALMaxSizeMB <= 0 || fb.wal.Size() < int64(CurrentConfig().WALMaxSizeMB)*1024*1024 {
+		return nil
+	}
+	return fb.checkpointInternal()
+}
+
+// checkpointInternal 写入所有任务的快照后清空预写日志，任一快照写入失败时保留日志，调用方需持有锁
+func (fb *FileBackend) checkpointInternal() error {
+	start := time.Now()
+	for fileID, task := range fb.tasks {
+		if err := fb.saveTaskFile(task); err != nil {
+			return fmt.Errorf("写入任务快照失败 %s: %v", fileID, err)
+		}
+	}
+	if err := fb.wal.Reset(); err != nil {
+		return err
+	}
+	Logger.Info("已写入任务检查点", "tasks", len(fb.tasks), "duration_ms", time.Since(start).Milliseconds())
+	return nil
+}
+
+// replayWAL 在JSON快照之上回放预写日志，日志中的记录都晚于最近一次检查点
+func (fb *FileBackend) replayWAL() error {
+	replayed, err := fb.wal.Replay(func(entry WALEntry) error {
+		switch entry.Op {
+		case WALOpSave:
+			var task UploadTask
+			if err := json.Unmarshal(entry.Payload, &task); err != nil {
+				return fmt.Errorf("解析预写日志记录失败 %d: %v", entry.Sequence, err)
+			}
+			normalizeTask(&task)
+			fb.tasks[entry.TaskID] = &task
+		case WALOpDelete:
+			delete(fb.tasks, entry.TaskID)
+			delete(fb.embedded, entry.TaskID)
+			os.Remove(filepath.Join(fb.storageDir, fmt.Sprintf("%s.json", fb.names.Resolve(entry.TaskID))))
+			fb.names.Forget(entry.TaskID)
+		default:
+			Logger.Warn("跳过未知的预写日志操作", "op", entry.Op, "sequence", entry.Sequence)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if replayed == 0 {
+		return nil
+	}
+
+	// 回放后立即写入检查点，使JSON快照与内存一致
+	Logger.Info("已回放预写日志", "entries", replayed)
+	return fb.checkpointInternal()
+}
+
+// loadTasks 加载所有已存在的任务
+func (fb *FileBackend) loadTasks() error {
+	if err := fb.recoverCorruptedTaskFiles(); err != nil {
+		return fmt.Errorf("恢复损坏的元数据文件失败: %v", err)
+	}
+
+	files, err := os.ReadDir(fb.storageDir)
+	if err != nil {
+		return err
+	}
+
+	embeddedTasks := make(map[string]*UploadTask)
+	for _, file := range files {
+		if filepath.Ext(file.Name()) == ".json" {
+			taskFile := filepath.Join(fb.storageDir, file.Name())
+			data, err := os.ReadFile(taskFile)
+			if err != nil {
+				continue
+			}
+
+			// 跳过无法解析或非任务的元数据文件（如索引文件）
+			var record taskFileRecord
+			if err := json.Unmarshal(data, &record); err != nil || record.UploadTask == nil || record.FileID == "" {
+				continue
+			}
+
+			task := record.UploadTask
+			normalizeTask(task)
+			fb.tasks[task.FileID] = task
+			fb.names.Register(strings.TrimSuffix(file.Name(), ".json"), task.FileID)
+			for _, sub := range record.EmbeddedSubTasks {
+				if sub != nil && sub.FileID != "" {
+					embeddedTasks[sub.FileID] = sub
+					fb.embedded[sub.FileID] = task.FileID
+				}
+			}
+		}
+	}
+	fb.names.DetectRegistered()
+
+	// 子任务有单独的文件时，说明压缩后又有更新，以单独的文件为准
+	for subID, sub := range embeddedTasks {
+		if _, exists := fb.tasks[subID]; exists {
+			delete(fb.embedded, subID)
+			continue
+		}
+		normalizeTask(sub)
+		fb.tasks[subID] = sub
+	}
+
+	if fb.wal != nil {
+		return fb.replayWAL()
+	}
+	return nil
+}
+
+// storagePersistBreaker 任务文件持久化的熔断器，磁盘持续出错时快速失败
+var storagePersistBreaker = RegisterCircuitBreaker("storage_persist", nil)
+
+// saveTaskFile 保存单个任务文件，安全文件名与其他任务冲突时使用更长的哈希；
+// 合并在父任务文件中的子任务随父任务一起写入，调用方需持有锁
+func (fb *FileBackend) saveTaskFile(task *UploadTask) error {
+	if _, embedded := fb.embedded[task.FileID]; embedded {
+		return nil
+	}
+
+	record := &taskFileRecord{UploadTask: task}
+	for _, subID := range task.SubTasks {
+		if fb.embedded[subID] != task.FileID {
+			continue
+		}
+		if sub, exists := fb.tasks[subID]; exists {
+			record.EmbeddedSubTasks = append(record.EmbeddedSubTasks, sub)
+		}
+	}
+	sort.Slice(record.EmbeddedSubTasks, func(i, j int) bool {
+		return record.EmbeddedSubTasks[i].FileID < record.EmbeddedSubTasks[j].FileID
+	})
+
+	return storagePersistBreaker.Execute(func() error {
+		return writeTaskRecordAs(fb.storageDir, fb.names.Resolve(task.FileID), record)
+	})
+}
+
+// writeTaskFile 将任务写入目录下的JSON文件
+func writeTaskFile(dir string, task *UploadTask) error {
+	// 使用安全的文件名
+	return writeTaskFileAs(dir, SafeFileKey(task.FileID), task)
+}
+
+// writeTaskFileAs 将任务写入目录下指定名称的JSON文件
+func writeTaskFileAs(dir, safeFileID string, task *UploadTask) error {
+	return writeTaskRecordAs(dir, safeFileID, &taskFileRecord{UploadTask: task})
+}
+
+// writeTaskRecordAs 将任务文件内容写入目录下指定名称的JSON文件
+func writeTaskRecordAs(dir, safeFileID string, record *taskFileRecord) error {
+	taskFile := filepath.Join(dir, fmt.Sprintf("%s.json", safeFileID))
+
+	// 确保目标目录存在（处理嵌套目录）
+	if err := EnsureDirectory(filepath.Dir(taskFile)); err != nil {
+		return fmt.Errorf("创建任务文件目录失败: %v", err)
+	}
+
+	data, err := marshalTaskRecord(record)
+	if err != nil {
+		return err
+	}
+
+	// 先写临时文件再重命名，写入中途崩溃不会留下空的或不完整的任务文件
+	writer, err := NewAtomicWriter(taskFile)
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(data); err != nil {
+		writer.Rollback()
+		return fmt.Errorf("写入任务文件失败: %v", err)
+	}
+	return writer.Commit()
+}
+
+// recoverCorruptedTaskFiles 检查元数据目录中为空或无法解析的JSON文件，用同名的 .json.tmp.* 临时文件中
+// 最新的有效版本恢复；临时文件是重命名前崩溃留下的，恢复后其余临时文件一并删除
+func (fb *FileBackend) recoverCorruptedTaskFiles() error {
+	entries, err := os.ReadDir(fb.storageDir)
+	if err != nil {
+		return err
+	}
+
+	// 按目标文件名归集临时文件
+	tempFiles := make(map[string][]string)
+	for _, entry := range entries {
+		name := entry.Name()
+		index := strings.Index(name, ".json.tmp.")
+		if entry.IsDir() || index < 0 {
+			continue
+		}
+		target := name[:index+len(".json")]
+		tempFiles[target] = append(tempFiles[target], name)
+	}
+
+	for target, temps := range tempFiles {
+		targetPath := filepath.Join(fb.storageDir, target)
+		if !validJSONFile(targetPath) {
+			// 临时文件名以纳秒时间戳结尾，从最新的开始尝试
+			sort.Sort(sort.Reverse(sort.StringSlice(temps)))
+			for i, temp := range temps {
+				tempPath := filepath.Join(fb.storageDir, temp)
+				if !validJSONFile(tempPath) {
+					continue
+				}
+				if err := os.Rename(tempPath, targetPath); err != nil {
+					return err
+				}
+				Logger.Warn("已从临时文件恢复损坏的元数据文件", "file", target, "temp_file", temp)
+				temps = append(temps[:i], temps[i+1:]...)
+				break
+			}
+		}
+
+		for _, temp := range temps {
+			os.Remove(filepath.Join(fb.storageDir, temp))
+		}
+	}
+
+	// 无法恢复的文件保留原样，加载时跳过并记录
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".json" {
+			continue
+		}
+		if !validJSONFile(filepath.Join(fb.storageDir, name)) {
+			Logger.Error("元数据文件已损坏且没有可用的临时文件", "file", name)
+		}
+	}
+	return nil
+}
+
+// validJSONFile 检查文件是否存在且内容为有效的JSON
+func validJSONFile(path string) bool {
+	data, err := os.ReadFile(path)
+	return err == nil && len(data) > 0 && json.Valid(data)
+}
+
+// normalizeTask 向后兼容：为旧任务设置默认值
+func normalizeTask(task *UploadTask) {
+	if task.TaskType == "" {
+		task.TaskType = "file"
+	}
+	if task.Chunks == nil {
+		task.Chunks = make(map[int]ChunkInfo)
+	}
+	if task.SubTasks == nil {
+		task.SubTasks = make([]string, 0)
+	}
+	if task.UploadedChunks == nil && len(task.Chunks) > 0 {
+		task.rebuildChunkBitmap()
+	}
+	if task.CurrentBytes == 0 && len(task.Chunks) > 0 {
+		task.recalculateCurrentBytes()
+	}
+	task.persistedStatus = task.Status
+}
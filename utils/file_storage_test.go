@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadTasksAfterCrashDuringWrite(t *testing.T) {
+	saved := Config
+	defer func() { Config = saved }()
+	Config.EnableWAL = false
+
+	dir := t.TempDir()
+	backend, err := NewFileBackend(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	taskFiles := make(map[string]string)
+	for _, fileID := range []string{"recovered", "truncated", "interrupted"} {
+		if err := backend.SaveTask(newTestTask(fileID, 4)); err != nil {
+			t.Fatal(err)
+		}
+		taskFiles[fileID] = filepath.Join(dir, backend.names.Resolve(fileID)+".json")
+	}
+	backend.Close()
+
+	writeFile := func(path string, data []byte) {
+		t.Helper()
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	readFile := func(path string) []byte {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	// 直接写入目标文件时崩溃：目标文件只有一半内容，重命名前的临时文件完整
+	full := readFile(taskFiles["recovered"])
+	writeFile(taskFiles["recovered"], full[:len(full)/2])
+	writeFile(taskFiles["recovered"]+".tmp.100", full[:len(full)/3])
+	writeFile(taskFiles["recovered"]+".tmp.200", full)
+
+	// 截断后崩溃且没有临时文件，无法恢复
+	writeFile(taskFiles["truncated"], nil)
+
+	// 写临时文件时崩溃：目标文件完好，只留下不完整的临时文件
+	interrupted := readFile(taskFiles["interrupted"])
+	writeFile(taskFiles["interrupted"]+".tmp.300", interrupted[:10])
+
+	reloaded, err := NewFileBackend(dir)
+	if err != nil {
+		t.Fatalf("加载损坏的元数据目录失败: %v", err)
+	}
+	defer reloaded.Close()
+
+	if task, exists := reloaded.GetTask("recovered"); !exists || task.TotalChunks != 4 {
+		t.Fatalf("未从临时文件恢复任务: %+v", task)
+	}
+	if _, exists := reloaded.GetTask("truncated"); exists {
+		t.Fatal("空的元数据文件不应加载为任务")
+	}
+	if _, exists := reloaded.GetTask("interrupted"); !exists {
+		t.Fatal("目标文件完好的任务应正常加载")
+	}
+	if string(readFile(taskFiles["interrupted"])) != string(interrupted) {
+		t.Fatal("完好的目标文件不应被临时文件覆盖")
+	}
+
+	// 恢复后不再残留临时文件
+	if temps, _ := filepath.Glob(filepath.Join(dir, "*.json.tmp.*")); len(temps) != 0 {
+		t.Fatalf("残留临时文件: %v", temps)
+	}
+}
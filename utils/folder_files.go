@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrFolderCompleted 文件夹任务已完成，不能再添加文件
+var ErrFolderCompleted = errors.New("文件夹任务已完成，不能添加文件")
+
+// ErrFolderPathConflict 添加的文件与文件夹中已有文件的相对路径重复
+var ErrFolderPathConflict = errors.New("文件夹中已存在相同相对路径的文件")
+
+// AddFolderFiles 向已有文件夹任务追加文件，为每个文件创建子任务并累加文件夹大小，返回新子任务ID；
+// 相对路径与已有文件或同批其他文件重复时返回冲突的路径和 ErrFolderPathConflict，不做任何修改
+func (s *TaskStorage) AddFolderFiles(folderTaskID string, files []FileInfo, tenantID string) ([]string, []string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	folderTask, exists := s.backend.GetTask(folderTaskID)
+	if !exists || folderTask.TaskType != "folder" || !folderTask.VisibleToTenant(tenantID) {
+		return nil, nil, fmt.Errorf("文件夹任务不存在: %s", folderTaskID)
+	}
+	if folderTask.Status == "completed" {
+		return nil, nil, ErrFolderCompleted
+	}
+
+	// 已有子任务的相对路径，创建时未提供相对路径的文件按文件名计
+	existingPaths := make(map[string]bool, len(folderTask.SubTasks))
+	for _, subTaskID := range folderTask.SubTasks {
+		if subTask, exists := s.backend.GetTask(subTaskID); exists {
+			subTaskPath := subTask.RelativePath
+			if subTaskPath == "" {
+				subTaskPath = subTask.FileName
+			}
+			existingPaths[subTaskPath] = true
+		}
+	}
+
+	conflicts := make([]string, 0)
+	subTaskIDs := make([]string, len(files))
+	idByPath := make(map[string]string, len(files))
+	for i, file := range files {
+		relativePath := file.resolvedRelativePath()
+		resolvedPath := relativePath
+		if resolvedPath == "" {
+			resolvedPath = file.Name
+		}
+		if existingPaths[resolvedPath] {
+			conflicts = append(conflicts, resolvedPath)
+			continue
+		}
+		existingPaths[resolvedPath] = true
+		subTaskIDs[i] = fmt.Sprintf("%s_%s_%d", folderTaskID, relativePath, time.Now().UnixNano())
+		idByPath[relativePath] = subTaskIDs[i]
+	}
+	if len(conflicts) > 0 {
+		return nil, conflicts, ErrFolderPathConflict
+	}
+
+	var addedSize int64
+	for i, file := range files {
+		subTask := &UploadTask{
+			FileID:       subTaskIDs[i],
+			FileName:     file.Name,
+			RelativePath: file.resolvedRelativePath(),
+			TotalChunks:  file.TotalChunks,
+			FileSize:     file.Size,
+			TaskType:     "file",
+			Status:       "pending",
+			CreatedAt:    time.Now(),
+			UpdatedAt:    time.Now(),
+			Chunks:       make(map[int]ChunkInfo),
+			ParentTaskID: folderTaskID,
+			IsSubTask:    true,
+			TenantID:     folderTask.TenantID,
+		}
+		for _, dependency := range file.DependsOn {
+			if dependencyID, exists := idByPath[dependency]; exists {
+				dependency = dependencyID
+			}
+			subTask.DependsOn = append(subTask.DependsOn, dependency)
+		}
+
+		if err := s.backend.SaveTask(subTask); err != nil {
+			return nil, nil, fmt.Errorf("保存子任务失败: %v", err)
+		}
+		addedSize += file.Size
+	}
+
+	// 子任务全部保存后再挂到文件夹，摘要不会统计到未创建的子任务
+	folderTask.SubTasks = append(folderTask.SubTasks, subTaskIDs...)
+	folderTask.FileSize += addedSize
+	folderTask.UpdatedAt = time.Now()
+	if err := s.backend.SaveTask(folderTask); err != nil {
+		return nil, nil, fmt.Errorf("保存文件夹任务失败: %v", err)
+	}
+	return subTaskIDs, nil, nil
+}
@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// newTestFolderStorage 创建使用文件后端的任务存储
+func newTestFolderStorage(t *testing.T) *TaskStorage {
+	t.Helper()
+	dir := t.TempDir()
+	backend, err := NewFileBackend(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { backend.Close() })
+	return &TaskStorage{storageDir: dir, backend: backend}
+}
+
+func TestAddFolderFilesDuringUpload(t *testing.T) {
+	const adders, chunks, chunkSize = 8, 20, 1024
+	storage := newTestFolderStorage(t)
+
+	folder, err := storage.CreateFolderTask("photos", []FileInfo{
+		{Name: "a.jpg", RelativePath: "a.jpg", Size: chunks * chunkSize, TotalChunks: chunks},
+		{Name: "b.jpg", RelativePath: "b.jpg", Size: chunks * chunkSize, TotalChunks: chunks},
+	}, nil, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploading := append([]string(nil), folder.SubTasks...)
+
+	var wg sync.WaitGroup
+	// 已有子任务持续上传分片
+	for _, subTaskID := range uploading {
+		wg.Add(1)
+		go func(subTaskID string) {
+			defer wg.Done()
+			for index := 0; index < chunks; index++ {
+				info := ChunkInfo{Index: index, Size: chunkSize, Status: "completed"}
+				if err := storage.UpdateChunk(subTaskID, index, info); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(subTaskID)
+	}
+	// 同时追加文件，每批两个不同路径的文件
+	for i := 0; i < adders; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			files := []FileInfo{
+				{Name: "c.jpg", RelativePath: fmt.Sprintf("batch%d/c.jpg", i), Size: 100, TotalChunks: 1},
+				{Name: "d.jpg", RelativePath: fmt.Sprintf("batch%d/d.jpg", i), Size: 200, TotalChunks: 1},
+			}
+			if _, _, err := storage.AddFolderFiles(folder.FileID, files, ""); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	summary, err := storage.GetFolderTaskSummary(folder.FileID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 2 + adders*2; summary.TotalFiles != want {
+		t.Fatalf("total_files = %d, want %d", summary.TotalFiles, want)
+	}
+	if want := int64(2*chunks*chunkSize + adders*300); summary.TotalSize != want {
+		t.Fatalf("total_size = %d, want %d", summary.TotalSize, want)
+	}
+	if summary.CompletedFiles != 2 || summary.UploadedSize != 2*chunks*chunkSize {
+		t.Fatalf("上传中的子任务进度丢失: %+v", summary)
+	}
+
+	// 文件夹任务只保存一份子任务列表，并发追加不能覆盖彼此或上传进度
+	stored, _ := storage.GetTask(folder.FileID)
+	if stored.FileSize != summary.TotalSize {
+		t.Fatalf("file_size = %d, want %d", stored.FileSize, summary.TotalSize)
+	}
+	for _, subTaskID := range uploading {
+		if subTask, _ := storage.GetTask(subTaskID); subTask.Status != "completed" {
+			t.Fatalf("%s: status = %s, want completed", subTaskID, subTask.Status)
+		}
+	}
+}
+
+func TestAddFolderFilesRejectsConflicts(t *testing.T) {
+	storage := newTestFolderStorage(t)
+
+	folder, err := storage.CreateFolderTask("docs", []FileInfo{
+		{Name: "a.txt", RelativePath: "a.txt", Size: 10, TotalChunks: 1},
+	}, nil, "", "tenant-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		files []FileInfo
+	}{
+		{"与已有文件重复", []FileInfo{{Name: "a.txt", RelativePath: "a.txt", Size: 1, TotalChunks: 1}}},
+		{"同批文件重复", []FileInfo{
+			{Name: "b.txt", RelativePath: "x/b.txt", Size: 1, TotalChunks: 1},
+			{Name: "b.txt", SubDirectory: "x", Size: 1, TotalChunks: 1},
+		}},
+	}
+	for _, tt := range tests {
+		_, conflicts, err := storage.AddFolderFiles(folder.FileID, tt.files, "tenant-a")
+		if !errors.Is(err, ErrFolderPathConflict) || len(conflicts) != 1 {
+			t.Fatalf("%s: conflicts = %v, err = %v", tt.name, conflicts, err)
+		}
+	}
+
+	// 冲突时不做任何修改
+	if stored, _ := storage.GetTask(folder.FileID); len(stored.SubTasks) != 1 || stored.FileSize != 10 {
+		t.Fatalf("冲突后文件夹被修改: sub_tasks=%d file_size=%d", len(stored.SubTasks), stored.FileSize)
+	}
+
+	if _, _, err := storage.AddFolderFiles(folder.FileID, []FileInfo{{Name: "c.txt", Size: 1, TotalChunks: 1}}, "tenant-b"); err == nil {
+		t.Fatal("其他租户不能向文件夹添加文件")
+	}
+
+	stored, _ := storage.GetTask(folder.FileID)
+	stored.Status = "completed"
+	if err := storage.SaveTask(stored); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := storage.AddFolderFiles(folder.FileID, []FileInfo{{Name: "c.txt", Size: 1, TotalChunks: 1}}, "tenant-a"); !errors.Is(err, ErrFolderCompleted) {
+		t.Fatalf("err = %v, want ErrFolderCompleted", err)
+	}
+}
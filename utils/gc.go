@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultTempFileMaxAge 临时文件超过该时间未修改即视为进程崩溃遗留
+const DefaultTempFileMaxAge = time.Hour
+
+// tempFilePattern AtomicWriter 创建的临时文件名：<目标文件>.tmp.<纳秒时间戳>
+const tempFilePattern = "*.tmp.*"
+
+// TempFileGC 删除 uploadDir 下超过 olderThan 未修改的 AtomicWriter 临时文件（提交前进程崩溃时遗留），返回删除的文件数
+func TempFileGC(uploadDir string, olderThan time.Duration) (int, error) {
+	cutoff := time.Now().Add(-olderThan)
+	removed := 0
+
+	err := filepath.WalkDir(uploadDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// 遍历期间被删除的目录直接跳过
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if matched, _ := filepath.Match(tempFilePattern, d.Name()); !matched {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil || !info.Mode().IsRegular() || !info.ModTime().Before(cutoff) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			Logger.Warn("删除遗留的临时文件失败", "path", path, "error", err)
+			return nil
+		}
+		Logger.Info("已删除遗留的临时文件", "path", path, "size", info.Size())
+		removed++
+		return nil
+	})
+	if err != nil {
+		return removed, fmt.Errorf("扫描临时文件失败: %v", err)
+	}
+	return removed, nil
+}
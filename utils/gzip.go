@@ -0,0 +1,143 @@
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/gin-gonic/gin"
+	"io"
+	"strings"
+	"sync"
+)
+
+// gzipWriterPools 按压缩级别复用gzip写入器
+var gzipWriterPools sync.Map
+
+// getGzipWriter 从对应压缩级别的池中取出写入器，级别无效时使用默认级别
+func getGzipWriter(level int, w io.Writer) (*gzip.Writer, *sync.Pool) {
+	if level < gzip.BestSpeed || level > gzip.BestCompression {
+		level = gzip.DefaultCompression
+	}
+	value, _ := gzipWriterPools.LoadOrStore(level, &sync.Pool{
+		New: func() interface{} {
+			gz, _ := gzip.NewWriterLevel(io.Discard, level)
+			return gz
+		},
+	})
+	pool := value.(*sync.Pool)
+	gz := pool.Get().(*gzip.Writer)
+	gz.Reset(w)
+	return gz, pool
+}
+
+// gzipResponseWriter 先缓冲响应体，达到最小压缩大小且响应为JSON时改为gzip输出，否则原样写出
+type gzipResponseWriter struct {
+	gin.ResponseWriter
+	minSize int
+	level   int
+	buffer  bytes.Buffer
+	decided bool
+	gz      *gzip.Writer
+	pool    *sync.Pool
+}
+
+// Write 缓冲或压缩写入响应体
+func (w *gzipResponseWriter) Write(data []byte) (int, error) {
+	if w.decided {
+		if w.gz != nil {
+			return w.gz.Write(data)
+		}
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.buffer.Write(data)
+	if w.buffer.Len() >= w.minSize {
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+// WriteString 与 Write 相同
+func (w *gzipResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush 流式响应刷新时不再等待缓冲区填满
+func (w *gzipResponseWriter) Flush() {
+	if !w.decided {
+		w.decide()
+	}
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide 根据缓冲的内容决定是否压缩并写出缓冲区
+func (w *gzipResponseWriter) decide() error {
+	w.decided = true
+	header := w.Header()
+	if w.buffer.Len() >= w.minSize && header.Get("Content-Encoding") == "" &&
+		strings.HasPrefix(header.Get("Content-Type"), "application/json") {
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		w.gz, w.pool = getGzipWriter(w.level, w.ResponseWriter)
+		_, err := w.gz.Write(w.buffer.Bytes())
+		w.buffer.Reset()
+		return err
+	}
+
+	if w.buffer.Len() == 0 {
+		return nil
+	}
+	_, err := w.ResponseWriter.Write(w.buffer.Bytes())
+	w.buffer.Reset()
+	return err
+}
+
+// finish 请求处理完成后写出剩余内容
+func (w *gzipResponseWriter) finish() {
+	if !w.decided {
+		w.decide()
+	}
+	if w.gz != nil {
+		w.gz.Close()
+		w.gz.Reset(io.Discard)
+		w.pool.Put(w.gz)
+		w.gz = nil
+	}
+}
+
+// GzipMiddleware 开启 CurrentConfig().EnableResponseCompression 时对客户端接受gzip的JSON响应进行压缩，
+// 小于 CurrentConfig().CompressionMinSizeBytes 的响应不压缩；excludedRoutes 中的路由（分片上传、合并、流式接口）不经过压缩。
+// 未使用 gin-contrib/gzip：其 v0.0.6 在处理函数执行前就设置 Content-Encoding，无法按响应大小和类型决定是否压缩
+func GzipMiddleware(excludedRoutes []string) gin.HandlerFunc {
+	excluded := make(map[string]bool, len(excludedRoutes))
+	for _, route := range excludedRoutes {
+		excluded[route] = true
+	}
+
+	return func(c *gin.Context) {
+		if !CurrentConfig().EnableResponseCompression || excluded[c.FullPath()] ||
+			strings.Contains(c.GetHeader("Connection"), "Upgrade") {
+			c.Next()
+			return
+		}
+
+		c.Header("Vary", "Accept-Encoding")
+		if !strings.Contains(c.GetHeader("Accept-Encoding"), "gzip") {
+			c.Next()
+			return
+		}
+
+		writer := &gzipResponseWriter{
+			ResponseWriter: c.Writer,
+			minSize:        CurrentConfig().CompressionMinSizeBytes,
+			level:          CurrentConfig().CompressionLevel,
+		}
+		c.Writer = writer
+		defer writer.finish()
+		c.Next()
+	}
+}
@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"lukechampine.com/blake3"
+	"os"
+)
+
+// 支持的文件校验算法
+const (
+	HashAlgorithmMD5    = "md5"
+	HashAlgorithmSHA256 = "sha256"
+	HashAlgorithmBLAKE3 = "blake3"
+)
+
+// FileHasher 文件和分片内容的校验算法，结果均为十六进制字符串
+type FileHasher interface {
+	HashFile(path string) (string, error)
+	HashBytes(data []byte) string
+	Algorithm() string
+	New() hash.Hash // 用于边写边计算的流式哈希
+}
+
+// Hasher 全局校验算法，由 InitStorage 根据配置创建
+var Hasher FileHasher = MD5Hasher{}
+
+// NewHasher 根据算法名称创建校验算法，为空时使用MD5（兼容未记录算法的旧任务）
+func NewHasher(algorithm string) (FileHasher, error) {
+	switch algorithm {
+	case "", HashAlgorithmMD5:
+		return MD5Hasher{}, nil
+	case HashAlgorithmSHA256:
+		return SHA256Hasher{}, nil
+	case HashAlgorithmBLAKE3:
+		return BLAKE3Hasher{}, nil
+	default:
+		return nil, fmt.Errorf("不支持的校验算法: %s", algorithm)
+	}
+}
+
+// MD5Hasher MD5校验
+type MD5Hasher struct{}
+
+// HashFile 计算文件的MD5
+func (MD5Hasher) HashFile(path string) (string, error) {
+	return FileMD5(path)
+}
+
+// HashBytes 计算数据的MD5
+func (MD5Hasher) HashBytes(data []byte) string {
+	return BytesMD5(data)
+}
+
+// Algorithm 算法名称
+func (MD5Hasher) Algorithm() string {
+	return HashAlgorithmMD5
+}
+
+// New 创建流式MD5
+func (MD5Hasher) New() hash.Hash {
+	return md5.New()
+}
+
+// SHA256Hasher SHA-256校验
+type SHA256Hasher struct{}
+
+// HashFile 计算文件的SHA-256
+func (h SHA256Hasher) HashFile(path string) (string, error) {
+	return hashFile(h.New(), path)
+}
+
+// HashBytes 计算数据的SHA-256
+func (SHA256Hasher) HashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Algorithm 算法名称
+func (SHA256Hasher) Algorithm() string {
+	return HashAlgorithmSHA256
+}
+
+// New 创建流式SHA-256
+func (SHA256Hasher) New() hash.Hash {
+	return sha256.New()
+}
+
+// BLAKE3Hasher BLAKE3校验，输出256位摘要
+type BLAKE3Hasher struct{}
+
+// HashFile 计算文件的BLAKE3
+func (h BLAKE3Hasher) HashFile(path string) (string, error) {
+	return hashFile(h.New(), path)
+}
+
+// HashBytes 计算数据的BLAKE3
+func (BLAKE3Hasher) HashBytes(data []byte) string {
+	sum := blake3.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Algorithm 算法名称
+func (BLAKE3Hasher) Algorithm() string {
+	return HashAlgorithmBLAKE3
+}
+
+// New 创建流式BLAKE3
+func (BLAKE3Hasher) New() hash.Hash {
+	return blake3.New(32, nil)
+}
+
+// hashFile 读取整个文件计算哈希
+func hashFile(hasher hash.Hash, path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(hasher, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
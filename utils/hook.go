@@ -0,0 +1,182 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 合并后处理钩子参数
+const (
+	defaultHookTimeout = 5 * time.Minute
+	hookOutputLimit    = 1024 // 失败日志中保留的命令输出字节数
+)
+
+// PostMergeHook 文件合并成功后执行的处理，如图片缩放、视频转码或格式校验
+type PostMergeHook interface {
+	Name() string
+	Execute(ctx context.Context, task *UploadTask, mergedPath string) error
+}
+
+// PostMergeHookConfig 合并后处理钩子配置
+type PostMergeHookConfig struct {
+	Name           string   `json:"name"`
+	Command        []string `json:"command"`         // 命令及参数，合并文件的路径作为最后一个参数
+	OnlyMIME       []string `json:"only_mime"`       // 只处理匹配的MIME类型，支持 image/* 形式的通配，为空时处理所有文件
+	TimeoutSeconds int      `json:"timeout_seconds"` // 单次执行超时（秒），0表示使用默认的5分钟
+}
+
+// CommandHook 以合并文件路径为参数运行外部命令
+type CommandHook struct {
+	name    string
+	command []string
+	timeout time.Duration
+}
+
+// NewCommandHook 根据配置创建命令钩子
+func NewCommandHook(config PostMergeHookConfig) (*CommandHook, error) {
+	if config.Name == "" {
+		return nil, fmt.Errorf("钩子缺少name")
+	}
+	if len(config.Command) == 0 || config.Command[0] == "" {
+		return nil, fmt.Errorf("钩子 %s 缺少command", config.Name)
+	}
+
+	timeout := defaultHookTimeout
+	if config.TimeoutSeconds > 0 {
+		timeout = time.Duration(config.TimeoutSeconds) * time.Second
+	}
+	return &CommandHook{
+		name:    config.Name,
+		command: config.Command,
+		timeout: timeout,
+	}, nil
+}
+
+// Name 钩子名称
+func (h *CommandHook) Name() string {
+	return h.name
+}
+
+// Execute 运行命令，任务信息通过环境变量传递，命令以非0状态退出时返回包含输出的错误
+func (h *CommandHook) Execute(ctx context.Context, task *UploadTask, mergedPath string) error {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	args := append(append([]string{}, h.command[1:]...), mergedPath)
+	cmd := exec.CommandContext(ctx, h.command[0], args...)
+	cmd.Env = append(os.Environ(),
+		"GO_UPLOADER_FILE_ID="+task.FileID,
+		"GO_UPLOADER_FILENAME="+task.FileName,
+		"GO_UPLOADER_RELATIVE_PATH="+task.RelativePath,
+		"GO_UPLOADER_MIME_TYPE="+task.MIMEType,
+		"GO_UPLOADER_FILE_MD5="+task.FileMD5,
+	)
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("执行超时（%s）", h.timeout)
+		}
+		return fmt.Errorf("%v: %s", err, tailOutput(output.String()))
+	}
+	return nil
+}
+
+// tailOutput 截取命令输出的末尾部分
+func tailOutput(output string) string {
+	output = strings.TrimSpace(output)
+	if len(output) > hookOutputLimit {
+		output = "..." + output[len(output)-hookOutputLimit:]
+	}
+	return output
+}
+
+// registeredHook 已注册的钩子及其MIME类型过滤条件
+type registeredHook struct {
+	hook     PostMergeHook
+	onlyMIME []string
+}
+
+// postMergeHooks 已注册的合并后处理钩子，启动时注册后只读
+var postMergeHooks []registeredHook
+
+// RegisterPostMergeHook 注册合并后处理钩子，onlyMIME 为空时处理所有文件
+func RegisterPostMergeHook(hook PostMergeHook, onlyMIME []string) {
+	postMergeHooks = append(postMergeHooks, registeredHook{hook: hook, onlyMIME: onlyMIME})
+}
+
+// RegisterPostMergeHooks 根据配置注册命令钩子，配置无效时不注册任何钩子并返回错误
+func RegisterPostMergeHooks(configs []PostMergeHookConfig) error {
+	hooks := make([]*CommandHook, 0, len(configs))
+	for _, config := range configs {
+		hook, err := NewCommandHook(config)
+		if err != nil {
+			return err
+		}
+		hooks = append(hooks, hook)
+	}
+	for i, hook := range hooks {
+		RegisterPostMergeHook(hook, configs[i].OnlyMIME)
+	}
+	return nil
+}
+
+// RunPostMergeHooks 在后台执行与任务匹配的钩子，按 parallel_hooks 配置依次或并发执行；
+// 钩子失败只记录日志，不影响合并结果
+func RunPostMergeHooks(task *UploadTask, mergedPath string) {
+	hooks := make([]PostMergeHook, 0, len(postMergeHooks))
+	for _, registered := range postMergeHooks {
+		if len(registered.onlyMIME) == 0 || matchMIMEType(task.MIMEType, registered.onlyMIME) {
+			hooks = append(hooks, registered.hook)
+		}
+	}
+	if len(hooks) == 0 {
+		return
+	}
+
+	// 钩子在后台执行，使用任务快照避免与后续的任务更新竞争
+	snapshot := *task
+	task = &snapshot
+
+	// 优雅关闭时等待钩子执行完成
+	done := Inflight.Begin()
+	parallel := CurrentConfig().ParallelHooks
+	go func() {
+		defer done()
+
+		if !parallel {
+			for _, hook := range hooks {
+				runPostMergeHook(hook, task, mergedPath)
+			}
+			return
+		}
+
+		var wg sync.WaitGroup
+		for _, hook := range hooks {
+			wg.Add(1)
+			go func(hook PostMergeHook) {
+				defer wg.Done()
+				runPostMergeHook(hook, task, mergedPath)
+			}(hook)
+		}
+		wg.Wait()
+	}()
+}
+
+// runPostMergeHook 执行单个钩子并记录结果
+func runPostMergeHook(hook PostMergeHook, task *UploadTask, mergedPath string) {
+	start := time.Now()
+	if err := hook.Execute(context.Background(), task, mergedPath); err != nil {
+		Logger.Error("合并后处理钩子执行失败", "hook", hook.Name(), "file_id", task.FileID, "path", mergedPath, "error", err)
+		return
+	}
+	Logger.Info("合并后处理钩子执行完成", "hook", hook.Name(), "file_id", task.FileID, "duration_ms", time.Since(start).Milliseconds())
+}
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// InflightTracker 跟踪进行中的上传和合并操作，用于优雅关闭
+type InflightTracker struct {
+	wg     sync.WaitGroup
+	active int64
+}
+
+// Inflight 全局进行中操作跟踪器
+var Inflight = &InflightTracker{}
+
+// Begin 标记操作开始，返回的函数用于标记操作结束
+func (t *InflightTracker) Begin() func() {
+	t.wg.Add(1)
+	atomic.AddInt64(&t.active, 1)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			atomic.AddInt64(&t.active, -1)
+			t.wg.Done()
+		})
+	}
+}
+
+// Count 获取当前进行中的操作数量
+func (t *InflightTracker) Count() int64 {
+	return atomic.LoadInt64(&t.active)
+}
+
+// Wait 等待所有进行中的操作完成，超时返回上下文错误
+func (t *InflightTracker) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// IPFilter 按客户端IP放行或拒绝请求，列表项为CIDR或单个IP
+type IPFilter struct {
+	allow []*net.IPNet
+	block []*net.IPNet
+}
+
+// ClientIPFilter 全局IP过滤器，未配置允许列表和阻止列表时为nil
+var ClientIPFilter *IPFilter
+
+// NewIPFilter 解析允许列表和阻止列表，包含无效的CIDR或IP时返回错误
+func NewIPFilter(allowlist, blocklist []string) (*IPFilter, error) {
+	allow, err := parseIPNets(allowlist)
+	if err != nil {
+		return nil, fmt.Errorf("IP允许列表无效: %v", err)
+	}
+	block, err := parseIPNets(blocklist)
+	if err != nil {
+		return nil, fmt.Errorf("IP阻止列表无效: %v", err)
+	}
+	return &IPFilter{allow: allow, block: block}, nil
+}
+
+// IsAllowed 判断IP是否放行：命中阻止列表时拒绝（优先于允许列表），允许列表非空时只放行列表中的IP
+func (f *IPFilter) IsAllowed(ip string) bool {
+	if f == nil || (len(f.allow) == 0 && len(f.block) == 0) {
+		return true
+	}
+
+	parsed := net.ParseIP(strings.TrimSpace(ip))
+	if parsed == nil {
+		return false
+	}
+	if containsIP(f.block, parsed) {
+		return false
+	}
+	return len(f.allow) == 0 || containsIP(f.allow, parsed)
+}
+
+// parseIPNets 解析CIDR列表，单个IP视为 /32（IPv4）或 /128（IPv6）
+func parseIPNets(entries []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if strings.Contains(entry, "/") {
+			_, ipNet, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, fmt.Errorf("无效的CIDR %q: %v", entry, err)
+			}
+			nets = append(nets, ipNet)
+			continue
+		}
+
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			return nil, fmt.Errorf("无效的IP %q", entry)
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			nets = append(nets, &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)})
+		} else {
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)})
+		}
+	}
+	return nets, nil
+}
+
+// containsIP IP是否属于任一网段
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, ipNet := range nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// rejectFilteredIP 客户端IP未通过过滤时返回403并终止请求，返回true表示已拒绝
+func rejectFilteredIP(c *gin.Context) bool {
+	if ClientIPFilter == nil {
+		return false
+	}
+
+	clientIP := c.ClientIP()
+	if ClientIPFilter.IsAllowed(clientIP) {
+		Logger.Debug("IP过滤放行", "client_ip", clientIP, "path", c.Request.URL.Path)
+		return false
+	}
+
+	Logger.Debug("IP过滤拒绝", "client_ip", clientIP, "path", c.Request.URL.Path)
+	c.JSON(http.StatusForbidden, gin.H{
+		"error": "客户端IP不允许访问",
+		"code":  403,
+	})
+	c.Abort()
+	return true
+}
+
+// IPFilterMiddleware 单独使用的IP过滤中间件，用于不经过 AuthMiddleware 的上传路由
+func IPFilterMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rejectFilteredIP(c) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// InitIPFilter 根据配置创建全局IP过滤器，列表均为空时不过滤
+func InitIPFilter() error {
+	if len(CurrentConfig().IPAllowlist) == 0 && len(CurrentConfig().IPBlocklist) == 0 {
+		ClientIPFilter = nil
+		return nil
+	}
+
+	filter, err := NewIPFilter(CurrentConfig().IPAllowlist, CurrentConfig().IPBlocklist)
+	if err != nil {
+		return err
+	}
+	ClientIPFilter = filter
+	Logger.Info("已启用客户端IP过滤", "allowlist", len(filter.allow), "blocklist", len(filter.block))
+	return nil
+}
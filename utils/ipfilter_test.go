@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIPFilterIsAllowed(t *testing.T) {
+	tests := []struct {
+		name      string
+		allowlist []string
+		blocklist []string
+		ip        string
+		want      bool
+	}{
+		{"未配置时放行", nil, nil, "203.0.113.1", true},
+		{"IPv4 在允许网段内", []string{"10.0.0.0/8"}, nil, "10.20.30.40", true},
+		{"IPv4 不在允许网段内", []string{"10.0.0.0/8"}, nil, "11.0.0.1", false},
+		{"IPv4 精确匹配", []string{"192.168.1.10"}, nil, "192.168.1.10", true},
+		{"IPv4 精确匹配不含相邻地址", []string{"192.168.1.10"}, nil, "192.168.1.11", false},
+		{"IPv4 阻止列表", nil, []string{"203.0.113.0/24"}, "203.0.113.99", false},
+		{"IPv4 不在阻止列表", nil, []string{"203.0.113.0/24"}, "203.0.114.1", true},
+		{"IPv6 在允许网段内", []string{"2001:db8::/32"}, nil, "2001:db8:1::1", true},
+		{"IPv6 不在允许网段内", []string{"2001:db8::/32"}, nil, "2001:db9::1", false},
+		{"IPv6 精确匹配", []string{"2001:db8::1"}, nil, "2001:db8::1", true},
+		{"IPv6 阻止列表", nil, []string{"fd00::/8"}, "fd12:3456::1", false},
+		{"IPv6 回环地址不匹配IPv4回环网段", []string{"127.0.0.0/8"}, nil, "::1", false},
+		{"IPv4映射的IPv6地址按IPv4匹配", []string{"10.0.0.0/8"}, nil, "::ffff:10.1.2.3", true},
+		{"重叠网段：阻止的子网优先于允许的大网段", []string{"10.0.0.0/8"}, []string{"10.1.0.0/16"}, "10.1.2.3", false},
+		{"重叠网段：子网外仍按允许列表放行", []string{"10.0.0.0/8"}, []string{"10.1.0.0/16"}, "10.2.0.1", true},
+		{"重叠网段：精确允许不能绕过阻止网段", []string{"10.1.2.3", "10.0.0.0/8"}, []string{"10.1.0.0/16"}, "10.1.2.3", false},
+		{"重叠网段：多个允许网段互相包含", []string{"172.16.0.0/12", "172.16.5.0/24"}, nil, "172.20.0.1", true},
+		{"IPv6 重叠网段", []string{"2001:db8::/32"}, []string{"2001:db8:bad::/48"}, "2001:db8:bad::1", false},
+		{"无效的客户端IP", []string{"10.0.0.0/8"}, nil, "not-an-ip", false},
+	}
+	for _, tt := range tests {
+		filter, err := NewIPFilter(tt.allowlist, tt.blocklist)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := filter.IsAllowed(tt.ip); got != tt.want {
+			t.Fatalf("%s: IsAllowed(%s) = %v, want %v", tt.name, tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestNewIPFilterRejectsInvalidEntries(t *testing.T) {
+	for _, entry := range []string{"10.0.0.0/33", "300.1.1.1", "2001:db8::/129", "example.com"} {
+		if _, err := NewIPFilter([]string{entry}, nil); err == nil {
+			t.Fatalf("允许列表项 %q 应无效", entry)
+		}
+		if _, err := NewIPFilter(nil, []string{entry}); err == nil {
+			t.Fatalf("阻止列表项 %q 应无效", entry)
+		}
+	}
+}
+
+func TestIPFilterMiddleware(t *testing.T) {
+	saved := ClientIPFilter
+	defer func() { ClientIPFilter = saved }()
+
+	filter, err := NewIPFilter([]string{"10.0.0.0/8", "2001:db8::/32"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ClientIPFilter = filter
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(IPFilterMiddleware())
+	r.GET("/ping", func(c *gin.Context) { c.String(http.StatusOK, "pong") })
+
+	for remoteAddr, want := range map[string]int{
+		"10.0.0.5:1234":      http.StatusOK,
+		"[2001:db8::5]:1234": http.StatusOK,
+		"192.0.2.1:1234":     http.StatusForbidden,
+		"[2001:db9::5]:1234": http.StatusForbidden,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Fatalf("%s: status = %d, want %d", remoteAddr, w.Code, want)
+		}
+	}
+}
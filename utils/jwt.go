@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"time"
+)
+
+// jwtIssuer 令牌签发者
+const jwtIssuer = "go-uploader"
+
+// Claims JWT声明
+type Claims struct {
+	jwt.RegisteredClaims
+	TenantID string `json:"tenant_id,omitempty"` // 签发令牌时请求的租户
+}
+
+// jwtSigningKey 获取JWT签名密钥，未配置JWTSecret时回退到SecretKey
+func jwtSigningKey() ([]byte, error) {
+	secret := CurrentConfig().JWTSecret
+	if secret == "" {
+		secret = CurrentConfig().SecretKey
+	}
+	if secret == "" {
+		return nil, fmt.Errorf("JWT签名密钥未配置")
+	}
+	return []byte(secret), nil
+}
+
+// JWTTokenTTL 获取配置的令牌有效期
+func JWTTokenTTL() time.Duration {
+	return time.Duration(CurrentConfig().JWTTokenTTL) * time.Second
+}
+
+// GenerateToken 签发JWT令牌，tenantID 为空时不限定租户
+func GenerateToken(subject, tenantID string, ttl time.Duration) (string, error) {
+	key, err := jwtSigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
+			Issuer:    jwtIssuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+		TenantID: tenantID,
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString(key)
+	if err != nil {
+		return "", fmt.Errorf("签发令牌失败: %v", err)
+	}
+	return signed, nil
+}
+
+// ValidateToken 校验JWT令牌并返回声明
+func ValidateToken(tokenString string) (*Claims, error) {
+	key, err := jwtSigningKey()
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		return key, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithIssuer(jwtIssuer))
+	if err != nil {
+		return nil, fmt.Errorf("令牌无效: %v", err)
+	}
+	if !token.Valid {
+		return nil, fmt.Errorf("令牌无效")
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+// Logger 全局结构化日志记录器，InitLogger 之前使用默认配置
+var Logger = slog.Default()
+
+// logFile 当前打开的日志文件，重新初始化时关闭
+var logFile *os.File
+
+// InitLogger 根据配置初始化全局日志记录器，需在gin启动前调用
+func InitLogger() error {
+	level, err := parseLogLevel(CurrentConfig().LogLevel)
+	if err != nil {
+		return err
+	}
+
+	var output io.Writer = os.Stderr
+	var file *os.File
+	if CurrentConfig().LogFile != "" {
+		file, err = os.OpenFile(CurrentConfig().LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("打开日志文件失败: %v", err)
+		}
+		output = file
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch strings.ToLower(CurrentConfig().LogFormat) {
+	case "", "text":
+		handler = slog.NewTextHandler(output, opts)
+	case "json":
+		handler = slog.NewJSONHandler(output, opts)
+	default:
+		if file != nil {
+			file.Close()
+		}
+		return fmt.Errorf("不支持的日志格式: %s", CurrentConfig().LogFormat)
+	}
+
+	if logFile != nil {
+		logFile.Close()
+	}
+	logFile = file
+
+	Logger = slog.New(handler)
+	// 标准库 log 的输出同样转发到结构化日志
+	slog.SetDefault(Logger)
+	gin.DefaultWriter = output
+	gin.DefaultErrorWriter = output
+	return nil
+}
+
+// parseLogLevel 解析日志级别字符串
+func parseLogLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("不支持的日志级别: %s", level)
+}
+
+// Fatal 记录错误日志后退出进程
+func Fatal(msg string, args ...any) {
+	Logger.Error(msg, args...)
+	os.Exit(1)
+}
+
+// GinLogger 替代gin默认日志的请求日志中间件
+func GinLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+
+		c.Next()
+
+		status := c.Writer.Status()
+		attrs := []any{
+			"method", c.Request.Method,
+			"path", path,
+			"status", status,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"client_ip", c.ClientIP(),
+		}
+		if id := c.GetString(RequestIDKey); id != "" {
+			attrs = append(attrs, RequestIDKey, id)
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, "errors", c.Errors.String())
+		}
+
+		switch {
+		case status >= 500:
+			Logger.Error("HTTP请求", attrs...)
+		case status >= 400:
+			Logger.Warn("HTTP请求", attrs...)
+		default:
+			Logger.Info("HTTP请求", attrs...)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrManifestMismatch 分片与服务端清单不一致
+var ErrManifestMismatch = errors.New("分片与上传清单不一致")
+
+// ManifestEntry 上传清单中的单个分片，md5 使用 CurrentConfig().HashAlgorithm 指定的算法
+type ManifestEntry struct {
+	Index int    `json:"index"`
+	MD5   string `json:"md5" example:"d41d8cd98f00b204e9800998ecf8427e"`
+	Size  int64  `json:"size"`
+}
+
+// ValidateManifest 校验上传清单：分片索引必须恰好为 0 到 n-1，校验值为十六进制，大小为正且不超过 CurrentConfig().MaxChunkSize
+func ValidateManifest(manifest []ManifestEntry) error {
+	if len(manifest) == 0 {
+		return fmt.Errorf("清单不能为空")
+	}
+
+	seen := make([]bool, len(manifest))
+	for _, entry := range manifest {
+		if entry.Index < 0 || entry.Index >= len(manifest) {
+			return fmt.Errorf("分片索引 %d 超出范围 [0, %d)", entry.Index, len(manifest))
+		}
+		if seen[entry.Index] {
+			return fmt.Errorf("分片索引 %d 重复", entry.Index)
+		}
+		seen[entry.Index] = true
+
+		if _, err := hex.DecodeString(entry.MD5); err != nil || entry.MD5 == "" {
+			return fmt.Errorf("分片 %d 的校验值无效: %q", entry.Index, entry.MD5)
+		}
+		if entry.Size <= 0 || entry.Size > CurrentConfig().MaxChunkSize {
+			return fmt.Errorf("分片 %d 的大小无效: %d", entry.Index, entry.Size)
+		}
+	}
+	return nil
+}
+
+// ManifestEntry 返回清单中指定分片的条目，任务没有清单或清单中没有该分片时返回false
+func (t *UploadTask) ManifestEntry(index int) (ManifestEntry, bool) {
+	if index < 0 || index >= len(t.Manifest) || t.Manifest[index].Index != index {
+		return ManifestEntry{}, false
+	}
+	return t.Manifest[index], true
+}
+
+// VerifyManifestChunk 将分片实际的大小和校验值与清单条目比较，不一致时返回 ErrManifestMismatch
+func VerifyManifestChunk(entry ManifestEntry, size int64, checksum string) error {
+	if size != entry.Size {
+		return fmt.Errorf("%w: 分片 %d 大小为 %d，清单中为 %d", ErrManifestMismatch, entry.Index, size, entry.Size)
+	}
+	if !strings.EqualFold(checksum, entry.MD5) {
+		return fmt.Errorf("%w: 分片 %d 校验值为 %s，清单中为 %s", ErrManifestMismatch, entry.Index, checksum, entry.MD5)
+	}
+	return nil
+}
+
+// SetManifest 为任务设置已通过 ValidateManifest 校验的上传清单，任务不存在时按 template 创建并返回true；
+// 已完成的分片与清单不一致时返回 ErrManifestMismatch
+func (s *TaskStorage) SetManifest(template *UploadTask, manifest []ManifestEntry) (*UploadTask, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// 按分片索引排序保存，便于按索引查找
+	sorted := make([]ManifestEntry, len(manifest))
+	var fileSize int64
+	for _, entry := range manifest {
+		sorted[entry.Index] = entry
+		fileSize += entry.Size
+	}
+
+	task, exists := s.backend.GetTask(template.FileID)
+	if !exists {
+		task = template
+		task.TotalChunks = len(manifest)
+		task.FileSize = fileSize
+		if task.Chunks == nil {
+			task.Chunks = make(map[int]ChunkInfo)
+		}
+	} else {
+		if task.TaskType == "folder" || task.Status == "completed" {
+			return nil, false, fmt.Errorf("任务类型为 %s、状态为 %s，不能设置上传清单", task.TaskType, task.Status)
+		}
+		if task.TotalChunks > 0 && task.TotalChunks != len(manifest) {
+			return nil, false, fmt.Errorf("%w: 任务有 %d 个分片，清单中有 %d 个", ErrManifestMismatch, task.TotalChunks, len(manifest))
+		}
+		for index, chunk := range task.Chunks {
+			if chunk.Status != "completed" || index < 0 || index >= len(sorted) {
+				continue
+			}
+			entry := sorted[index]
+			if chunk.Size != entry.Size || (chunk.MD5 != "" && !strings.EqualFold(chunk.MD5, entry.MD5)) {
+				return nil, false, fmt.Errorf("%w: 已上传的分片 %d 与清单不一致", ErrManifestMismatch, index)
+			}
+		}
+		task.TotalChunks = len(manifest)
+		if task.FileSize == 0 {
+			task.FileSize = fileSize
+		}
+	}
+
+	task.Manifest = sorted
+	task.UpdatedAt = time.Now()
+	if err := s.backend.SaveTask(task); err != nil {
+		return nil, false, err
+	}
+
+	Events.Publish(NewTaskEvent(task))
+	return task, !exists, nil
+}
@@ -0,0 +1,229 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 自动合并任务状态
+const (
+	MergeStateQueued  = "queued"
+	MergeStateMerging = "merging"
+	MergeStateDone    = "done"
+	MergeStateFailed  = "failed"
+)
+
+// mergeQueueCapacity 合并队列缓冲区大小
+const mergeQueueCapacity = 1024
+
+// MergeFunc 执行合并的回调，返回合并后的文件路径
+type MergeFunc func(task *UploadTask) (string, error)
+
+// mergeRequest 合并队列中的请求
+type mergeRequest struct {
+	fileID string
+	task   *UploadTask
+}
+
+// MergeJobStatus 自动合并任务的状态
+type MergeJobStatus struct {
+	FileID     string    `json:"file_id"`
+	State      string    `json:"state"`
+	Position   int       `json:"position,omitempty"` // 排队位置，从1开始
+	Worker     int       `json:"worker,omitempty"`   // 正在处理的工作协程编号
+	FilePath   string    `json:"file_path,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	EnqueuedAt time.Time `json:"enqueued_at"`
+	FinishedAt time.Time `json:"finished_at,omitempty"`
+}
+
+// MergeQueue 后台自动合并队列
+type MergeQueue struct {
+	requests  chan mergeRequest
+	mergeFunc MergeFunc
+	workers   int
+	mutex     sync.Mutex
+	pending   []string // 排队中的文件ID，按入队顺序
+	jobs      map[string]*MergeJobStatus
+	busy      int
+	closed    bool
+	wg        sync.WaitGroup
+}
+
+// AutoMergeQueue 全局自动合并队列（未启用自动合并时为nil）
+var AutoMergeQueue *MergeQueue
+
+// StartMergeQueue 创建合并队列并启动工作协程
+func StartMergeQueue(workers int, mergeFunc MergeFunc) *MergeQueue {
+	if workers <= 0 {
+		workers = 1
+	}
+
+	q := &MergeQueue{
+		requests:  make(chan mergeRequest, mergeQueueCapacity),
+		mergeFunc: mergeFunc,
+		workers:   workers,
+		jobs:      make(map[string]*MergeJobStatus),
+	}
+
+	for i := 1; i <= workers; i++ {
+		q.wg.Add(1)
+		go q.worker(i)
+	}
+	return q
+}
+
+// Enqueue 将任务加入合并队列，已在排队或合并中的任务不会重复入队
+func (q *MergeQueue) Enqueue(task *UploadTask) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.closed {
+		return false
+	}
+	if job, exists := q.jobs[task.FileID]; exists && (job.State == MergeStateQueued || job.State == MergeStateMerging) {
+		return true
+	}
+
+	select {
+	case q.requests <- mergeRequest{fileID: task.FileID, task: task}:
+	default:
+		Logger.Warn("合并队列已满，跳过自动合并", "file_id", task.FileID)
+		return false
+	}
+
+	q.pending = append(q.pending, task.FileID)
+	q.jobs[task.FileID] = &MergeJobStatus{
+		FileID:     task.FileID,
+		State:      MergeStateQueued,
+		EnqueuedAt: time.Now(),
+	}
+	return true
+}
+
+// Status 获取指定任务的合并状态
+func (q *MergeQueue) Status(fileID string) (MergeJobStatus, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	job, exists := q.jobs[fileID]
+	if !exists {
+		return MergeJobStatus{}, false
+	}
+
+	status := *job
+	if status.State == MergeStateQueued {
+		for i, id := range q.pending {
+			if id == fileID {
+				status.Position = i + 1
+				break
+			}
+		}
+	}
+	return status, true
+}
+
+// Stats 获取队列长度和工作协程状态
+func (q *MergeQueue) Stats() (queued, busy, workers int) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return len(q.pending), q.busy, q.workers
+}
+
+// Forget 移除已结束任务的合并记录
+func (q *MergeQueue) Forget(fileID string) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if job, exists := q.jobs[fileID]; exists && (job.State == MergeStateDone || job.State == MergeStateFailed) {
+		delete(q.jobs, fileID)
+	}
+}
+
+// Shutdown 停止接收新请求并等待队列中的合并全部完成
+func (q *MergeQueue) Shutdown(ctx context.Context) error {
+	q.mutex.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.requests)
+	}
+	q.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// worker 合并工作协程
+func (q *MergeQueue) worker(id int) {
+	defer q.wg.Done()
+
+	for req := range q.requests {
+		q.markMerging(req.fileID, id)
+
+		// 使用最新的任务状态，避免合并入队后被修改的任务
+		task := req.task
+		if latest, exists := Storage.GetTask(req.fileID); exists {
+			task = latest
+		}
+
+		filePath, err := q.mergeFunc(task)
+		q.markFinished(req.fileID, filePath, err)
+		if err != nil {
+			Logger.Error("自动合并失败", "file_id", req.fileID, "error", err)
+		} else {
+			Logger.Info("自动合并完成", "file_id", req.fileID, "path", filePath)
+		}
+	}
+}
+
+// markMerging 标记任务开始合并
+func (q *MergeQueue) markMerging(fileID string, worker int) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, id := range q.pending {
+		if id == fileID {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			break
+		}
+	}
+	if job, exists := q.jobs[fileID]; exists {
+		job.State = MergeStateMerging
+		job.Worker = worker
+	}
+	q.busy++
+}
+
+// markFinished 记录合并结果
+func (q *MergeQueue) markFinished(fileID, filePath string, err error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.busy--
+	job, exists := q.jobs[fileID]
+	if !exists {
+		return
+	}
+
+	job.Worker = 0
+	job.FinishedAt = time.Now()
+	if err != nil {
+		job.State = MergeStateFailed
+		job.Error = err.Error()
+		return
+	}
+	job.State = MergeStateDone
+	job.FilePath = filePath
+	job.Error = ""
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ParseMetadata 校验任务自定义元数据：必须为合法JSON且不超过 CurrentConfig().MaxMetadataSizeBytes 字节；
+// 空内容或 null 返回nil，表示没有元数据
+func ParseMetadata(data []byte) (json.RawMessage, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil, nil
+	}
+	if limit := CurrentConfig().MaxMetadataSizeBytes; limit > 0 && len(data) > limit {
+		return nil, fmt.Errorf("元数据大小超出限制: %d > %d 字节", len(data), limit)
+	}
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("元数据不是合法的JSON")
+	}
+	return append(json.RawMessage(nil), data...), nil
+}
+
+// SetMetadata 替换任务的自定义元数据，metadata 为nil时清空
+func (s *TaskStorage) SetMetadata(fileID string, metadata json.RawMessage) (*UploadTask, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	task, exists := s.backend.GetTask(fileID)
+	if !exists {
+		return nil, fmt.Errorf("任务不存在: %s", fileID)
+	}
+
+	task.Metadata = metadata
+	task.UpdatedAt = time.Now()
+	if err := s.backend.SaveTask(task); err != nil {
+		return nil, err
+	}
+
+	Events.Publish(NewTaskEvent(task))
+	return task, nil
+}
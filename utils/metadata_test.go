@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMetadata(t *testing.T) {
+	saved := Config
+	defer func() { Config = saved }()
+	Config.MaxMetadataSizeBytes = 64
+
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{`{"record_id": 42}`, `{"record_id": 42}`, false},
+		{`  ["a", "b"]  `, `["a", "b"]`, false},
+		{`"pipeline"`, `"pipeline"`, false},
+		{``, ``, false},
+		{`null`, ``, false},
+		{`{"record_id": 42`, ``, true},
+		{`{record_id: 42}`, ``, true},
+		{`not json`, ``, true},
+		{`{"a": 1} {"b": 2}`, ``, true},
+		{`{"data": "` + strings.Repeat("x", 64) + `"}`, ``, true},
+	}
+	for _, tt := range tests {
+		metadata, err := ParseMetadata([]byte(tt.input))
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%q: err = %v, wantErr %v", tt.input, err, tt.wantErr)
+		}
+		if string(metadata) != tt.want {
+			t.Fatalf("%q: metadata = %q, want %q", tt.input, metadata, tt.want)
+		}
+	}
+}
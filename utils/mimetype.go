@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"io"
+	"net/http"
+	"strings"
+)
+
+// mimeSniffLength MIME类型探测读取的字节数
+const mimeSniffLength = 512
+
+// mimeTypeUnknown 无法识别内容时 http.DetectContentType 返回的类型
+const mimeTypeUnknown = "application/octet-stream"
+
+// DetectMIMEType 读取前512字节探测MIME类型，返回的类型不含参数；inconclusive表示无法识别
+func DetectMIMEType(reader io.Reader) (mimeType string, inconclusive bool, err error) {
+	buf := make([]byte, mimeSniffLength)
+	n, err := io.ReadFull(reader, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", false, err
+	}
+
+	detected := http.DetectContentType(buf[:n])
+	if idx := strings.Index(detected, ";"); idx >= 0 {
+		detected = detected[:idx]
+	}
+	detected = strings.TrimSpace(detected)
+
+	return detected, detected == mimeTypeUnknown, nil
+}
+
+// IsMIMETypeAllowed 按配置的黑白名单校验MIME类型，支持 image/* 形式的通配
+func IsMIMETypeAllowed(mimeType string) bool {
+	if matchMIMEType(mimeType, CurrentConfig().BlockedMIMETypes) {
+		return false
+	}
+	if len(CurrentConfig().AllowedMIMETypes) == 0 {
+		return true
+	}
+	return matchMIMEType(mimeType, CurrentConfig().AllowedMIMETypes)
+}
+
+// matchMIMEType 检查MIME类型是否匹配列表中任一模式
+func matchMIMEType(mimeType string, patterns []string) bool {
+	mimeType = strings.ToLower(mimeType)
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "*" || pattern == "*/*" || pattern == mimeType {
+			return true
+		}
+		if strings.HasSuffix(pattern, "/*") && strings.HasPrefix(mimeType, strings.TrimSuffix(pattern, "*")) {
+			return true
+		}
+	}
+	return false
+}
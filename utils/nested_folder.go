@@ -0,0 +1,224 @@
+package utils
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// collectFolderSubTasksInternal 递归收集文件夹及其嵌套文件夹的子任务ID，同时累加文件数、总大小和嵌套文件夹数，调用方需持有锁
+func (s *TaskStorage) collectFolderSubTasksInternal(folderTask *UploadTask, summary *FolderTaskSummary, subTaskIDs []string, visited map[string]bool) []string {
+	if visited[folderTask.FileID] {
+		return subTaskIDs
+	}
+	visited[folderTask.FileID] = true
+
+	summary.TotalFiles += len(folderTask.SubTasks)
+	summary.TotalSize += folderTask.FileSize
+	subTaskIDs = append(subTaskIDs, folderTask.SubTasks...)
+
+	for _, nestedID := range folderTask.NestedSubFolders {
+		nested, exists := s.backend.GetTask(nestedID)
+		if !exists || nested.TaskType != "folder" {
+			continue
+		}
+		summary.NestedFolders++
+		subTaskIDs = s.collectFolderSubTasksInternal(nested, summary, subTaskIDs, visited)
+	}
+	return subTaskIDs
+}
+
+// aggregateSubTasks 并行统计子任务状态：子任务按 CurrentConfig().SummaryWorkers 分段，
+// 每个工作协程逐个统计子任务（每次短暂持有读锁）并累加到局部摘要，结束时合并到 summary
+func (s *TaskStorage) aggregateSubTasks(summary *FolderTaskSummary, subTaskIDs []string) {
+	if len(subTaskIDs) == 0 {
+		return
+	}
+
+	workers := CurrentConfig().SummaryWorkers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(subTaskIDs) {
+		workers = len(subTaskIDs)
+	}
+
+	var (
+		mutex sync.Mutex
+		wg    sync.WaitGroup
+	)
+	batchSize := (len(subTaskIDs) + workers - 1) / workers
+	for start := 0; start < len(subTaskIDs); start += batchSize {
+		end := start + batchSize
+		if end > len(subTaskIDs) {
+			end = len(subTaskIDs)
+		}
+		batch := subTaskIDs[start:end]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			partial := &FolderTaskSummary{}
+			for _, subTaskID := range batch {
+				s.summarizeSubTask(partial, subTaskID)
+			}
+
+			mutex.Lock()
+			summary.CompletedFiles += partial.CompletedFiles
+			summary.FailedFiles += partial.FailedFiles
+			summary.UploadedSize += partial.UploadedSize
+			mutex.Unlock()
+		}()
+	}
+	wg.Wait()
+}
+
+// summarizeSubTask 持有读锁将子任务计入摘要，GetTask 返回的是存储中的任务本身，锁外读取分片会与 UpdateChunk 竞争
+func (s *TaskStorage) summarizeSubTask(summary *FolderTaskSummary, subTaskID string) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if subTask, exists := s.backend.GetTask(subTaskID); exists {
+		accumulateSubTaskSummary(summary, subTask)
+	}
+}
+
+// accumulateSubTaskSummary 将子任务的完成、失败数和已上传大小计入摘要
+func accumulateSubTaskSummary(summary *FolderTaskSummary, subTask *UploadTask) {
+	switch subTask.Status {
+	case "completed":
+		summary.CompletedFiles++
+		summary.UploadedSize += subTask.FileSize
+	case "failed":
+		summary.FailedFiles++
+	default:
+		// 计算部分上传的大小
+		uploadedChunks := completedChunkIndexes(subTask)
+		if len(uploadedChunks) > 0 && subTask.TotalChunks > 0 {
+			chunkSize := subTask.FileSize / int64(subTask.TotalChunks)
+			summary.UploadedSize += int64(len(uploadedChunks)) * chunkSize
+		}
+	}
+}
+
+// deleteFolderContentsInternal 递归删除文件夹的子任务和嵌套文件夹，不删除文件夹本身，调用方需持有锁
+func (s *TaskStorage) deleteFolderContentsInternal(folderTask *UploadTask, visited map[string]bool) {
+	if visited[folderTask.FileID] {
+		return
+	}
+	visited[folderTask.FileID] = true
+
+	for _, subTaskID := range folderTask.SubTasks {
+		s.deleteTaskInternal(subTaskID)
+	}
+
+	for _, nestedID := range folderTask.NestedSubFolders {
+		if nested, exists := s.backend.GetTask(nestedID); exists && nested.TaskType == "folder" {
+			s.deleteFolderContentsInternal(nested, visited)
+		}
+		s.deleteTaskInternal(nestedID)
+	}
+}
+
+// unlinkNestedFolderInternal 将嵌套文件夹从父文件夹的子文件夹列表中移除，调用方需持有锁
+func (s *TaskStorage) unlinkNestedFolderInternal(folderTask *UploadTask) {
+	if folderTask.ParentTaskID == "" {
+		return
+	}
+	parent, exists := s.backend.GetTask(folderTask.ParentTaskID)
+	if !exists {
+		return
+	}
+
+	remaining := make([]string, 0, len(parent.NestedSubFolders))
+	for _, nestedID := range parent.NestedSubFolders {
+		if nestedID != folderTask.FileID {
+			remaining = append(remaining, nestedID)
+		}
+	}
+	if len(remaining) == len(parent.NestedSubFolders) {
+		return
+	}
+
+	parent.NestedSubFolders = remaining
+	parent.UpdatedAt = time.Now()
+	if err := s.backend.SaveTask(parent); err != nil {
+		Logger.Error("更新父文件夹任务失败", "file_id", parent.FileID, "error", err)
+	}
+}
+
+// rootFolderInternal 沿 ParentTaskID 向上查找最外层的任务，调用方需持有锁
+func (s *TaskStorage) rootFolderInternal(task *UploadTask) *UploadTask {
+	visited := map[string]bool{task.FileID: true}
+	for task.ParentTaskID != "" && !visited[task.ParentTaskID] {
+		parent, exists := s.backend.GetTask(task.ParentTaskID)
+		if !exists {
+			break
+		}
+		visited[parent.FileID] = true
+		task = parent
+	}
+	return task
+}
+
+// folderUsedBytesInternal 递归统计文件夹下所有子任务已占用的字节数，调用方需持有锁
+func (s *TaskStorage) folderUsedBytesInternal(folderTask *UploadTask, visited map[string]bool) int64 {
+	if visited[folderTask.FileID] {
+		return 0
+	}
+	visited[folderTask.FileID] = true
+
+	var used int64
+	for _, subTaskID := range folderTask.SubTasks {
+		if subTask, exists := s.backend.GetTask(subTaskID); exists {
+			used += subTask.CurrentBytes
+		}
+	}
+	for _, nestedID := range folderTask.NestedSubFolders {
+		if nested, exists := s.backend.GetTask(nestedID); exists {
+			used += s.folderUsedBytesInternal(nested, visited)
+		}
+	}
+	return used
+}
+
+// FolderDepth 返回文件夹任务的嵌套深度，最外层文件夹为1
+func (s *TaskStorage) FolderDepth(folderTaskID string) int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	task, exists := s.backend.GetTask(folderTaskID)
+	if !exists {
+		return 0
+	}
+
+	depth := 1
+	visited := map[string]bool{task.FileID: true}
+	for task.ParentTaskID != "" && !visited[task.ParentTaskID] {
+		parent, exists := s.backend.GetTask(task.ParentTaskID)
+		if !exists {
+			break
+		}
+		visited[parent.FileID] = true
+		task = parent
+		depth++
+	}
+	return depth
+}
+
+// PathsExceedingDepth 返回相对路径中目录层级超过 maxDepth 的路径，maxDepth<=0 表示不限制
+func PathsExceedingDepth(files []FileInfo, maxDepth int) []string {
+	if maxDepth <= 0 {
+		return nil
+	}
+
+	var paths []string
+	for _, file := range files {
+		relativePath := file.resolvedRelativePath()
+		if strings.Count(relativePath, "/") > maxDepth {
+			paths = append(paths, relativePath)
+		}
+	}
+	return paths
+}
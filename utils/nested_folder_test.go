@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+)
+
+// newTestLargeFolder 创建含 files 个子任务的文件夹，三分之一已完成，三分之一上传了一半分片
+func newTestLargeFolder(tb testing.TB, files int) (*TaskStorage, []string) {
+	tb.Helper()
+	dir := tb.TempDir()
+	backend, err := NewFileBackend(dir)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { backend.Close() })
+	storage := &TaskStorage{storageDir: dir, backend: backend}
+
+	infos := make([]FileInfo, files)
+	for i := range infos {
+		infos[i] = FileInfo{Name: fmt.Sprintf("%d.bin", i), RelativePath: fmt.Sprintf("%d.bin", i), Size: 4096, TotalChunks: 4}
+	}
+	folder, err := storage.CreateFolderTask("large", infos, nil, "", "")
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	for i, subTaskID := range folder.SubTasks {
+		subTask, _ := backend.GetTask(subTaskID)
+		switch i % 3 {
+		case 0:
+			subTask.Status = "completed"
+		case 1:
+			for index := 0; index < 2; index++ {
+				subTask.Chunks[index] = ChunkInfo{Index: index, Size: 1024, Status: "completed"}
+			}
+		}
+	}
+	return storage, folder.SubTasks
+}
+
+func TestAggregateSubTasksMatchesSequential(t *testing.T) {
+	saved := Config
+	defer func() { Config = saved }()
+
+	const files = 1000
+	storage, subTaskIDs := newTestLargeFolder(t, files)
+
+	Config.SummaryWorkers = 1
+	sequential := &FolderTaskSummary{}
+	storage.aggregateSubTasks(sequential, subTaskIDs)
+
+	completed := (files + 2) / 3
+	partial := (files + 1) / 3
+	if sequential.CompletedFiles != completed || sequential.UploadedSize != int64(completed*4096+partial*2048) {
+		t.Fatalf("顺序统计结果错误: %+v", sequential)
+	}
+
+	for _, workers := range []int{3, 7, files * 2} {
+		Config.SummaryWorkers = workers
+		parallel := &FolderTaskSummary{}
+		storage.aggregateSubTasks(parallel, subTaskIDs)
+		if *parallel != *sequential {
+			t.Fatalf("workers=%d: %+v, want %+v", workers, parallel, sequential)
+		}
+	}
+}
+
+func TestFolderSummaryDuringChunkUpdates(t *testing.T) {
+	saved := Config
+	defer func() { Config = saved }()
+	Config.SummaryWorkers = 4
+
+	const chunks, chunkSize = 200, 1024
+	storage := newTestFolderStorage(t)
+	folder, err := storage.CreateFolderTask("photos", []FileInfo{
+		{Name: "a.jpg", RelativePath: "a.jpg", Size: chunks * chunkSize, TotalChunks: chunks},
+		{Name: "b.jpg", RelativePath: "b.jpg", Size: chunks * chunkSize, TotalChunks: chunks},
+	}, nil, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 客户端上传期间轮询文件夹状态，-race 下可检测统计与分片更新之间的数据竞争
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for index := 0; index < chunks; index++ {
+			info := ChunkInfo{Index: index, Size: chunkSize, Status: "completed"}
+			if err := storage.UpdateChunk(folder.SubTasks[0], index, info); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	for polling := true; polling; {
+		select {
+		case <-done:
+			polling = false
+		default:
+		}
+		if _, err := storage.GetFolderTaskSummary(folder.FileID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	summary, err := storage.GetFolderTaskSummary(folder.FileID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.UploadedSize != chunks*chunkSize {
+		t.Fatalf("UploadedSize = %d, want %d", summary.UploadedSize, chunks*chunkSize)
+	}
+}
+
+// BenchmarkAggregateSubTasks 10000个子任务的文件夹摘要，对比单协程与工作池统计
+func BenchmarkAggregateSubTasks(b *testing.B) {
+	saved := Config
+	defer func() { Config = saved }()
+
+	storage, subTaskIDs := newTestLargeFolder(b, 10000)
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers_%d", workers), func(b *testing.B) {
+			Config.SummaryWorkers = workers
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				storage.aggregateSubTasks(&FolderTaskSummary{}, subTaskIDs)
+			}
+		})
+	}
+}
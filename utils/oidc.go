@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+	"sync"
+	"time"
+)
+
+// 认证驱动
+const (
+	AuthDriverSecret = "secret"
+	AuthDriverOIDC   = "oidc"
+)
+
+// AuthSubjectKey gin上下文中保存已认证主体（OIDC sub）的键
+const AuthSubjectKey = "auth_subject"
+
+// jwksCacheTTL OIDC签名公钥缓存时间
+const jwksCacheTTL = time.Hour
+
+// OIDCProvider OIDC身份提供方，负责授权码流程和ID令牌校验
+type OIDCProvider struct {
+	verifier     *oidc.IDTokenVerifier
+	oauth2Config oauth2.Config
+}
+
+// OIDC 全局OIDC提供方（未启用OIDC认证时为nil）
+var OIDC *OIDCProvider
+
+// InitOIDC 根据配置初始化OIDC提供方，启动时获取发现文档
+func InitOIDC(ctx context.Context) error {
+	switch CurrentConfig().AuthDriver {
+	case "", AuthDriverSecret:
+		OIDC = nil
+		return nil
+	case AuthDriverOIDC:
+	default:
+		return fmt.Errorf("不支持的认证驱动: %s", CurrentConfig().AuthDriver)
+	}
+	if CurrentConfig().OIDCIssuer == "" || CurrentConfig().OIDCClientID == "" {
+		return fmt.Errorf("OIDC认证需要配置 oidc_issuer 和 oidc_client_id")
+	}
+
+	provider, err := oidc.NewProvider(ctx, CurrentConfig().OIDCIssuer)
+	if err != nil {
+		return fmt.Errorf("获取OIDC发现文档失败: %v", err)
+	}
+
+	var discovery struct {
+		JWKSURL string `json:"jwks_uri"`
+	}
+	if err := provider.Claims(&discovery); err != nil {
+		return fmt.Errorf("解析OIDC发现文档失败: %v", err)
+	}
+	if discovery.JWKSURL == "" {
+		return fmt.Errorf("OIDC发现文档缺少 jwks_uri")
+	}
+
+	keySet := &cachedKeySet{jwksURL: discovery.JWKSURL, ttl: jwksCacheTTL}
+	OIDC = &OIDCProvider{
+		verifier: oidc.NewVerifier(CurrentConfig().OIDCIssuer, keySet, &oidc.Config{ClientID: CurrentConfig().OIDCClientID}),
+		oauth2Config: oauth2.Config{
+			ClientID:     CurrentConfig().OIDCClientID,
+			ClientSecret: CurrentConfig().OIDCClientSecret,
+			RedirectURL:  CurrentConfig().OIDCRedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       []string{oidc.ScopeOpenID, "profile", "email"},
+		},
+	}
+	return nil
+}
+
+// RandomState 生成授权请求使用的随机 state
+func RandomState() (string, error) {
+	return randomHex(16)
+}
+
+// AuthCodeURL 生成跳转到身份提供方的授权地址
+func (p *OIDCProvider) AuthCodeURL(state string) string {
+	return p.oauth2Config.AuthCodeURL(state)
+}
+
+// Exchange 使用授权码换取并校验ID令牌，返回原始令牌、主体和过期时间
+func (p *OIDCProvider) Exchange(ctx context.Context, code string) (string, string, time.Time, error) {
+	token, err := p.oauth2Config.Exchange(ctx, code)
+	if err != nil {
+		return "", "", time.Time{}, fmt.Errorf("换取令牌失败: %v", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return "", "", time.Time{}, fmt.Errorf("令牌响应中缺少 id_token")
+	}
+
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return "", "", time.Time{}, fmt.Errorf("校验ID令牌失败: %v", err)
+	}
+	return rawIDToken, idToken.Subject, idToken.Expiry, nil
+}
+
+// VerifyToken 校验Bearer ID令牌，返回主体（sub）和 tenant_id 声明中的租户
+func (p *OIDCProvider) VerifyToken(ctx context.Context, rawIDToken string) (string, string, error) {
+	if rawIDToken == "" {
+		return "", "", fmt.Errorf("缺少ID令牌")
+	}
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return "", "", err
+	}
+
+	var claims struct {
+		TenantID string `json:"tenant_id"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return "", "", fmt.Errorf("解析ID令牌声明失败: %v", err)
+	}
+	return idToken.Subject, claims.TenantID, nil
+}
+
+// cachedKeySet 带过期时间的JWKS缓存，超过TTL后重新拉取公钥
+type cachedKeySet struct {
+	jwksURL   string
+	ttl       time.Duration
+	mutex     sync.Mutex
+	keySet    *oidc.RemoteKeySet
+	fetchedAt time.Time
+}
+
+// VerifySignature 使用缓存的公钥校验JWT签名
+func (k *cachedKeySet) VerifySignature(ctx context.Context, jwt string) ([]byte, error) {
+	return k.current().VerifySignature(ctx, jwt)
+}
+
+// current 返回未过期的公钥集合，过期时重建以强制刷新
+func (k *cachedKeySet) current() *oidc.RemoteKeySet {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if k.keySet == nil || time.Since(k.fetchedAt) > k.ttl {
+		k.keySet = oidc.NewRemoteKeySet(context.Background(), k.jwksURL)
+		k.fetchedAt = time.Now()
+	}
+	return k.keySet
+}
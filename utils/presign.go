@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// 预签名URL中的保留参数
+const (
+	PresignExpiresParam   = "expires"
+	PresignSignatureParam = "sig"
+)
+
+// 预签名URL校验错误
+var (
+	ErrPresignExpired = errors.New("签名已过期")
+	ErrPresignInvalid = errors.New("签名无效")
+)
+
+// Sign 为参数生成带过期时间的HMAC-SHA256签名，返回包含 expires 和 sig 的查询字符串
+func Sign(params map[string]string, ttl time.Duration, secret string) string {
+	values := url.Values{}
+	for key, value := range params {
+		values.Set(key, value)
+	}
+	values.Set(PresignExpiresParam, strconv.FormatInt(time.Now().Add(ttl).Unix(), 10))
+	values.Del(PresignSignatureParam)
+
+	signature := presignSignature(values, secret)
+	return values.Encode() + "&" + PresignSignatureParam + "=" + signature
+}
+
+// Verify 校验参数的签名和过期时间，params 需包含 Sign 生成的 expires 和 sig
+func Verify(params map[string]string, secret string) error {
+	signature := params[PresignSignatureParam]
+	if signature == "" || secret == "" {
+		return ErrPresignInvalid
+	}
+
+	values := url.Values{}
+	for key, value := range params {
+		if key != PresignSignatureParam {
+			values.Set(key, value)
+		}
+	}
+
+	// 先校验签名，避免根据篡改过的过期时间返回不同的错误
+	expected := presignSignature(values, secret)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return ErrPresignInvalid
+	}
+
+	expires, err := strconv.ParseInt(params[PresignExpiresParam], 10, 64)
+	if err != nil {
+		return ErrPresignInvalid
+	}
+	if time.Now().Unix() > expires {
+		return ErrPresignExpired
+	}
+	return nil
+}
+
+// presignSignature 对按键排序编码后的参数计算十六进制HMAC-SHA256
+func presignSignature(values url.Values, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(values.Encode()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
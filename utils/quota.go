@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// MaxSizeHeader 客户端指定单次上传会话存储配额的请求头（字节）
+const MaxSizeHeader = "X-Max-Size"
+
+// ErrQuotaExceeded 上传超出存储配额
+var ErrQuotaExceeded = errors.New("超出上传存储配额")
+
+// QuotaUsage 任务存储配额使用情况
+type QuotaUsage struct {
+	FileID         string `json:"file_id"`
+	Scope          string `json:"scope"` // task 或 folder（子任务按所属文件夹统计）
+	ScopeTaskID    string `json:"scope_task_id"`
+	UsedBytes      int64  `json:"used_bytes"`
+	LimitBytes     int64  `json:"limit_bytes"` // 0表示不限制
+	RemainingBytes int64  `json:"remaining_bytes"`
+	Unlimited      bool   `json:"unlimited"`
+}
+
+// RequestedQuotaLimit 解析请求头中的配额，不能超过全局限制，未指定时返回0
+func RequestedQuotaLimit(headerValue string) int64 {
+	requested, err := strconv.ParseInt(headerValue, 10, 64)
+	if err != nil || requested <= 0 {
+		return 0
+	}
+	if global := CurrentConfig().MaxTotalUploadBytes; global > 0 && requested > global {
+		return global
+	}
+	return requested
+}
+
+// GetQuotaUsage 获取任务的配额使用情况
+func (s *TaskStorage) GetQuotaUsage(fileID string) (*QuotaUsage, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	task, exists := s.backend.GetTask(fileID)
+	if !exists {
+		return nil, fmt.Errorf("任务不存在: %s", fileID)
+	}
+	return s.quotaUsageInternal(task), nil
+}
+
+// CheckQuota 检查写入分片后是否超出配额，超出时返回 ErrQuotaExceeded；
+// 重传已完成的分片时扣除旧分片的大小，与 UpdateChunk 累计的用量一致
+func (s *TaskStorage) CheckQuota(fileID string, chunkIndex int, incoming int64) (*QuotaUsage, error) {
+	s.mutex.RLock()
+	task, exists := s.backend.GetTask(fileID)
+	if !exists {
+		s.mutex.RUnlock()
+		return nil, fmt.Errorf("任务不存在: %s", fileID)
+	}
+	usage := s.quotaUsageInternal(task)
+	var replaced int64
+	if previous, ok := task.Chunks[chunkIndex]; ok && previous.Status == "completed" {
+		replaced = previous.Size
+	}
+	s.mutex.RUnlock()
+
+	if !usage.Unlimited && usage.UsedBytes-replaced+incoming > usage.LimitBytes {
+		return usage, fmt.Errorf("%w: 已用 %d 字节，新增 %d 字节，限制 %d 字节",
+			ErrQuotaExceeded, usage.UsedBytes, incoming, usage.LimitBytes)
+	}
+	return usage, nil
+}
+
+// quotaUsageInternal 计算配额使用情况，子任务累计最外层文件夹下所有子任务的用量，调用方需持有锁
+func (s *TaskStorage) quotaUsageInternal(task *UploadTask) *QuotaUsage {
+	scopeTask := task
+	if task.IsSubTask && task.ParentTaskID != "" {
+		scopeTask = s.rootFolderInternal(task)
+	}
+
+	usage := &QuotaUsage{
+		FileID:      task.FileID,
+		Scope:       "task",
+		ScopeTaskID: scopeTask.FileID,
+		UsedBytes:   scopeTask.CurrentBytes,
+		LimitBytes:  scopeTask.MaxBytes,
+	}
+
+	if scopeTask.TaskType == "folder" {
+		usage.Scope = "folder"
+		usage.UsedBytes = s.folderUsedBytesInternal(scopeTask, make(map[string]bool))
+	}
+
+	if usage.LimitBytes <= 0 {
+		usage.LimitBytes = CurrentConfig().MaxTotalUploadBytes
+	}
+	if usage.LimitBytes <= 0 {
+		usage.Unlimited = true
+		return usage
+	}
+
+	usage.RemainingBytes = usage.LimitBytes - usage.UsedBytes
+	if usage.RemainingBytes < 0 {
+		usage.RemainingBytes = 0
+	}
+	return usage
+}
+
+// recalculateCurrentBytes 根据已完成的分片重新计算任务已用空间（兼容旧任务）
+func (t *UploadTask) recalculateCurrentBytes() {
+	t.CurrentBytes = 0
+	for _, chunk := range t.Chunks {
+		if chunk.Status == "completed" {
+			t.CurrentBytes += chunk.Size
+		}
+	}
+}
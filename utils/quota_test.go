@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckQuotaExcludesReuploadedChunk(t *testing.T) {
+	storage := newTestFolderStorage(t)
+	if err := storage.SaveTask(&UploadTask{
+		FileID:       "task-1",
+		TaskType:     "file",
+		TotalChunks:  3,
+		Status:       "uploading",
+		MaxBytes:     3000,
+		CurrentBytes: 2000,
+		Chunks: map[int]ChunkInfo{
+			0: {Index: 0, Size: 1000, Status: "completed"},
+			1: {Index: 1, Size: 1000, Status: "completed"},
+			2: {Index: 2, Size: 1000, Status: "failed"},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重传已完成的分片替换旧数据，不重复计入用量
+	if _, err := storage.CheckQuota("task-1", 1, 1000); err != nil {
+		t.Fatalf("重传已完成的分片不应超出配额: %v", err)
+	}
+	if _, err := storage.CheckQuota("task-1", 1, 2001); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("替换后的用量超出配额时应拒绝: %v", err)
+	}
+
+	// 失败的分片未计入用量，按新增计算
+	if _, err := storage.CheckQuota("task-1", 2, 1000); err != nil {
+		t.Fatalf("用量恰好达到配额时应接受: %v", err)
+	}
+	usage, err := storage.CheckQuota("task-1", 2, 1001)
+	if !errors.Is(err, ErrQuotaExceeded) || usage.UsedBytes != 2000 {
+		t.Fatalf("超出配额时应返回 ErrQuotaExceeded: %v %+v", err, usage)
+	}
+
+	if _, err := storage.CheckQuota("missing", 0, 1); err == nil {
+		t.Fatal("任务不存在时应返回错误")
+	}
+}
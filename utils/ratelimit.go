@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// limiterIdleTimeout 客户端限流器闲置回收时间
+const limiterIdleTimeout = 10 * time.Minute
+
+// clientLimiter 单个客户端的令牌桶
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// RateLimiter 按客户端IP限流的令牌桶限流器
+type RateLimiter struct {
+	mutex   sync.Mutex
+	clients map[string]*clientLimiter
+	rps     rate.Limit
+	burst   int
+	lastGC  time.Time
+}
+
+// NewRateLimiter 创建限流器
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	if burst <= 0 {
+		burst = int(math.Ceil(rps))
+	}
+	return &RateLimiter{
+		clients: make(map[string]*clientLimiter),
+		rps:     rate.Limit(rps),
+		burst:   burst,
+		lastGC:  time.Now(),
+	}
+}
+
+// reserve 为客户端预留一个令牌，返回需要等待的时间（0表示允许通过）
+func (rl *RateLimiter) reserve(clientIP string) time.Duration {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	now := time.Now()
+	client, exists := rl.clients[clientIP]
+	if !exists {
+		client = &clientLimiter{limiter: rate.NewLimiter(rl.rps, rl.burst)}
+		rl.clients[clientIP] = client
+	}
+	client.lastSeen = now
+
+	// 定期回收闲置的客户端限流器
+	if now.Sub(rl.lastGC) > limiterIdleTimeout {
+		for ip, cl := range rl.clients {
+			if now.Sub(cl.lastSeen) > limiterIdleTimeout {
+				delete(rl.clients, ip)
+			}
+		}
+		rl.lastGC = now
+	}
+
+	reservation := client.limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return time.Second
+	}
+
+	delay := reservation.DelayFrom(now)
+	if delay > 0 {
+		// 不等待令牌，立即拒绝并归还预留
+		reservation.CancelAt(now)
+	}
+	return delay
+}
+
+// Middleware 返回限流中间件，超过限制时返回429
+func (rl *RateLimiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		delay := rl.reserve(c.ClientIP())
+		if delay > 0 {
+			retryAfter := int(math.Ceil(delay.Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":       "请求过于频繁",
+				"retry_after": retryAfter,
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RateLimitMiddleware 根据配置创建限流中间件，RateLimitRPS<=0时不限流
+func RateLimitMiddleware() gin.HandlerFunc {
+	if CurrentConfig().RateLimitRPS <= 0 {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+	return NewRateLimiter(CurrentConfig().RateLimitRPS, CurrentConfig().RateLimitBurst).Middleware()
+}
@@ -0,0 +1,143 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// redisTaskKeyPrefix Redis任务键前缀
+const redisTaskKeyPrefix = "go-uploader:task:"
+
+// redisOpTimeout 单次Redis操作超时
+const redisOpTimeout = 5 * time.Second
+
+// RedisBackend 基于Redis的持久化后端，支持多实例共享任务状态
+type RedisBackend struct {
+	client *redis.Client
+}
+
+// NewRedisBackend 创建Redis持久化后端
+func NewRedisBackend(addr, password string, db int) (*RedisBackend, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       db,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("连接Redis失败: %v", err)
+	}
+
+	return &RedisBackend{client: client}, nil
+}
+
+// redisTaskKey 生成任务在Redis中的键
+func redisTaskKey(fileID string) string {
+	return redisTaskKeyPrefix + fileID
+}
+
+// SaveTask 序列化任务并写入Redis
+func (rb *RedisBackend) SaveTask(task *UploadTask) error {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+
+	if err := rb.client.Set(ctx, redisTaskKey(task.FileID), data, 0).Err(); err != nil {
+		return fmt.Errorf("写入Redis失败: %v", err)
+	}
+	return nil
+}
+
+// GetTask 从Redis读取任务
+func (rb *RedisBackend) GetTask(fileID string) (*UploadTask, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+
+	data, err := rb.client.Get(ctx, redisTaskKey(fileID)).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			Logger.Error("读取Redis任务失败", "file_id", fileID, "error", err)
+		}
+		return nil, false
+	}
+
+	var task UploadTask
+	if err := json.Unmarshal(data, &task); err != nil {
+		Logger.Error("解析Redis任务失败", "file_id", fileID, "error", err)
+		return nil, false
+	}
+
+	normalizeTask(&task)
+	return &task, true
+}
+
+// DeleteTask 从Redis删除任务
+func (rb *RedisBackend) DeleteTask(fileID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+
+	return rb.client.Del(ctx, redisTaskKey(fileID)).Err()
+}
+
+// GetAllTasks 扫描所有任务键并批量读取
+func (rb *RedisBackend) GetAllTasks() map[string]*UploadTask {
+	tasks := make(map[string]*UploadTask)
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+
+	var cursor uint64
+	for {
+		keys, next, err := rb.client.Scan(ctx, cursor, redisTaskKeyPrefix+"*", 500).Result()
+		if err != nil {
+			Logger.Error("扫描Redis任务失败", "error", err)
+			return tasks
+		}
+
+		if len(keys) > 0 {
+			values, err := rb.client.MGet(ctx, keys...).Result()
+			if err != nil {
+				Logger.Error("批量读取Redis任务失败", "error", err)
+				return tasks
+			}
+
+			for _, value := range values {
+				str, ok := value.(string)
+				if !ok {
+					continue
+				}
+
+				var task UploadTask
+				if err := json.Unmarshal([]byte(str), &task); err != nil {
+					continue
+				}
+
+				normalizeTask(&task)
+				tasks[task.FileID] = &task
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+
+	return tasks
+}
+
+// Close 关闭Redis连接
+func (rb *RedisBackend) Close() error {
+	return rb.client.Close()
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"time"
+)
+
+// RequestIDHeader 请求ID请求头和响应头
+const RequestIDHeader = "X-Request-Id"
+
+// RequestIDKey 请求ID在gin上下文中的键
+const RequestIDKey = "request_id"
+
+// maxRequestIDLength 客户端传入请求ID的最大长度，超出时重新生成
+const maxRequestIDLength = 128
+
+// requestIDContextKey 请求ID在标准库上下文中的键
+type requestIDContextKey struct{}
+
+// RequestIDMiddleware 读取或生成请求ID，写入上下文并在响应头中返回
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, id := ContextWithRequestID(c.Request.Context(), c.GetHeader(RequestIDHeader))
+
+		c.Set(RequestIDKey, id)
+		c.Request = c.Request.WithContext(ctx)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// RequestLogger 返回带有请求ID字段的日志记录器
+func RequestLogger(c *gin.Context) *slog.Logger {
+	if id := c.GetString(RequestIDKey); id != "" {
+		return Logger.With(RequestIDKey, id)
+	}
+	return Logger
+}
+
+// LoggerFromContext 从上下文中取出请求ID并返回对应的日志记录器
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	if id, ok := ctx.Value(requestIDContextKey{}).(string); ok && id != "" {
+		return Logger.With(RequestIDKey, id)
+	}
+	return Logger
+}
+
+// ContextWithRequestID 将请求ID写入上下文，ID无效时重新生成，返回实际使用的ID
+func ContextWithRequestID(ctx context.Context, id string) (context.Context, string) {
+	if !isValidRequestID(id) {
+		id = newRequestID()
+	}
+	return context.WithValue(ctx, requestIDContextKey{}, id), id
+}
+
+// isValidRequestID 只接受长度合理的可见ASCII字符，避免日志注入
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID 生成UUID v4格式的请求ID
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
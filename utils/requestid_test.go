@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// uuidV4Pattern UUID v4 格式
+var uuidV4Pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestIDMiddleware())
+	r.GET("/ping", func(c *gin.Context) {
+		// 处理函数通过gin上下文和请求上下文拿到的ID与响应头一致
+		fromContext, _ := c.Request.Context().Value(requestIDContextKey{}).(string)
+		c.String(http.StatusOK, c.GetString(RequestIDKey)+" "+fromContext)
+	})
+
+	tests := []struct {
+		name     string
+		header   string
+		echoed   bool
+		generate bool
+	}{
+		{"回传客户端的请求ID", "client-request-1", true, false},
+		{"未提供时生成", "", false, true},
+		{"包含控制字符时重新生成", "bad\nid", false, true},
+		{"过长时重新生成", strings.Repeat("a", maxRequestIDLength+1), false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			if tt.header != "" {
+				req.Header[RequestIDHeader] = []string{tt.header}
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			id := w.Header().Get(RequestIDHeader)
+			if tt.echoed && id != tt.header {
+				t.Fatalf("响应头 = %q, want %q", id, tt.header)
+			}
+			if tt.generate && !uuidV4Pattern.MatchString(id) {
+				t.Fatalf("生成的请求ID不是UUID v4: %q", id)
+			}
+			if body := w.Body.String(); body != id+" "+id {
+				t.Fatalf("上下文中的请求ID = %q, want %q", body, id)
+			}
+		})
+	}
+
+	// 每个请求生成不同的ID
+	first, second := httptest.NewRecorder(), httptest.NewRecorder()
+	r.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/ping", nil))
+	r.ServeHTTP(second, httptest.NewRequest(http.MethodGet, "/ping", nil))
+	if first.Header().Get(RequestIDHeader) == second.Header().Get(RequestIDHeader) {
+		t.Fatal("不同请求生成了相同的请求ID")
+	}
+}
@@ -2,9 +2,12 @@ package utils
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
 	"math"
+	"math/rand"
+	"sort"
+	"sync"
 	"time"
 )
 
@@ -14,6 +17,8 @@ type RetryConfig struct {
 	InitialDelay  time.Duration // 初始延迟
 	MaxDelay      time.Duration // 最大延迟
 	BackoffFactor float64       // 退避因子
+	JitterFactor  float64       // 随机抖动比例（0-1），在延迟上增加最多 delay*JitterFactor 的随机时间，避免大量请求同时重试
+	MaxJitter     time.Duration // 抖动的绝对上限，0表示不限制
 }
 
 // DefaultRetryConfig 默认重试配置
@@ -22,6 +27,8 @@ var DefaultRetryConfig = RetryConfig{
 	InitialDelay:  1 * time.Second,
 	MaxDelay:      30 * time.Second,
 	BackoffFactor: 2.0,
+	JitterFactor:  0.2,
+	MaxJitter:     5 * time.Second,
 }
 
 // IsRetryableError 判断错误是否可重试
@@ -79,7 +86,7 @@ func RetryWithBackoff(ctx context.Context, operation func() error, config RetryC
 			
 			// 计算延迟时间
 			delay := calculateDelay(attempt, config)
-			log.Printf("操作失败，第 %d 次重试，%v 后重试: %v", attempt+1, delay, err)
+			Logger.Warn("操作失败，稍后重试", "attempt", attempt+1, "delay_ms", delay.Milliseconds(), "error", err)
 			
 			// 等待或检查取消
 			select {
@@ -91,7 +98,7 @@ func RetryWithBackoff(ctx context.Context, operation func() error, config RetryC
 		} else {
 			// 成功
 			if attempt > 0 {
-				log.Printf("操作在第 %d 次重试后成功", attempt+1)
+				Logger.Info("操作重试后成功", "attempt", attempt+1)
 			}
 			return nil
 		}
@@ -100,7 +107,7 @@ func RetryWithBackoff(ctx context.Context, operation func() error, config RetryC
 	return fmt.Errorf("操作在 %d 次重试后仍然失败: %v", config.MaxRetries, lastErr)
 }
 
-// calculateDelay 计算延迟时间（指数退避）
+// calculateDelay 计算延迟时间（指数退避加随机抖动）
 func calculateDelay(attempt int, config RetryConfig) time.Duration {
 	delay := config.InitialDelay
 	
@@ -115,7 +122,24 @@ func calculateDelay(attempt int, config RetryConfig) time.Duration {
 		delay = config.MaxDelay
 	}
 	
-	return delay
+	return delay + calculateJitter(delay, config)
+}
+
+// calculateJitter 返回 [0, delay*JitterFactor) 内的随机抖动，不超过 MaxJitter
+func calculateJitter(delay time.Duration, config RetryConfig) time.Duration {
+	factor := math.Min(config.JitterFactor, 1)
+	if factor <= 0 || delay <= 0 {
+		return 0
+	}
+
+	maxJitter := time.Duration(float64(delay) * factor)
+	if config.MaxJitter > 0 && maxJitter > config.MaxJitter {
+		maxJitter = config.MaxJitter
+	}
+	if maxJitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(maxJitter)))
 }
 
 // contains 检查字符串是否包含子字符串（忽略大小写）
@@ -151,15 +175,46 @@ func toLower(s string) string {
 	return string(result)
 }
 
+// 熔断器默认参数：连续失败次数达到上限后开启，经过重置时间后进入半开状态试探
+const (
+	defaultBreakerMaxFailures  = 5
+	defaultBreakerResetTimeout = 30 * time.Second
+)
+
+// ErrCircuitOpen 熔断器开启，操作被拒绝
+var ErrCircuitOpen = errors.New("熔断器开启，拒绝执行")
+
+// ErrCircuitBreakerNotFound 熔断器不存在
+var ErrCircuitBreakerNotFound = errors.New("熔断器不存在")
+
 // CircuitBreaker 熔断器
 type CircuitBreaker struct {
+	name         string
+	mutex        sync.Mutex
 	maxFailures  int
 	resetTimeout time.Duration
 	failures     int
 	lastFailTime time.Time
-	state        string // "closed", "open", "half-open"
+	state        string           // "closed", "open", "half-open"
+	isFailure    func(error) bool // 判断错误是否计入失败次数，为nil时所有错误都计入
 }
 
+// CircuitBreakerStatus 熔断器状态
+type CircuitBreakerStatus struct {
+	Name                string     `json:"name"`
+	State               string     `json:"state"` // closed, open, half-open
+	Failures            int        `json:"failures"`
+	MaxFailures         int        `json:"max_failures"`
+	ResetTimeoutSeconds float64    `json:"reset_timeout_seconds"`
+	LastFailure         *time.Time `json:"last_failure,omitempty"`
+}
+
+// circuitBreakers 已注册的熔断器，按名称索引
+var (
+	circuitBreakersMutex sync.Mutex
+	circuitBreakers      = make(map[string]*CircuitBreaker)
+)
+
 // NewCircuitBreaker 创建新的熔断器
 func NewCircuitBreaker(maxFailures int, resetTimeout time.Duration) *CircuitBreaker {
 	return &CircuitBreaker{
@@ -169,25 +224,50 @@ func NewCircuitBreaker(maxFailures int, resetTimeout time.Duration) *CircuitBrea
 	}
 }
 
+// RegisterCircuitBreaker 创建使用默认参数的熔断器并注册到管理接口，isFailure 为nil时所有错误都计入失败次数
+func RegisterCircuitBreaker(name string, isFailure func(error) bool) *CircuitBreaker {
+	cb := NewCircuitBreaker(defaultBreakerMaxFailures, defaultBreakerResetTimeout)
+	cb.name = name
+	cb.isFailure = isFailure
+
+	circuitBreakersMutex.Lock()
+	defer circuitBreakersMutex.Unlock()
+	circuitBreakers[name] = cb
+	return cb
+}
+
 // Execute 执行操作（带熔断保护）
 func (cb *CircuitBreaker) Execute(operation func() error) error {
 	// 检查熔断器状态
+	cb.mutex.Lock()
 	if cb.state == "open" {
 		if time.Since(cb.lastFailTime) > cb.resetTimeout {
 			cb.state = "half-open"
 		} else {
-			return fmt.Errorf("熔断器开启，拒绝执行")
+			cb.mutex.Unlock()
+			return ErrCircuitOpen
 		}
 	}
+	cb.mutex.Unlock()
 	
-	// 执行操作
+	// 执行操作（不持有锁，允许并发执行）
 	err := operation()
 	
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
 	if err != nil {
+		if cb.isFailure != nil && !cb.isFailure(err) {
+			return err
+		}
+
 		cb.failures++
 		cb.lastFailTime = time.Now()
 		
 		if cb.failures >= cb.maxFailures {
+			if cb.state != "open" {
+				Logger.Warn("熔断器开启", "name", cb.name, "failures", cb.failures, "error", err)
+			}
 			cb.state = "open"
 		}
 		
@@ -198,4 +278,67 @@ func (cb *CircuitBreaker) Execute(operation func() error) error {
 	cb.failures = 0
 	cb.state = "closed"
 	return nil
-} 
\ No newline at end of file
+}
+
+// Status 返回熔断器状态，开启时间超过重置时间的熔断器报告为半开
+func (cb *CircuitBreaker) Status() CircuitBreakerStatus {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	status := CircuitBreakerStatus{
+		Name:                cb.name,
+		State:               cb.state,
+		Failures:            cb.failures,
+		MaxFailures:         cb.maxFailures,
+		ResetTimeoutSeconds: cb.resetTimeout.Seconds(),
+	}
+	if cb.state == "open" && time.Since(cb.lastFailTime) > cb.resetTimeout {
+		status.State = "half-open"
+	}
+	if !cb.lastFailTime.IsZero() {
+		lastFailure := cb.lastFailTime
+		status.LastFailure = &lastFailure
+	}
+	return status
+}
+
+// Reset 手动关闭熔断器并清零失败次数
+func (cb *CircuitBreaker) Reset() {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	cb.failures = 0
+	cb.state = "closed"
+}
+
+// CircuitBreakers 返回所有已注册熔断器的状态，按名称排序
+func CircuitBreakers() []CircuitBreakerStatus {
+	circuitBreakersMutex.Lock()
+	breakers := make([]*CircuitBreaker, 0, len(circuitBreakers))
+	for _, cb := range circuitBreakers {
+		breakers = append(breakers, cb)
+	}
+	circuitBreakersMutex.Unlock()
+
+	statuses := make([]CircuitBreakerStatus, 0, len(breakers))
+	for _, cb := range breakers {
+		statuses = append(statuses, cb.Status())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// ResetCircuitBreaker 按名称手动关闭熔断器，返回重置前的状态
+func ResetCircuitBreaker(name string) (CircuitBreakerStatus, error) {
+	circuitBreakersMutex.Lock()
+	cb, exists := circuitBreakers[name]
+	circuitBreakersMutex.Unlock()
+	if !exists {
+		return CircuitBreakerStatus{}, ErrCircuitBreakerNotFound
+	}
+
+	previous := cb.Status()
+	cb.Reset()
+	Logger.Info("熔断器已手动重置", "name", name, "previous_state", previous.State, "failures", previous.Failures)
+	return previous, nil
+}
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalculateDelayJitterDistribution(t *testing.T) {
+	const runs = 1000
+	config := RetryConfig{
+		InitialDelay:  100 * time.Millisecond,
+		MaxDelay:      time.Second,
+		BackoffFactor: 2,
+		JitterFactor:  0.25,
+		MaxJitter:     150 * time.Millisecond,
+	}
+
+	tests := []struct {
+		attempt   int
+		base      time.Duration
+		maxJitter time.Duration
+	}{
+		{0, 100 * time.Millisecond, 25 * time.Millisecond},
+		{2, 400 * time.Millisecond, 100 * time.Millisecond},
+		// 达到最大延迟后抖动受 MaxJitter 限制
+		{5, time.Second, 150 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		var sum time.Duration
+		var buckets [4]int
+		for i := 0; i < runs; i++ {
+			delay := calculateDelay(tt.attempt, config)
+			if delay < tt.base || delay >= tt.base+tt.maxJitter {
+				t.Fatalf("attempt=%d: delay %v 超出 [%v, %v)", tt.attempt, delay, tt.base, tt.base+tt.maxJitter)
+			}
+			jitter := delay - tt.base
+			sum += jitter
+			buckets[int(jitter*4/tt.maxJitter)]++
+		}
+
+		// 抖动在区间内均匀分布：均值接近区间中点，四等分的每段都有足够的样本
+		mean := sum / runs
+		if mean < tt.maxJitter*4/10 || mean > tt.maxJitter*6/10 {
+			t.Fatalf("attempt=%d: 抖动均值 %v 偏离 %v", tt.attempt, mean, tt.maxJitter/2)
+		}
+		for i, count := range buckets {
+			if count < runs*15/100 {
+				t.Fatalf("attempt=%d: 第%d段只有 %d 个样本: %v", tt.attempt, i, count, buckets)
+			}
+		}
+	}
+}
+
+func TestCalculateDelayWithoutJitter(t *testing.T) {
+	config := RetryConfig{InitialDelay: 100 * time.Millisecond, MaxDelay: time.Second, BackoffFactor: 2}
+	for i := 0; i < 100; i++ {
+		if delay := calculateDelay(3, config); delay != 800*time.Millisecond {
+			t.Fatalf("JitterFactor 为0时延迟应固定: %v", delay)
+		}
+	}
+}
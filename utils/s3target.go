@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// 合并文件存储目标
+const (
+	StorageTargetLocal = "local"
+	StorageTargetS3    = "s3"
+)
+
+// S3分片上传的分片大小，S3要求除最后一个分片外至少5MB且最多10000个分片
+const (
+	s3PartSize = 16 * 1024 * 1024
+	s3MaxParts = 10000
+)
+
+// S3Target 将合并后的文件上传到S3兼容的对象存储
+type S3Target struct {
+	client *s3.Client
+	bucket string
+	prefix string
+}
+
+// S3 全局对象存储目标（存储目标为 local 时为nil）
+var S3 *S3Target
+
+// InitStorageTarget 根据配置初始化合并文件的存储目标
+func InitStorageTarget(ctx context.Context) error {
+	switch CurrentConfig().StorageTarget {
+	case "", StorageTargetLocal:
+		S3 = nil
+		return nil
+	case StorageTargetS3:
+	default:
+		return fmt.Errorf("不支持的存储目标: %s", CurrentConfig().StorageTarget)
+	}
+	if CurrentConfig().S3Bucket == "" {
+		return fmt.Errorf("S3存储目标需要配置 s3_bucket")
+	}
+
+	options := []func(*awsconfig.LoadOptions) error{}
+	if CurrentConfig().S3Region != "" {
+		options = append(options, awsconfig.WithRegion(CurrentConfig().S3Region))
+	}
+	// 未配置访问密钥时使用默认凭证链（环境变量、共享配置文件、实例角色等）
+	if CurrentConfig().S3AccessKey != "" {
+		options = append(options, awsconfig.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(CurrentConfig().S3AccessKey, CurrentConfig().S3SecretKey, "")))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, options...)
+	if err != nil {
+		return fmt.Errorf("加载S3配置失败: %v", err)
+	}
+
+	// 自定义端点（MinIO等）通常不支持虚拟主机风格的存储桶地址
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if CurrentConfig().S3Endpoint != "" {
+			o.BaseEndpoint = aws.String(CurrentConfig().S3Endpoint)
+			o.UsePathStyle = true
+		}
+	})
+
+	S3 = &S3Target{
+		client: client,
+		bucket: CurrentConfig().S3Bucket,
+		prefix: strings.Trim(CurrentConfig().S3KeyPrefix, "/"),
+	}
+	return nil
+}
+
+// ObjectKey 合并文件在存储桶中的对象键，由键前缀和相对于合并目录的路径组成
+func (t *S3Target) ObjectKey(mergedPath string) string {
+	return path.Join(t.prefix, mergedPath)
+}
+
+// ObjectURL 对象的 s3://bucket/key 地址
+func (t *S3Target) ObjectURL(key string) string {
+	return fmt.Sprintf("s3://%s/%s", t.bucket, key)
+}
+
+// Upload 使用S3原生分片上传接口上传本地文件，返回对象地址，失败时中止分片上传
+func (t *S3Target) Upload(ctx context.Context, localPath, key, contentType string) (string, error) {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return "", fmt.Errorf("打开合并文件失败: %v", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", fmt.Errorf("读取合并文件信息失败: %v", err)
+	}
+
+	input := &s3.CreateMultipartUploadInput{
+		Bucket: aws.String(t.bucket),
+		Key:    aws.String(key),
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+	created, err := t.client.CreateMultipartUpload(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("创建S3分片上传失败: %v", err)
+	}
+
+	parts, err := t.uploadParts(ctx, file, info.Size(), key, created.UploadId)
+	if err == nil {
+		_, err = t.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+			Bucket:          aws.String(t.bucket),
+			Key:             aws.String(key),
+			UploadId:        created.UploadId,
+			MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+		})
+		if err != nil {
+			err = fmt.Errorf("完成S3分片上传失败: %v", err)
+		}
+	}
+	if err != nil {
+		// 使用独立的上下文中止，避免请求已超时导致未完成的分片残留在存储桶中
+		if _, abortErr := t.client.AbortMultipartUpload(context.Background(), &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(t.bucket),
+			Key:      aws.String(key),
+			UploadId: created.UploadId,
+		}); abortErr != nil {
+			LoggerFromContext(ctx).Warn("中止S3分片上传失败", "key", key, "error", abortErr)
+		}
+		return "", err
+	}
+
+	return t.ObjectURL(key), nil
+}
+
+// uploadParts 按顺序上传文件的所有分片
+func (t *S3Target) uploadParts(ctx context.Context, file *os.File, size int64, key string, uploadID *string) ([]types.CompletedPart, error) {
+	partSize := int64(s3PartSize)
+	if size > partSize*s3MaxParts {
+		partSize = (size + s3MaxParts - 1) / s3MaxParts
+	}
+
+	parts := make([]types.CompletedPart, 0, size/partSize+1)
+	for offset, number := int64(0), int32(1); offset < size || number == 1; offset, number = offset+partSize, number+1 {
+		length := partSize
+		if remaining := size - offset; remaining < length {
+			length = remaining
+		}
+
+		output, err := t.client.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket:        aws.String(t.bucket),
+			Key:           aws.String(key),
+			UploadId:      uploadID,
+			PartNumber:    aws.Int32(number),
+			Body:          io.NewSectionReader(file, offset, length),
+			ContentLength: aws.Int64(length),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("上传S3分片 %d 失败: %v", number, err)
+		}
+		parts = append(parts, types.CompletedPart{ETag: output.ETag, PartNumber: aws.Int32(number)})
+	}
+	return parts, nil
+}
@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+)
+
+// 文件名搜索模式
+const (
+	SearchModeContains = "contains"
+	SearchModePrefix   = "prefix"
+)
+
+// MatchesFilename 检查任务的文件名或相对路径是否匹配查询（不区分大小写）
+func (t *UploadTask) MatchesFilename(query, mode string) bool {
+	query = strings.ToLower(query)
+	for _, field := range []string{t.FileName, t.RelativePath} {
+		if field == "" {
+			continue
+		}
+		field = strings.ToLower(field)
+		if mode == SearchModePrefix {
+			if strings.HasPrefix(field, query) {
+				return true
+			}
+		} else if strings.Contains(field, query) {
+			return true
+		}
+	}
+	return false
+}
+
+// SearchByFilename 按文件名搜索主任务，mode 为 prefix（前缀匹配）或 contains（包含匹配）
+func (s *TaskStorage) SearchByFilename(query, mode string) []*UploadTask {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var tasks []*UploadTask
+	for _, task := range s.backend.GetAllTasks() {
+		if !task.IsSubTask && task.MatchesFilename(query, mode) {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
+// TaskQuery 任务列表筛选条件，多个条件同时提供时取交集
+type TaskQuery struct {
+	TagKey     string // 标签键，为空时不按标签筛选
+	TagValue   string // 标签值，为空时只检查键
+	Filename   string
+	SearchMode string // 文件名匹配模式：prefix 或 contains
+	Status     string
+	TenantID   string // 租户ID，为空时不按租户筛选
+	Limit      int    // 大于0时只返回最近更新的N个任务
+}
+
+// QueryTasks 按条件筛选任务，未指定标签时只返回主任务
+func (s *TaskStorage) QueryTasks(query TaskQuery) []*UploadTask {
+	var tasks []*UploadTask
+	if query.TagKey != "" {
+		tasks = s.ListByTag(query.TagKey, query.TagValue)
+	} else if query.Filename != "" {
+		tasks = s.SearchByFilename(query.Filename, query.SearchMode)
+	} else {
+		for _, task := range s.GetMainTasks() {
+			tasks = append(tasks, task)
+		}
+	}
+
+	filtered := tasks[:0]
+	for _, task := range tasks {
+		if query.Filename != "" && !task.MatchesFilename(query.Filename, query.SearchMode) {
+			continue
+		}
+		if query.Status != "" && task.Status != query.Status {
+			continue
+		}
+		if !task.VisibleToTenant(query.TenantID) {
+			continue
+		}
+		filtered = append(filtered, task)
+	}
+	tasks = filtered
+
+	// 指定数量限制时返回最近更新的任务
+	if query.Limit > 0 && len(tasks) > query.Limit {
+		sort.Slice(tasks, func(i, j int) bool {
+			return tasks[i].UpdatedAt.After(tasks[j].UpdatedAt)
+		})
+		tasks = tasks[:query.Limit]
+	}
+	return tasks
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"sort"
+	"testing"
+)
+
+// taskIDs 返回排序后的任务ID
+func taskIDs(tasks []*UploadTask) []string {
+	ids := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		ids = append(ids, task.FileID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestSearchByFilenameUnicode(t *testing.T) {
+	storage := newTestFolderStorage(t)
+
+	for fileID, fileName := range map[string]string{
+		"report":  "年度报告2024.docx",
+		"notes":   "ÄRGER_notes.txt",
+		"resume":  "Résumé.pdf",
+		"unicode": "emoji_🎉.png",
+	} {
+		task := newTestTask(fileID, 1)
+		task.FileName = fileName
+		if err := storage.SaveTask(task); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 子任务与主任务同名，搜索结果中不能出现
+	folder, err := storage.CreateFolderTask("归档", []FileInfo{
+		{Name: "年度报告2023.docx", RelativePath: "2023/年度报告2023.docx", Size: 1, TotalChunks: 1},
+		{Name: "ärger.txt", RelativePath: "ärger.txt", Size: 1, TotalChunks: 1},
+	}, nil, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query string
+		mode  string
+		want  []string
+	}{
+		{"报告", SearchModeContains, []string{"report"}},
+		{"年度", SearchModePrefix, []string{"report"}},
+		{"ärger", SearchModePrefix, []string{"notes"}},
+		{"RÉSUMÉ", SearchModeContains, []string{"resume"}},
+		{"🎉", SearchModeContains, []string{"unicode"}},
+		{"归档", SearchModePrefix, []string{folder.FileID}},
+		{"2023", SearchModeContains, nil},
+		{"告", SearchModePrefix, nil},
+	}
+	for _, tt := range tests {
+		got := taskIDs(storage.SearchByFilename(tt.query, tt.mode))
+		if len(got) != len(tt.want) || (len(got) > 0 && got[0] != tt.want[0]) {
+			t.Fatalf("%s %q: got %v, want %v", tt.mode, tt.query, got, tt.want)
+		}
+		for _, task := range storage.SearchByFilename(tt.query, tt.mode) {
+			if task.IsSubTask {
+				t.Fatalf("%s %q: 结果中包含子任务 %s", tt.mode, tt.query, task.FileID)
+			}
+		}
+	}
+
+	// 与状态筛选同时使用时取交集
+	report, _ := storage.GetTask("report")
+	report.Status = "completed"
+	storage.SaveTask(report)
+	query := TaskQuery{Filename: "報告", SearchMode: SearchModeContains, Status: "completed"}
+	if got := storage.QueryTasks(query); len(got) != 0 {
+		t.Fatalf("繁体字不应匹配简体文件名: %v", taskIDs(got))
+	}
+	query.Filename = "报告"
+	if got := taskIDs(storage.QueryTasks(query)); len(got) != 1 || got[0] != "report" {
+		t.Fatalf("status=completed: got %v", got)
+	}
+	query.Status = "pending"
+	if got := storage.QueryTasks(query); len(got) != 0 {
+		t.Fatalf("status=pending: got %v", taskIDs(got))
+	}
+}
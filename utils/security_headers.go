@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"github.com/gin-gonic/gin"
+	"strconv"
+)
+
+// SecurityHeadersConfig 安全相关响应头配置，值为空的响应头不设置
+type SecurityHeadersConfig struct {
+	HSTSEnabled           bool   `json:"hsts_enabled"`            // 启用TLS时设置 Strict-Transport-Security
+	HSTSMaxAge            int    `json:"hsts_max_age"`            // HSTS 有效期（秒）
+	ContentSecurityPolicy string `json:"content_security_policy"` // Content-Security-Policy
+	XFrameOptions         string `json:"x_frame_options"`         // X-Frame-Options
+	XContentTypeOptions   string `json:"x_content_type_options"`  // X-Content-Type-Options
+	ReferrerPolicy        string `json:"referrer_policy"`         // Referrer-Policy
+	PermissionsPolicy     string `json:"permissions_policy"`      // Permissions-Policy
+}
+
+// SecurityHeadersMiddleware 为所有响应添加安全相关的响应头，HSTS 只在启用TLS时设置
+func SecurityHeadersMiddleware(cfg SecurityHeadersConfig) gin.HandlerFunc {
+	headers := map[string]string{
+		"Content-Security-Policy": cfg.ContentSecurityPolicy,
+		"X-Frame-Options":         cfg.XFrameOptions,
+		"X-Content-Type-Options":  cfg.XContentTypeOptions,
+		"Referrer-Policy":         cfg.ReferrerPolicy,
+		"Permissions-Policy":      cfg.PermissionsPolicy,
+	}
+	if cfg.HSTSEnabled && CurrentConfig().TLSEnabled && cfg.HSTSMaxAge > 0 {
+		headers["Strict-Transport-Security"] = "max-age=" + strconv.Itoa(cfg.HSTSMaxAge)
+	}
+	for name, value := range headers {
+		if value == "" {
+			delete(headers, name)
+		}
+	}
+
+	return func(c *gin.Context) {
+		for name, value := range headers {
+			c.Header(name, value)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// semaphoreCleanupInterval 回收空闲客户端信号量的间隔
+const semaphoreCleanupInterval = 5 * time.Minute
+
+// semaphore 单个客户端正在占用的上传名额
+type semaphore struct {
+	inUse int
+}
+
+// PerClientSemaphore 按客户端IP限制同时进行的上传数，名额用尽时立即拒绝而不是排队等待
+type PerClientSemaphore struct {
+	mutex       sync.Mutex
+	clients     map[string]*semaphore
+	lastCleanup time.Time
+}
+
+// UploadSlots 分片上传的全局客户端并发名额
+var UploadSlots = NewPerClientSemaphore()
+
+// NewPerClientSemaphore 创建按客户端限制并发的信号量
+func NewPerClientSemaphore() *PerClientSemaphore {
+	return &PerClientSemaphore{
+		clients:     make(map[string]*semaphore),
+		lastCleanup: time.Now(),
+	}
+}
+
+// TryAcquire 为客户端占用一个名额，limit<=0 表示不限制；名额用尽时返回false，
+// 成功时返回的 release 必须且只能调用一次
+func (p *PerClientSemaphore) TryAcquire(clientIP string, limit int) (func(), bool) {
+	if limit <= 0 {
+		return func() {}, true
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	// 定期回收没有占用名额的客户端
+	now := time.Now()
+	if now.Sub(p.lastCleanup) > semaphoreCleanupInterval {
+		for ip, sem := range p.clients {
+			if sem.inUse == 0 {
+				delete(p.clients, ip)
+			}
+		}
+		p.lastCleanup = now
+	}
+
+	sem, exists := p.clients[clientIP]
+	if !exists {
+		sem = &semaphore{}
+		p.clients[clientIP] = sem
+	}
+	if sem.inUse >= limit {
+		return nil, false
+	}
+	sem.inUse++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			p.mutex.Lock()
+			defer p.mutex.Unlock()
+			sem.inUse--
+		})
+	}, true
+}
+
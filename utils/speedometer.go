@@ -0,0 +1,215 @@
+package utils
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// speedometerMinSamples 计算预计剩余时间所需的最少样本数
+const speedometerMinSamples = 3
+
+// speedSample 单个分片完成的时间和大小
+type speedSample struct {
+	at    time.Time
+	bytes int64
+}
+
+// SpeedStats 任务的上传速度统计（字节/秒）
+type SpeedStats struct {
+	CurrentBps float64 `json:"upload_speed_bps"`  // 最近窗口内的速度
+	PeakBps    float64 `json:"peak_speed_bps"`    // 开始测速以来窗口速度的峰值
+	AverageBps float64 `json:"average_speed_bps"` // 开始测速以来的平均速度
+	Samples    int     `json:"samples"`           // 窗口内的样本数
+}
+
+// Speedometer 以环形缓冲区保存最近N个分片的完成时间，按滑动窗口计算上传速度
+type Speedometer struct {
+	mutex   sync.Mutex
+	samples []speedSample
+	next    int // 下一个写入位置
+	count   int
+
+	peak  float64   // 每次记录后窗口速度的最大值
+	first time.Time // 首个样本的时间，作为平均速度的计时起点
+	total int64     // 首个样本之后完成的字节数
+}
+
+// NewSpeedometer 创建窗口大小为 size 的测速器
+func NewSpeedometer(size int) *Speedometer {
+	if size < speedometerMinSamples {
+		size = speedometerMinSamples
+	}
+	return &Speedometer{samples: make([]speedSample, size)}
+}
+
+// Record 记录一个分片完成
+func (m *Speedometer) Record(bytes int64, at time.Time) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.first.IsZero() {
+		m.first = at
+	} else {
+		m.total += bytes
+	}
+
+	m.samples[m.next] = speedSample{at: at, bytes: bytes}
+	m.next = (m.next + 1) % len(m.samples)
+	if m.count < len(m.samples) {
+		m.count++
+	}
+
+	if rate, _ := m.rateInternal(); rate > m.peak {
+		m.peak = rate
+	}
+}
+
+// Rate 返回窗口内的上传速度（字节/秒）和样本数，最早的样本只作为计时起点
+func (m *Speedometer) Rate() (float64, int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.rateInternal()
+}
+
+// Stats 返回当前、峰值和平均速度
+func (m *Speedometer) Stats() SpeedStats {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	stats := SpeedStats{PeakBps: m.peak}
+	stats.CurrentBps, stats.Samples = m.rateInternal()
+	if m.count > 0 {
+		newest := m.samples[(m.next-1+len(m.samples))%len(m.samples)].at
+		if elapsed := newest.Sub(m.first).Seconds(); elapsed > 0 {
+			stats.AverageBps = float64(m.total) / elapsed
+		}
+	}
+	return stats
+}
+
+// rateInternal 计算窗口内的上传速度，调用方需持有锁
+func (m *Speedometer) rateInternal() (float64, int) {
+	if m.count < 2 {
+		return 0, m.count
+	}
+
+	oldest := (m.next - m.count + len(m.samples)) % len(m.samples)
+	newest := (m.next - 1 + len(m.samples)) % len(m.samples)
+
+	var bytes int64
+	for i := 1; i < m.count; i++ {
+		bytes += m.samples[(oldest+i)%len(m.samples)].bytes
+	}
+
+	elapsed := m.samples[newest].at.Sub(m.samples[oldest].at).Seconds()
+	if elapsed <= 0 {
+		return 0, m.count
+	}
+	return float64(bytes) / elapsed, m.count
+}
+
+// speedometerRegistry 按任务ID保存测速器，只存在于内存中
+type speedometerRegistry struct {
+	mutex  sync.Mutex
+	meters map[string]*Speedometer
+}
+
+// speedometers 全局测速器注册表
+var speedometers = &speedometerRegistry{meters: make(map[string]*Speedometer)}
+
+// record 记录任务的分片完成，不存在时按当前窗口配置创建
+func (r *speedometerRegistry) record(fileID string, bytes int64, at time.Time) {
+	r.mutex.Lock()
+	meter, exists := r.meters[fileID]
+	if !exists {
+		meter = NewSpeedometer(CurrentConfig().SpeedometerWindowSize)
+		r.meters[fileID] = meter
+	}
+	r.mutex.Unlock()
+
+	meter.Record(bytes, at)
+}
+
+// get 获取任务的测速器
+func (r *speedometerRegistry) get(fileID string) (*Speedometer, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	meter, exists := r.meters[fileID]
+	return meter, exists
+}
+
+// remove 删除任务的测速器
+func (r *speedometerRegistry) remove(fileID string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.meters, fileID)
+}
+
+// EstimateUpload 返回任务的上传速度（字节/秒）和预计剩余秒数，样本不足或速度为0时剩余时间为nil
+func EstimateUpload(fileID string, remainingBytes int64) (float64, *int64) {
+	meter, exists := speedometers.get(fileID)
+	if !exists {
+		return 0, nil
+	}
+
+	speed, samples := meter.Rate()
+	if samples < speedometerMinSamples || speed <= 0 {
+		return speed, nil
+	}
+	if remainingBytes < 0 {
+		remainingBytes = 0
+	}
+	eta := int64(math.Ceil(float64(remainingBytes) / speed))
+	return speed, &eta
+}
+
+// TaskSpeedStats 返回任务的速度统计，没有完成过分片的任务返回零值
+func TaskSpeedStats(fileID string) SpeedStats {
+	meter, exists := speedometers.get(fileID)
+	if !exists {
+		return SpeedStats{}
+	}
+	return meter.Stats()
+}
+
+// RemainingBytes 估算任务剩余需要上传的字节数，未记录文件大小时按已上传分片的平均大小估算
+func (t *UploadTask) RemainingBytes() int64 {
+	if t.FileSize > 0 {
+		if remaining := t.FileSize - t.CurrentBytes; remaining > 0 {
+			return remaining
+		}
+		return 0
+	}
+
+	uploaded := 0
+	for _, chunk := range t.Chunks {
+		if chunk.Status == "completed" {
+			uploaded++
+		}
+	}
+	if uploaded == 0 || t.TotalChunks <= uploaded {
+		return 0
+	}
+	return int64(t.TotalChunks-uploaded) * (t.CurrentBytes / int64(uploaded))
+}
+
+// recordChunkSpeedInternal 更新任务及其所有上级文件夹的测速器，调用方需持有锁
+func (s *TaskStorage) recordChunkSpeedInternal(task *UploadTask, bytes int64, at time.Time) {
+	speedometers.record(task.FileID, bytes, at)
+
+	visited := map[string]bool{task.FileID: true}
+	for parentID := task.ParentTaskID; parentID != "" && !visited[parentID]; {
+		visited[parentID] = true
+		speedometers.record(parentID, bytes, at)
+
+		parent, exists := s.backend.GetTask(parentID)
+		if !exists {
+			break
+		}
+		parentID = parent.ParentTaskID
+	}
+}
@@ -0,0 +1,346 @@
+package utils
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	_ "modernc.org/sqlite"
+	"time"
+)
+
+// sqliteSchema 任务表结构，分片信息以JSON保存
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS tasks (
+	file_id         TEXT PRIMARY KEY,
+	filename        TEXT NOT NULL DEFAULT '',
+	relative_path   TEXT NOT NULL DEFAULT '',
+	total_chunks    INTEGER NOT NULL DEFAULT 0,
+	file_size       INTEGER NOT NULL DEFAULT 0,
+	file_md5        TEXT NOT NULL DEFAULT '',
+	status          TEXT NOT NULL DEFAULT '',
+	created_at      TEXT NOT NULL DEFAULT '',
+	updated_at      TEXT NOT NULL DEFAULT '',
+	chunks          TEXT NOT NULL DEFAULT '{}',
+	uploaded_chunks BLOB,
+	retry_count     INTEGER NOT NULL DEFAULT 0,
+	task_type       TEXT NOT NULL DEFAULT 'file',
+	parent_task_id  TEXT NOT NULL DEFAULT '',
+	folder_name     TEXT NOT NULL DEFAULT '',
+	sub_tasks       TEXT NOT NULL DEFAULT '[]',
+	is_sub_task     INTEGER NOT NULL DEFAULT 0,
+	mime_type       TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS idx_tasks_status ON tasks(status);
+CREATE INDEX IF NOT EXISTS idx_tasks_parent ON tasks(parent_task_id);
+`
+
+// sqliteAddedColumns 建表后新增的列，启动时自动补齐
+var sqliteAddedColumns = []struct {
+	name       string
+	definition string
+}{
+	{"estimated_completion_at", "TEXT NOT NULL DEFAULT ''"},
+	{"tags", "TEXT NOT NULL DEFAULT '{}'"},
+	{"failure_reason", "TEXT NOT NULL DEFAULT ''"},
+	{"merged_path", "TEXT NOT NULL DEFAULT ''"},
+	{"current_bytes", "INTEGER NOT NULL DEFAULT 0"},
+	{"max_bytes", "INTEGER NOT NULL DEFAULT 0"},
+	{"nested_sub_folders", "TEXT NOT NULL DEFAULT '[]'"},
+	{"storage_url", "TEXT NOT NULL DEFAULT ''"},
+	{"depends_on", "TEXT NOT NULL DEFAULT '[]'"},
+	{"merge_time", "INTEGER NOT NULL DEFAULT 0"},
+	{"hash_algorithm", "TEXT NOT NULL DEFAULT ''"},
+	{"tenant_id", "TEXT NOT NULL DEFAULT ''"},
+	{"cloned_from", "TEXT NOT NULL DEFAULT ''"},
+	{"version_number", "INTEGER NOT NULL DEFAULT 0"},
+	{"previous_versions", "TEXT NOT NULL DEFAULT '[]'"},
+	{"pending_cleanup", "INTEGER NOT NULL DEFAULT 0"},
+	{"progress_webhook_every_n", "INTEGER NOT NULL DEFAULT 0"},
+	{"metadata", "TEXT NOT NULL DEFAULT ''"},
+	{"merge_result", "TEXT NOT NULL DEFAULT ''"},
+	{"manifest", "TEXT NOT NULL DEFAULT '[]'"},
+}
+
+// sqliteTaskColumns 查询任务时的列顺序，需与 scanSQLiteTask 保持一致
+const sqliteTaskColumns = `file_id, filename, relative_path, total_chunks, file_size, file_md5, status,
+	created_at, updated_at, chunks, uploaded_chunks, retry_count, task_type, parent_task_id,
+	folder_name, sub_tasks, is_sub_task, mime_type, estimated_completion_at, tags,
+	failure_reason, merged_path, current_bytes, max_bytes, nested_sub_folders, storage_url, depends_on, merge_time, hash_algorithm, tenant_id, cloned_from,
+	version_number, previous_versions, pending_cleanup, progress_webhook_every_n, metadata, merge_result, manifest`
+
+// SQLiteBackend 基于SQLite的持久化后端（纯Go实现，无需CGO）
+type SQLiteBackend struct {
+	db *sql.DB
+}
+
+// NewSQLiteBackend 打开SQLite数据库并自动建表
+func NewSQLiteBackend(dbPath string) (*SQLiteBackend, error) {
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("打开SQLite数据库失败: %v", err)
+	}
+
+	// SQLite同一时间只允许一个写入者，使用单连接避免锁冲突
+	db.SetMaxOpenConns(1)
+
+	for _, pragma := range []string{"PRAGMA journal_mode=WAL", "PRAGMA busy_timeout=5000"} {
+		if _, err := db.Exec(pragma); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("设置SQLite参数失败: %v", err)
+		}
+	}
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("初始化SQLite表结构失败: %v", err)
+	}
+	if err := migrateSQLiteColumns(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteBackend{db: db}, nil
+}
+
+// SaveTask 插入或更新任务
+func (sb *SQLiteBackend) SaveTask(task *UploadTask) error {
+	chunks, err := json.Marshal(task.Chunks)
+	if err != nil {
+		return err
+	}
+	subTasks, err := json.Marshal(task.SubTasks)
+	if err != nil {
+		return err
+	}
+	tags, err := json.Marshal(task.Tags)
+	if err != nil {
+		return err
+	}
+	nestedSubFolders, err := json.Marshal(task.NestedSubFolders)
+	if err != nil {
+		return err
+	}
+	dependsOn, err := json.Marshal(task.DependsOn)
+	if err != nil {
+		return err
+	}
+	previousVersions, err := json.Marshal(task.PreviousVersions)
+	if err != nil {
+		return err
+	}
+	manifest, err := json.Marshal(task.Manifest)
+	if err != nil {
+		return err
+	}
+	mergeResult := ""
+	if task.MergeResult != nil {
+		data, err := json.Marshal(task.MergeResult)
+		if err != nil {
+			return err
+		}
+		mergeResult = string(data)
+	}
+
+	_, err = sb.db.Exec(`INSERT OR REPLACE INTO tasks (`+sqliteTaskColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		task.FileID, task.FileName, task.RelativePath, task.TotalChunks, task.FileSize, task.FileMD5, task.Status,
+		task.CreatedAt.Format(time.RFC3339Nano), task.UpdatedAt.Format(time.RFC3339Nano), string(chunks),
+		task.UploadedChunks, task.RetryCount, task.TaskType, task.ParentTaskID,
+		task.FolderName, string(subTasks), task.IsSubTask, task.MIMEType, formatSQLiteTime(task.EstimatedCompletionAt),
+		string(tags), task.FailureReason, task.MergedPath, task.CurrentBytes, task.MaxBytes, string(nestedSubFolders), task.StorageURL,
+		string(dependsOn), int64(task.MergeTime), task.HashAlgorithm, task.TenantID, task.ClonedFrom,
+		task.VersionNumber, string(previousVersions), task.PendingCleanup, task.ProgressWebhookEveryN, string(task.Metadata), mergeResult, string(manifest))
+	if err != nil {
+		return fmt.Errorf("写入SQLite失败: %v", err)
+	}
+	return nil
+}
+
+// GetTask 读取任务
+func (sb *SQLiteBackend) GetTask(fileID string) (*UploadTask, bool) {
+	row := sb.db.QueryRow(`SELECT `+sqliteTaskColumns+` FROM tasks WHERE file_id = ?`, fileID)
+
+	task, err := scanSQLiteTask(row)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			Logger.Error("读取SQLite任务失败", "file_id", fileID, "error", err)
+		}
+		return nil, false
+	}
+	return task, true
+}
+
+// DeleteTask 删除任务
+func (sb *SQLiteBackend) DeleteTask(fileID string) error {
+	if _, err := sb.db.Exec(`DELETE FROM tasks WHERE file_id = ?`, fileID); err != nil {
+		return fmt.Errorf("删除SQLite任务失败: %v", err)
+	}
+	return nil
+}
+
+// GetAllTasks 读取所有任务
+func (sb *SQLiteBackend) GetAllTasks() map[string]*UploadTask {
+	tasks := make(map[string]*UploadTask)
+
+	rows, err := sb.db.Query(`SELECT ` + sqliteTaskColumns + ` FROM tasks`)
+	if err != nil {
+		Logger.Error("查询SQLite任务失败", "error", err)
+		return tasks
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		task, err := scanSQLiteTask(rows)
+		if err != nil {
+			Logger.Error("解析SQLite任务失败", "error", err)
+			continue
+		}
+		tasks[task.FileID] = task
+	}
+	if err := rows.Err(); err != nil {
+		Logger.Error("遍历SQLite任务失败", "error", err)
+	}
+
+	return tasks
+}
+
+// Close 关闭数据库连接
+func (sb *SQLiteBackend) Close() error {
+	return sb.db.Close()
+}
+
+// ExportToFile 将所有任务导出为每个任务一个JSON文件的格式，用于灾难恢复
+func (sb *SQLiteBackend) ExportToFile(outputDir string) error {
+	if err := EnsureDirectory(outputDir); err != nil {
+		return fmt.Errorf("创建导出目录失败: %v", err)
+	}
+
+	for fileID, task := range sb.GetAllTasks() {
+		if err := writeTaskFile(outputDir, task); err != nil {
+			return fmt.Errorf("导出任务失败 [%s]: %v", fileID, err)
+		}
+	}
+	return nil
+}
+
+// sqliteScanner 兼容 *sql.Row 和 *sql.Rows
+type sqliteScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanSQLiteTask 将一行记录解析为任务
+func scanSQLiteTask(scanner sqliteScanner) (*UploadTask, error) {
+	var (
+		task                 UploadTask
+		createdAt, updatedAt string
+		chunks, subTasks     string
+		estimatedCompletion  string
+		tags                 string
+		nestedSubFolders     string
+		dependsOn            string
+		previousVersions     string
+		metadata             string
+		mergeResult          string
+		manifest             string
+	)
+
+	err := scanner.Scan(&task.FileID, &task.FileName, &task.RelativePath, &task.TotalChunks, &task.FileSize,
+		&task.FileMD5, &task.Status, &createdAt, &updatedAt, &chunks, &task.UploadedChunks, &task.RetryCount,
+		&task.TaskType, &task.ParentTaskID, &task.FolderName, &subTasks, &task.IsSubTask, &task.MIMEType,
+		&estimatedCompletion, &tags, &task.FailureReason, &task.MergedPath, &task.CurrentBytes, &task.MaxBytes,
+		&nestedSubFolders, &task.StorageURL, &dependsOn, &task.MergeTime, &task.HashAlgorithm, &task.TenantID, &task.ClonedFrom,
+		&task.VersionNumber, &previousVersions, &task.PendingCleanup, &task.ProgressWebhookEveryN, &metadata, &mergeResult, &manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	task.EstimatedCompletionAt = parseSQLiteTime(estimatedCompletion)
+
+	task.CreatedAt, _ = time.Parse(time.RFC3339Nano, createdAt)
+	task.UpdatedAt, _ = time.Parse(time.RFC3339Nano, updatedAt)
+	if err := json.Unmarshal([]byte(chunks), &task.Chunks); err != nil {
+		return nil, fmt.Errorf("解析分片信息失败: %v", err)
+	}
+	if err := json.Unmarshal([]byte(subTasks), &task.SubTasks); err != nil {
+		return nil, fmt.Errorf("解析子任务列表失败: %v", err)
+	}
+	if err := json.Unmarshal([]byte(tags), &task.Tags); err != nil {
+		return nil, fmt.Errorf("解析任务标签失败: %v", err)
+	}
+	if err := json.Unmarshal([]byte(nestedSubFolders), &task.NestedSubFolders); err != nil {
+		return nil, fmt.Errorf("解析嵌套文件夹列表失败: %v", err)
+	}
+	if err := json.Unmarshal([]byte(dependsOn), &task.DependsOn); err != nil {
+		return nil, fmt.Errorf("解析任务依赖失败: %v", err)
+	}
+	if err := json.Unmarshal([]byte(previousVersions), &task.PreviousVersions); err != nil {
+		return nil, fmt.Errorf("解析历史版本列表失败: %v", err)
+	}
+	if metadata != "" {
+		task.Metadata = json.RawMessage(metadata)
+	}
+	if err := json.Unmarshal([]byte(manifest), &task.Manifest); err != nil {
+		return nil, fmt.Errorf("解析上传清单失败: %v", err)
+	}
+	if mergeResult != "" {
+		if err := json.Unmarshal([]byte(mergeResult), &task.MergeResult); err != nil {
+			return nil, fmt.Errorf("解析合并结果失败: %v", err)
+		}
+	}
+
+	normalizeTask(&task)
+	return &task, nil
+}
+
+// migrateSQLiteColumns 为旧数据库补齐新增的列
+func migrateSQLiteColumns(db *sql.DB) error {
+	rows, err := db.Query(`PRAGMA table_info(tasks)`)
+	if err != nil {
+		return fmt.Errorf("读取SQLite表结构失败: %v", err)
+	}
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, colType   string
+			defaultValue    sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			rows.Close()
+			return fmt.Errorf("读取SQLite表结构失败: %v", err)
+		}
+		existing[name] = true
+	}
+	rows.Close()
+
+	for _, column := range sqliteAddedColumns {
+		if existing[column.name] {
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE tasks ADD COLUMN %s %s", column.name, column.definition)); err != nil {
+			return fmt.Errorf("添加SQLite列 %s 失败: %v", column.name, err)
+		}
+	}
+	return nil
+}
+
+// formatSQLiteTime 格式化可选时间，为空时存储空字符串
+func formatSQLiteTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// parseSQLiteTime 解析可选时间，空字符串或格式错误时返回nil
+func parseSQLiteTime(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// dashboardLargestFiles 仪表盘返回的最大文件数量
+const dashboardLargestFiles = 10
+
+// DashboardStats 管理仪表盘的汇总统计，日期范围均按UTC计算
+type DashboardStats struct {
+	GeneratedAt    time.Time      `json:"generated_at"`
+	TotalTasks     int            `json:"total_tasks"`
+	TaskCounts     map[string]int `json:"task_counts"` // 按状态统计的任务数
+	BytesToday     int64          `json:"bytes_today"`
+	BytesThisWeek  int64          `json:"bytes_this_week"` // 本周从周一开始
+	BytesAllTime   int64          `json:"bytes_all_time"`
+	LargestFiles   []LargestFile  `json:"largest_files"`
+	MergedFiles    int            `json:"merged_files"` // 记录了合并耗时的文件数
+	AverageMergeMs float64        `json:"average_merge_ms"`
+	GoroutineCount int            `json:"goroutine_count"`
+}
+
+// LargestFile 已完成的大文件
+type LargestFile struct {
+	FileID       string    `json:"file_id"`
+	FileName     string    `json:"filename"`
+	RelativePath string    `json:"relative_path,omitempty"`
+	FileSize     int64     `json:"file_size"`
+	CompletedAt  time.Time `json:"completed_at"`
+}
+
+// StatsCollector 按需重新计算仪表盘统计，结果在内存中缓存 ttl 时间
+type StatsCollector struct {
+	ttl time.Duration
+
+	mutex   sync.Mutex
+	cached  *DashboardStats
+	expires time.Time
+}
+
+// Stats 全局统计收集器
+var Stats = NewStatsCollector(30 * time.Second)
+
+// NewStatsCollector 创建统计收集器
+func NewStatsCollector(ttl time.Duration) *StatsCollector {
+	return &StatsCollector{ttl: ttl}
+}
+
+// Dashboard 返回仪表盘统计，缓存过期时重新计算；协程数每次实时读取
+func (sc *StatsCollector) Dashboard() DashboardStats {
+	sc.mutex.Lock()
+	now := time.Now().UTC()
+	if sc.cached == nil || !now.Before(sc.expires) {
+		sc.cached = buildDashboardStats(Storage.GetAllTasks(), now)
+		sc.expires = now.Add(sc.ttl)
+	}
+	stats := *sc.cached
+	sc.mutex.Unlock()
+
+	stats.GoroutineCount = runtime.NumGoroutine()
+	return stats
+}
+
+// buildDashboardStats 汇总所有任务；已完成任务的最后更新时间即完成时间，字节数只统计单文件任务避免与文件夹重复计算
+func buildDashboardStats(tasks map[string]*UploadTask, now time.Time) *DashboardStats {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	// time.Weekday 以周日为0，换算为距本周一的天数
+	weekStart := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+
+	stats := &DashboardStats{
+		GeneratedAt:  now,
+		TotalTasks:   len(tasks),
+		TaskCounts:   make(map[string]int),
+		LargestFiles: make([]LargestFile, 0, dashboardLargestFiles),
+	}
+
+	var totalMergeTime time.Duration
+	completed := make([]*UploadTask, 0)
+	for _, task := range tasks {
+		stats.TaskCounts[task.Status]++
+		if task.TaskType == "folder" || task.Status != "completed" {
+			continue
+		}
+		completed = append(completed, task)
+
+		completedAt := task.UpdatedAt.UTC()
+		stats.BytesAllTime += task.FileSize
+		if !completedAt.Before(weekStart) {
+			stats.BytesThisWeek += task.FileSize
+		}
+		if !completedAt.Before(today) {
+			stats.BytesToday += task.FileSize
+		}
+		if task.MergeTime > 0 {
+			stats.MergedFiles++
+			totalMergeTime += task.MergeTime
+		}
+	}
+
+	if stats.MergedFiles > 0 {
+		stats.AverageMergeMs = float64(totalMergeTime) / float64(time.Millisecond) / float64(stats.MergedFiles)
+	}
+
+	sort.Slice(completed, func(i, j int) bool {
+		if completed[i].FileSize != completed[j].FileSize {
+			return completed[i].FileSize > completed[j].FileSize
+		}
+		return completed[i].FileID < completed[j].FileID
+	})
+	if len(completed) > dashboardLargestFiles {
+		completed = completed[:dashboardLargestFiles]
+	}
+	for _, task := range completed {
+		stats.LargestFiles = append(stats.LargestFiles, LargestFile{
+			FileID:       task.FileID,
+			FileName:     task.FileName,
+			RelativePath: task.RelativePath,
+			FileSize:     task.FileSize,
+			CompletedAt:  task.UpdatedAt.UTC(),
+		})
+	}
+	return stats
+}
@@ -4,9 +4,12 @@ import (
 	"crypto/md5"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -39,26 +42,106 @@ func sanitizeFileID(fileID string) string {
 	return SanitizeFileID(fileID)
 }
 
+// ErrInvalidTransition 非法的任务状态转换
+var ErrInvalidTransition = errors.New("非法的任务状态转换")
+
+// transitions 任务状态机，记录每个状态允许转换到的后继状态
+var transitions = map[string][]string{
+	"pending":        {"uploading", "paused", "failed"},
+	"uploading":      {"paused", "completed", "failed", "partial_failed"},
+	"paused":         {"uploading", "failed"},
+	"completed":      {"failed", "corrupted"},    // 分片已全部上传但合并失败，或后台校验发现文件损坏
+	"failed":         {"uploading", "completed"}, // 恢复上传或重新合并成功
+	"partial_failed": {"uploading", "completed"},
+	"corrupted":      {"completed", "failed"},    // 重新合并
+}
+
+// CanTransition 检查状态转换是否合法，状态不变视为合法
+func CanTransition(from, to string) bool {
+	if from == to {
+		return true
+	}
+	return containsString(transitions[from], to)
+}
+
+// FailureReasonInactivityTimeout 任务长时间未收到分片导致失败
+const FailureReasonInactivityTimeout = "inactivity_timeout"
+
+// FailureReasonClientDisconnected 合并过程中客户端断开连接导致合并中止
+const FailureReasonClientDisconnected = "client_disconnected"
+
 // UploadTask 上传任务结构 - 支持文件夹和单文件任务
 type UploadTask struct {
-	FileID       string            `json:"file_id"`
-	FileName     string            `json:"filename"`
-	RelativePath string            `json:"relative_path"`
-	TotalChunks  int               `json:"total_chunks"`
-	FileSize     int64             `json:"file_size"`
-	FileMD5      string            `json:"file_md5"`
-	Status       string            `json:"status"` // uploading, completed, failed, paused
-	CreatedAt    time.Time         `json:"created_at"`
-	UpdatedAt    time.Time         `json:"updated_at"`
-	Chunks       map[int]ChunkInfo `json:"chunks"`
-	RetryCount   int               `json:"retry_count"`
-	
+	FileID         string            `json:"file_id"`
+	FileName       string            `json:"filename"`
+	RelativePath   string            `json:"relative_path"`
+	TotalChunks    int               `json:"total_chunks"`
+	FileSize       int64             `json:"file_size"`
+	FileMD5        string            `json:"file_md5"`
+	Status         string            `json:"status"`                   // uploading, completed, failed, paused
+	FailureReason  string            `json:"failure_reason,omitempty"` // 失败原因，如 inactivity_timeout
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+	Chunks         map[int]ChunkInfo `json:"chunks"`
+	UploadedChunks []byte            `json:"uploaded_chunks_bitmap"` // 已上传分片位图，每个分片占一位
+	RetryCount     int               `json:"retry_count"`
+
 	// 新增字段 - 支持文件夹任务
-	TaskType     string            `json:"task_type"`      // "file" 或 "folder"
-	ParentTaskID string            `json:"parent_task_id"` // 父任务ID（用于子文件）
-	FolderName   string            `json:"folder_name"`    // 文件夹名称
-	SubTasks     []string          `json:"sub_tasks"`      // 子任务ID列表（文件夹任务使用）
-	IsSubTask    bool              `json:"is_sub_task"`    // 是否为子任务
+	TaskType     string   `json:"task_type"`      // "file" 或 "folder"
+	ParentTaskID string   `json:"parent_task_id"` // 父任务ID（用于子文件）
+	FolderName   string   `json:"folder_name"`    // 文件夹名称
+	SubTasks     []string `json:"sub_tasks"`      // 子任务ID列表（文件夹任务使用）
+	IsSubTask    bool     `json:"is_sub_task"`    // 是否为子任务
+	MIMEType     string   `json:"mime_type"`      // 首个分片探测到的MIME类型
+
+	// 会话心跳
+	EstimatedCompletionAt *time.Time `json:"estimated_completion_at,omitempty"` // 客户端上报的预计完成时间
+
+	// 任务标签
+	Tags map[string]string `json:"tags,omitempty"` // 用于分组和筛选的标签
+
+	// 自定义元数据
+	Metadata json.RawMessage `json:"metadata,omitempty" swaggertype:"object"` // 应用自定义的任意JSON，如业务记录ID
+
+	// 合并结果
+	MergedPath  string        `json:"merged_path,omitempty"`                      // 合并后文件相对于合并目录的路径
+	StorageURL  string        `json:"storage_url,omitempty"`                      // 合并文件上传到对象存储后的地址，如 s3://bucket/key
+	MergeTime   time.Duration `json:"merge_time,omitempty" swaggertype:"integer"` // 合并耗时（纳秒）
+	MergeResult *MergeResult  `json:"merge_result,omitempty"`                     // 最近一次成功合并的结果，重复的合并请求直接返回该结果
+
+	// 文件校验算法
+	HashAlgorithm string `json:"hash_algorithm,omitempty"` // 计算 file_md5 使用的算法，为空表示MD5
+
+	// 存储配额
+	CurrentBytes int64 `json:"current_bytes"`       // 已上传分片占用的字节数
+	MaxBytes     int64 `json:"max_bytes,omitempty"` // 会话配额（字节），0表示使用全局配额
+
+	// 嵌套文件夹
+	NestedSubFolders []string `json:"nested_sub_folders,omitempty"` // 子文件夹任务ID列表，子文件夹的 ParentTaskID 指向当前文件夹
+
+	// 任务依赖
+	DependsOn []string `json:"depends_on,omitempty"` // 开始上传前必须已完成的任务ID列表
+
+	// 多租户
+	TenantID string `json:"tenant_id,omitempty"` // 所属租户，合并文件存放在合并目录下的同名子目录
+
+	// 任务克隆
+	ClonedFrom string `json:"cloned_from,omitempty"` // 克隆来源任务的ID
+
+	// 文件版本
+	VersionNumber    int      `json:"version_number,omitempty"`    // 合并文件的版本号，启用版本管理时设置
+	PreviousVersions []string `json:"previous_versions,omitempty"` // 合并时该路径已有的历史版本文件
+
+	// 上传清单
+	Manifest []ManifestEntry `json:"manifest,omitempty"` // 服务端保存的分片校验清单，存在时上传的分片必须与之一致
+
+	// 进度通知
+	ProgressWebhookEveryN int `json:"progress_webhook_every_n,omitempty"` // 每完成多少个分片触发一次 task.progress Webhook，0表示使用上级文件夹或全局配置
+
+	// 分片清理
+	PendingCleanup bool `json:"pending_cleanup,omitempty"` // 合并完成后分片目录尚未清理，启动时补做清理
+
+	persistedStatus string // 最近一次持久化时的状态，用于检测状态变化
 }
 
 // ChunkInfo 分片信息
@@ -66,9 +149,20 @@ type ChunkInfo struct {
 	Index     int       `json:"index"`
 	Size      int64     `json:"size"`
 	MD5       string    `json:"md5"`
-	Status    string    `json:"status"` // pending, uploading, completed, failed
+	Status    string    `json:"status"` // pending, uploading, completed, failed, permanently_failed
 	UploadedAt time.Time `json:"uploaded_at"`
 	RetryCount int       `json:"retry_count"`
+	CASKey     string    `json:"cas_key,omitempty"` // 内容寻址存储的对象键，未启用时为空
+}
+
+// MergeResult 成功合并的结果
+type MergeResult struct {
+	FilePath   string        `json:"file_path"`                        // 合并文件的本地路径
+	MD5        string        `json:"md5"`                              // 合并文件的校验值
+	Size       int64         `json:"size"`                             // 合并文件大小（字节）
+	MergeTime  time.Duration `json:"merge_time" swaggertype:"integer"` // 合并耗时（纳秒）
+	StorageURL string        `json:"storage_url,omitempty"`            // 上传到对象存储后的地址
+	MergedAt   time.Time     `json:"merged_at"`                        // 合并完成时间
 }
 
 // FolderTaskSummary 文件夹任务摘要信息
@@ -80,38 +174,165 @@ type FolderTaskSummary struct {
 	UploadedSize    int64   `json:"uploaded_size"`
 	CompletionRate  float64 `json:"completion_rate"`
 	Status          string  `json:"status"` // uploading, completed, failed, paused
+	NestedFolders   int     `json:"nested_folders"` // 嵌套子文件夹数量（递归统计）
+
+	// 上传速度（仅保存在内存中）
+	UploadSpeedBps float64 `json:"upload_speed_bps"`      // 最近分片的滑动窗口速度（字节/秒）
+	ETASeconds     *int64  `json:"eta_seconds,omitempty"` // 预计剩余秒数，样本不足时为空
+}
+
+// 存储驱动类型
+const (
+	StorageDriverFile   = "file"
+	StorageDriverRedis  = "redis"
+	StorageDriverSQLite = "sqlite"
+)
+
+// StorageBackend 任务持久化后端接口
+type StorageBackend interface {
+	SaveTask(task *UploadTask) error
+	GetTask(fileID string) (*UploadTask, bool)
+	DeleteTask(fileID string) error
+	GetAllTasks() map[string]*UploadTask
+	Close() error
 }
 
 // TaskStorage 任务存储管理器
 type TaskStorage struct {
 	storageDir string
 	mutex      sync.RWMutex
-	tasks      map[string]*UploadTask
+	backend    StorageBackend
 }
 
 var Storage *TaskStorage
 
 // InitStorage 初始化存储管理器
 func InitStorage() error {
-	storageDir := filepath.Join(Config.UploadDir, ".metadata")
+	// 校验分片加密密钥，无效时拒绝启动
+	if err := initEncryptionKey(); err != nil {
+		return err
+	}
+
+	// 文件和分片的校验算法
+	hasher, err := NewHasher(CurrentConfig().HashAlgorithm)
+	if err != nil {
+		return err
+	}
+	Hasher = hasher
+
+	storageDir := filepath.Join(CurrentConfig().UploadDir, ".metadata")
 	if err := EnsureDirectory(storageDir); err != nil {
 		return fmt.Errorf("创建元数据目录失败: %v", err)
 	}
 
+	backend, err := newStorageBackend(storageDir)
+	if err != nil {
+		return err
+	}
+
+	if err := initDeduplication(storageDir); err != nil {
+		return err
+	}
+
+	if err := initCAS(); err != nil {
+		return err
+	}
+
+	if err := initDLQ(); err != nil {
+		return err
+	}
+
+	if err := initTrash(); err != nil {
+		return err
+	}
+
+	if err := initArchive(); err != nil {
+		return err
+	}
+
+	if err := initAPIKeys(storageDir); err != nil {
+		return err
+	}
+
+	if err := initTaskGroups(storageDir); err != nil {
+		return err
+	}
+
+	if err := initVersions(storageDir); err != nil {
+		return err
+	}
+
+	// 任务缓存减少高频分片上传时的持久化写入
+	if CurrentConfig().CacheSize > 0 {
+		interval := time.Duration(CurrentConfig().WriteBehindIntervalMs) * time.Millisecond
+		if interval <= 0 {
+			interval = time.Second
+		}
+		backend = NewLRUTaskCache(backend, CurrentConfig().CacheSize, interval)
+	}
+
 	Storage = &TaskStorage{
 		storageDir: storageDir,
-		tasks:      make(map[string]*UploadTask),
+		backend:    backend,
+	}
+
+	// 合并完成后、清理分片前进程退出的任务，在此补做清理
+	Storage.cleanupPendingChunks()
+
+	// 任务加载完成后（损坏的任务文件已用临时文件恢复）再删除崩溃遗留的临时文件
+	if _, err := TempFileGC(CurrentConfig().UploadDir, DefaultTempFileMaxAge); err != nil {
+		Logger.Error("清理遗留的临时文件失败", "error", err)
+	}
+
+	return nil
+}
+
+// newStorageBackend 根据配置创建持久化后端
+func newStorageBackend(storageDir string) (StorageBackend, error) {
+	switch CurrentConfig().StorageDriver {
+	case "", StorageDriverFile:
+		return NewFileBackend(storageDir)
+	case StorageDriverRedis:
+		return NewRedisBackend(CurrentConfig().RedisAddr, CurrentConfig().RedisPassword, CurrentConfig().RedisDB)
+	case StorageDriverSQLite:
+		dbPath := CurrentConfig().SQLitePath
+		if dbPath == "" {
+			dbPath = filepath.Join(storageDir, "tasks.db")
+		}
+		return NewSQLiteBackend(dbPath)
+	default:
+		return nil, fmt.Errorf("不支持的存储驱动: %s", CurrentConfig().StorageDriver)
 	}
+}
 
-	// 加载已存在的任务
-	return Storage.loadTasks()
+// Close 关闭内容寻址存储和存储后端
+func (s *TaskStorage) Close() error {
+	if CAS != nil {
+		if err := CAS.Close(); err != nil {
+			Logger.Error("关闭内容寻址存储失败", "error", err)
+		}
+	}
+	return s.backend.Close()
 }
 
-// CreateFolderTask 创建文件夹任务
-func (s *TaskStorage) CreateFolderTask(folderName string, files []FileInfo) (*UploadTask, error) {
+// CreateFolderTask 创建文件夹任务，parentFolderTaskID 非空时作为该文件夹任务的子文件夹并沿用其租户
+func (s *TaskStorage) CreateFolderTask(folderName string, files []FileInfo, tags map[string]string, parentFolderTaskID, tenantID string) (*UploadTask, error) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
+	var parentFolder *UploadTask
+	if parentFolderTaskID != "" {
+		parent, exists := s.backend.GetTask(parentFolderTaskID)
+		if !exists || parent.TaskType != "folder" || !parent.VisibleToTenant(tenantID) {
+			return nil, fmt.Errorf("父文件夹任务不存在: %s", parentFolderTaskID)
+		}
+		if parent.Status == "completed" {
+			return nil, fmt.Errorf("父文件夹任务已完成: %s", parentFolderTaskID)
+		}
+		parentFolder = parent
+		tenantID = parent.TenantID
+	}
+
 	// 创建文件夹任务ID
 	folderTaskID := fmt.Sprintf("folder_%s_%d", folderName, time.Now().UnixNano())
 	
@@ -133,16 +354,34 @@ func (s *TaskStorage) CreateFolderTask(folderName string, files []FileInfo) (*Up
 		FileSize:     totalSize,
 		SubTasks:     make([]string, 0, len(files)),
 		IsSubTask:    false,
+		Tags:         tags,
+		TenantID:     tenantID,
+	}
+	if parentFolder != nil {
+		folderTask.ParentTaskID = parentFolder.FileID
+		folderTask.IsSubTask = true
+	}
+
+	// 先生成所有子任务ID，依赖中的相对路径需替换为子任务ID
+	subTaskIDs := make([]string, len(files))
+	idByPath := make(map[string]string, len(files))
+	for i, file := range files {
+		relativePath := file.resolvedRelativePath()
+		subTaskIDs[i] = fmt.Sprintf("%s_%s_%d", folderTaskID, relativePath, time.Now().UnixNano())
+		if _, exists := idByPath[relativePath]; !exists {
+			idByPath[relativePath] = subTaskIDs[i]
+		}
 	}
 
 	// 创建子文件任务
-	for _, file := range files {
-		subTaskID := fmt.Sprintf("%s_%s_%d", folderTaskID, file.RelativePath, time.Now().UnixNano())
+	for i, file := range files {
+		relativePath := file.resolvedRelativePath()
+		subTaskID := subTaskIDs[i]
 		
 		subTask := &UploadTask{
 			FileID:       subTaskID,
 			FileName:     file.Name,
-			RelativePath: file.RelativePath,
+			RelativePath: relativePath,
 			TotalChunks:  file.TotalChunks,
 			FileSize:     file.Size,
 			TaskType:     "file",
@@ -152,83 +391,89 @@ func (s *TaskStorage) CreateFolderTask(folderName string, files []FileInfo) (*Up
 			Chunks:       make(map[int]ChunkInfo),
 			ParentTaskID: folderTaskID,
 			IsSubTask:    true,
+			TenantID:     tenantID,
+		}
+		for _, dependency := range file.DependsOn {
+			if dependencyID, exists := idByPath[dependency]; exists {
+				dependency = dependencyID
+			}
+			subTask.DependsOn = append(subTask.DependsOn, dependency)
 		}
 
 		// 保存子任务
-		s.tasks[subTaskID] = subTask
-		folderTask.SubTasks = append(folderTask.SubTasks, subTaskID)
-		
-		// 保存子任务到磁盘
-		if err := s.saveTaskFile(subTask); err != nil {
+		if err := s.backend.SaveTask(subTask); err != nil {
 			return nil, fmt.Errorf("保存子任务失败: %v", err)
 		}
+		folderTask.SubTasks = append(folderTask.SubTasks, subTaskID)
 	}
 
 	// 保存主任务
-	s.tasks[folderTaskID] = folderTask
-	if err := s.saveTaskFile(folderTask); err != nil {
+	if err := s.backend.SaveTask(folderTask); err != nil {
 		return nil, fmt.Errorf("保存文件夹任务失败: %v", err)
 	}
 
+	// 挂载到父文件夹
+	if parentFolder != nil {
+		parentFolder.NestedSubFolders = append(parentFolder.NestedSubFolders, folderTaskID)
+		parentFolder.UpdatedAt = time.Now()
+		if err := s.backend.SaveTask(parentFolder); err != nil {
+			return nil, fmt.Errorf("保存父文件夹任务失败: %v", err)
+		}
+	}
+
 	return folderTask, nil
 }
 
 // FileInfo 文件信息结构
 type FileInfo struct {
-	Name         string `json:"name"`
-	RelativePath string `json:"relative_path"`
-	Size         int64  `json:"size"`
-	TotalChunks  int    `json:"total_chunks"`
+	Name         string `json:"name" example:"a.jpg"`
+	RelativePath string `json:"relative_path" example:"2024/a.jpg"`
+	Size         int64  `json:"size" example:"10485760"`
+	TotalChunks  int    `json:"total_chunks" example:"2"`
+	SubDirectory string `json:"sub_directory,omitempty" example:"2024"` // 文件所在的子目录
+	Depth        int    `json:"depth,omitempty" example:"1"`            // 子目录层级，0表示位于文件夹根目录
+
+	DependsOn []string `json:"depends_on,omitempty" example:"2024/b.jpg"` // 依赖的文件：同一请求中其他文件的相对路径或已有任务的ID
+}
+
+// resolvedRelativePath 未提供相对路径时由子目录和文件名拼接
+func (f FileInfo) resolvedRelativePath() string {
+	if f.RelativePath != "" || f.SubDirectory == "" {
+		return f.RelativePath
+	}
+	return path.Join(f.SubDirectory, f.Name)
 }
 
 // GetFolderTaskSummary 获取文件夹任务摘要
 func (s *TaskStorage) GetFolderTaskSummary(folderTaskID string) (*FolderTaskSummary, error) {
+	// 只在收集子任务ID时持有读锁，子任务较多时由工作协程并行统计
 	s.mutex.RLock()
-	defer s.mutex.RUnlock()
-
-	folderTask, exists := s.tasks[folderTaskID]
+	folderTask, exists := s.backend.GetTask(folderTaskID)
 	if !exists || folderTask.TaskType != "folder" {
+		s.mutex.RUnlock()
 		return nil, fmt.Errorf("文件夹任务不存在")
 	}
 
-	summary := &FolderTaskSummary{
-		TotalFiles: len(folderTask.SubTasks),
-		TotalSize:  folderTask.FileSize,
-	}
+	// 递归统计当前文件夹及所有嵌套文件夹
+	summary := &FolderTaskSummary{}
+	subTaskIDs := s.collectFolderSubTasksInternal(folderTask, summary, nil, make(map[string]bool))
+	folderStatus := folderTask.Status
+	s.mutex.RUnlock()
 
-	// 统计子任务状态
-	for _, subTaskID := range folderTask.SubTasks {
-		subTask, exists := s.tasks[subTaskID]
-		if !exists {
-			continue
-		}
-
-		switch subTask.Status {
-		case "completed":
-			summary.CompletedFiles++
-			summary.UploadedSize += subTask.FileSize
-		case "failed":
-			summary.FailedFiles++
-		default:
-			// 计算部分上传的大小
-			uploadedChunks := s.getUploadedChunksInternal(subTaskID)
-			if len(uploadedChunks) > 0 && subTask.TotalChunks > 0 {
-				chunkSize := subTask.FileSize / int64(subTask.TotalChunks)
-				summary.UploadedSize += int64(len(uploadedChunks)) * chunkSize
-			}
-		}
-	}
+	s.aggregateSubTasks(summary, subTaskIDs)
 
 	// 计算完成率
 	if summary.TotalSize > 0 {
 		summary.CompletionRate = float64(summary.UploadedSize) / float64(summary.TotalSize) * 100
 	}
+	summary.UploadSpeedBps, summary.ETASeconds = EstimateUpload(folderTaskID, summary.TotalSize-summary.UploadedSize)
 
 	// 确定文件夹任务状态
 	if summary.CompletedFiles == summary.TotalFiles {
 		summary.Status = "completed"
-		folderTask.Status = "completed"
-		s.saveTaskFile(folderTask)
+		if folderStatus != "completed" {
+			s.completeFolderTask(folderTaskID)
+		}
 	} else if summary.FailedFiles > 0 {
 		// 如果有失败的文件，但不是所有文件都完成或失败，保持上传状态允许重试
 		if summary.CompletedFiles+summary.FailedFiles == summary.TotalFiles {
@@ -245,19 +490,34 @@ func (s *TaskStorage) GetFolderTaskSummary(folderTaskID string) (*FolderTaskSumm
 	return summary, nil
 }
 
+// completeFolderTask 所有子任务完成后将文件夹任务标记为完成
+func (s *TaskStorage) completeFolderTask(folderTaskID string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	folderTask, exists := s.backend.GetTask(folderTaskID)
+	if !exists || folderTask.Status == "completed" {
+		return
+	}
+	if transitionTaskInternal(folderTask, "completed") == nil {
+		dispatchStatusWebhook(folderTask)
+		s.backend.SaveTask(folderTask)
+	}
+}
+
 // GetSubTasks 获取文件夹的所有子任务
 func (s *TaskStorage) GetSubTasks(folderTaskID string) ([]*UploadTask, error) {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
-	folderTask, exists := s.tasks[folderTaskID]
+	folderTask, exists := s.backend.GetTask(folderTaskID)
 	if !exists || folderTask.TaskType != "folder" {
 		return nil, fmt.Errorf("文件夹任务不存在")
 	}
 
 	subTasks := make([]*UploadTask, 0, len(folderTask.SubTasks))
 	for _, subTaskID := range folderTask.SubTasks {
-		if subTask, exists := s.tasks[subTaskID]; exists {
+		if subTask, exists := s.backend.GetTask(subTaskID); exists {
 			subTasks = append(subTasks, subTask)
 		}
 	}
@@ -271,9 +531,47 @@ func (s *TaskStorage) SaveTask(task *UploadTask) error {
 	defer s.mutex.Unlock()
 
 	task.UpdatedAt = time.Now()
-	s.tasks[task.FileID] = task
 
-	return s.saveTaskFile(task)
+	dispatchStatusWebhook(task)
+	if err := s.backend.SaveTask(task); err != nil {
+		return err
+	}
+
+	Events.Publish(NewTaskEvent(task))
+	return nil
+}
+
+// TransitionTask 按状态机校验并更新任务状态，非法转换返回 ErrInvalidTransition
+func (s *TaskStorage) TransitionTask(fileID, newStatus string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	task, exists := s.backend.GetTask(fileID)
+	if !exists {
+		return fmt.Errorf("任务不存在: %s", fileID)
+	}
+
+	if err := transitionTaskInternal(task, newStatus); err != nil {
+		return err
+	}
+	task.UpdatedAt = time.Now()
+
+	dispatchStatusWebhook(task)
+	if err := s.backend.SaveTask(task); err != nil {
+		return err
+	}
+
+	Events.Publish(NewTaskEvent(task))
+	return nil
+}
+
+// transitionTaskInternal 校验并修改内存中的任务状态，不保存
+func transitionTaskInternal(task *UploadTask, newStatus string) error {
+	if !CanTransition(task.Status, newStatus) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, task.Status, newStatus)
+	}
+	task.Status = newStatus
+	return nil
 }
 
 // GetTask 获取任务信息
@@ -281,8 +579,7 @@ func (s *TaskStorage) GetTask(fileID string) (*UploadTask, bool) {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
-	task, exists := s.tasks[fileID]
-	return task, exists
+	return s.backend.GetTask(fileID)
 }
 
 // UpdateChunk 更新分片状态
@@ -290,7 +587,7 @@ func (s *TaskStorage) UpdateChunk(fileID string, chunkIndex int, chunkInfo Chunk
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	task, exists := s.tasks[fileID]
+	task, exists := s.backend.GetTask(fileID)
 	if !exists {
 		return fmt.Errorf("任务不存在: %s", fileID)
 	}
@@ -299,10 +596,39 @@ func (s *TaskStorage) UpdateChunk(fileID string, chunkIndex int, chunkInfo Chunk
 		task.Chunks = make(map[int]ChunkInfo)
 	}
 
+	// 累计已上传字节数，重传的分片先扣除旧的大小
+	previous, ok := task.Chunks[chunkIndex]
+	if ok && previous.Status == "completed" {
+		task.CurrentBytes -= previous.Size
+	}
+	applyChunkRetryBudget(previous, &chunkInfo)
+
+	// 重新链接的分片已增加新对象的引用，释放旧条目持有的引用；对象键相同时同样释放，否则引用计数只增不减
+	if chunkInfo.CASKey == "" {
+		chunkInfo.CASKey = previous.CASKey
+	} else if previous.CASKey != "" && CAS != nil {
+		if err := CAS.Release(previous.CASKey); err != nil {
+			Logger.Error("释放内容对象引用失败", "file_id", fileID, "chunk_index", chunkIndex, "error", err)
+		}
+	}
+	if chunkInfo.Status == "completed" {
+		task.CurrentBytes += chunkInfo.Size
+	}
+
 	chunkInfo.UploadedAt = time.Now()
 	task.Chunks[chunkIndex] = chunkInfo
 	task.UpdatedAt = time.Now()
 
+	if chunkInfo.Status == "completed" {
+		s.recordChunkSpeedInternal(task, chunkInfo.Size, chunkInfo.UploadedAt)
+	}
+
+	if chunkInfo.Status == "completed" {
+		task.markChunkUploaded(chunkIndex)
+	} else {
+		task.clearChunkUploaded(chunkIndex)
+	}
+
 	// 检查是否所有分片都完成
 	completedChunks := 0
 	for _, chunk := range task.Chunks {
@@ -311,8 +637,30 @@ func (s *TaskStorage) UpdateChunk(fileID string, chunkIndex int, chunkInfo Chunk
 		}
 	}
 
+	// 新完成的分片数达到间隔时推送进度，重传已完成的分片不重复推送
+	if chunkInfo.Status == "completed" && !(ok && previous.Status == "completed") {
+		s.dispatchProgressWebhookInternal(task, completedChunks)
+	}
+
+	// 收到首个分片时开始上传
+	if chunkInfo.Status == "completed" && task.Status == "pending" {
+		transitionTaskInternal(task, "uploading")
+	}
+
+	// 分片重试次数耗尽时任务失败
+	if chunkInfo.Status == ChunkStatusPermanentlyFailed {
+		if err := transitionTaskInternal(task, "failed"); err != nil {
+			Logger.Warn("分片重试次数已耗尽，但任务状态未更新", "file_id", fileID, "chunk_index", chunkIndex, "error", err)
+		} else {
+			task.FailureReason = FailureReasonRetryBudgetExhausted
+		}
+	}
+
 	if completedChunks == task.TotalChunks {
-		task.Status = "completed"
+		if err := transitionTaskInternal(task, "completed"); err != nil {
+			Logger.Warn("分片已全部上传，但任务状态未更新", "file_id", fileID, "error", err)
+		}
+		speedometers.remove(fileID)
 		
 		// 如果是子任务，检查父任务是否完成
 		if task.IsSubTask && task.ParentTaskID != "" {
@@ -320,12 +668,38 @@ func (s *TaskStorage) UpdateChunk(fileID string, chunkIndex int, chunkInfo Chunk
 		}
 	}
 
-	return s.saveTaskFile(task)
+	if chunkInfo.Status == "completed" {
+		event := NewWebhookEvent(WebhookEventChunkUploaded, task)
+		event.Data = map[string]interface{}{
+			"chunk_index":      chunkIndex,
+			"completed_chunks": completedChunks,
+		}
+		Dispatch(event)
+	}
+
+	dispatchStatusWebhook(task)
+	if err := s.backend.SaveTask(task); err != nil {
+		return err
+	}
+
+	Events.Publish(NewTaskEvent(task))
+
+	// 重试次数耗尽的任务移入死信队列
+	if task.Status == "failed" && task.FailureReason == FailureReasonRetryBudgetExhausted {
+		s.moveToDeadLetterInternal(task)
+		return nil
+	}
+
+	// 所有分片上传完成后自动加入合并队列
+	if CurrentConfig().EnableAutoMerge && AutoMergeQueue != nil && completedChunks == task.TotalChunks {
+		AutoMergeQueue.Enqueue(task)
+	}
+	return nil
 }
 
 // checkAndUpdateParentTask 检查并更新父任务状态
 func (s *TaskStorage) checkAndUpdateParentTask(parentTaskID string) {
-	parentTask, exists := s.tasks[parentTaskID]
+	parentTask, exists := s.backend.GetTask(parentTaskID)
 	if !exists || parentTask.TaskType != "folder" {
 		return
 	}
@@ -334,7 +708,7 @@ func (s *TaskStorage) checkAndUpdateParentTask(parentTaskID string) {
 	anyFailed := false
 	
 	for _, subTaskID := range parentTask.SubTasks {
-		subTask, exists := s.tasks[subTaskID]
+		subTask, exists := s.backend.GetTask(subTaskID)
 		if !exists {
 			continue
 		}
@@ -347,14 +721,39 @@ func (s *TaskStorage) checkAndUpdateParentTask(parentTaskID string) {
 		}
 	}
 
+	// 嵌套文件夹全部完成后当前文件夹才算完成
+	for _, nestedID := range parentTask.NestedSubFolders {
+		nested, exists := s.backend.GetTask(nestedID)
+		if !exists {
+			continue
+		}
+		if nested.Status != "completed" {
+			allCompleted = false
+		}
+		if nested.Status == "failed" || nested.Status == "partial_failed" {
+			anyFailed = true
+		}
+	}
+
+	var err error
 	if allCompleted {
-		parentTask.Status = "completed"
+		err = transitionTaskInternal(parentTask, "completed")
 	} else if anyFailed {
-		parentTask.Status = "uploading" // 保持上传状态，允许重试
+		err = transitionTaskInternal(parentTask, "uploading") // 保持上传状态，允许重试
+	}
+	if err != nil {
+		Logger.Warn("更新文件夹任务状态失败", "file_id", parentTaskID, "error", err)
+		return
 	}
 
 	parentTask.UpdatedAt = time.Now()
-	s.saveTaskFile(parentTask)
+	dispatchStatusWebhook(parentTask)
+	s.backend.SaveTask(parentTask)
+
+	// 嵌套文件夹完成后继续向上检查
+	if allCompleted && parentTask.ParentTaskID != "" {
+		s.checkAndUpdateParentTask(parentTask.ParentTaskID)
+	}
 }
 
 // GetUploadedChunks 获取已上传的分片列表
@@ -365,13 +764,68 @@ func (s *TaskStorage) GetUploadedChunks(fileID string) []int {
 	return s.getUploadedChunksInternal(fileID)
 }
 
+// ChunkRange 连续的分片索引区间（含两端）
+type ChunkRange struct {
+	Start int
+	End   int
+}
+
+// MarshalJSON 区间序列化为 [start,end]
+func (r ChunkRange) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf("[%d,%d]", r.Start, r.End)), nil
+}
+
+// GetUploadedChunkRanges 获取已上传分片的连续区间，分片数很多时比逐个列出索引更紧凑
+func (s *TaskStorage) GetUploadedChunkRanges(fileID string) []ChunkRange {
+	return ChunkRanges(s.GetUploadedChunks(fileID))
+}
+
+// ChunkRanges 将分片索引合并为按起始索引排序的连续区间
+func ChunkRanges(indexes []int) []ChunkRange {
+	sorted := append(make([]int, 0, len(indexes)), indexes...)
+	sort.Ints(sorted)
+
+	ranges := make([]ChunkRange, 0)
+	for _, index := range sorted {
+		if last := len(ranges) - 1; last >= 0 && index <= ranges[last].End+1 {
+			if index > ranges[last].End {
+				ranges[last].End = index
+			}
+			continue
+		}
+		ranges = append(ranges, ChunkRange{Start: index, End: index})
+	}
+	return ranges
+}
+
+// GetMissingChunks 获取任务中尚未上传的分片索引
+func (s *TaskStorage) GetMissingChunks(fileID string) ([]int, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	task, exists := s.backend.GetTask(fileID)
+	if !exists {
+		return nil, fmt.Errorf("任务不存在: %s", fileID)
+	}
+	if task.TotalChunks <= 0 {
+		return nil, fmt.Errorf("任务分片总数未知: %s", fileID)
+	}
+
+	return task.missingChunks(), nil
+}
+
 // getUploadedChunksInternal 内部方法，不加锁
 func (s *TaskStorage) getUploadedChunksInternal(fileID string) []int {
-	task, exists := s.tasks[fileID]
+	task, exists := s.backend.GetTask(fileID)
 	if !exists {
 		return []int{}
 	}
 
+	return completedChunkIndexes(task)
+}
+
+// completedChunkIndexes 获取任务中已完成的分片索引
+func completedChunkIndexes(task *UploadTask) []int {
 	var uploaded []int
 	for index, chunk := range task.Chunks {
 		if chunk.Status == "completed" {
@@ -382,92 +836,104 @@ func (s *TaskStorage) getUploadedChunksInternal(fileID string) []int {
 	return uploaded
 }
 
-// CleanupExpiredTasks 清理过期任务（超过7天的失败任务）
-func (s *TaskStorage) CleanupExpiredTasks() error {
+// CleanupExpiredTasks 按 CurrentConfig().CleanupPolicies 清理过期任务，dryRun 为true时只返回会被清理的任务
+func (s *TaskStorage) CleanupExpiredTasks(dryRun bool) ([]CleanedTask, error) {
+	return s.ApplyCleanupPolicies(CurrentConfig().CleanupPolicies, dryRun, true)
+}
+
+// ApplyCleanupPolicies 依次应用每条清理策略，reapDeadLetters 为true时同时清理过期的死信队列条目
+func (s *TaskStorage) ApplyCleanupPolicies(policies []CleanupPolicy, dryRun, reapDeadLetters bool) ([]CleanedTask, error) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	expiredTime := time.Now().AddDate(0, 0, -7) // 7天前
+	now := time.Now()
+	cleaned := make([]CleanedTask, 0)
+	matched := make(map[string]bool)
+	for index, policy := range policies {
+		if !policy.valid() {
+			Logger.Warn("跳过无效的清理策略", "policy", index)
+			continue
+		}
 
-	for fileID, task := range s.tasks {
-		if (task.Status == "failed" || task.Status == "paused") && task.UpdatedAt.Before(expiredTime) {
-			// 删除相关文件 - 使用安全的文件ID作为目录名
-			safeFileID := sanitizeFileID(fileID)
-			taskDir := filepath.Join(Config.UploadDir, safeFileID)
-			os.RemoveAll(taskDir)
+		for fileID, task := range s.backend.GetAllTasks() {
+			if matched[fileID] || !policy.Matches(task, now) {
+				continue
+			}
+			matched[fileID] = true
+			cleaned = append(cleaned, newCleanedTask(task, index))
+		}
+	}
 
-			// 删除锁文件
-			lockPath := filepath.Join(Config.UploadDir, safeFileID+".lock")
-			os.Remove(lockPath)
-			mergeLockPath := filepath.Join(Config.UploadDir, safeFileID+".merge.lock")
-			os.Remove(mergeLockPath)
+	sort.Slice(cleaned, func(i, j int) bool {
+		return cleaned[i].UpdatedAt.Before(cleaned[j].UpdatedAt)
+	})
+	if dryRun {
+		return cleaned, nil
+	}
 
-			// 删除元数据文件
-			taskFile := filepath.Join(s.storageDir, fmt.Sprintf("%s.json", safeFileID))
-			os.Remove(taskFile)
+	for _, item := range cleaned {
+		// 文件夹任务会连同子任务一起删除，子任务可能已不存在
+		task, exists := s.backend.GetTask(item.FileID)
+		if !exists {
+			continue
+		}
+		if err := s.removeTaskInternal(task); err != nil {
+			Logger.Error("清理任务失败", "file_id", item.FileID, "error", err)
+		}
+	}
 
-			delete(s.tasks, fileID)
+	// 清理超过保留期的死信队列条目
+	if reapDeadLetters && DLQ != nil && CurrentConfig().DLQRetentionDays > 0 {
+		if reaped := s.reapDeadLettersInternal(now.AddDate(0, 0, -CurrentConfig().DLQRetentionDays)); reaped > 0 {
+			Logger.Info("已清理过期的死信队列条目", "count", reaped)
 		}
 	}
 
-	return nil
+	return cleaned, nil
 }
 
-// loadTasks 加载所有已存在的任务
-func (s *TaskStorage) loadTasks() error {
-	files, err := os.ReadDir(s.storageDir)
-	if err != nil {
-		return err
-	}
-
-	for _, file := range files {
-		if filepath.Ext(file.Name()) == ".json" {
-			taskFile := filepath.Join(s.storageDir, file.Name())
-			data, err := os.ReadFile(taskFile)
-			if err != nil {
-				continue
-			}
+// FailInactiveTasks 将超过指定时间未收到分片的上传中任务标记为失败，返回标记的任务数
+func (s *TaskStorage) FailInactiveTasks(timeout time.Duration) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
 
-			var task UploadTask
-			if err := json.Unmarshal(data, &task); err != nil {
-				continue
-			}
+	deadline := time.Now().Add(-timeout)
+	failed := 0
+	for fileID, task := range s.backend.GetAllTasks() {
+		if task.Status != "uploading" || !task.UpdatedAt.Before(deadline) {
+			continue
+		}
 
-			// 向后兼容：为旧任务设置默认值
-			if task.TaskType == "" {
-				task.TaskType = "file"
-			}
-			if task.Chunks == nil {
-				task.Chunks = make(map[int]ChunkInfo)
-			}
-			if task.SubTasks == nil {
-				task.SubTasks = make([]string, 0)
-			}
+		if err := transitionTaskInternal(task, "failed"); err != nil {
+			continue
+		}
+		task.FailureReason = FailureReasonInactivityTimeout
+		task.UpdatedAt = time.Now()
 
-			s.tasks[task.FileID] = &task
+		dispatchStatusWebhook(task)
+		if err := s.backend.SaveTask(task); err != nil {
+			Logger.Error("标记超时任务失败", "file_id", fileID, "error", err)
+			continue
 		}
+		Events.Publish(NewTaskEvent(task))
+		failed++
 	}
 
-	return nil
+	return failed
 }
 
-// saveTaskFile 保存单个任务文件
-func (s *TaskStorage) saveTaskFile(task *UploadTask) error {
-	// 使用安全的文件名
-	safeFileID := sanitizeFileID(task.FileID)
-	taskFile := filepath.Join(s.storageDir, fmt.Sprintf("%s.json", safeFileID))
-	
-	// 确保目标目录存在（处理嵌套目录）
-	if err := EnsureDirectory(filepath.Dir(taskFile)); err != nil {
-		return fmt.Errorf("创建任务文件目录失败: %v", err)
-	}
-	
-	data, err := json.MarshalIndent(task, "", "  ")
-	if err != nil {
-		return err
+// isExpired 检查任务是否已过期，预计完成时间在未来的任务不会过期
+func (t *UploadTask) isExpired(cutoff time.Time) bool {
+	lastActive := t.UpdatedAt
+	if t.EstimatedCompletionAt != nil {
+		if t.EstimatedCompletionAt.After(time.Now()) {
+			return false
+		}
+		if t.EstimatedCompletionAt.After(lastActive) {
+			lastActive = *t.EstimatedCompletionAt
+		}
 	}
-
-	return os.WriteFile(taskFile, data, 0644)
+	return lastActive.Before(cutoff)
 }
 
 // GetAllTasks 获取所有任务
@@ -475,11 +941,7 @@ func (s *TaskStorage) GetAllTasks() map[string]*UploadTask {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
-	tasks := make(map[string]*UploadTask)
-	for k, v := range s.tasks {
-		tasks[k] = v
-	}
-	return tasks
+	return s.backend.GetAllTasks()
 }
 
 // GetMainTasks 获取主任务（非子任务）
@@ -488,7 +950,7 @@ func (s *TaskStorage) GetMainTasks() map[string]*UploadTask {
 	defer s.mutex.RUnlock()
 
 	mainTasks := make(map[string]*UploadTask)
-	for k, v := range s.tasks {
+	for k, v := range s.backend.GetAllTasks() {
 		if !v.IsSubTask {
 			mainTasks[k] = v
 		}
@@ -496,43 +958,142 @@ func (s *TaskStorage) GetMainTasks() map[string]*UploadTask {
 	return mainTasks
 }
 
+// PendingCleanupTasks 返回已合并完成但分片目录尚未清理的任务
+func (s *TaskStorage) PendingCleanupTasks() []*UploadTask {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var tasks []*UploadTask
+	for _, task := range s.backend.GetAllTasks() {
+		if task.PendingCleanup && task.Status == "completed" {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
+// CleanupMergedChunks 删除已合并任务的分片目录和上传锁文件，成功后清除 PendingCleanup 标记
+func (s *TaskStorage) CleanupMergedChunks(fileID string) error {
+	if err := os.RemoveAll(ChunkDir(fileID)); err != nil {
+		return fmt.Errorf("清理分片目录失败: %v", err)
+	}
+	if err := os.Remove(filepath.Join(CurrentConfig().UploadDir, SafeFileKey(fileID)+".lock")); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("清理上传锁文件失败: %v", err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	task, exists := s.backend.GetTask(fileID)
+	if !exists || !task.PendingCleanup {
+		return nil
+	}
+	task.PendingCleanup = false
+	return s.backend.SaveTask(task)
+}
+
+// cleanupPendingChunks 清理所有待清理任务的分片目录，启动时进程内没有进行中的合并，残留的合并锁文件一并删除
+func (s *TaskStorage) cleanupPendingChunks() {
+	tasks := s.PendingCleanupTasks()
+	for _, task := range tasks {
+		if err := s.CleanupMergedChunks(task.FileID); err != nil {
+			Logger.Error("清理已合并任务的分片失败", "file_id", task.FileID, "error", err)
+			continue
+		}
+		os.Remove(filepath.Join(CurrentConfig().UploadDir, SafeFileKey(task.FileID)+".merge.lock"))
+	}
+	if len(tasks) > 0 {
+		Logger.Info("已清理上次运行遗留的分片目录", "tasks", len(tasks))
+	}
+}
+
 // DeleteTask 删除任务
 func (s *TaskStorage) DeleteTask(fileID string) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	task, exists := s.tasks[fileID]
+	task, exists := s.backend.GetTask(fileID)
 	if !exists {
 		return fmt.Errorf("任务不存在")
 	}
 
-	// 如果是文件夹任务，删除所有子任务
+	return s.removeTaskInternal(task)
+}
+
+// removeTaskInternal 删除任务，文件夹任务递归删除所有子任务和嵌套文件夹，调用方需持有锁
+func (s *TaskStorage) removeTaskInternal(task *UploadTask) error {
 	if task.TaskType == "folder" {
-		for _, subTaskID := range task.SubTasks {
-			s.deleteTaskInternal(subTaskID)
-		}
+		s.deleteFolderContentsInternal(task, make(map[string]bool))
+		s.unlinkNestedFolderInternal(task)
 	}
 
-	return s.deleteTaskInternal(fileID)
+	return s.deleteTaskInternal(task.FileID)
 }
 
 // deleteTaskInternal 内部删除任务方法
 func (s *TaskStorage) deleteTaskInternal(fileID string) error {
+	// 启用回收站时分片目录和任务快照移入回收站，内容引用保留到清空回收站时再释放
+	trashed := false
+	if CurrentConfig().EnableTrash && Trash != nil {
+		if task, exists := s.backend.GetTask(fileID); exists {
+			if _, err := Trash.Add(task); err != nil {
+				return fmt.Errorf("移入回收站失败: %v", err)
+			}
+			trashed = true
+		}
+	}
+
+	if !trashed {
+		// 释放去重内容引用
+		if Dedup != nil {
+			if task, exists := s.backend.GetTask(fileID); exists && task.Status == "completed" && task.FileMD5 != "" {
+				if err := Dedup.Release(task.TenantID, task.FileMD5); err != nil {
+					Logger.Error("释放去重引用失败", "file_id", fileID, "error", err)
+				}
+			}
+		}
+
+		// 释放分片内容对象引用，引用归零时删除对象
+		if task, exists := s.backend.GetTask(fileID); exists {
+			releaseChunkObjects(task)
+		}
+	}
+
+	if AutoMergeQueue != nil {
+		AutoMergeQueue.Forget(fileID)
+	}
+	speedometers.remove(fileID)
+
+	removeTaskArtifacts(fileID)
+
+	// 删除元数据
+	return s.backend.DeleteTask(fileID)
+}
+
+// releaseChunkObjects 释放任务分片的内容对象引用，引用归零时删除对象
+func releaseChunkObjects(task *UploadTask) {
+	if CAS == nil {
+		return
+	}
+	for index, chunk := range task.Chunks {
+		if chunk.CASKey == "" {
+			continue
+		}
+		if err := CAS.Release(chunk.CASKey); err != nil {
+			Logger.Error("释放内容对象引用失败", "file_id", task.FileID, "chunk_index", index, "error", err)
+		}
+	}
+}
+
+// removeTaskArtifacts 删除任务的分片目录和锁文件
+func removeTaskArtifacts(fileID string) {
 	// 删除相关文件 - 使用安全的文件ID作为目录名
-	safeFileID := sanitizeFileID(fileID)
-	taskDir := filepath.Join(Config.UploadDir, safeFileID)
-	os.RemoveAll(taskDir)
+	safeFileID := SafeFileKey(fileID)
+	os.RemoveAll(ChunkDir(fileID))
 
 	// 删除锁文件
-	lockPath := filepath.Join(Config.UploadDir, safeFileID+".lock")
+	lockPath := filepath.Join(CurrentConfig().UploadDir, safeFileID+".lock")
 	os.Remove(lockPath)
-	mergeLockPath := filepath.Join(Config.UploadDir, safeFileID+".merge.lock")
+	mergeLockPath := filepath.Join(CurrentConfig().UploadDir, safeFileID+".merge.lock")
 	os.Remove(mergeLockPath)
-
-	// 删除元数据文件
-	taskFile := filepath.Join(s.storageDir, fmt.Sprintf("%s.json", safeFileID))
-	os.Remove(taskFile)
-
-	delete(s.tasks, fileID)
-	return nil
 } 
\ No newline at end of file
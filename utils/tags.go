@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 标签限制
+const (
+	MaxTagsPerTask = 32
+	MaxTagLength   = 128
+)
+
+// ErrInvalidTags 标签不符合限制
+var ErrInvalidTags = errors.New("标签无效")
+
+// ValidateTags 校验标签：键和值必须为非空且不超过128个字符，单个任务最多32个标签
+func ValidateTags(tags map[string]string) error {
+	if len(tags) > MaxTagsPerTask {
+		return fmt.Errorf("标签数量超出限制: %d > %d", len(tags), MaxTagsPerTask)
+	}
+	for key, value := range tags {
+		if key == "" || value == "" {
+			return fmt.Errorf("标签的键和值不能为空")
+		}
+		if len([]rune(key)) > MaxTagLength || len([]rune(value)) > MaxTagLength {
+			return fmt.Errorf("标签长度超出限制: %s (最多%d个字符)", key, MaxTagLength)
+		}
+	}
+	return nil
+}
+
+// ParseTagFilter 解析 key:value 形式的标签筛选条件，只提供key时匹配任意值
+func ParseTagFilter(filter string) (string, string, error) {
+	key, value, _ := strings.Cut(filter, ":")
+	if key == "" {
+		return "", "", fmt.Errorf("无效的标签筛选条件: %s", filter)
+	}
+	return key, value, nil
+}
+
+// HasTag 检查任务是否带有指定标签，value为空时只检查键
+func (t *UploadTask) HasTag(key, value string) bool {
+	tagValue, exists := t.Tags[key]
+	if !exists {
+		return false
+	}
+	return value == "" || tagValue == value
+}
+
+// MergeTags 合并两组标签，返回新的标签集合
+func MergeTags(current, updates map[string]string) map[string]string {
+	merged := make(map[string]string, len(current)+len(updates))
+	for key, value := range current {
+		merged[key] = value
+	}
+	for key, value := range updates {
+		merged[key] = value
+	}
+	return merged
+}
+
+// TagsEqual 比较两组标签是否相同
+func TagsEqual(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if other, exists := b[key]; !exists || other != value {
+			return false
+		}
+	}
+	return true
+}
+
+// ListByTag 列出带有指定标签的任务
+func (s *TaskStorage) ListByTag(key, value string) []*UploadTask {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var tasks []*UploadTask
+	for _, task := range s.backend.GetAllTasks() {
+		if task.HasTag(key, value) {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
+// SetTags 设置任务标签，replace为false时在锁内与已有标签合并，合并结果不符合限制时返回 ErrInvalidTags；
+// 返回保存后任务的浅拷贝，调用方在锁外读取标签不会与其他更新竞争
+func (s *TaskStorage) SetTags(fileID string, tags map[string]string, replace bool) (*UploadTask, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	task, exists := s.backend.GetTask(fileID)
+	if !exists {
+		return nil, fmt.Errorf("任务不存在: %s", fileID)
+	}
+
+	if !replace {
+		tags = MergeTags(task.Tags, tags)
+	}
+	if err := ValidateTags(tags); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTags, err)
+	}
+
+	task.Tags = tags
+	task.UpdatedAt = time.Now()
+	if err := s.backend.SaveTask(task); err != nil {
+		return nil, err
+	}
+
+	Events.Publish(NewTaskEvent(task))
+	saved := *task
+	return &saved, nil
+}
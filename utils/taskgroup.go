@@ -0,0 +1,258 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrTaskGroupNotFound 任务组不存在
+var ErrTaskGroupNotFound = errors.New("任务组不存在")
+
+// ErrFolderTaskInGroup 文件夹任务已在任务组中
+var ErrFolderTaskInGroup = errors.New("文件夹任务已在任务组中")
+
+// TaskGroup 任务组，把多个文件夹任务作为一个批次跟踪进度；解散任务组不影响其中的任务
+type TaskGroup struct {
+	GroupID       string    `json:"group_id"`
+	Name          string    `json:"name"`
+	FolderTaskIDs []string  `json:"folder_task_ids"`
+	TenantID      string    `json:"tenant_id,omitempty"` // 所属租户，只能加入同一租户的文件夹任务
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// TaskGroupSummary 任务组进度摘要，汇总所有文件夹任务（含嵌套文件夹）的统计信息
+type TaskGroupSummary struct {
+	GroupID        string   `json:"group_id"`
+	Name           string   `json:"name"`
+	FolderTasks    int      `json:"folder_tasks"`
+	TotalFiles     int      `json:"total_files"`
+	CompletedFiles int      `json:"completed_files"`
+	FailedFiles    int      `json:"failed_files"`
+	TotalSize      int64    `json:"total_size"`
+	UploadedSize   int64    `json:"uploaded_size"`
+	CompletionRate float64  `json:"completion_rate"`
+	Status         string   `json:"status"`                         // pending, uploading, completed, partial_failed
+	MissingTasks   []string `json:"missing_folder_tasks,omitempty"` // 已被删除的文件夹任务
+}
+
+// TaskGroupStore 任务组存储，持久化为元数据目录下的JSON文件
+type TaskGroupStore struct {
+	path   string
+	mutex  sync.RWMutex
+	groups map[string]*TaskGroup
+}
+
+// TaskGroups 全局任务组存储
+var TaskGroups *TaskGroupStore
+
+// NewTaskGroupStore 创建任务组存储并加载已有任务组
+func NewTaskGroupStore(path string) (*TaskGroupStore, error) {
+	store := &TaskGroupStore{
+		path:   path,
+		groups: make(map[string]*TaskGroup),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, fmt.Errorf("读取任务组文件失败: %v", err)
+	}
+
+	if err := json.Unmarshal(data, &store.groups); err != nil {
+		return nil, fmt.Errorf("解析任务组文件失败: %v", err)
+	}
+	return store, nil
+}
+
+// Create 创建任务组
+func (s *TaskGroupStore) Create(name string, folderTaskIDs []string, tenantID string) (TaskGroup, error) {
+	id, err := randomHex(8)
+	if err != nil {
+		return TaskGroup{}, err
+	}
+
+	now := time.Now()
+	group := &TaskGroup{
+		GroupID:       "group_" + id,
+		Name:          name,
+		FolderTaskIDs: append(make([]string, 0, len(folderTaskIDs)), folderTaskIDs...),
+		TenantID:      tenantID,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.groups[group.GroupID] = group
+	if err := s.persist(); err != nil {
+		delete(s.groups, group.GroupID)
+		return TaskGroup{}, err
+	}
+	return *group, nil
+}
+
+// Get 获取租户的任务组，属于其他租户的任务组视为不存在
+func (s *TaskGroupStore) Get(groupID, tenantID string) (TaskGroup, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	group, exists := s.groups[groupID]
+	if !exists || (tenantID != "" && group.TenantID != tenantID) {
+		return TaskGroup{}, ErrTaskGroupNotFound
+	}
+	return copyTaskGroup(group), nil
+}
+
+// List 按创建时间列出租户的任务组，tenantID 为空时列出全部
+func (s *TaskGroupStore) List(tenantID string) []TaskGroup {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	groups := make([]TaskGroup, 0, len(s.groups))
+	for _, group := range s.groups {
+		if tenantID == "" || group.TenantID == tenantID {
+			groups = append(groups, copyTaskGroup(group))
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].CreatedAt.Before(groups[j].CreatedAt)
+	})
+	return groups
+}
+
+// AddFolderTask 将文件夹任务加入任务组
+func (s *TaskGroupStore) AddFolderTask(groupID, folderTaskID, tenantID string) (TaskGroup, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	group, exists := s.groups[groupID]
+	if !exists || (tenantID != "" && group.TenantID != tenantID) {
+		return TaskGroup{}, ErrTaskGroupNotFound
+	}
+	for _, existingID := range group.FolderTaskIDs {
+		if existingID == folderTaskID {
+			return TaskGroup{}, ErrFolderTaskInGroup
+		}
+	}
+
+	previousUpdatedAt := group.UpdatedAt
+	group.FolderTaskIDs = append(group.FolderTaskIDs, folderTaskID)
+	group.UpdatedAt = time.Now()
+	if err := s.persist(); err != nil {
+		group.FolderTaskIDs = group.FolderTaskIDs[:len(group.FolderTaskIDs)-1]
+		group.UpdatedAt = previousUpdatedAt
+		return TaskGroup{}, err
+	}
+	return copyTaskGroup(group), nil
+}
+
+// Delete 解散任务组，其中的文件夹任务保持不变
+func (s *TaskGroupStore) Delete(groupID, tenantID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	group, exists := s.groups[groupID]
+	if !exists || (tenantID != "" && group.TenantID != tenantID) {
+		return ErrTaskGroupNotFound
+	}
+
+	delete(s.groups, groupID)
+	if err := s.persist(); err != nil {
+		s.groups[groupID] = group
+		return err
+	}
+	return nil
+}
+
+// persist 原子写入任务组文件，调用方需持有锁
+func (s *TaskGroupStore) persist() error {
+	data, err := json.MarshalIndent(s.groups, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	writer, err := NewAtomicWriter(s.path)
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(data); err != nil {
+		writer.Rollback()
+		return fmt.Errorf("写入任务组文件失败: %v", err)
+	}
+	return writer.Commit()
+}
+
+// copyTaskGroup 复制任务组，避免调用方修改存储中的文件夹任务列表
+func copyTaskGroup(group *TaskGroup) TaskGroup {
+	copied := *group
+	copied.FolderTaskIDs = append(make([]string, 0, len(group.FolderTaskIDs)), group.FolderTaskIDs...)
+	return copied
+}
+
+// initTaskGroups 初始化全局任务组存储
+func initTaskGroups(storageDir string) error {
+	store, err := NewTaskGroupStore(filepath.Join(storageDir, "task_groups.json"))
+	if err != nil {
+		return err
+	}
+	TaskGroups = store
+	return nil
+}
+
+// GetTaskGroupSummary 汇总任务组中所有文件夹任务的进度，已删除的文件夹任务记录在 MissingTasks 中
+func (s *TaskStorage) GetTaskGroupSummary(group TaskGroup) *TaskGroupSummary {
+	summary := &TaskGroupSummary{
+		GroupID:     group.GroupID,
+		Name:        group.Name,
+		FolderTasks: len(group.FolderTaskIDs),
+	}
+
+	completedFolders, failedFolders := 0, 0
+	for _, folderTaskID := range group.FolderTaskIDs {
+		folderSummary, err := s.GetFolderTaskSummary(folderTaskID)
+		if err != nil {
+			summary.MissingTasks = append(summary.MissingTasks, folderTaskID)
+			continue
+		}
+
+		summary.TotalFiles += folderSummary.TotalFiles
+		summary.CompletedFiles += folderSummary.CompletedFiles
+		summary.FailedFiles += folderSummary.FailedFiles
+		summary.TotalSize += folderSummary.TotalSize
+		summary.UploadedSize += folderSummary.UploadedSize
+		switch folderSummary.Status {
+		case "completed":
+			completedFolders++
+		case "partial_failed":
+			failedFolders++
+		}
+	}
+
+	if summary.TotalSize > 0 {
+		summary.CompletionRate = float64(summary.UploadedSize) / float64(summary.TotalSize) * 100
+	}
+
+	// 所有文件夹都处理完后，有失败的文件夹则为部分失败
+	existingFolders := summary.FolderTasks - len(summary.MissingTasks)
+	switch {
+	case existingFolders == 0:
+		summary.Status = "pending"
+	case completedFolders == existingFolders:
+		summary.Status = "completed"
+	case completedFolders+failedFolders == existingFolders:
+		summary.Status = "partial_failed"
+	default:
+		summary.Status = "uploading"
+	}
+	return summary
+}
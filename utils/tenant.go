@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"path/filepath"
+	"regexp"
+)
+
+// TenantHeader 租户ID请求头
+const TenantHeader = "X-Tenant-ID"
+
+// TenantIDKey 租户ID在gin上下文中的键
+const TenantIDKey = "tenant_id"
+
+// tenantIDPattern 租户ID同时用作合并目录下的子目录名，只允许字母、数字、下划线和连字符
+var tenantIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// ValidateTenantID 校验租户ID格式
+func ValidateTenantID(tenantID string) error {
+	if !tenantIDPattern.MatchString(tenantID) {
+		return fmt.Errorf("租户ID只能包含字母、数字、下划线和连字符，长度不超过64")
+	}
+	return nil
+}
+
+// TenantMiddleware 读取 X-Tenant-ID 请求头并写入上下文，未提供时不限定租户
+func TenantMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := c.GetHeader(TenantHeader)
+		if tenantID != "" {
+			if err := ValidateTenantID(tenantID); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "无效的租户ID: " + err.Error()})
+				c.Abort()
+				return
+			}
+			c.Set(TenantIDKey, tenantID)
+		}
+		c.Next()
+	}
+}
+
+// TenantFromContext 获取请求的租户ID，为空表示不限定租户
+func TenantFromContext(c *gin.Context) string {
+	return c.GetString(TenantIDKey)
+}
+
+// TenantMergedDir 租户合并文件的存储目录，未指定租户时为合并目录本身
+func TenantMergedDir(tenantID string) string {
+	if tenantID == "" {
+		return CurrentConfig().MergedDir
+	}
+	return filepath.Join(CurrentConfig().MergedDir, tenantID)
+}
+
+// VisibleToTenant 检查任务对租户是否可见，tenantID 为空时不做限定
+func (t *UploadTask) VisibleToTenant(tenantID string) bool {
+	return tenantID == "" || t.TenantID == tenantID
+}
+
+// GetTenantTask 获取租户的任务，属于其他租户的任务视为不存在
+func (s *TaskStorage) GetTenantTask(fileID, tenantID string) (*UploadTask, bool) {
+	task, exists := s.GetTask(fileID)
+	if !exists || !task.VisibleToTenant(tenantID) {
+		return nil, false
+	}
+	return task, true
+}
+
+// GetTenantTasks 获取租户的所有任务，tenantID 为空时返回全部任务；
+// 内存后端按租户逐个筛选，支持分区的后端可将租户ID用作分区键
+func (s *TaskStorage) GetTenantTasks(tenantID string) map[string]*UploadTask {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	tasks := s.backend.GetAllTasks()
+	if tenantID == "" {
+		return tasks
+	}
+	filtered := make(map[string]*UploadTask)
+	for fileID, task := range tasks {
+		if task.TenantID == tenantID {
+			filtered[fileID] = task
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"context"
+	"golang.org/x/time/rate"
+	"io"
+	"strconv"
+	"time"
+)
+
+// BandwidthLimitHeader 客户端指定单次上传带宽限制的请求头（字节/秒）
+const BandwidthLimitHeader = "X-Bandwidth-Limit"
+
+// ThrottledReader 基于令牌桶限制读取速率的Reader
+type ThrottledReader struct {
+	ctx     context.Context
+	reader  io.Reader
+	limiter *rate.Limiter
+}
+
+// NewThrottledReader 创建限速Reader，bytesPerSec<=0 时不限速
+func NewThrottledReader(ctx context.Context, reader io.Reader, bytesPerSec int64) io.Reader {
+	if bytesPerSec <= 0 {
+		return reader
+	}
+
+	// 桶容量为一秒的流量，单次读取不会超过桶容量
+	burst := int(bytesPerSec)
+	limiter := rate.NewLimiter(rate.Limit(bytesPerSec), burst)
+	// 令牌桶初始为满，先消耗掉避免首秒突发
+	limiter.AllowN(time.Now(), burst)
+
+	return &ThrottledReader{
+		ctx:     ctx,
+		reader:  reader,
+		limiter: limiter,
+	}
+}
+
+// Read 读取数据并等待足够的令牌
+func (t *ThrottledReader) Read(p []byte) (int, error) {
+	if burst := t.limiter.Burst(); len(p) > burst {
+		p = p[:burst]
+	}
+
+	n, err := t.reader.Read(p)
+	if n > 0 {
+		if waitErr := t.limiter.WaitN(t.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+// EffectiveBandwidthLimit 计算实际带宽限制，请求头指定的值不能超过全局限制
+func EffectiveBandwidthLimit(headerValue string) int64 {
+	global := CurrentConfig().BandwidthLimitBytesPerSec
+
+	requested, err := strconv.ParseInt(headerValue, 10, 64)
+	if err != nil || requested <= 0 {
+		return global
+	}
+	if global > 0 && requested > global {
+		return global
+	}
+	return requested
+}
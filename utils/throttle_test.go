@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+)
+
+// throttledRate 以 limit 限速读取 size 字节，返回实测速率（字节/秒）
+func throttledRate(tb testing.TB, size int, limit int64) float64 {
+	tb.Helper()
+	reader := NewThrottledReader(context.Background(), bytes.NewReader(make([]byte, size)), limit)
+
+	start := time.Now()
+	n, err := io.Copy(io.Discard, reader)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return float64(n) / time.Since(start).Seconds()
+}
+
+func TestThrottledReaderStaysWithinLimit(t *testing.T) {
+	const limit = 2 << 20
+	measured := throttledRate(t, 1<<20, limit)
+	if measured < limit*0.9 || measured > limit*1.1 {
+		t.Fatalf("实测速率 %.0f B/s 偏离限制 %d B/s 超过10%%", measured, limit)
+	}
+}
+
+func TestEffectiveBandwidthLimit(t *testing.T) {
+	saved := Config
+	defer func() { Config = saved }()
+
+	tests := []struct {
+		global int64
+		header string
+		want   int64
+	}{
+		{0, "", 0},
+		{0, "1000", 1000},
+		{5000, "", 5000},
+		{5000, "1000", 1000},
+		{5000, "9000", 5000},
+		{5000, "abc", 5000},
+		{5000, "-1", 5000},
+	}
+	for _, tt := range tests {
+		Config.BandwidthLimitBytesPerSec = tt.global
+		if got := EffectiveBandwidthLimit(tt.header); got != tt.want {
+			t.Fatalf("global=%d header=%q: got %d, want %d", tt.global, tt.header, got, tt.want)
+		}
+	}
+}
+
+// BenchmarkThrottledReader 限速读取的实测吞吐量，limit_% 为实测速率占配置限制的百分比
+func BenchmarkThrottledReader(b *testing.B) {
+	const limit = 4 << 20
+	var measured float64
+	for i := 0; i < b.N; i++ {
+		measured = throttledRate(b, 512<<10, limit)
+	}
+	b.ReportMetric(measured/(1<<20), "MB/s")
+	b.ReportMetric(measured/limit*100, "limit_%")
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"strings"
+	"time"
+)
+
+// TimeoutMiddleware 按路由设置请求上下文的超时时间，timeouts 的键为路由模式（如 /upload_chunk），值为秒数；
+// 未配置或配置为0的路由不设置超时。处理函数在超时后尚未写入响应时返回504
+func TimeoutMiddleware(timeouts map[string]int) gin.HandlerFunc {
+	// 复制配置，避免热加载解析配置文件时修改正在使用的映射
+	routes := make(map[string]time.Duration, len(timeouts))
+	for pattern, seconds := range timeouts {
+		if seconds > 0 {
+			routes[pattern] = time.Duration(seconds) * time.Second
+		}
+	}
+
+	return func(c *gin.Context) {
+		timeout := matchRouteTimeout(routes, c.FullPath())
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if ctx.Err() == context.DeadlineExceeded && !c.Writer.Written() {
+			RequestLogger(c).Warn("请求处理超时", "route", c.FullPath(), "timeout_seconds", int(timeout.Seconds()))
+			c.AbortWithStatusJSON(504, gin.H{"error": "请求处理超时"})
+		}
+	}
+}
+
+// RequestTimedOut 请求上下文是否因路由超时而结束
+func RequestTimedOut(c *gin.Context) bool {
+	return c.Request.Context().Err() == context.DeadlineExceeded
+}
+
+// matchRouteTimeout 查找路由的超时时间，完全匹配优先，否则使用最长的后缀匹配，使配置不依赖路由组前缀
+func matchRouteTimeout(routes map[string]time.Duration, fullPath string) time.Duration {
+	if fullPath == "" {
+		return 0
+	}
+	if timeout, exists := routes[fullPath]; exists {
+		return timeout
+	}
+
+	var matched string
+	for pattern := range routes {
+		if !strings.HasPrefix(pattern, "/") || !strings.HasSuffix(fullPath, pattern) {
+			continue
+		}
+		if len(pattern) > len(matched) {
+			matched = pattern
+		}
+	}
+	if matched == "" {
+		return 0
+	}
+	return routes[matched]
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"fmt"
+	"golang.org/x/crypto/acme/autocert"
+	"os"
+	"path/filepath"
+)
+
+// ValidateTLSConfig 启动时校验TLS配置，证书和私钥文件必须存在且可读
+func ValidateTLSConfig() error {
+	if !CurrentConfig().TLSEnabled {
+		return nil
+	}
+
+	if CurrentConfig().TLSAutoTLS {
+		if CurrentConfig().TLSACMEDomain == "" {
+			return fmt.Errorf("启用自动TLS时必须配置 tls_acme_domain")
+		}
+		return nil
+	}
+
+	if CurrentConfig().TLSCertFile == "" || CurrentConfig().TLSKeyFile == "" {
+		return fmt.Errorf("启用TLS时必须配置 tls_cert_file 和 tls_key_file")
+	}
+	if err := checkReadableFile(CurrentConfig().TLSCertFile); err != nil {
+		return fmt.Errorf("TLS证书文件不可用: %v", err)
+	}
+	if err := checkReadableFile(CurrentConfig().TLSKeyFile); err != nil {
+		return fmt.Errorf("TLS私钥文件不可用: %v", err)
+	}
+	return nil
+}
+
+// NewAutocertManager 创建Let's Encrypt证书管理器，证书缓存在上传目录的 .acme 下
+func NewAutocertManager() (*autocert.Manager, error) {
+	cacheDir := filepath.Join(CurrentConfig().UploadDir, ".acme")
+	if err := EnsureDirectory(cacheDir); err != nil {
+		return nil, fmt.Errorf("创建ACME缓存目录失败: %v", err)
+	}
+
+	return &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(CurrentConfig().TLSACMEDomain),
+		Cache:      autocert.DirCache(cacheDir),
+	}, nil
+}
+
+// checkReadableFile 检查文件存在、不是目录且可读
+func checkReadableFile(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s 是目录", path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
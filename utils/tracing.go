@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName 创建span使用的tracer名称
+const tracerName = "go-uploader"
+
+// tracerProvider 启用OpenTelemetry时创建，关闭服务时导出剩余的span
+var tracerProvider *sdktrace.TracerProvider
+
+// InitTracing 启用OpenTelemetry时创建OTLP gRPC导出器并设置全局 TracerProvider；
+// 未启用时保持默认的空实现，创建span没有额外开销
+func InitTracing(ctx context.Context) error {
+	if !CurrentConfig().OpenTelemetryEnabled {
+		return nil
+	}
+
+	// http:// 前缀的地址使用明文连接，https:// 使用TLS
+	exporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpointURL(CurrentConfig().OTLPEndpoint))
+	if err != nil {
+		return fmt.Errorf("创建OTLP导出器失败: %v", err)
+	}
+
+	serviceName := CurrentConfig().OTLPServiceName
+	if serviceName == "" {
+		serviceName = tracerName
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", serviceName)))
+	if err != nil {
+		return fmt.Errorf("创建追踪资源失败: %v", err)
+	}
+
+	tracerProvider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	Logger.Info("已启用OpenTelemetry追踪", "endpoint", CurrentConfig().OTLPEndpoint, "service_name", serviceName)
+	return nil
+}
+
+// ShutdownTracing 导出剩余的span并关闭 TracerProvider
+func ShutdownTracing(ctx context.Context) error {
+	if tracerProvider == nil {
+		return nil
+	}
+	return tracerProvider.Shutdown(ctx)
+}
+
+// TracingMiddleware 从 traceparent 请求头恢复上游的追踪上下文，为每个请求创建服务端span并写入请求上下文
+func TracingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		// 未匹配路由时只用请求方法命名，避免span名称随URL无限增长
+		route := c.FullPath()
+		spanName := c.Request.Method
+		if route != "" {
+			spanName += " " + route
+		}
+		ctx, span := otel.Tracer(tracerName).Start(ctx, spanName,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Request.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", c.Request.URL.Path),
+				attribute.String("client.address", c.ClientIP()),
+			),
+		)
+		defer span.End()
+		defer recordPanic(span)
+
+		if id := c.GetString(RequestIDKey); id != "" {
+			span.SetAttributes(attribute.String("request_id", id))
+		}
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= 500 {
+			span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", status))
+		}
+	}
+}
+
+// WithSpan 在子span中执行 fn，fn 返回错误或panic时记录到span，span在任何情况下都会结束
+func WithSpan(ctx context.Context, name string, attrs []attribute.KeyValue, fn func(ctx context.Context) error) error {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+	defer span.End()
+	defer recordPanic(span)
+
+	err := fn(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+// recordPanic 将panic记录到span后继续向上抛出，需在 span.End 之后 defer
+func recordPanic(span trace.Span) {
+	if r := recover(); r != nil {
+		span.RecordError(fmt.Errorf("panic: %v", r))
+		span.SetStatus(codes.Error, "panic")
+		panic(r)
+	}
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"compress/gzip"
+	"fmt"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"os"
+	"strings"
+)
+
+// 分片传输压缩格式，对应请求头 Content-Encoding
+const (
+	ContentEncodingGzip = "gzip"
+	ContentEncodingZstd = "zstd"
+)
+
+// AcceptedChunkEncodings 接受的分片压缩格式，作为 Accept-Encoding 响应头返回给客户端
+const AcceptedChunkEncodings = ContentEncodingZstd + ", " + ContentEncodingGzip
+
+// ParseContentEncoding 规范化 Content-Encoding 请求头，未压缩时返回空字符串
+func ParseContentEncoding(header string) string {
+	encoding := strings.ToLower(strings.TrimSpace(header))
+	if encoding == "identity" {
+		return ""
+	}
+	return encoding
+}
+
+// IsSupportedChunkEncoding 检查是否支持该分片压缩格式
+func IsSupportedChunkEncoding(encoding string) bool {
+	return encoding == ContentEncodingGzip || encoding == ContentEncodingZstd
+}
+
+// NewChunkDecoder 创建分片解压读取器
+func NewChunkDecoder(encoding string, r io.Reader) (io.ReadCloser, error) {
+	switch encoding {
+	case ContentEncodingGzip:
+		return gzip.NewReader(r)
+	case ContentEncodingZstd:
+		decoder, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("不支持的压缩格式: %s", encoding)
+	}
+}
+
+// DecodeChunkToTempFile 将压缩的分片解压到上传目录下的临时文件，返回路径和解压后的大小；
+// 最多读取 limit+1 字节，超出限制由调用方按分片大小校验拒绝，避免解压炸弹耗尽磁盘
+func DecodeChunkToTempFile(encoding string, src io.Reader, limit int64) (string, int64, error) {
+	decoder, err := NewChunkDecoder(encoding, src)
+	if err != nil {
+		return "", 0, err
+	}
+	defer decoder.Close()
+
+	if err := EnsureDirectory(CurrentConfig().UploadDir); err != nil {
+		return "", 0, fmt.Errorf("创建上传目录失败: %v", err)
+	}
+	file, err := os.CreateTemp(CurrentConfig().UploadDir, ".decoded-chunk-*.tmp")
+	if err != nil {
+		return "", 0, fmt.Errorf("创建临时文件失败: %v", err)
+	}
+
+	size, err := io.Copy(file, io.LimitReader(decoder, limit+1))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", 0, err
+	}
+	return file.Name(), size, nil
+}
@@ -0,0 +1,360 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrTrashEntryNotFound 回收站中不存在该条目
+var ErrTrashEntryNotFound = errors.New("回收站中不存在该条目")
+
+// trashTimeFormat 条目目录名中的时间戳格式（ISO8601基本格式，不含Windows不允许的冒号）
+const trashTimeFormat = "20060102T150405.000000000Z"
+
+// trashEntryFile 条目目录中保存任务快照的文件
+const trashEntryFile = "entry.json"
+
+// trashChunksDir 条目目录中存放原分片目录的子目录
+const trashChunksDir = "chunks"
+
+// TrashEntry 回收站条目
+type TrashEntry struct {
+	Entry     string      `json:"entry"` // 条目目录名：<时间戳>_<安全的文件ID>
+	Task      *UploadTask `json:"task"`  // 删除时的任务快照
+	Size      int64       `json:"size"`  // 分片文件占用的字节数
+	TrashedAt time.Time   `json:"trashed_at"`
+}
+
+// TrashBin 回收站，每个被删除的任务一个目录，保存任务快照和原分片目录
+type TrashBin struct {
+	dir   string
+	mutex sync.Mutex
+}
+
+// Trash 全局回收站
+var Trash *TrashBin
+
+// NewTrashBin 创建回收站目录
+func NewTrashBin(dir string) (*TrashBin, error) {
+	if err := EnsureDirectory(dir); err != nil {
+		return nil, fmt.Errorf("创建回收站目录失败: %v", err)
+	}
+	return &TrashBin{dir: dir}, nil
+}
+
+// entryDir 条目目录路径，拒绝包含路径分隔符的条目名
+func (b *TrashBin) entryDir(name string) (string, error) {
+	if name == "" || name != filepath.Base(name) || name == "." || name == ".." {
+		return "", ErrTrashEntryNotFound
+	}
+	return filepath.Join(b.dir, name), nil
+}
+
+// Add 将任务的分片目录移入回收站并保存任务快照，保存失败时分片目录移回原位置
+func (b *TrashBin) Add(task *UploadTask) (*TrashEntry, error) {
+	now := time.Now()
+	name := now.UTC().Format(trashTimeFormat) + "_" + SafeFileKey(task.FileID)
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	dir := filepath.Join(b.dir, name)
+	if err := EnsureDirectory(dir); err != nil {
+		return nil, fmt.Errorf("创建回收站条目失败: %v", err)
+	}
+
+	chunkDir := ChunkDir(task.FileID)
+	trashedChunks := filepath.Join(dir, trashChunksDir)
+	moved := false
+	if _, err := os.Stat(chunkDir); err == nil {
+		if err := os.Rename(chunkDir, trashedChunks); err != nil {
+			os.RemoveAll(dir)
+			return nil, fmt.Errorf("移动分片目录失败: %v", err)
+		}
+		moved = true
+	}
+
+	entry := &TrashEntry{
+		Entry:     name,
+		Task:      task,
+		Size:      trashDirSize(trashedChunks),
+		TrashedAt: now,
+	}
+	if err := writeTrashEntry(dir, entry); err != nil {
+		if moved {
+			os.Rename(trashedChunks, chunkDir)
+		}
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Get 读取单个条目
+func (b *TrashBin) Get(name string) (*TrashEntry, error) {
+	dir, err := b.entryDir(name)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return readTrashEntry(dir)
+}
+
+// List 列出所有条目，最近删除的排在前面
+func (b *TrashBin) List() ([]*TrashEntry, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	dirs, err := os.ReadDir(b.dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取回收站失败: %v", err)
+	}
+
+	entries := make([]*TrashEntry, 0, len(dirs))
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		entry, err := readTrashEntry(filepath.Join(b.dir, dir.Name()))
+		if err != nil {
+			Logger.Warn("跳过无法读取的回收站条目", "entry", dir.Name(), "error", err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].TrashedAt.After(entries[j].TrashedAt)
+	})
+	return entries, nil
+}
+
+// Remove 永久删除条目及其分片文件
+func (b *TrashBin) Remove(name string) error {
+	dir, err := b.entryDir(name)
+	if err != nil {
+		return err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("删除回收站条目失败: %v", err)
+	}
+	return nil
+}
+
+// restoreChunks 将条目中的分片目录移回上传目录
+func (b *TrashBin) restoreChunks(entry *TrashEntry) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	trashedChunks := filepath.Join(b.dir, entry.Entry, trashChunksDir)
+	if _, err := os.Stat(trashedChunks); os.IsNotExist(err) {
+		return nil
+	}
+
+	chunkDir := filepath.Join(CurrentConfig().UploadDir, SafeFileKey(entry.Task.FileID))
+	if _, err := os.Stat(chunkDir); err == nil {
+		return fmt.Errorf("分片目录已存在: %s", chunkDir)
+	}
+	if err := os.Rename(trashedChunks, chunkDir); err != nil {
+		return fmt.Errorf("恢复分片目录失败: %v", err)
+	}
+	return nil
+}
+
+// writeTrashEntry 原子写入条目的任务快照
+func writeTrashEntry(dir string, entry *TrashEntry) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	writer, err := NewAtomicWriter(filepath.Join(dir, trashEntryFile))
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(data); err != nil {
+		writer.Rollback()
+		return fmt.Errorf("写入回收站条目失败: %v", err)
+	}
+	return writer.Commit()
+}
+
+// readTrashEntry 读取并解析条目目录中的任务快照
+func readTrashEntry(dir string) (*TrashEntry, error) {
+	data, err := os.ReadFile(filepath.Join(dir, trashEntryFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrTrashEntryNotFound
+		}
+		return nil, err
+	}
+
+	var entry TrashEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("解析回收站条目失败: %v", err)
+	}
+	if entry.Task == nil {
+		return nil, fmt.Errorf("回收站条目缺少任务信息")
+	}
+	normalizeTask(entry.Task)
+	return &entry, nil
+}
+
+// trashDirSize 统计目录下文件的总大小，目录不存在时为0
+func trashDirSize(dir string) int64 {
+	var size int64
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// RestoreTrashEntry 将回收站中的任务移回活动存储；文件夹任务同时恢复回收站中属于它的子任务和嵌套文件夹，
+// 返回恢复的任务和一并恢复的其他任务数
+func (s *TaskStorage) RestoreTrashEntry(name string) (*UploadTask, int, error) {
+	if Trash == nil {
+		return nil, 0, ErrTrashEntryNotFound
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry, err := Trash.Get(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := s.restoreTrashEntryInternal(entry); err != nil {
+		return nil, 0, err
+	}
+
+	restored := 0
+	if entry.Task.TaskType == "folder" {
+		restored = s.restoreFolderContentsInternal(entry.Task.FileID)
+	}
+	return entry.Task, restored, nil
+}
+
+// restoreTrashEntryInternal 恢复单个条目，调用方需持有锁
+func (s *TaskStorage) restoreTrashEntryInternal(entry *TrashEntry) error {
+	task := entry.Task
+	if _, exists := s.backend.GetTask(task.FileID); exists {
+		return ErrTaskAlreadyActive
+	}
+
+	if err := Trash.restoreChunks(entry); err != nil {
+		return err
+	}
+	if err := s.backend.SaveTask(task); err != nil {
+		return err
+	}
+	if err := Trash.Remove(entry.Entry); err != nil {
+		Logger.Error("删除回收站条目失败", "entry", entry.Entry, "error", err)
+	}
+
+	// 嵌套文件夹删除时已从父文件夹中移除，父文件夹仍存在时重新挂回
+	if task.TaskType == "folder" && task.ParentTaskID != "" {
+		if parent, exists := s.backend.GetTask(task.ParentTaskID); exists && parent.TaskType == "folder" && !containsString(parent.NestedSubFolders, task.FileID) {
+			parent.NestedSubFolders = append(parent.NestedSubFolders, task.FileID)
+			if err := s.backend.SaveTask(parent); err != nil {
+				Logger.Error("重新关联父文件夹失败", "file_id", task.FileID, "parent_task_id", parent.FileID, "error", err)
+			}
+		}
+	}
+
+	Logger.Info("已从回收站恢复任务", "file_id", task.FileID, "entry", entry.Entry)
+	Events.Publish(NewTaskEvent(task))
+	return nil
+}
+
+// restoreFolderContentsInternal 递归恢复回收站中父任务为指定文件夹的条目，返回恢复的条目数，调用方需持有锁
+func (s *TaskStorage) restoreFolderContentsInternal(folderTaskID string) int {
+	entries, err := Trash.List()
+	if err != nil {
+		Logger.Error("读取回收站失败", "error", err)
+		return 0
+	}
+
+	restored := 0
+	for _, entry := range entries {
+		if entry.Task.ParentTaskID != folderTaskID {
+			continue
+		}
+		if err := s.restoreTrashEntryInternal(entry); err != nil {
+			Logger.Warn("恢复文件夹内容失败", "folder_task_id", folderTaskID, "entry", entry.Entry, "error", err)
+			continue
+		}
+		restored++
+		if entry.Task.TaskType == "folder" {
+			restored += s.restoreFolderContentsInternal(entry.Task.FileID)
+		}
+	}
+	return restored
+}
+
+// EmptyTrash 永久删除早于 cutoff 移入回收站的条目，tenantID 非空时只删除该租户的条目；
+// 移入回收站时保留的去重和分片内容对象引用在此时释放
+func (s *TaskStorage) EmptyTrash(cutoff time.Time, tenantID string) (int, error) {
+	if Trash == nil {
+		return 0, nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entries, err := Trash.List()
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, entry := range entries {
+		if !entry.TrashedAt.Before(cutoff) || !entry.Task.VisibleToTenant(tenantID) {
+			continue
+		}
+
+		task := entry.Task
+		if Dedup != nil && task.Status == "completed" && task.FileMD5 != "" {
+			if err := Dedup.Release(task.TenantID, task.FileMD5); err != nil {
+				Logger.Error("释放去重引用失败", "file_id", task.FileID, "error", err)
+			}
+		}
+		releaseChunkObjects(task)
+
+		if err := Trash.Remove(entry.Entry); err != nil {
+			Logger.Error("删除回收站条目失败", "entry", entry.Entry, "error", err)
+			continue
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// initTrash 初始化回收站
+func initTrash() error {
+	bin, err := NewTrashBin(CurrentConfig().TrashDir)
+	if err != nil {
+		return err
+	}
+	Trash = bin
+	return nil
+}
@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// WebhookEventFileCorrupted 后台校验发现合并文件的MD5与任务记录不一致
+const WebhookEventFileCorrupted = "file.corrupted"
+
+// TaskStatusCorrupted 合并文件已损坏
+const TaskStatusCorrupted = "corrupted"
+
+// BackgroundVerifier 定期重新计算合并目录中文件的MD5，与任务记录比对以发现静默损坏
+type BackgroundVerifier struct {
+	interval time.Duration
+	running  sync.Mutex // 保证同一时间只有一个校验过程
+}
+
+// NewBackgroundVerifier 创建后台校验器
+func NewBackgroundVerifier(interval time.Duration) *BackgroundVerifier {
+	return &BackgroundVerifier{interval: interval}
+}
+
+// Run 每隔 interval 执行一次校验，直到 ctx 取消
+func (v *BackgroundVerifier) Run(ctx context.Context) {
+	ticker := time.NewTicker(v.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !v.running.TryLock() {
+				Logger.Warn("上一轮文件校验尚未结束，跳过本次校验")
+				continue
+			}
+			checked, corrupted := v.verifyAll(ctx)
+			v.running.Unlock()
+			Logger.Info("后台文件校验完成", "checked", checked, "corrupted", corrupted)
+		}
+	}
+}
+
+// verifyAll 遍历合并目录，校验所有有任务记录的文件，返回校验数和损坏数
+func (v *BackgroundVerifier) verifyAll(ctx context.Context) (int, int) {
+	// 只校验已完成且记录了MD5的任务
+	tasksByPath := make(map[string]*UploadTask)
+	for _, task := range Storage.GetAllTasks() {
+		if task.Status == "completed" && task.MergedPath != "" && task.FileMD5 != "" {
+			tasksByPath[task.MergedPath] = task
+		}
+	}
+
+	checked, corrupted := 0, 0
+	err := filepath.WalkDir(CurrentConfig().MergedDir, func(path string, entry fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			Logger.Warn("读取合并目录失败", "path", path, "error", err)
+			return nil
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(CurrentConfig().MergedDir, path)
+		if err != nil {
+			return nil
+		}
+		task, exists := tasksByPath[filepath.ToSlash(rel)]
+		if !exists {
+			return nil
+		}
+
+		// 按合并时记录的算法校验，修改 hash_algorithm 后旧文件仍可正确比对
+		hasher, err := NewHasher(task.HashAlgorithm)
+		if err != nil {
+			Logger.Warn("任务记录的校验算法不受支持", "file_id", task.FileID, "hash_algorithm", task.HashAlgorithm)
+			return nil
+		}
+		actual, err := hasher.HashFile(path)
+		if err != nil {
+			Logger.Warn("计算文件校验值失败", "path", path, "error", err)
+			return nil
+		}
+		checked++
+
+		if actual == task.FileMD5 {
+			return nil
+		}
+		corrupted++
+		Logger.Error("合并文件MD5不一致，文件可能已损坏", "file_id", task.FileID, "path", path, "expected_md5", task.FileMD5, "actual_md5", actual)
+		if err := Storage.MarkTaskCorrupted(task.FileID, task.FileMD5, actual); err != nil {
+			Logger.Error("标记文件损坏失败", "file_id", task.FileID, "error", err)
+		}
+		return nil
+	})
+	if err != nil && ctx.Err() == nil {
+		Logger.Error("遍历合并目录失败", "error", err)
+	}
+	return checked, corrupted
+}
+
+// MarkTaskCorrupted 将任务标记为损坏并触发 file.corrupted 事件，任务在校验期间被重新合并时不做修改
+func (s *TaskStorage) MarkTaskCorrupted(fileID, expectedMD5, actualMD5 string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	task, exists := s.backend.GetTask(fileID)
+	if !exists || task.FileMD5 != expectedMD5 {
+		return nil
+	}
+	if err := transitionTaskInternal(task, TaskStatusCorrupted); err != nil {
+		return err
+	}
+	task.UpdatedAt = time.Now()
+
+	if err := s.backend.SaveTask(task); err != nil {
+		return err
+	}
+	Events.Publish(NewTaskEvent(task))
+
+	event := NewWebhookEvent(WebhookEventFileCorrupted, task)
+	event.Data = map[string]interface{}{
+		"merged_path":  task.MergedPath,
+		"expected_md5": expectedMD5,
+		"actual_md5":   actualMD5,
+	}
+	Dispatch(event)
+	return nil
+}
@@ -0,0 +1,251 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrVersionNotFound 文件不存在该历史版本
+var ErrVersionNotFound = errors.New("文件版本不存在")
+
+// FileVersion 合并文件被覆盖前保存的历史版本
+type FileVersion struct {
+	Version    int       `json:"version"`
+	Path       string    `json:"path"` // 版本文件相对于合并目录的路径：<原路径>.v<N>
+	Size       int64     `json:"size"`
+	ModifiedAt time.Time `json:"modified_at"` // 该版本文件的最后修改时间
+	ArchivedAt time.Time `json:"archived_at"` // 被新版本覆盖的时间
+}
+
+// versionHistory 单个合并文件的版本记录
+type versionHistory struct {
+	Current  int           `json:"current"` // 当前文件的版本号
+	Versions []FileVersion `json:"versions"`
+}
+
+// VersionRegistry 记录合并文件的历史版本，持久化为元数据目录下的JSON文件，键为文件相对于合并目录的路径
+type VersionRegistry struct {
+	path  string
+	mutex sync.Mutex
+	files map[string]*versionHistory
+}
+
+// Versions 全局版本记录
+var Versions *VersionRegistry
+
+// NewVersionRegistry 创建版本记录并加载已有记录
+func NewVersionRegistry(path string) (*VersionRegistry, error) {
+	registry := &VersionRegistry{
+		path:  path,
+		files: make(map[string]*versionHistory),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return registry, nil
+		}
+		return nil, fmt.Errorf("读取版本记录失败: %v", err)
+	}
+
+	if err := json.Unmarshal(data, &registry.files); err != nil {
+		return nil, fmt.Errorf("解析版本记录失败: %v", err)
+	}
+	return registry, nil
+}
+
+// Archive 将合并目录下已存在的文件重命名为 <路径>.v<N> 保存为历史版本，文件不存在时返回nil
+func (r *VersionRegistry) Archive(relPath string) (*FileVersion, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	fullPath := mergedFilePath(relPath)
+	info, err := os.Lstat(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("读取文件信息失败: %v", err)
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("目标路径不是普通文件: %s", relPath)
+	}
+
+	history := r.historyInternal(relPath)
+
+	// 跳过磁盘上已被占用的版本文件名（例如版本记录丢失后残留的文件）
+	number := history.Current
+	versionPath := fmt.Sprintf("%s.v%d", relPath, number)
+	for {
+		if _, err := os.Lstat(mergedFilePath(versionPath)); os.IsNotExist(err) {
+			break
+		}
+		number++
+		versionPath = fmt.Sprintf("%s.v%d", relPath, number)
+	}
+
+	if err := os.Rename(fullPath, mergedFilePath(versionPath)); err != nil {
+		return nil, fmt.Errorf("保存历史版本失败: %v", err)
+	}
+
+	version := FileVersion{
+		Version:    number,
+		Path:       versionPath,
+		Size:       info.Size(),
+		ModifiedAt: info.ModTime(),
+		ArchivedAt: time.Now(),
+	}
+	history.Versions = append(history.Versions, version)
+	history.Current = number + 1
+	r.files[relPath] = history
+
+	if err := r.persist(); err != nil {
+		Logger.Error("保存版本记录失败", "path", relPath, "error", err)
+	}
+	return &version, nil
+}
+
+// Restore 撤销 Archive：把历史版本移回原路径（覆盖未完成的新文件）并删除该版本记录
+func (r *VersionRegistry) Restore(relPath string, version *FileVersion) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := os.Rename(mergedFilePath(version.Path), mergedFilePath(relPath)); err != nil {
+		return fmt.Errorf("恢复历史版本失败: %v", err)
+	}
+
+	if history, exists := r.files[relPath]; exists {
+		history.Versions = removeFileVersion(history.Versions, version.Version)
+		history.Current = version.Version
+		if len(history.Versions) == 0 && history.Current <= 1 {
+			delete(r.files, relPath)
+		}
+	}
+	return r.persist()
+}
+
+// Current 返回文件当前的版本号，没有历史版本时为1
+func (r *VersionRegistry) Current(relPath string) int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if history, exists := r.files[relPath]; exists {
+		return history.Current
+	}
+	return 1
+}
+
+// Has 文件是否有版本记录
+func (r *VersionRegistry) Has(relPath string) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	_, exists := r.files[relPath]
+	return exists
+}
+
+// List 返回文件的历史版本，按版本号从小到大排序
+func (r *VersionRegistry) List(relPath string) []FileVersion {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	history, exists := r.files[relPath]
+	if !exists {
+		return []FileVersion{}
+	}
+
+	versions := append(make([]FileVersion, 0, len(history.Versions)), history.Versions...)
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+	return versions
+}
+
+// Paths 返回文件所有历史版本的路径，按版本号从小到大排序
+func (r *VersionRegistry) Paths(relPath string) []string {
+	versions := r.List(relPath)
+	paths := make([]string, 0, len(versions))
+	for _, version := range versions {
+		paths = append(paths, version.Path)
+	}
+	return paths
+}
+
+// Remove 删除一个历史版本及其文件
+func (r *VersionRegistry) Remove(relPath string, number int) (FileVersion, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	history, exists := r.files[relPath]
+	if !exists {
+		return FileVersion{}, ErrVersionNotFound
+	}
+
+	for _, version := range history.Versions {
+		if version.Version != number {
+			continue
+		}
+		if err := os.Remove(mergedFilePath(version.Path)); err != nil && !os.IsNotExist(err) {
+			return FileVersion{}, fmt.Errorf("删除版本文件失败: %v", err)
+		}
+		history.Versions = removeFileVersion(history.Versions, number)
+		return version, r.persist()
+	}
+	return FileVersion{}, ErrVersionNotFound
+}
+
+// historyInternal 返回文件的版本记录，不存在时新建（已存在的文件视为版本1），调用方需持有锁
+func (r *VersionRegistry) historyInternal(relPath string) *versionHistory {
+	if history, exists := r.files[relPath]; exists {
+		return history
+	}
+	return &versionHistory{Current: 1, Versions: []FileVersion{}}
+}
+
+// persist 原子写入版本记录，调用方需持有锁
+func (r *VersionRegistry) persist() error {
+	data, err := json.MarshalIndent(r.files, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	writer, err := NewAtomicWriter(r.path)
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(data); err != nil {
+		writer.Rollback()
+		return fmt.Errorf("写入版本记录失败: %v", err)
+	}
+	return writer.Commit()
+}
+
+// removeFileVersion 从列表中移除指定版本号
+func removeFileVersion(versions []FileVersion, number int) []FileVersion {
+	kept := versions[:0]
+	for _, version := range versions {
+		if version.Version != number {
+			kept = append(kept, version)
+		}
+	}
+	return kept
+}
+
+// mergedFilePath 合并目录下相对路径对应的完整路径
+func mergedFilePath(relPath string) string {
+	return filepath.Join(CurrentConfig().MergedDir, filepath.FromSlash(relPath))
+}
+
+// initVersions 初始化全局版本记录
+func initVersions(storageDir string) error {
+	registry, err := NewVersionRegistry(filepath.Join(storageDir, "versions.json"))
+	if err != nil {
+		return err
+	}
+	Versions = registry
+	return nil
+}
@@ -0,0 +1,180 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// walFileName 预写日志文件名，位于元数据目录
+const walFileName = "tasks.wal"
+
+// 预写日志操作类型
+const (
+	WALOpSave   = "save"
+	WALOpDelete = "delete"
+)
+
+// WALEntry 预写日志记录，每条记录占一行JSON
+type WALEntry struct {
+	Op        string          `json:"op"`
+	TaskID    string          `json:"task_id"`
+	Payload   json.RawMessage `json:"payload,omitempty"` // save 操作的任务JSON
+	Sequence  uint64          `json:"sequence"`
+	Timestamp time.Time       `json:"timestamp"`
+	Checksum  uint32          `json:"checksum"` // 其余字段的CRC32，用于发现写入中断的记录
+}
+
+// checksum 计算记录除 Checksum 外所有字段的CRC32
+func (e *WALEntry) checksum() uint32 {
+	h := crc32.NewIEEE()
+	fmt.Fprintf(h, "%s\x00%s\x00%d\x00%d\x00", e.Op, e.TaskID, e.Sequence, e.Timestamp.UnixNano())
+	h.Write(e.Payload)
+	return h.Sum32()
+}
+
+// checkpointer 支持检查点的存储后端
+type checkpointer interface {
+	Checkpoint() error
+}
+
+// WriteAheadLog 追加写入的任务预写日志，每条记录写入后立即同步到磁盘
+type WriteAheadLog struct {
+	path     string
+	mutex    sync.Mutex
+	file     *os.File
+	size     int64
+	sequence uint64
+}
+
+// OpenWriteAheadLog 打开或创建预写日志
+func OpenWriteAheadLog(path string) (*WriteAheadLog, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("打开预写日志失败: %v", err)
+	}
+	return &WriteAheadLog{path: path, file: file}, nil
+}
+
+// Replay 按顺序回放日志记录；遇到不完整或校验失败的记录时停止，并截断其后的内容，
+// 返回回放的记录数
+func (w *WriteAheadLog) Replay(apply func(entry WALEntry) error) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("读取预写日志失败: %v", err)
+	}
+
+	reader := bufio.NewReader(w.file)
+	var offset int64
+	replayed := 0
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF && len(line) == 0 {
+			break
+		}
+		if err != nil && err != io.EOF {
+			return replayed, fmt.Errorf("读取预写日志失败: %v", err)
+		}
+
+		// 没有换行符的最后一行是写入中途崩溃留下的
+		var entry WALEntry
+		if err == io.EOF || json.Unmarshal(line, &entry) != nil || entry.Checksum != entry.checksum() {
+			Logger.Warn("预写日志存在不完整的记录，已丢弃其后的内容", "file", w.path, "offset", offset, "replayed", replayed)
+			break
+		}
+
+		if err := apply(entry); err != nil {
+			return replayed, err
+		}
+		offset += int64(len(line))
+		w.sequence = entry.Sequence
+		replayed++
+	}
+
+	if err := w.file.Truncate(offset); err != nil {
+		return replayed, fmt.Errorf("截断预写日志失败: %v", err)
+	}
+	w.size = offset
+	return replayed, nil
+}
+
+// Append 追加一条记录并同步到磁盘
+func (w *WriteAheadLog) Append(op, taskID string, payload []byte) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	entry := WALEntry{
+		Op:        op,
+		TaskID:    taskID,
+		Payload:   payload,
+		Sequence:  w.sequence + 1,
+		Timestamp: time.Now(),
+	}
+	entry.Checksum = entry.checksum()
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	n, err := w.file.Write(data)
+	w.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("写入预写日志失败: %v", err)
+	}
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("同步预写日志失败: %v", err)
+	}
+	w.sequence = entry.Sequence
+	return nil
+}
+
+// Size 返回日志文件当前大小
+func (w *WriteAheadLog) Size() int64 {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.size
+}
+
+// Reset 清空日志，在所有任务写入快照后调用；序号继续递增
+func (w *WriteAheadLog) Reset() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if err := w.file.Truncate(0); err != nil {
+		return fmt.Errorf("清空预写日志失败: %v", err)
+	}
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("同步预写日志失败: %v", err)
+	}
+	w.size = 0
+	return nil
+}
+
+// Close 关闭日志文件
+func (w *WriteAheadLog) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.file.Close()
+}
+
+// Checkpoint 将所有任务写入快照并清空预写日志，存储后端不使用预写日志时不做任何操作
+func (s *TaskStorage) Checkpoint() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if cp, ok := s.backend.(checkpointer); ok {
+		return cp.Checkpoint()
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Webhook事件类型
+const (
+	WebhookEventTaskCompleted   = "task.completed"
+	WebhookEventTaskFailed      = "task.failed"
+	WebhookEventFolderCompleted = "folder.completed"
+	WebhookEventChunkUploaded   = "chunk.uploaded"
+	WebhookEventTaskProgress    = "task.progress"
+)
+
+// WebhookConfig Webhook配置
+type WebhookConfig struct {
+	URL     string            `json:"url"`
+	Events  []string          `json:"events"`
+	Headers map[string]string `json:"headers"`
+}
+
+// WebhookEvent Webhook事件负载
+type WebhookEvent struct {
+	Event     string                 `json:"event"`
+	Timestamp time.Time              `json:"timestamp"`
+	Task      *UploadTask            `json:"task"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+}
+
+// webhookRetryConfig Webhook投递重试配置（共3次尝试）
+var webhookRetryConfig = RetryConfig{
+	MaxRetries:    2,
+	InitialDelay:  1 * time.Second,
+	MaxDelay:      10 * time.Second,
+	BackoffFactor: 2.0,
+	JitterFactor:  0.25,
+}
+
+// webhookClient Webhook投递使用的HTTP客户端
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// NewWebhookEvent 创建事件，任务快照不包含分片明细以控制负载大小
+func NewWebhookEvent(event string, task *UploadTask) WebhookEvent {
+	snapshot := *task
+	snapshot.Chunks = nil
+
+	return WebhookEvent{
+		Event:     event,
+		Timestamp: time.Now(),
+		Task:      &snapshot,
+	}
+}
+
+// Dispatch 异步投递事件到所有订阅该事件的Webhook，失败只记录日志
+func Dispatch(event WebhookEvent) {
+	if len(CurrentConfig().Webhooks) == 0 {
+		return
+	}
+
+	var payload []byte
+	for _, hook := range CurrentConfig().Webhooks {
+		if !containsString(hook.Events, event.Event) {
+			continue
+		}
+
+		if payload == nil {
+			data, err := json.Marshal(event)
+			if err != nil {
+				Logger.Error("序列化Webhook事件失败", "event", event.Event, "error", err)
+				return
+			}
+			payload = data
+		}
+
+		go deliverWebhook(hook, event.Event, payload)
+	}
+}
+
+// deliverWebhook 带重试地投递单个Webhook
+func deliverWebhook(hook WebhookConfig, eventType string, payload []byte) {
+	err := RetryWithBackoff(context.Background(), func() error {
+		req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Webhook-Event", eventType)
+		for key, value := range hook.Headers {
+			req.Header.Set(key, value)
+		}
+
+		resp, err := webhookClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode >= 500 {
+			return fmt.Errorf("server error: 状态码 %d", resp.StatusCode)
+		}
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("Webhook返回异常状态码: %d", resp.StatusCode)
+		}
+		return nil
+	}, webhookRetryConfig)
+
+	if err != nil {
+		Logger.Error("Webhook投递失败", "event", eventType, "url", hook.URL, "error", err)
+	}
+}
+
+// dispatchStatusWebhook 任务状态发生变化时触发对应的Webhook事件
+func dispatchStatusWebhook(task *UploadTask) {
+	if task.Status == task.persistedStatus {
+		return
+	}
+	task.persistedStatus = task.Status
+
+	switch task.Status {
+	case "completed":
+		if task.TaskType == "folder" {
+			Dispatch(NewWebhookEvent(WebhookEventFolderCompleted, task))
+		} else {
+			Dispatch(NewWebhookEvent(WebhookEventTaskCompleted, task))
+		}
+	case "failed":
+		Dispatch(NewWebhookEvent(WebhookEventTaskFailed, task))
+	}
+}
+
+// progressWebhookIntervalInternal 返回任务的进度Webhook间隔（每完成多少个分片触发一次），
+// 依次使用任务自身、最近的上级文件夹和 CurrentConfig().ProgressWebhookEveryN 的设置，调用方需持有锁
+func (s *TaskStorage) progressWebhookIntervalInternal(task *UploadTask) int {
+	visited := map[string]bool{}
+	for current := task; current != nil && !visited[current.FileID]; {
+		if current.ProgressWebhookEveryN > 0 {
+			return current.ProgressWebhookEveryN
+		}
+		visited[current.FileID] = true
+		if current.ParentTaskID == "" {
+			break
+		}
+		parent, exists := s.backend.GetTask(current.ParentTaskID)
+		if !exists {
+			break
+		}
+		current = parent
+	}
+	return CurrentConfig().ProgressWebhookEveryN
+}
+
+// dispatchProgressWebhookInternal 已完成分片数达到进度Webhook间隔的整数倍时触发 task.progress 事件，调用方需持有锁
+func (s *TaskStorage) dispatchProgressWebhookInternal(task *UploadTask, completedChunks int) {
+	interval := s.progressWebhookIntervalInternal(task)
+	if interval <= 0 || completedChunks == 0 || completedChunks%interval != 0 {
+		return
+	}
+
+	completionRate := 0.0
+	if task.TotalChunks > 0 {
+		completionRate = float64(completedChunks) / float64(task.TotalChunks) * 100
+	}
+	speed, _ := EstimateUpload(task.FileID, 0)
+
+	event := NewWebhookEvent(WebhookEventTaskProgress, task)
+	event.Data = map[string]interface{}{
+		"completion_rate":  completionRate,
+		"uploaded_chunks":  completedChunks,
+		"upload_speed_bps": speed,
+	}
+	Dispatch(event)
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// writeProbeSize 写入测试文件的大小
+const writeProbeSize = 32
+
+// writeProbeResult 目录写入测试的结果
+type writeProbeResult struct {
+	err       error
+	checkedAt time.Time
+}
+
+// WriteProbe 检查目录能否实际写入文件，结果按目录缓存，避免频繁的健康检查反复写磁盘
+type WriteProbe struct {
+	mutex   sync.Mutex
+	results map[string]writeProbeResult
+}
+
+// DirWriteProbe 全局目录写入测试
+var DirWriteProbe = &WriteProbe{results: make(map[string]writeProbeResult)}
+
+// Check 在目录中写入并删除一个32字节的临时文件，ttl 内重复检查同一目录时返回缓存的结果
+func (p *WriteProbe) Check(dir string, ttl time.Duration) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if result, exists := p.results[dir]; exists && time.Since(result.checkedAt) < ttl {
+		return result.err
+	}
+
+	err := probeDirectoryWrite(dir)
+	if err != nil {
+		Logger.Warn("目录写入测试失败", "dir", dir, "error", err)
+	}
+	p.results[dir] = writeProbeResult{err: err, checkedAt: time.Now()}
+	return err
+}
+
+// probeDirectoryWrite 写入、同步并删除临时文件，任一步骤失败时返回错误
+func probeDirectoryWrite(dir string) error {
+	file, err := os.CreateTemp(dir, ".healthcheck.tmp.*")
+	if err != nil {
+		return fmt.Errorf("创建测试文件失败: %v", err)
+	}
+	path := file.Name()
+
+	_, err = file.Write(make([]byte, writeProbeSize))
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return fmt.Errorf("写入测试文件失败: %v", err)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("删除测试文件失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// writeQueueCapacityPerWorker 每个工作协程对应的排队请求数
+const writeQueueCapacityPerWorker = 16
+
+// writeRequest 写入队列中的请求，write 在工作协程中执行，结果通过 resultCh 返回给提交方
+type writeRequest struct {
+	path     string
+	write    func() error
+	resultCh chan error
+}
+
+// WriteQueue 分片写入队列，限制同时写磁盘的协程数，避免高并发上传时磁盘IO过载
+type WriteQueue struct {
+	requests chan writeRequest
+	workers  int
+	busy     int64
+	mutex    sync.RWMutex // 提交时持读锁，关闭时持写锁，保证不会向已关闭的通道发送
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// ChunkWriteQueue 全局分片写入队列（chunk_write_workers 为0时为nil，分片直接写入）
+var ChunkWriteQueue *WriteQueue
+
+// StartWriteQueue 创建写入队列并启动工作协程
+func StartWriteQueue(workers int) *WriteQueue {
+	if workers <= 0 {
+		workers = 1
+	}
+
+	q := &WriteQueue{
+		requests: make(chan writeRequest, workers*writeQueueCapacityPerWorker),
+		workers:  workers,
+	}
+
+	for i := 0; i < workers; i++ {
+		q.wg.Add(1)
+		go q.worker()
+	}
+	return q
+}
+
+// Do 提交写入并阻塞到工作协程执行完毕；队列为nil时直接写入。
+// 排队期间 ctx 取消则放弃写入，开始执行后等待写入完成，避免调用方提前释放 write 使用的资源
+func (q *WriteQueue) Do(ctx context.Context, path string, write func() error) error {
+	if q == nil {
+		return write()
+	}
+
+	req := writeRequest{
+		path:     path,
+		write:    write,
+		resultCh: make(chan error, 1),
+	}
+
+	q.mutex.RLock()
+	if q.closed {
+		q.mutex.RUnlock()
+		return fmt.Errorf("写入队列已关闭")
+	}
+	select {
+	case q.requests <- req:
+		q.mutex.RUnlock()
+	case <-ctx.Done():
+		q.mutex.RUnlock()
+		return fmt.Errorf("等待写入队列超时: %v", ctx.Err())
+	}
+	return <-req.resultCh
+}
+
+// Stats 返回排队中的请求数、正在写入的协程数和工作协程总数
+func (q *WriteQueue) Stats() (queued, busy, workers int) {
+	return len(q.requests), int(atomic.LoadInt64(&q.busy)), q.workers
+}
+
+// Shutdown 停止接收新请求并等待队列中的写入全部完成
+func (q *WriteQueue) Shutdown(ctx context.Context) error {
+	q.mutex.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.requests)
+	}
+	q.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// worker 写入工作协程
+func (q *WriteQueue) worker() {
+	defer q.wg.Done()
+
+	for req := range q.requests {
+		atomic.AddInt64(&q.busy, 1)
+		req.resultCh <- q.execute(req)
+		atomic.AddInt64(&q.busy, -1)
+	}
+}
+
+// execute 执行单个写入，写入中的panic转换为错误返回，避免工作协程退出导致提交方永久阻塞
+func (q *WriteQueue) execute(req writeRequest) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			Logger.Error("分片写入发生panic", "path", req.path, "panic", r)
+			err = fmt.Errorf("写入分片时发生错误: %v", r)
+		}
+	}()
+	return req.write()
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWriteQueueLimitsConcurrentWrites(t *testing.T) {
+	const workers = 3
+	q := StartWriteQueue(workers)
+	defer q.Shutdown(context.Background())
+
+	var active, peak int64
+	var wg sync.WaitGroup
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := q.Do(context.Background(), "chunk", func() error {
+				n := atomic.AddInt64(&active, 1)
+				for {
+					max := atomic.LoadInt64(&peak)
+					if n <= max || atomic.CompareAndSwapInt64(&peak, max, n) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				atomic.AddInt64(&active, -1)
+				return nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if peak > workers {
+		t.Fatalf("同时写入的协程数 = %d，超过 %d", peak, workers)
+	}
+}
+
+// BenchmarkChunkWrite 模拟并发上传写分片，对比直接写入和经过写入队列的吞吐量
+func BenchmarkChunkWrite(b *testing.B) {
+	const chunkSize = 256 * 1024
+	data := make([]byte, chunkSize)
+
+	for _, bc := range []struct {
+		name    string
+		workers int
+	}{
+		{"direct", 0},
+		{"queue_4", 4},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			dir := b.TempDir()
+			var q *WriteQueue
+			if bc.workers > 0 {
+				q = StartWriteQueue(bc.workers)
+				defer q.Shutdown(context.Background())
+			}
+
+			var seq int64
+			b.SetBytes(chunkSize)
+			// 并发上传数远多于工作协程数
+			b.SetParallelism(8)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					path := filepath.Join(dir, fmt.Sprintf("%d.part", atomic.AddInt64(&seq, 1)))
+					err := q.Do(context.Background(), path, func() error {
+						return os.WriteFile(path, data, 0644)
+					})
+					if err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}